@@ -0,0 +1,92 @@
+// Package account generates and checks wallet account numbers: ten digits,
+// the last a Luhn check digit, written 123-4-56789-0 so they can be read out
+// over the phone. The check digit catches any single mistyped digit and
+// nearly every pair of swapped neighbours before money moves.
+package account
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// Length is the number of digits in an account number, check digit
+// included.
+const Length = 10
+
+var (
+	// ErrFormat is returned for anything but ten digits, optionally
+	// grouped with spaces or dashes.
+	ErrFormat = errors.New("must be 10 digits")
+	// ErrCheckDigit is returned when the digits are well formed but do not
+	// add up, which usually means one was misheard or mistyped.
+	ErrCheckDigit = errors.New("check digit does not match")
+)
+
+var payloadRange = big.NewInt(1000000000)
+
+// New returns a random account number, unformatted.
+func New() string {
+	n, err := rand.Int(rand.Reader, payloadRange)
+	if err != nil {
+		panic(err)
+	}
+	payload := n.String()
+	payload = strings.Repeat("0", Length-1-len(payload)) + payload
+	return payload + string(checkDigit(payload))
+}
+
+// Parse strips the grouping from an account number as typed and checks its
+// digits, returning it unformatted.
+func Parse(s string) (string, error) {
+	number := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(s))
+	if len(number) != Length {
+		return "", ErrFormat
+	}
+	for _, r := range number {
+		if r < '0' || r > '9' {
+			return "", ErrFormat
+		}
+	}
+	if checkDigit(number[:Length-1]) != number[Length-1] {
+		return "", ErrCheckDigit
+	}
+
+	return number, nil
+}
+
+// Format groups an unformatted account number as 123-4-56789-0.
+func Format(number string) string {
+	if len(number) != Length {
+		return number
+	}
+	return number[:3] + "-" + number[3:4] + "-" + number[4:9] + "-" + number[9:]
+}
+
+// Mask formats an account number showing only its last four digits, as
+// xxx-x-xx789-0.
+func Mask(number string) string {
+	if len(number) != Length {
+		return number
+	}
+	return Format(strings.Repeat("x", Length-4) + number[Length-4:])
+}
+
+// checkDigit is the Luhn check digit of payload: from the right, every
+// other digit starting with the last is doubled, and the digit brings the
+// sum of all digits to a multiple of ten.
+func checkDigit(payload string) byte {
+	sum := 0
+	for i := 0; i < len(payload); i++ {
+		d := int(payload[len(payload)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return byte('0' + (10-sum%10)%10)
+}
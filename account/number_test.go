@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package account_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/account"
+)
+
+func TestParse(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected string
+		err      error
+	}
+
+	cases := []testCase{
+		{name: "unformatted", input: "1234567897", expected: "1234567897"},
+		{name: "formatted", input: "123-4-56789-7", expected: "1234567897"},
+		{name: "spaced", input: " 123 4 56789 7 ", expected: "1234567897"},
+		{name: "leading zeros", input: "000-0-00000-0", expected: "0000000000"},
+		{name: "mistyped digit", input: "123-4-56788-7", err: account.ErrCheckDigit},
+		{name: "swapped digits", input: "123-4-56879-7", err: account.ErrCheckDigit},
+		{name: "too short", input: "123-4-5678-7", err: account.ErrFormat},
+		{name: "letters", input: "123-4-5678a-7", err: account.ErrFormat},
+		{name: "empty", input: "", err: account.ErrFormat},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			number, err := account.Parse(c.input)
+
+			// Assert
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.expected, number)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	// Act
+	number := account.New()
+
+	// Assert
+	assert.Len(t, number, account.Length)
+	parsed, err := account.Parse(number)
+	assert.NoError(t, err)
+	assert.Equal(t, number, parsed)
+}
+
+func TestFormat(t *testing.T) {
+	t.Run("format", func(t *testing.T) {
+		// Act
+		formatted := account.Format("1234567897")
+
+		// Assert
+		assert.Equal(t, "123-4-56789-7", formatted)
+	})
+
+	t.Run("mask", func(t *testing.T) {
+		// Act
+		masked := account.Mask("1234567897")
+
+		// Assert
+		assert.Equal(t, "xxx-x-xx789-7", masked)
+	})
+}
@@ -0,0 +1,295 @@
+// Package aggregate holds the wallet as an event-sourced aggregate: its state
+// is whatever its events, applied in order, make it. Commands check the
+// wallet's rules and record new events; nothing here touches the database.
+package aggregate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	WalletCreated = "WalletCreated"
+	FundsCredited = "FundsCredited"
+	FundsDebited  = "FundsDebited"
+	StatusChanged = "StatusChanged"
+	WalletUpdated = "WalletUpdated"
+)
+
+const (
+	StatusActive   = "Active"
+	StatusDeactive = "Deactive"
+	StatusClosed   = "Closed"
+)
+
+var (
+	// ErrWalletClosed is returned by every command on a closed wallet.
+	ErrWalletClosed = errors.New("wallet closed")
+	// ErrInsufficientFunds is returned when a debit exceeds the balance.
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrBalanceNotZero is returned when closing a wallet with funds left.
+	ErrBalanceNotZero = errors.New("wallet balance not zero")
+)
+
+// Event is one change in a wallet's stream. Version is the event's position
+// in the stream, starting at 1, and is the wallet's version once applied.
+// Only the fields of its type are set.
+type Event struct {
+	WalletID   int64     `json:"-"`
+	TenantID   string    `json:"-"`
+	Version    int64     `json:"-"`
+	Type       string    `json:"-"`
+	RecordedAt time.Time `json:"-"`
+
+	// WalletCreated
+	PublicID      string     `json:"public_id,omitempty"`
+	AccountNumber string     `json:"account_number,omitempty"`
+	Currency      string     `json:"currency,omitempty"`
+	Status        string     `json:"status,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+	ClosedAt      *time.Time `json:"closed_at,omitempty"`
+	ClosureReason string     `json:"closure_reason,omitempty"`
+
+	// FundsCredited and FundsDebited; WalletCreated's opening balance.
+	Amount float64 `json:"amount,omitempty"`
+	Reason string  `json:"reason,omitempty"`
+
+	// WalletCreated and WalletUpdated. Metadata is merged into the wallet's
+	// at the top level, keys set to null being removed.
+	Metadata     json.RawMessage   `json:"metadata,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	RemoveLabels []string          `json:"remove_labels,omitempty"`
+}
+
+// Opening is the state a wallet is created with.
+type Opening struct {
+	PublicID      string
+	AccountNumber string
+	Currency      string
+	Balance       float64
+	Status        string
+	CreatedAt     time.Time
+	Metadata      json.RawMessage
+	Labels        map[string]string
+}
+
+// Wallet is the state its events make. It is stored as JSON in snapshots of
+// the stream.
+type Wallet struct {
+	WalletID      int64             `json:"wallet_id"`
+	PublicID      string            `json:"public_id"`
+	AccountNumber string            `json:"account_number"`
+	TenantID      string            `json:"tenant_id"`
+	Currency      string            `json:"currency"`
+	Balance       float64           `json:"balance"`
+	Status        string            `json:"status"`
+	Version       int64             `json:"version"`
+	CreatedAt     time.Time         `json:"created_at"`
+	ClosedAt      *time.Time        `json:"closed_at"`
+	ClosureReason string            `json:"closure_reason"`
+	Metadata      json.RawMessage   `json:"metadata"`
+	Labels        map[string]string `json:"labels"`
+
+	changes []Event
+}
+
+// Create starts the stream of a new wallet. A zero CreatedAt means now and
+// an empty status Active.
+func Create(id int64, tenantID string, o Opening) (*Wallet, error) {
+	w := &Wallet{WalletID: id, TenantID: tenantID}
+	e := Event{Type: WalletCreated, PublicID: o.PublicID, AccountNumber: o.AccountNumber, Currency: o.Currency, Amount: o.Balance, Status: o.Status, Metadata: o.Metadata, Labels: o.Labels}
+	if !o.CreatedAt.IsZero() {
+		createdAt := o.CreatedAt.UTC().Truncate(time.Microsecond)
+		e.CreatedAt = &createdAt
+	}
+	if e.Status == "" {
+		e.Status = StatusActive
+	}
+	if err := w.record(e); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Rebuild replays a wallet's stream from its first event.
+func Rebuild(events []Event) (*Wallet, error) {
+	if len(events) == 0 || events[0].Type != WalletCreated {
+		return nil, errors.New("stream must start with " + WalletCreated)
+	}
+
+	w := &Wallet{WalletID: events[0].WalletID, TenantID: events[0].TenantID}
+	for _, e := range events {
+		if err := w.Apply(e); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Apply changes the wallet's state by an event already in its stream.
+func (w *Wallet) Apply(e Event) error {
+	if w.Version != 0 && e.Version != w.Version+1 {
+		return fmt.Errorf("wallet %d: event version %d does not follow %d", w.WalletID, e.Version, w.Version)
+	}
+
+	switch e.Type {
+	case WalletCreated:
+		if w.Version != 0 {
+			return fmt.Errorf("wallet %d: %s at version %d", w.WalletID, e.Type, e.Version)
+		}
+		w.PublicID = e.PublicID
+		w.AccountNumber = e.AccountNumber
+		w.Currency = e.Currency
+		w.Balance = e.Amount
+		w.Status = e.Status
+		w.CreatedAt = e.RecordedAt
+		if e.CreatedAt != nil {
+			w.CreatedAt = *e.CreatedAt
+		}
+		w.ClosedAt = e.ClosedAt
+		w.ClosureReason = e.ClosureReason
+		w.Metadata = json.RawMessage("{}")
+		w.Labels = map[string]string{}
+		w.update(e)
+	case FundsCredited:
+		w.Balance = roundCents(w.Balance + e.Amount)
+	case FundsDebited:
+		w.Balance = roundCents(w.Balance - e.Amount)
+	case StatusChanged:
+		w.Status = e.Status
+		if e.Status == StatusClosed {
+			closedAt := e.RecordedAt
+			w.ClosedAt = &closedAt
+			w.ClosureReason = e.Reason
+		}
+	case WalletUpdated:
+		w.update(e)
+	default:
+		return fmt.Errorf("wallet %d: unknown event type %s", w.WalletID, e.Type)
+	}
+
+	w.Version = e.Version
+	return nil
+}
+
+// Changes returns the events recorded since the wallet was loaded or last
+// saved, in order.
+func (w *Wallet) Changes() []Event {
+	return w.changes
+}
+
+// Saved forgets the recorded events once they are in the stream.
+func (w *Wallet) Saved() {
+	w.changes = nil
+}
+
+func (w *Wallet) Credit(amount float64, reason string) error {
+	if w.Status == StatusClosed {
+		return ErrWalletClosed
+	}
+	if amount <= 0 {
+		return fmt.Errorf("credit amount must be positive, got %v", amount)
+	}
+
+	return w.record(Event{Type: FundsCredited, Amount: amount, Reason: reason})
+}
+
+func (w *Wallet) Debit(amount float64, reason string) error {
+	if w.Status == StatusClosed {
+		return ErrWalletClosed
+	}
+	if amount <= 0 {
+		return fmt.Errorf("debit amount must be positive, got %v", amount)
+	}
+	if roundCents(w.Balance-amount) < 0 {
+		return ErrInsufficientFunds
+	}
+
+	return w.record(Event{Type: FundsDebited, Amount: amount, Reason: reason})
+}
+
+// ChangeStatus activates or deactivates the wallet; Close closes it.
+func (w *Wallet) ChangeStatus(status string) error {
+	if w.Status == StatusClosed {
+		return ErrWalletClosed
+	}
+	if status != StatusActive && status != StatusDeactive {
+		return fmt.Errorf("unknown wallet status %s", status)
+	}
+
+	return w.record(Event{Type: StatusChanged, Status: status})
+}
+
+// Close closes the wallet for good. Its balance must have been moved out
+// first.
+func (w *Wallet) Close(reason string) error {
+	if w.Status == StatusClosed {
+		return ErrWalletClosed
+	}
+	if w.Balance != 0 {
+		return ErrBalanceNotZero
+	}
+
+	return w.record(Event{Type: StatusChanged, Status: StatusClosed, Reason: reason})
+}
+
+// Update merges metadata into the wallet's and sets and removes labels.
+func (w *Wallet) Update(metadata json.RawMessage, setLabels map[string]string, removeLabels []string) error {
+	if w.Status == StatusClosed {
+		return ErrWalletClosed
+	}
+
+	return w.record(Event{Type: WalletUpdated, Metadata: metadata, Labels: setLabels, RemoveLabels: removeLabels})
+}
+
+// record stamps the event as the wallet's next, applies it and keeps it to
+// be saved. An event the wallet cannot apply is not kept.
+func (w *Wallet) record(e Event) error {
+	e.WalletID = w.WalletID
+	e.TenantID = w.TenantID
+	e.Version = w.Version + 1
+	// Postgres keeps microseconds; dropping the rest here keeps a replayed
+	// wallet equal to its row.
+	e.RecordedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if err := w.Apply(e); err != nil {
+		return err
+	}
+	w.changes = append(w.changes, e)
+	return nil
+}
+
+func (w *Wallet) update(e Event) {
+	if len(e.Metadata) > 0 {
+		merged := map[string]json.RawMessage{}
+		json.Unmarshal(w.Metadata, &merged)
+		patch := map[string]json.RawMessage{}
+		json.Unmarshal(e.Metadata, &patch)
+		for key, value := range patch {
+			if string(value) == "null" {
+				delete(merged, key)
+			} else {
+				merged[key] = value
+			}
+		}
+		w.Metadata, _ = json.Marshal(merged)
+	}
+
+	labels := map[string]string{}
+	for key, value := range w.Labels {
+		labels[key] = value
+	}
+	for _, key := range e.RemoveLabels {
+		delete(labels, key)
+	}
+	for key, value := range e.Labels {
+		labels[key] = value
+	}
+	w.Labels = labels
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,140 @@
+//go:build unit
+// +build unit
+
+package aggregate_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/aggregate"
+)
+
+func TestWallet(t *testing.T) {
+	t.Run("create records the opening state", func(t *testing.T) {
+		// Arrange
+		createdAt := time.Date(2019, time.May, 1, 9, 30, 0, 0, time.UTC)
+
+		// Act
+		w, err := aggregate.Create(7, "acme", aggregate.Opening{PublicID: "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3", Currency: "THB", Balance: 150, CreatedAt: createdAt, Labels: map[string]string{"team": "ops"}})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), w.Version)
+		assert.Equal(t, aggregate.StatusActive, w.Status)
+		assert.Equal(t, 150.0, w.Balance)
+		assert.Equal(t, createdAt, w.CreatedAt)
+		assert.Equal(t, "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3", w.PublicID)
+		assert.JSONEq(t, `{}`, string(w.Metadata))
+		if assert.Len(t, w.Changes(), 1) {
+			e := w.Changes()[0]
+			assert.Equal(t, aggregate.WalletCreated, e.Type)
+			assert.Equal(t, int64(7), e.WalletID)
+			assert.Equal(t, "acme", e.TenantID)
+			assert.Equal(t, "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3", e.PublicID)
+		}
+	})
+
+	t.Run("rebuild replays the recorded events", func(t *testing.T) {
+		// Arrange
+		w, err := aggregate.Create(7, "acme", aggregate.Opening{Currency: "THB", Balance: 100})
+		assert.NoError(t, err)
+		assert.NoError(t, w.Credit(50.25, "top up"))
+		assert.NoError(t, w.Debit(30.1, ""))
+		assert.NoError(t, w.Update(json.RawMessage(`{"owner":"u-1"}`), map[string]string{"team": "ops"}, nil))
+		assert.NoError(t, w.ChangeStatus(aggregate.StatusDeactive))
+
+		// Act
+		rebuilt, err := aggregate.Rebuild(w.Changes())
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 120.15, rebuilt.Balance)
+		assert.Equal(t, aggregate.StatusDeactive, rebuilt.Status)
+		assert.Equal(t, int64(5), rebuilt.Version)
+		assert.JSONEq(t, `{"owner":"u-1"}`, string(rebuilt.Metadata))
+		assert.Equal(t, map[string]string{"team": "ops"}, rebuilt.Labels)
+		assert.Empty(t, rebuilt.Changes())
+	})
+
+	t.Run("stream may start at a later version", func(t *testing.T) {
+		// Arrange
+		events := []aggregate.Event{
+			{WalletID: 1, TenantID: "acme", Version: 4, Type: aggregate.WalletCreated, Currency: "THB", Status: aggregate.StatusActive, Amount: 1000},
+			{WalletID: 1, TenantID: "acme", Version: 5, Type: aggregate.FundsDebited, Amount: 250},
+		}
+
+		// Act
+		w, err := aggregate.Rebuild(events)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 750.0, w.Balance)
+		assert.Equal(t, int64(5), w.Version)
+	})
+
+	t.Run("gap in stream", func(t *testing.T) {
+		// Arrange
+		events := []aggregate.Event{
+			{WalletID: 1, Version: 1, Type: aggregate.WalletCreated, Currency: "THB", Status: aggregate.StatusActive},
+			{WalletID: 1, Version: 3, Type: aggregate.FundsCredited, Amount: 250},
+		}
+
+		// Act
+		_, err := aggregate.Rebuild(events)
+
+		// Assert
+		assert.EqualError(t, err, "wallet 1: event version 3 does not follow 1")
+	})
+
+	t.Run("debit beyond balance", func(t *testing.T) {
+		// Arrange
+		w, err := aggregate.Create(7, "acme", aggregate.Opening{Currency: "THB", Balance: 100})
+		assert.NoError(t, err)
+		w.Saved()
+
+		// Act
+		err = w.Debit(100.01, "")
+
+		// Assert
+		assert.ErrorIs(t, err, aggregate.ErrInsufficientFunds)
+		assert.Equal(t, 100.0, w.Balance)
+		assert.Empty(t, w.Changes())
+	})
+
+	t.Run("close", func(t *testing.T) {
+		// Arrange
+		w, err := aggregate.Create(7, "acme", aggregate.Opening{Currency: "THB", Balance: 100})
+		assert.NoError(t, err)
+
+		// Act
+		notEmpty := w.Close("moving away")
+		_ = w.Debit(100, "closure")
+		err = w.Close("moving away")
+
+		// Assert
+		assert.ErrorIs(t, notEmpty, aggregate.ErrBalanceNotZero)
+		assert.NoError(t, err)
+		assert.Equal(t, aggregate.StatusClosed, w.Status)
+		assert.Equal(t, "moving away", w.ClosureReason)
+		assert.NotNil(t, w.ClosedAt)
+		assert.ErrorIs(t, w.Credit(1, ""), aggregate.ErrWalletClosed)
+		assert.ErrorIs(t, w.ChangeStatus(aggregate.StatusActive), aggregate.ErrWalletClosed)
+	})
+
+	t.Run("update removes metadata keys set to null", func(t *testing.T) {
+		// Arrange
+		w, err := aggregate.Create(7, "acme", aggregate.Opening{Currency: "THB", Metadata: json.RawMessage(`{"owner":"u-1","tier":"gold"}`), Labels: map[string]string{"team": "ops", "region": "th"}})
+		assert.NoError(t, err)
+
+		// Act
+		err = w.Update(json.RawMessage(`{"tier":null,"channel":"app"}`), map[string]string{"team": "risk"}, []string{"region"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"owner":"u-1","channel":"app"}`, string(w.Metadata))
+		assert.Equal(t, map[string]string{"team": "risk"}, w.Labels)
+	})
+}
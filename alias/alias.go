@@ -0,0 +1,113 @@
+// Package alias normalises the aliases a wallet can be paid by: a phone
+// number, an email address or a handle. The kind is told from the alias
+// itself, so one field takes any of them, and each normal form is distinct
+// from the others': phones start with +, emails contain @ and handles
+// start with a letter.
+package alias
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+const (
+	TypePhone  = "phone"
+	TypeEmail  = "email"
+	TypeHandle = "handle"
+)
+
+// DefaultCallingCode is the country a phone number in national form, with
+// a leading 0 instead of a calling code, is read as being in.
+const DefaultCallingCode = "66"
+
+var (
+	ErrPhone  = errors.New("must be a phone number in international form, +66812345678, or national form, 0812345678")
+	ErrEmail  = errors.New("must be an email address")
+	ErrHandle = errors.New("must be 3 to 30 letters, digits, dots or underscores, starting with a letter")
+)
+
+var (
+	phoneChars = regexp.MustCompile(`^\+?[0-9 ()./-]*[0-9][0-9 ()./-]*$`)
+	handle     = regexp.MustCompile(`^[a-z][a-z0-9_.]{2,29}$`)
+)
+
+type Alias struct {
+	Type  string
+	Value string
+}
+
+// Parse works out which kind of alias s is and normalises it: phone numbers
+// to E.164, emails and handles, which may be written with a leading @, to
+// lower case.
+func Parse(s string) (Alias, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.Index(s, "@") > 0:
+		value, err := normalizeEmail(s)
+		return Alias{Type: TypeEmail, Value: value}, err
+	case phoneChars.MatchString(s):
+		value, err := normalizePhone(s)
+		return Alias{Type: TypePhone, Value: value}, err
+	default:
+		value := strings.ToLower(strings.TrimPrefix(s, "@"))
+		if !handle.MatchString(value) {
+			return Alias{Type: TypeHandle}, ErrHandle
+		}
+		return Alias{Type: TypeHandle, Value: value}, nil
+	}
+}
+
+// normalizePhone writes a phone number as + and 8 to 15 digits, the first
+// not 0. Numbers may be given with + or 00 before the calling code, or in
+// national form with a leading 0, and grouped with spaces, dashes, dots or
+// parentheses. A trunk 0 written after the calling code, as in +44 (0)20 or
+// +66 081, is dropped, so each number has one normal form.
+func normalizePhone(s string) (string, error) {
+	// "(0)" marks the trunk prefix, dialled only within the country.
+	s = strings.ReplaceAll(s, "(0)", "")
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+
+	switch {
+	case strings.HasPrefix(s, "+"):
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	case strings.HasPrefix(digits, "0"):
+		digits = DefaultCallingCode + digits[1:]
+	default:
+		return "", ErrPhone
+	}
+	// No number in the default country starts with 0 after its calling code.
+	if strings.HasPrefix(digits, DefaultCallingCode+"0") {
+		digits = DefaultCallingCode + digits[len(DefaultCallingCode)+1:]
+	}
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", ErrPhone
+	}
+
+	return "+" + digits, nil
+}
+
+func normalizeEmail(s string) (string, error) {
+	s = strings.ToLower(s)
+	local, domain, _ := strings.Cut(s, "@")
+	if len(s) > 254 || len(local) > 64 || strings.ContainsAny(s, " \t\r\n") || strings.Contains(domain, "@") {
+		return "", ErrEmail
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return "", ErrEmail
+	}
+	for _, label := range labels {
+		if label == "" {
+			return "", ErrEmail
+		}
+	}
+
+	return s, nil
+}
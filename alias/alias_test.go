@@ -0,0 +1,53 @@
+//go:build unit
+// +build unit
+
+package alias_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/alias"
+)
+
+func TestParse(t *testing.T) {
+	type testCase struct {
+		name     string
+		input    string
+		expected alias.Alias
+		err      error
+	}
+
+	cases := []testCase{
+		{name: "national phone", input: "081-234-5678", expected: alias.Alias{Type: alias.TypePhone, Value: "+66812345678"}},
+		{name: "international phone", input: "+66 81 234 5678", expected: alias.Alias{Type: alias.TypePhone, Value: "+66812345678"}},
+		{name: "phone with 00 prefix", input: "0066812345678", expected: alias.Alias{Type: alias.TypePhone, Value: "+66812345678"}},
+		{name: "phone with bracketed trunk 0", input: "+66 (0)81 234 5678", expected: alias.Alias{Type: alias.TypePhone, Value: "+66812345678"}},
+		{name: "phone with trunk 0 after calling code", input: "+660812345678", expected: alias.Alias{Type: alias.TypePhone, Value: "+66812345678"}},
+		{name: "phone with 00 prefix and trunk 0", input: "00660812345678", expected: alias.Alias{Type: alias.TypePhone, Value: "+66812345678"}},
+		{name: "foreign phone with bracketed trunk 0", input: "+44 (0)20 7946 0018", expected: alias.Alias{Type: alias.TypePhone, Value: "+442079460018"}},
+		{name: "foreign phone", input: "+1 (415) 555-0100", expected: alias.Alias{Type: alias.TypePhone, Value: "+14155550100"}},
+		{name: "phone without prefix", input: "812345678", expected: alias.Alias{Type: alias.TypePhone}, err: alias.ErrPhone},
+		{name: "phone too short", input: "+66 12", expected: alias.Alias{Type: alias.TypePhone}, err: alias.ErrPhone},
+		{name: "phone too long", input: "+66 1234 5678 9012 34", expected: alias.Alias{Type: alias.TypePhone}, err: alias.ErrPhone},
+		{name: "email", input: " Somchai.J@Example.co.th ", expected: alias.Alias{Type: alias.TypeEmail, Value: "somchai.j@example.co.th"}},
+		{name: "email without domain dot", input: "somchai@localhost", expected: alias.Alias{Type: alias.TypeEmail}, err: alias.ErrEmail},
+		{name: "email with two @", input: "a@b@example.com", expected: alias.Alias{Type: alias.TypeEmail}, err: alias.ErrEmail},
+		{name: "handle", input: "@Somchai_J", expected: alias.Alias{Type: alias.TypeHandle, Value: "somchai_j"}},
+		{name: "handle without @", input: "somchai.j", expected: alias.Alias{Type: alias.TypeHandle, Value: "somchai.j"}},
+		{name: "handle too short", input: "@sj", expected: alias.Alias{Type: alias.TypeHandle}, err: alias.ErrHandle},
+		{name: "handle starting with digit", input: "@1somchai", expected: alias.Alias{Type: alias.TypeHandle}, err: alias.ErrHandle},
+		{name: "empty", input: "", expected: alias.Alias{Type: alias.TypeHandle}, err: alias.ErrHandle},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			a, err := alias.Parse(c.input)
+
+			// Assert
+			assert.Equal(t, c.err, err)
+			assert.Equal(t, c.expected, a)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderAPIKey   = "X-API-Key"
+	HeaderTenantID = "X-Tenant-ID"
+
+	// DefaultTenant owns every wallet created before tenants existed and is
+	// used for anonymous access when it is allowed.
+	DefaultTenant = "default"
+
+	// RoleApprover may approve or reject changes held for maker-checker
+	// approval.
+	RoleApprover = "approver"
+	// RoleAdmin may run back-office jobs such as interest accrual for its
+	// tenant.
+	RoleAdmin = "admin"
+
+	principalKey = "auth.principal"
+	tenantKey    = "auth.tenant"
+)
+
+// ErrUnknownKey is returned by a KeyStore when no active key matches.
+var ErrUnknownKey = errors.New("unknown api key")
+
+type Principal struct {
+	ID             string
+	TenantID       string
+	Roles          []string
+	ServiceAccount bool
+}
+
+func (p Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type KeyStore interface {
+	Lookup(keyHash string) (*Principal, error)
+}
+
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// TenantID returns the tenant resolved for the request, falling back to the
+// default tenant when no authentication middleware ran.
+func TenantID(c echo.Context) string {
+	if tenant, ok := c.Get(tenantKey).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
+
+func PrincipalFrom(c echo.Context) (Principal, bool) {
+	p, ok := c.Get(principalKey).(Principal)
+	return p, ok
+}
+
+func SetPrincipal(c echo.Context, p Principal, tenantID string) {
+	c.Set(principalKey, p)
+	c.Set(tenantKey, tenantID)
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+type postgresKeyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresKeyStore(db *sql.DB) KeyStore {
+	return postgresKeyStore{db: db}
+}
+
+func (s postgresKeyStore) Lookup(keyHash string) (*Principal, error) {
+	p := Principal{}
+	var tenant sql.NullString
+	row := s.db.QueryRow("SELECT principal_id, tenant_id, roles, service_account FROM api_keys WHERE key_hash=$1 AND revoked_at IS NULL", keyHash)
+	err := row.Scan(&p.ID, &tenant, pq.Array(&p.Roles), &p.ServiceAccount)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUnknownKey
+		}
+		return nil, err
+	}
+
+	p.TenantID = tenant.String
+	return &p, nil
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/topnarapat/go-wallet/errs"
+)
+
+type Config struct {
+	Skipper        middleware.Skipper
+	Store          KeyStore
+	AllowAnonymous bool
+}
+
+// Middleware authenticates the caller by API key and resolves its tenant.
+// Regular keys are bound to one tenant; service account keys act on behalf
+// of the tenant named in X-Tenant-ID.
+func Middleware(config Config) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			key := c.Request().Header.Get(HeaderAPIKey)
+			requested := c.Request().Header.Get(HeaderTenantID)
+
+			if key == "" {
+				if !config.AllowAnonymous {
+					return errs.NewUnauthorizedError()
+				}
+				if requested != "" && requested != DefaultTenant {
+					return errs.NewForbiddenError("anonymous callers cannot select a tenant")
+				}
+				SetPrincipal(c, Principal{ID: "anonymous", TenantID: DefaultTenant}, DefaultTenant)
+				return next(c)
+			}
+
+			principal, err := config.Store.Lookup(HashKey(key))
+			if err != nil {
+				if errors.Is(err, ErrUnknownKey) {
+					return errs.NewUnauthorizedError()
+				}
+				return errs.NewUnexpectedError().WithCause(err)
+			}
+
+			tenant, err := resolveTenant(*principal, requested)
+			if err != nil {
+				return err
+			}
+
+			SetPrincipal(c, *principal, tenant)
+			return next(c)
+		}
+	}
+}
+
+func resolveTenant(p Principal, requested string) (string, error) {
+	if p.ServiceAccount {
+		if requested != "" {
+			return requested, nil
+		}
+		if p.TenantID != "" {
+			return p.TenantID, nil
+		}
+		return "", errs.NewBadRequest("X-Tenant-ID is required for service accounts")
+	}
+
+	if requested != "" && requested != p.TenantID {
+		return "", errs.NewForbiddenError("api key does not belong to the requested tenant")
+	}
+	return p.TenantID, nil
+}
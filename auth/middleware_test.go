@@ -0,0 +1,127 @@
+//go:build unit
+// +build unit
+
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+)
+
+type keyStore map[string]auth.Principal
+
+func (s keyStore) Lookup(keyHash string) (*auth.Principal, error) {
+	p, ok := s[keyHash]
+	if !ok {
+		return nil, auth.ErrUnknownKey
+	}
+	return &p, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	store := keyStore{
+		auth.HashKey("acme-key"):    {ID: "acme-app", TenantID: "acme"},
+		auth.HashKey("service-key"): {ID: "settlement", ServiceAccount: true},
+	}
+
+	serve := func(config auth.Config, header http.Header) (string, error) {
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		req.Header = header
+		c := e.NewContext(req, httptest.NewRecorder())
+
+		var tenant string
+		err := auth.Middleware(config)(func(c echo.Context) error {
+			tenant = auth.TenantID(c)
+			return nil
+		})(c)
+		return tenant, err
+	}
+
+	t.Run("resolve tenant from api key", func(t *testing.T) {
+		// Arrange
+		header := http.Header{}
+		header.Set(auth.HeaderAPIKey, "acme-key")
+
+		// Act
+		tenant, err := serve(auth.Config{Store: store}, header)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "acme", tenant)
+	})
+
+	t.Run("reject another tenant for regular key", func(t *testing.T) {
+		// Arrange
+		header := http.Header{}
+		header.Set(auth.HeaderAPIKey, "acme-key")
+		header.Set(auth.HeaderTenantID, "globex")
+
+		// Act
+		_, err := serve(auth.Config{Store: store}, header)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewForbiddenError(""))
+	})
+
+	t.Run("service account acts for requested tenant", func(t *testing.T) {
+		// Arrange
+		header := http.Header{}
+		header.Set(auth.HeaderAPIKey, "service-key")
+		header.Set(auth.HeaderTenantID, "globex")
+
+		// Act
+		tenant, err := serve(auth.Config{Store: store}, header)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "globex", tenant)
+	})
+
+	t.Run("service account requires tenant header", func(t *testing.T) {
+		// Arrange
+		header := http.Header{}
+		header.Set(auth.HeaderAPIKey, "service-key")
+
+		// Act
+		_, err := serve(auth.Config{Store: store}, header)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewBadRequest(""))
+	})
+
+	t.Run("reject unknown key", func(t *testing.T) {
+		// Arrange
+		header := http.Header{}
+		header.Set(auth.HeaderAPIKey, "stolen")
+
+		// Act
+		_, err := serve(auth.Config{Store: store, AllowAnonymous: true}, header)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewUnauthorizedError())
+	})
+
+	t.Run("reject missing key", func(t *testing.T) {
+		// Act
+		_, err := serve(auth.Config{Store: store}, http.Header{})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewUnauthorizedError())
+	})
+
+	t.Run("anonymous uses default tenant", func(t *testing.T) {
+		// Act
+		tenant, err := serve(auth.Config{Store: store, AllowAnonymous: true}, http.Header{})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, auth.DefaultTenant, tenant)
+	})
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+const cliUsage = `Usage:
+  go-wallet                 serve the API
+  go-wallet <command> [flags]
+
+Commands:
+  import-wallets [-tenant ID] [-format csv|ndjson] [-dry-run] [FILE]
+        import wallets from FILE, or standard input when FILE is - or missing
+  export-wallets [-tenant ID] [-format csv|ndjson] [-o FILE]
+        export every wallet of the tenant to FILE or standard output
+  rebuild-projections [-tenant ID] [-wallet ID]
+        replay wallet streams and rewrite the wallets that differ; every
+        tenant's wallets unless -tenant or -wallet narrows it down
+  trial-balance [-tenant ID] [-from DATE] [-to DATE] [-format json|csv] [-o FILE]
+        every ledger account's opening and closing balance and what was
+        posted to it from the start of -from to the end of -to, the month
+        to date by default
+  account-activity -account CODE [-currency CUR] [-tenant ID] [-from DATE] [-to DATE] [-format json|csv] [-o FILE]
+        every line posted to the ledger account in the period, with the
+        balance it left
+  balance-sheet [-tenant ID] [-as-of DATE] [-format json|csv] [-o FILE]
+        assets, liabilities and equity at the end of the day, today by
+        default
+
+Report dates are YYYY-MM-DD days in BUSINESS_TIMEZONE. Reports are JSON
+unless -format or the -o extension says CSV.
+`
+
+// cli runs admin commands against the database instead of serving the API.
+type cli struct {
+	bulkSrv   service.BulkWalletService
+	streamSrv service.StreamService
+	ledgerSrv service.LedgerService
+	stdin     io.Reader
+	stdout    io.Writer
+	stderr    io.Writer
+}
+
+func newCLI(db *sql.DB) cli {
+	return cli{
+		bulkSrv:   service.NewBulkWalletService(repository.NewWalletRepository(db), repository.NewTenantRepository(db)),
+		streamSrv: service.NewStreamService(repository.NewStreamRepository(db)),
+		ledgerSrv: service.NewLedgerService(repository.NewLedgerRepository(db), businessLocation()),
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+	}
+}
+
+// run executes the command named by args[0] and returns the exit code.
+func (c cli) run(args []string) int {
+	switch args[0] {
+	case "import-wallets":
+		return c.importWallets(args[1:])
+	case "export-wallets":
+		return c.exportWallets(args[1:])
+	case "rebuild-projections":
+		return c.rebuildProjections(args[1:])
+	case "trial-balance":
+		return c.trialBalance(args[1:])
+	case "account-activity":
+		return c.accountActivity(args[1:])
+	case "balance-sheet":
+		return c.balanceSheet(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(c.stdout, cliUsage)
+		return 0
+	}
+
+	fmt.Fprintf(c.stderr, "unknown command %q\n\n%s", args[0], cliUsage)
+	return 2
+}
+
+func (c cli) importWallets(args []string) int {
+	flags := c.flagSet("import-wallets")
+	tenantID := flags.String("tenant", auth.DefaultTenant, "tenant to import into")
+	format := flags.String("format", "", "csv or ndjson; taken from the file extension when unset")
+	dryRun := flags.Bool("dry-run", false, "only report problems, import nothing")
+	if flags.Parse(args) != nil {
+		return 2
+	}
+
+	path := flags.Arg(0)
+	var body io.Reader = c.stdin
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return 1
+		}
+		defer f.Close()
+		body = f
+	}
+	if *format == "" {
+		*format = formatOf(path)
+	}
+
+	report, err := c.bulkSrv.ImportWallets(*tenantID, service.ImportWalletsRequest{Format: *format, DryRun: *dryRun, Body: body})
+	if err != nil {
+		return c.fail(err)
+	}
+
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if len(report.Errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+func (c cli) exportWallets(args []string) int {
+	flags := c.flagSet("export-wallets")
+	tenantID := flags.String("tenant", auth.DefaultTenant, "tenant to export")
+	format := flags.String("format", "", "csv or ndjson; taken from the -o extension when unset")
+	output := flags.String("o", "", "file to write instead of standard output")
+	if flags.Parse(args) != nil {
+		return 2
+	}
+	if *format == "" {
+		*format = formatOf(*output)
+	}
+
+	var w io.Writer = c.stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := c.bulkSrv.ExportWallets(*tenantID, *format, w); err != nil {
+		return c.fail(err)
+	}
+	return 0
+}
+
+func (c cli) rebuildProjections(args []string) int {
+	flags := c.flagSet("rebuild-projections")
+	tenantID := flags.String("tenant", "", "tenant to rebuild; every tenant when unset")
+	walletID := flags.Int64("wallet", 0, "single wallet to rebuild")
+	if flags.Parse(args) != nil {
+		return 2
+	}
+
+	report, err := c.streamSrv.RebuildProjections(*tenantID, *walletID)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	encoder := json.NewEncoder(c.stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	return 0
+}
+
+func (c cli) trialBalance(args []string) int {
+	flags := c.flagSet("trial-balance")
+	tenantID := flags.String("tenant", auth.DefaultTenant, "tenant to report on")
+	from := flags.String("from", "", "first day of the period; the first of -to's month when unset")
+	to := flags.String("to", "", "last day of the period; today when unset")
+	format := flags.String("format", "", "json or csv; taken from the -o extension when unset")
+	output := flags.String("o", "", "file to write instead of standard output")
+	if flags.Parse(args) != nil {
+		return 2
+	}
+
+	return c.report(*format, *output, func() (interface{}, error) {
+		return c.ledgerSrv.GetTrialBalance(*tenantID, *from, *to)
+	}, func(w io.Writer) error {
+		return c.ledgerSrv.ExportTrialBalance(*tenantID, *from, *to, w)
+	})
+}
+
+func (c cli) accountActivity(args []string) int {
+	flags := c.flagSet("account-activity")
+	tenantID := flags.String("tenant", auth.DefaultTenant, "tenant to report on")
+	request := service.AccountActivityRequest{}
+	flags.StringVar(&request.Code, "account", "", "account code, such as 1000 or 2000-42")
+	flags.StringVar(&request.Currency, "currency", "", "currency of the account; needed when it is held in more than one")
+	flags.StringVar(&request.From, "from", "", "first day of the period; the first of -to's month when unset")
+	flags.StringVar(&request.To, "to", "", "last day of the period; today when unset")
+	format := flags.String("format", "", "json or csv; taken from the -o extension when unset")
+	output := flags.String("o", "", "file to write instead of standard output")
+	if flags.Parse(args) != nil {
+		return 2
+	}
+	if request.Code == "" {
+		fmt.Fprintln(c.stderr, "-account is required")
+		return 2
+	}
+
+	return c.report(*format, *output, func() (interface{}, error) {
+		return c.ledgerSrv.GetAccountActivity(*tenantID, request)
+	}, func(w io.Writer) error {
+		return c.ledgerSrv.ExportAccountActivity(*tenantID, request, w)
+	})
+}
+
+func (c cli) balanceSheet(args []string) int {
+	flags := c.flagSet("balance-sheet")
+	tenantID := flags.String("tenant", auth.DefaultTenant, "tenant to report on")
+	asOf := flags.String("as-of", "", "day to report at the end of; today when unset")
+	format := flags.String("format", "", "json or csv; taken from the -o extension when unset")
+	output := flags.String("o", "", "file to write instead of standard output")
+	if flags.Parse(args) != nil {
+		return 2
+	}
+
+	return c.report(*format, *output, func() (interface{}, error) {
+		return c.ledgerSrv.GetBalanceSheet(*tenantID, *asOf)
+	}, func(w io.Writer) error {
+		return c.ledgerSrv.ExportBalanceSheet(*tenantID, *asOf, w)
+	})
+}
+
+// report writes a report to output, or standard output, as indented JSON or
+// as export's CSV.
+func (c cli) report(format string, output string, report func() (interface{}, error), export func(io.Writer) error) int {
+	if format == "" {
+		format = service.FormatJSON
+		if strings.EqualFold(filepath.Ext(output), ".csv") {
+			format = service.FormatCSV
+		}
+	}
+	if format != service.FormatJSON && format != service.FormatCSV {
+		fmt.Fprintln(c.stderr, "-format must be json or csv")
+		return 2
+	}
+
+	var w io.Writer = c.stdout
+	if output != "" {
+		f, err := os.Create(output)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if format == service.FormatCSV {
+		if err := export(w); err != nil {
+			return c.fail(err)
+		}
+		return 0
+	}
+
+	body, err := report()
+	if err != nil {
+		return c.fail(err)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(body)
+	return 0
+}
+
+func (c cli) flagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	return flags
+}
+
+// fail prints err, one line per field problem, and returns the exit code.
+func (c cli) fail(err error) int {
+	var appErr errs.AppError
+	if !errors.As(err, &appErr) || len(appErr.Fields) == 0 {
+		fmt.Fprintln(c.stderr, err)
+		return 1
+	}
+
+	for _, field := range appErr.Fields {
+		fmt.Fprintf(c.stderr, "%s: %s\n", field.Field, field.Message)
+	}
+	return 1
+}
+
+// formatOf picks the format a file's extension suggests, CSV by default.
+func formatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ndjson", ".jsonl":
+		return service.FormatNDJSON
+	}
+	return service.FormatCSV
+}
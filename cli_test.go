@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestCLI(t *testing.T) {
+	t.Run("import from standard input", func(t *testing.T) {
+		// Arrange
+		bulkService := service.NewBulkWalletServiceMock()
+		bulkService.On("ImportWallets", "acme", mock.MatchedBy(func(r service.ImportWalletsRequest) bool { return r.Format == "ndjson" && r.DryRun })).
+			Return(&service.ImportReport{DryRun: true, Rows: 1, TotalBalance: 150, Errors: []errs.FieldError{}}, nil)
+
+		stdout := &bytes.Buffer{}
+		c := cli{bulkSrv: bulkService, stdin: strings.NewReader(`{"balance":150,"created_at":"2019-05-01T09:30:00Z"}`), stdout: stdout, stderr: &bytes.Buffer{}}
+
+		// Act
+		code := c.run([]string{"import-wallets", "-tenant", "acme", "-format", "ndjson", "-dry-run"})
+
+		// Assert
+		assert.Equal(t, 0, code)
+		assert.Contains(t, stdout.String(), `"total_balance": 150`)
+	})
+
+	t.Run("import problems", func(t *testing.T) {
+		// Arrange
+		bulkService := service.NewBulkWalletServiceMock()
+		bulkService.On("ImportWallets", "default", mock.MatchedBy(func(r service.ImportWalletsRequest) bool { return r.Format == "csv" })).
+			Return((*service.ImportReport)(nil), errs.NewFieldValidationError([]errs.FieldError{{Field: "rows[0].balance", Message: "must be a number"}}))
+
+		stderr := &bytes.Buffer{}
+		c := cli{bulkSrv: bulkService, stdin: strings.NewReader("balance,created_at\nten,2019-05-01T09:30:00Z\n"), stdout: &bytes.Buffer{}, stderr: stderr}
+
+		// Act
+		code := c.run([]string{"import-wallets", "-"})
+
+		// Assert
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "rows[0].balance: must be a number\n", stderr.String())
+	})
+
+	t.Run("rebuild projections of one tenant", func(t *testing.T) {
+		// Arrange
+		streamService := service.NewStreamServiceMock()
+		streamService.On("RebuildProjections", "acme", int64(0)).Return(&service.ProjectionReport{Wallets: 3, Changed: []int64{2}}, nil)
+
+		stdout := &bytes.Buffer{}
+		c := cli{streamSrv: streamService, stdout: stdout, stderr: &bytes.Buffer{}}
+
+		// Act
+		code := c.run([]string{"rebuild-projections", "-tenant", "acme"})
+
+		// Assert
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "{\n  \"wallets\": 3,\n  \"changed\": [\n    2\n  ]\n}\n", stdout.String())
+	})
+
+	t.Run("trial balance as csv", func(t *testing.T) {
+		// Arrange
+		ledgerService := service.NewLedgerServiceMock()
+		ledgerService.On("ExportTrialBalance", "acme", "2023-03-01", "2023-03-31", mock.Anything).Return("currency,code\n", nil)
+
+		stdout := &bytes.Buffer{}
+		c := cli{ledgerSrv: ledgerService, stdout: stdout, stderr: &bytes.Buffer{}}
+
+		// Act
+		code := c.run([]string{"trial-balance", "-tenant", "acme", "-from", "2023-03-01", "-to", "2023-03-31", "-format", "csv"})
+
+		// Assert
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "currency,code\n", stdout.String())
+	})
+
+	t.Run("account activity needs an account", func(t *testing.T) {
+		// Arrange
+		ledgerService := service.NewLedgerServiceMock()
+		stderr := &bytes.Buffer{}
+		c := cli{ledgerSrv: ledgerService, stdout: &bytes.Buffer{}, stderr: stderr}
+
+		// Act
+		code := c.run([]string{"account-activity", "-tenant", "acme"})
+
+		// Assert
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr.String(), "-account is required")
+		ledgerService.AssertNotCalled(t, "GetAccountActivity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		// Arrange
+		stderr := &bytes.Buffer{}
+		c := cli{bulkSrv: service.NewBulkWalletServiceMock(), stdout: &bytes.Buffer{}, stderr: stderr}
+
+		// Act
+		code := c.run([]string{"migrate"})
+
+		// Assert
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr.String(), `unknown command "migrate"`)
+	})
+}
@@ -0,0 +1,6 @@
+-- Table Definition
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key TEXT PRIMARY KEY,
+    tokens FLOAT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
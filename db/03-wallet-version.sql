@@ -0,0 +1,2 @@
+-- Optimistic concurrency
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,5 @@
+-- Wallet closure
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP;
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS closure_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS wallets_open_idx ON wallets (wallet_id) WHERE wallet_status <> 'Closed';
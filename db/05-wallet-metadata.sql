@@ -0,0 +1,11 @@
+-- Wallet metadata and labels
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS wallet_labels (
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id) ON DELETE CASCADE,
+    label_key TEXT NOT NULL,
+    label_value TEXT NOT NULL,
+    PRIMARY KEY (wallet_id, label_key)
+);
+
+CREATE INDEX IF NOT EXISTS wallet_labels_key_value_idx ON wallet_labels (label_key, label_value);
@@ -0,0 +1,69 @@
+-- Wallet event history, recorded by trigger so every change to a wallet is
+-- captured, and published with NOTIFY when the change commits.
+CREATE TABLE IF NOT EXISTS wallet_events (
+    event_id BIGSERIAL PRIMARY KEY,
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    event_type TEXT NOT NULL,
+    amount FLOAT NOT NULL DEFAULT 0,
+    balance FLOAT NOT NULL,
+    wallet_status TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS wallet_events_wallet_idx ON wallet_events (wallet_id, event_id);
+
+CREATE OR REPLACE FUNCTION record_wallet_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO wallet_events (wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.wallet_id, 'created', NEW.balance, NEW.balance, NEW.wallet_status);
+        RETURN NEW;
+    END IF;
+
+    IF NEW.balance > OLD.balance THEN
+        INSERT INTO wallet_events (wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.wallet_id, 'credited', NEW.balance - OLD.balance, NEW.balance, NEW.wallet_status);
+    ELSIF NEW.balance < OLD.balance THEN
+        INSERT INTO wallet_events (wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.wallet_id, 'debited', OLD.balance - NEW.balance, NEW.balance, NEW.wallet_status);
+    END IF;
+
+    IF NEW.wallet_status <> OLD.wallet_status THEN
+        INSERT INTO wallet_events (wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.wallet_id, 'status_changed', 0, NEW.balance, NEW.wallet_status);
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallets_record_event ON wallets;
+CREATE TRIGGER wallets_record_event
+    AFTER INSERT OR UPDATE OF balance, wallet_status ON wallets
+    FOR EACH ROW EXECUTE FUNCTION record_wallet_event();
+
+CREATE OR REPLACE FUNCTION notify_wallet_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('wallet_events', json_build_object(
+        'event_id', NEW.event_id,
+        'wallet_id', NEW.wallet_id,
+        'event_type', NEW.event_type,
+        'amount', NEW.amount,
+        'balance', NEW.balance,
+        'wallet_status', NEW.wallet_status,
+        'created_at', NEW.created_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallet_events_notify ON wallet_events;
+CREATE TRIGGER wallet_events_notify
+    AFTER INSERT ON wallet_events
+    FOR EACH ROW EXECUTE FUNCTION notify_wallet_event();
+
+-- Seed history for wallets created before the trigger existed.
+INSERT INTO wallet_events (wallet_id, event_type, amount, balance, wallet_status, created_at)
+SELECT w.wallet_id, 'created', w.balance, w.balance, w.wallet_status, w.created_at
+FROM wallets w
+WHERE NOT EXISTS (SELECT 1 FROM wallet_events e WHERE e.wallet_id = w.wallet_id);
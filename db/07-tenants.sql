@@ -0,0 +1,73 @@
+-- Tenants and per-tenant configuration
+CREATE TABLE IF NOT EXISTS tenants (
+    tenant_id TEXT PRIMARY KEY,
+    tenant_name TEXT NOT NULL,
+    default_currency TEXT NOT NULL DEFAULT 'THB',
+    max_balance FLOAT,
+    max_transaction_amount FLOAT,
+    created_at TIMESTAMP NOT NULL DEFAULT (now())
+);
+
+INSERT INTO tenants (tenant_id, tenant_name) VALUES ('default', 'Default') ON CONFLICT DO NOTHING;
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default' REFERENCES tenants (tenant_id);
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'THB';
+CREATE INDEX IF NOT EXISTS wallets_tenant_idx ON wallets (tenant_id, wallet_id);
+
+ALTER TABLE wallet_events ADD COLUMN IF NOT EXISTS tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX IF EXISTS wallet_events_wallet_idx;
+CREATE INDEX IF NOT EXISTS wallet_events_tenant_wallet_idx ON wallet_events (tenant_id, wallet_id, event_id);
+CREATE INDEX IF NOT EXISTS wallet_events_tenant_idx ON wallet_events (tenant_id, event_id);
+
+-- API keys, stored as SHA-256 hex digests. Service accounts have no fixed
+-- tenant and act on behalf of the tenant named in X-Tenant-ID.
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_hash TEXT PRIMARY KEY,
+    principal_id TEXT NOT NULL,
+    tenant_id TEXT REFERENCES tenants (tenant_id),
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    service_account BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    revoked_at TIMESTAMP
+);
+
+CREATE OR REPLACE FUNCTION record_wallet_event() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        INSERT INTO wallet_events (tenant_id, wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.tenant_id, NEW.wallet_id, 'created', NEW.balance, NEW.balance, NEW.wallet_status);
+        RETURN NEW;
+    END IF;
+
+    IF NEW.balance > OLD.balance THEN
+        INSERT INTO wallet_events (tenant_id, wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.tenant_id, NEW.wallet_id, 'credited', NEW.balance - OLD.balance, NEW.balance, NEW.wallet_status);
+    ELSIF NEW.balance < OLD.balance THEN
+        INSERT INTO wallet_events (tenant_id, wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.tenant_id, NEW.wallet_id, 'debited', OLD.balance - NEW.balance, NEW.balance, NEW.wallet_status);
+    END IF;
+
+    IF NEW.wallet_status <> OLD.wallet_status THEN
+        INSERT INTO wallet_events (tenant_id, wallet_id, event_type, amount, balance, wallet_status)
+        VALUES (NEW.tenant_id, NEW.wallet_id, 'status_changed', 0, NEW.balance, NEW.wallet_status);
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION notify_wallet_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('wallet_events', json_build_object(
+        'event_id', NEW.event_id,
+        'tenant_id', NEW.tenant_id,
+        'wallet_id', NEW.wallet_id,
+        'event_type', NEW.event_type,
+        'amount', NEW.amount,
+        'balance', NEW.balance,
+        'wallet_status', NEW.wallet_status,
+        'created_at', NEW.created_at
+    )::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,14 @@
+-- Pockets: named sub-balances of a wallet. Money in a pocket is not part of
+-- the wallet's spendable balance but still belongs to the wallet.
+CREATE TABLE IF NOT EXISTS pockets (
+    pocket_id SERIAL PRIMARY KEY,
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    pocket_name TEXT NOT NULL,
+    balance FLOAT NOT NULL DEFAULT 0,
+    pocket_status TEXT NOT NULL DEFAULT 'Active',
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    closed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS pockets_wallet_idx ON pockets (wallet_id, pocket_id);
+CREATE UNIQUE INDEX IF NOT EXISTS pockets_open_name_idx ON pockets (wallet_id, pocket_name) WHERE pocket_status <> 'Closed';
@@ -0,0 +1,24 @@
+-- Maker-checker approvals. Balance adjustments above a tenant's threshold,
+-- and status changes of wallets holding more than it, wait here for a second
+-- user to approve or reject them.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS approval_threshold FLOAT;
+
+CREATE TABLE IF NOT EXISTS approvals (
+    approval_id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    action TEXT NOT NULL,
+    operation TEXT NOT NULL DEFAULT '',
+    amount FLOAT NOT NULL DEFAULT 0,
+    wallet_status TEXT NOT NULL DEFAULT '',
+    approval_status TEXT NOT NULL DEFAULT 'Pending',
+    requested_by TEXT NOT NULL,
+    decided_by TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT (now()),
+    expires_at TIMESTAMP NOT NULL,
+    decided_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS approvals_tenant_status_idx ON approvals (tenant_id, approval_status, approval_id);
+CREATE INDEX IF NOT EXISTS approvals_pending_expiry_idx ON approvals (expires_at) WHERE approval_status = 'Pending';
@@ -0,0 +1,20 @@
+-- Every risk rule evaluated for a debit, whether or not it matched, so rules
+-- can be tuned against what they would have caught.
+CREATE TABLE IF NOT EXISTS risk_evaluations (
+    evaluation_id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    operation TEXT NOT NULL,
+    amount FLOAT NOT NULL,
+    rule_name TEXT NOT NULL,
+    expression TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    matched BOOLEAN NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    decision TEXT NOT NULL,
+    facts JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS risk_evaluations_wallet_idx ON risk_evaluations (tenant_id, wallet_id, evaluation_id);
+CREATE INDEX IF NOT EXISTS risk_evaluations_rule_idx ON risk_evaluations (rule_name, matched);
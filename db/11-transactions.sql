@@ -0,0 +1,18 @@
+-- Balance adjustments made through PUT /wallet/:id, and reversals of them.
+-- A reversal links to the transaction it reverses, whose reversed_amount
+-- tracks how much of it has been refunded so far.
+CREATE TABLE IF NOT EXISTS transactions (
+    transaction_id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    operation TEXT NOT NULL,
+    amount FLOAT NOT NULL CHECK (amount > 0),
+    balance FLOAT NOT NULL,
+    reversal_of BIGINT REFERENCES transactions (transaction_id),
+    reversed_amount FLOAT NOT NULL DEFAULT 0 CHECK (reversed_amount >= 0),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS transactions_wallet_idx ON transactions (tenant_id, wallet_id, transaction_id);
+CREATE INDEX IF NOT EXISTS transactions_reversal_idx ON transactions (reversal_of) WHERE reversal_of IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Fee schedule per tenant and operation type. A rule with an empty currency
+-- is the fallback for currencies without a rule of their own. Fees are
+-- credited to revenue_wallet_id, which must hold the same currency as the
+-- wallet charged.
+CREATE TABLE IF NOT EXISTS fee_rules (
+    fee_rule_id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    operation TEXT NOT NULL,
+    currency TEXT NOT NULL DEFAULT '',
+    flat FLOAT NOT NULL DEFAULT 0 CHECK (flat >= 0),
+    percent FLOAT NOT NULL DEFAULT 0 CHECK (percent >= 0),
+    min_fee FLOAT CHECK (min_fee >= 0),
+    max_fee FLOAT CHECK (max_fee >= 0),
+    tiers JSONB NOT NULL DEFAULT '[]',
+    revenue_wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    UNIQUE (tenant_id, operation, currency)
+);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS fee_of BIGINT REFERENCES transactions (transaction_id);
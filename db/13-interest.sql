@@ -0,0 +1,35 @@
+-- Interest on wallet balances. A tenant with an interest_rate (annual, in
+-- percent) accrues interest daily under its day-count convention and posts
+-- it to wallets monthly. Accruals are kept unrounded; each posting pays the
+-- whole cents and carries the remainder into the next month.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS interest_rate FLOAT CHECK (interest_rate >= 0);
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS interest_day_count TEXT NOT NULL DEFAULT 'ACT/365'
+    CHECK (interest_day_count IN ('ACT/365', 'ACT/360', 'ACT/ACT'));
+
+CREATE TABLE IF NOT EXISTS interest_accruals (
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    accrual_date DATE NOT NULL,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    balance FLOAT NOT NULL,
+    rate FLOAT NOT NULL,
+    day_count TEXT NOT NULL,
+    amount NUMERIC NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    PRIMARY KEY (wallet_id, accrual_date)
+);
+
+CREATE INDEX IF NOT EXISTS interest_accruals_tenant_date_idx ON interest_accruals (tenant_id, accrual_date);
+
+CREATE TABLE IF NOT EXISTS interest_postings (
+    posting_id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    period DATE NOT NULL,
+    accrued NUMERIC NOT NULL,
+    carried_in NUMERIC NOT NULL,
+    amount NUMERIC(20, 2) NOT NULL,
+    carry_out NUMERIC NOT NULL,
+    transaction_id BIGINT REFERENCES transactions (transaction_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    UNIQUE (wallet_id, period)
+);
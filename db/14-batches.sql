@@ -0,0 +1,40 @@
+-- Batches of balance adjustments and transfers submitted together and
+-- processed in the background. Each item records its own outcome; the item
+-- is marked in the same database transaction that moves its money, so a
+-- batch interrupted part way resumes without applying an item twice.
+CREATE TABLE IF NOT EXISTS batches (
+    batch_id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    mode TEXT NOT NULL CHECK (mode IN ('all_or_nothing', 'best_effort')),
+    batch_status TEXT NOT NULL DEFAULT 'Pending'
+        CHECK (batch_status IN ('Pending', 'Processing', 'Completed', 'Failed')),
+    requested_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    claimed_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS batches_tenant_idx ON batches (tenant_id, batch_id);
+CREATE INDEX IF NOT EXISTS batches_open_idx ON batches (batch_id) WHERE batch_status IN ('Pending', 'Processing');
+
+CREATE TABLE IF NOT EXISTS batch_items (
+    item_id BIGSERIAL PRIMARY KEY,
+    batch_id INT NOT NULL REFERENCES batches (batch_id),
+    position INT NOT NULL,
+    operation TEXT NOT NULL CHECK (operation IN ('Add', 'Deduct', 'Transfer')),
+    wallet_id INT NOT NULL,
+    to_wallet_id INT,
+    amount FLOAT NOT NULL CHECK (amount > 0),
+    reference TEXT NOT NULL DEFAULT '',
+    item_status TEXT NOT NULL DEFAULT 'Pending'
+        CHECK (item_status IN ('Pending', 'Succeeded', 'Failed', 'Skipped')),
+    fee FLOAT NOT NULL DEFAULT 0,
+    transaction_id BIGINT REFERENCES transactions (transaction_id),
+    error_code TEXT NOT NULL DEFAULT '',
+    error_message TEXT NOT NULL DEFAULT '',
+    processed_at TIMESTAMPTZ,
+    UNIQUE (batch_id, position)
+);
+
+-- Both legs of a transfer name the other wallet.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS counterparty_wallet_id INT REFERENCES wallets (wallet_id);
@@ -0,0 +1,47 @@
+-- Wallet event store. Every change to a wallet is an event appended to the
+-- wallet's stream; the wallets row and its labels are a projection of the
+-- stream, kept up to date in the same database transaction. Two writers
+-- appending at the same stream version collide on the primary key, which is
+-- the optimistic concurrency check. Events are never changed or removed.
+CREATE TABLE IF NOT EXISTS event_store (
+    stream_id BIGINT NOT NULL,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    stream_version BIGINT NOT NULL CHECK (stream_version > 0),
+    event_type TEXT NOT NULL
+        CHECK (event_type IN ('WalletCreated', 'FundsCredited', 'FundsDebited', 'StatusChanged', 'WalletUpdated')),
+    data JSONB NOT NULL DEFAULT '{}',
+    recorded_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC'),
+    PRIMARY KEY (stream_id, stream_version)
+);
+
+CREATE INDEX IF NOT EXISTS event_store_tenant_idx ON event_store (tenant_id, stream_id);
+
+CREATE OR REPLACE FUNCTION reject_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'event_store is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS event_store_append_only ON event_store;
+CREATE TRIGGER event_store_append_only
+    BEFORE UPDATE OR DELETE ON event_store
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_event_change();
+
+-- Wallets that predate the event store start their stream with a single
+-- WalletCreated event holding their state at the time, at their current
+-- version, so the next change follows on from it.
+INSERT INTO event_store (stream_id, tenant_id, stream_version, event_type, data, recorded_at)
+SELECT w.wallet_id, w.tenant_id, w.version, 'WalletCreated',
+    jsonb_strip_nulls(jsonb_build_object(
+        'currency', w.currency,
+        'status', w.wallet_status,
+        'created_at', to_char(w.created_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'closed_at', to_char(w.closed_at, 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"'),
+        'closure_reason', w.closure_reason,
+        'amount', NULLIF(w.balance, 0),
+        'metadata', w.metadata,
+        'labels', (SELECT jsonb_object_agg(l.label_key, l.label_value) FROM wallet_labels l WHERE l.wallet_id = w.wallet_id)
+    )),
+    w.created_at
+FROM wallets w
+ON CONFLICT (stream_id, stream_version) DO NOTHING;
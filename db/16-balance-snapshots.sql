@@ -0,0 +1,20 @@
+-- Daily balance snapshots. Each row is a wallet's balance and status at the
+-- end of a business day, taken_at being that instant in UTC, and the stream
+-- version it had reached. Point-in-time balances start from the latest
+-- snapshot at or before the time asked for and replay only the events after
+-- it.
+CREATE TABLE IF NOT EXISTS balance_snapshots (
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    snapshot_date DATE NOT NULL,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    taken_at TIMESTAMP NOT NULL,
+    balance FLOAT NOT NULL,
+    wallet_status TEXT NOT NULL,
+    version BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    PRIMARY KEY (wallet_id, snapshot_date)
+);
+
+CREATE INDEX IF NOT EXISTS balance_snapshots_wallet_taken_idx ON balance_snapshots (wallet_id, taken_at DESC);
+CREATE INDEX IF NOT EXISTS balance_snapshots_tenant_idx ON balance_snapshots (tenant_id, snapshot_date);
+CREATE INDEX IF NOT EXISTS event_store_recorded_idx ON event_store (stream_id, recorded_at);
@@ -0,0 +1,10 @@
+-- Public wallet ids. Clients address a wallet by an opaque, random id
+-- instead of its sequential wallet_id, which leaks how many wallets exist
+-- and invites guessing a neighbour's. Adding the column fills in an id for
+-- every existing wallet, the default being evaluated row by row.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS public_id TEXT NOT NULL
+    DEFAULT ('wal_' || encode(gen_random_bytes(16), 'hex'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS wallets_public_id_key ON wallets (public_id);
@@ -0,0 +1,48 @@
+-- Wallet account numbers: ten digits for customers to read out and type,
+-- the last a Luhn check digit so a misheard digit is caught before money
+-- moves. Drawn at random, like public ids, so they give nothing away about
+-- other wallets. new_account_number() draws one no wallet has yet.
+CREATE OR REPLACE FUNCTION new_account_number() RETURNS TEXT AS $$
+DECLARE
+    number TEXT;
+    total INT;
+    digit INT;
+BEGIN
+    LOOP
+        number := lpad(floor(random() * 1000000000)::BIGINT::TEXT, 9, '0');
+        -- From the right, every other digit starting with the last is
+        -- doubled; the check digit brings the sum to a multiple of ten.
+        total := 0;
+        FOR i IN 1..9 LOOP
+            digit := substr(number, 10 - i, 1)::INT;
+            IF i % 2 = 1 THEN
+                digit := digit * 2;
+                IF digit > 9 THEN
+                    digit := digit - 9;
+                END IF;
+            END IF;
+            total := total + digit;
+        END LOOP;
+        number := number || ((10 - total % 10) % 10)::TEXT;
+        EXIT WHEN NOT EXISTS (SELECT 1 FROM wallets WHERE account_number = number);
+    END LOOP;
+    RETURN number;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TABLE wallets ADD COLUMN IF NOT EXISTS account_number TEXT;
+
+-- One statement per wallet, so each draw sees the numbers drawn before it.
+DO $$
+DECLARE
+    w RECORD;
+BEGIN
+    FOR w IN SELECT wallet_id FROM wallets WHERE account_number IS NULL ORDER BY wallet_id LOOP
+        UPDATE wallets SET account_number = new_account_number() WHERE wallet_id = w.wallet_id;
+    END LOOP;
+END;
+$$;
+
+ALTER TABLE wallets ALTER COLUMN account_number SET DEFAULT new_account_number();
+ALTER TABLE wallets ALTER COLUMN account_number SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS wallets_account_number_key ON wallets (account_number);
@@ -0,0 +1,16 @@
+-- Alias directory: phone numbers (E.164), email addresses and handles a
+-- wallet can be paid by. An alias is registered once it has been verified,
+-- by whoever verified it, and points at one wallet of the tenant; closing
+-- the wallet releases its aliases.
+CREATE TABLE IF NOT EXISTS wallet_aliases (
+    alias_id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    wallet_id INT NOT NULL REFERENCES wallets (wallet_id),
+    alias_type TEXT NOT NULL CHECK (alias_type IN ('phone', 'email', 'handle')),
+    alias_value TEXT NOT NULL,
+    verified_by TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    UNIQUE (tenant_id, alias_value)
+);
+
+CREATE INDEX IF NOT EXISTS wallet_aliases_wallet_idx ON wallet_aliases (wallet_id, alias_id);
@@ -0,0 +1,4 @@
+-- PromptPay bill payment. A tenant that takes top-ups by PromptPay has a
+-- 15-digit biller id, its tax id and a two-digit suffix, issued by its
+-- bank; payments into it carry the wallet's account number as reference 1.
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS promptpay_biller_id TEXT CHECK (promptpay_biller_id ~ '^[0-9]{15}$');
@@ -0,0 +1,122 @@
+-- Double-entry ledger. Each tenant has a chart of accounts per currency:
+-- the system accounts 1000 Cash, 1900 Suspense, 3100 Fee payouts, 4000 Fees
+-- revenue and 5000 Interest expense, and a liability account 2000-<wallet
+-- id> per wallet, which holds the wallet's balance and its pockets'. Every
+-- movement of money posts a journal entry in the same database transaction;
+-- an entry whose debits and credits differ is refused at commit. Entries are
+-- never changed or removed.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    account_id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    currency TEXT NOT NULL,
+    account_code TEXT NOT NULL,
+    account_name TEXT NOT NULL,
+    account_type TEXT NOT NULL CHECK (account_type IN ('Asset', 'Liability', 'Equity', 'Revenue', 'Expense')),
+    wallet_id INT UNIQUE REFERENCES wallets (wallet_id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (now()),
+    UNIQUE (tenant_id, currency, account_code)
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    entry_id BIGSERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL REFERENCES tenants (tenant_id),
+    currency TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    transaction_id BIGINT REFERENCES transactions (transaction_id),
+    posted_at TIMESTAMPTZ NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX IF NOT EXISTS journal_entries_tenant_idx ON journal_entries (tenant_id, posted_at);
+CREATE INDEX IF NOT EXISTS journal_entries_transaction_idx ON journal_entries (transaction_id) WHERE transaction_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS journal_lines (
+    line_id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL REFERENCES journal_entries (entry_id),
+    account_id INT NOT NULL REFERENCES ledger_accounts (account_id),
+    debit NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (debit >= 0),
+    credit NUMERIC(20, 2) NOT NULL DEFAULT 0 CHECK (credit >= 0),
+    CHECK ((debit = 0) <> (credit = 0))
+);
+
+CREATE INDEX IF NOT EXISTS journal_lines_entry_idx ON journal_lines (entry_id);
+CREATE INDEX IF NOT EXISTS journal_lines_account_idx ON journal_lines (account_id, entry_id);
+
+-- Checked when the database transaction commits, once every line of the
+-- entry is in.
+CREATE OR REPLACE FUNCTION check_entry_balanced() RETURNS trigger AS $$
+BEGIN
+    IF (SELECT count(*) < 2 OR sum(debit) <> sum(credit) FROM journal_lines WHERE entry_id = NEW.entry_id) THEN
+        RAISE EXCEPTION 'journal entry % does not balance', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS journal_entries_balanced ON journal_entries;
+CREATE CONSTRAINT TRIGGER journal_entries_balanced
+    AFTER INSERT ON journal_entries DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_entry_balanced();
+
+DROP TRIGGER IF EXISTS journal_lines_balanced ON journal_lines;
+CREATE CONSTRAINT TRIGGER journal_lines_balanced
+    AFTER INSERT ON journal_lines DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_entry_balanced();
+
+CREATE OR REPLACE FUNCTION reject_journal_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'the journal is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS journal_entries_append_only ON journal_entries;
+CREATE TRIGGER journal_entries_append_only
+    BEFORE UPDATE OR DELETE ON journal_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_journal_change();
+
+DROP TRIGGER IF EXISTS journal_lines_append_only ON journal_lines;
+CREATE TRIGGER journal_lines_append_only
+    BEFORE UPDATE OR DELETE ON journal_lines
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_journal_change();
+
+-- Money already in wallets when the ledger starts has no entry to show
+-- where it came from, so each wallet opens with its balance and its pockets'
+-- credited against suspense.
+INSERT INTO ledger_accounts (tenant_id, currency, account_code, account_name, account_type)
+SELECT held.tenant_id, held.currency, a.code, a.name, a.type
+FROM (SELECT tenant_id, default_currency AS currency FROM tenants UNION SELECT tenant_id, currency FROM wallets) held
+CROSS JOIN (VALUES
+    ('1000', 'Cash', 'Asset'),
+    ('1900', 'Suspense', 'Asset'),
+    ('3100', 'Fee payouts', 'Equity'),
+    ('4000', 'Fees revenue', 'Revenue'),
+    ('5000', 'Interest expense', 'Expense')
+) AS a (code, name, type)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_accounts (tenant_id, currency, account_code, account_name, account_type, wallet_id)
+SELECT tenant_id, currency, '2000-' || wallet_id, 'Wallet ' || account_number, 'Liability', wallet_id
+FROM wallets
+ON CONFLICT DO NOTHING;
+
+DO $$
+DECLARE
+    opening RECORD;
+    opening_entry BIGINT;
+BEGIN
+    FOR opening IN
+        SELECT a.tenant_id, a.currency, a.account_id, s.account_id AS suspense_id,
+            round((w.balance + COALESCE((SELECT sum(p.balance) FROM pockets p WHERE p.wallet_id = w.wallet_id AND p.pocket_status <> 'Closed'), 0))::NUMERIC, 2) AS amount
+        FROM wallets w
+        JOIN ledger_accounts a ON a.wallet_id = w.wallet_id
+        JOIN ledger_accounts s ON s.tenant_id = w.tenant_id AND s.currency = w.currency AND s.account_code = '1900'
+        WHERE NOT EXISTS (SELECT 1 FROM journal_lines l WHERE l.account_id = a.account_id)
+    LOOP
+        CONTINUE WHEN opening.amount <= 0;
+        INSERT INTO journal_entries (tenant_id, currency, description)
+        VALUES (opening.tenant_id, opening.currency, 'opening balance')
+        RETURNING entry_id INTO opening_entry;
+        INSERT INTO journal_lines (entry_id, account_id, debit, credit)
+        VALUES (opening_entry, opening.suspense_id, opening.amount, 0), (opening_entry, opening.account_id, 0, opening.amount);
+    END LOOP;
+END;
+$$;
@@ -0,0 +1,49 @@
+-- Wallet event ids in commit order. event_id is drawn from its sequence when
+-- the row is inserted, so a transaction holding a lower id can commit after
+-- a higher one has been streamed, and a client resuming from the higher id
+-- would never see it. Each event is instead numbered as its transaction
+-- commits, under a per-tenant lock held until the commit is visible, so a
+-- tenant's positions only ever grow in the order its events become visible.
+-- The position is the id clients see and resume from.
+ALTER TABLE wallet_events ADD COLUMN IF NOT EXISTS position BIGINT;
+
+CREATE SEQUENCE IF NOT EXISTS wallet_event_position;
+
+UPDATE wallet_events SET position = event_id WHERE position IS NULL;
+SELECT setval('wallet_event_position', COALESCE((SELECT max(position) FROM wallet_events), 0) + 1, false);
+
+CREATE UNIQUE INDEX IF NOT EXISTS wallet_events_position_idx ON wallet_events (position);
+CREATE INDEX IF NOT EXISTS wallet_events_tenant_position_idx ON wallet_events (tenant_id, position);
+CREATE INDEX IF NOT EXISTS wallet_events_tenant_wallet_position_idx ON wallet_events (tenant_id, wallet_id, position);
+
+-- Runs when the transaction commits, once all its events are in, and
+-- publishes each event with its position.
+CREATE OR REPLACE FUNCTION position_wallet_event() RETURNS trigger AS $$
+DECLARE
+    assigned BIGINT;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('wallet_events'), hashtext(NEW.tenant_id));
+
+    UPDATE wallet_events SET position = nextval('wallet_event_position')
+    WHERE event_id = NEW.event_id
+    RETURNING position INTO assigned;
+
+    PERFORM pg_notify('wallet_events', json_build_object(
+        'event_id', assigned,
+        'tenant_id', NEW.tenant_id,
+        'wallet_id', NEW.wallet_id,
+        'event_type', NEW.event_type,
+        'amount', NEW.amount,
+        'balance', NEW.balance,
+        'wallet_status', NEW.wallet_status,
+        'created_at', NEW.created_at
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS wallet_events_notify ON wallet_events;
+DROP TRIGGER IF EXISTS wallet_events_position ON wallet_events;
+CREATE CONSTRAINT TRIGGER wallet_events_position
+    AFTER INSERT ON wallet_events DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION position_wallet_event();
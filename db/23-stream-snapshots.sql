@@ -0,0 +1,10 @@
+-- Stream snapshots. Each row is a wallet's aggregate state at a version of
+-- its stream, taken as the stream passes every hundredth event. Loading a
+-- wallet to change it starts from its snapshot and replays only the events
+-- after it, so the write path never reads the wallets projection for state.
+CREATE TABLE IF NOT EXISTS stream_snapshots (
+    stream_id BIGINT PRIMARY KEY,
+    stream_version BIGINT NOT NULL,
+    state JSONB NOT NULL,
+    taken_at TIMESTAMP NOT NULL DEFAULT (now() AT TIME ZONE 'UTC')
+);
@@ -0,0 +1,14 @@
+-- When the event store started. The streams 15-event-store.sql began for
+-- wallets that already existed hold each wallet's state at that time, not
+-- how it got there, so their balances cannot be replayed to any earlier
+-- moment. Installs that already had the store take the first change it
+-- recorded since, the earliest time known to follow the seeding.
+CREATE TABLE IF NOT EXISTS event_store_start (
+    started_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO event_store_start (started_at)
+SELECT COALESCE(
+    (SELECT MIN(e.recorded_at) FROM event_store e WHERE e.event_type <> 'WalletCreated'),
+    now() AT TIME ZONE 'UTC')
+WHERE NOT EXISTS (SELECT 1 FROM event_store_start);
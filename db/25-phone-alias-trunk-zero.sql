@@ -0,0 +1,8 @@
+-- Phone aliases registered with a trunk 0 after the default calling code,
+-- +660812345678, were stored as given rather than as +66812345678, which
+-- lookups now use. Rewrite them, unless the tenant already has the number
+-- in its normal form; such a duplicate can no longer be looked up and is
+-- left for the tenant to remove.
+UPDATE wallet_aliases a SET alias_value = '+66' || substr(a.alias_value, 5)
+WHERE a.alias_type = 'phone' AND a.alias_value LIKE '+660%'
+    AND NOT EXISTS (SELECT 1 FROM wallet_aliases b WHERE b.tenant_id = a.tenant_id AND b.alias_value = '+66' || substr(a.alias_value, 5));
@@ -2,39 +2,302 @@ package errs
 
 import "net/http"
 
+type Code string
+
+const (
+	CodeBadRequest               Code = "BAD_REQUEST"
+	CodeInvalidID                Code = "INVALID_ID"
+	CodeInvalidRequestBody       Code = "INVALID_REQUEST_BODY"
+	CodeValidationFailed         Code = "VALIDATION_FAILED"
+	CodeNotFound                 Code = "NOT_FOUND"
+	CodeWalletNotFound           Code = "WALLET_NOT_FOUND"
+	CodeInsufficientFunds        Code = "INSUFFICIENT_FUNDS"
+	CodeInvalidOperation         Code = "INVALID_OPERATION"
+	CodeInvalidStatus            Code = "INVALID_STATUS"
+	CodeRouteNotFound            Code = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed         Code = "METHOD_NOT_ALLOWED"
+	CodeRateLimited              Code = "RATE_LIMITED"
+	CodePreconditionFailed       Code = "PRECONDITION_FAILED"
+	CodeWalletClosed             Code = "WALLET_CLOSED"
+	CodeBalanceNotZero           Code = "BALANCE_NOT_ZERO"
+	CodeInvalidDestination       Code = "INVALID_DESTINATION"
+	CodeUnauthorized             Code = "UNAUTHORIZED"
+	CodeForbidden                Code = "FORBIDDEN"
+	CodeTenantNotFound           Code = "TENANT_NOT_FOUND"
+	CodeLimitExceeded            Code = "LIMIT_EXCEEDED"
+	CodePocketNotFound           Code = "POCKET_NOT_FOUND"
+	CodePocketNameTaken          Code = "POCKET_NAME_TAKEN"
+	CodeWalletInactive           Code = "WALLET_INACTIVE"
+	CodeApprovalNotFound         Code = "APPROVAL_NOT_FOUND"
+	CodeApprovalNotPending       Code = "APPROVAL_NOT_PENDING"
+	CodeApprovalExpired          Code = "APPROVAL_EXPIRED"
+	CodeTransactionDenied        Code = "TRANSACTION_DENIED"
+	CodeTransactionNotFound      Code = "TRANSACTION_NOT_FOUND"
+	CodeTransactionReversed      Code = "TRANSACTION_REVERSED"
+	CodeTransactionNotReversible Code = "TRANSACTION_NOT_REVERSIBLE"
+	CodeReversalExceedsOriginal  Code = "REVERSAL_EXCEEDS_ORIGINAL"
+	CodeInterestNotConfigured    Code = "INTEREST_NOT_CONFIGURED"
+	CodePromptPayNotConfigured   Code = "PROMPTPAY_NOT_CONFIGURED"
+	CodeBatchNotFound            Code = "BATCH_NOT_FOUND"
+	CodeAliasNotFound            Code = "ALIAS_NOT_FOUND"
+	CodeAliasTaken               Code = "ALIAS_TAKEN"
+	CodeLedgerAccountNotFound    Code = "LEDGER_ACCOUNT_NOT_FOUND"
+	CodeApprovalRequired         Code = "APPROVAL_REQUIRED"
+	CodeUnsupportedMediaType     Code = "UNSUPPORTED_MEDIA_TYPE"
+	CodeInternal                 Code = "INTERNAL_ERROR"
+)
+
+var titles = map[Code]string{
+	CodeBadRequest:               "Bad request",
+	CodeInvalidID:                "Invalid identifier",
+	CodeInvalidRequestBody:       "Invalid request body",
+	CodeValidationFailed:         "Validation failed",
+	CodeNotFound:                 "Resource not found",
+	CodeWalletNotFound:           "Wallet not found",
+	CodeInsufficientFunds:        "Insufficient funds",
+	CodeInvalidOperation:         "Invalid operation",
+	CodeInvalidStatus:            "Invalid status",
+	CodeRouteNotFound:            "Route not found",
+	CodeMethodNotAllowed:         "Method not allowed",
+	CodeRateLimited:              "Too many requests",
+	CodePreconditionFailed:       "Precondition failed",
+	CodeWalletClosed:             "Wallet closed",
+	CodeBalanceNotZero:           "Balance not zero",
+	CodeInvalidDestination:       "Invalid destination wallet",
+	CodeUnauthorized:             "Unauthorized",
+	CodeForbidden:                "Forbidden",
+	CodeTenantNotFound:           "Tenant not found",
+	CodeLimitExceeded:            "Limit exceeded",
+	CodePocketNotFound:           "Pocket not found",
+	CodePocketNameTaken:          "Pocket name taken",
+	CodeWalletInactive:           "Wallet inactive",
+	CodeApprovalNotFound:         "Approval not found",
+	CodeApprovalNotPending:       "Approval not pending",
+	CodeApprovalExpired:          "Approval expired",
+	CodeTransactionDenied:        "Transaction denied",
+	CodeTransactionNotFound:      "Transaction not found",
+	CodeTransactionReversed:      "Transaction already reversed",
+	CodeTransactionNotReversible: "Transaction not reversible",
+	CodeReversalExceedsOriginal:  "Reversal exceeds original",
+	CodeInterestNotConfigured:    "Interest not configured",
+	CodePromptPayNotConfigured:   "PromptPay not configured",
+	CodeBatchNotFound:            "Batch not found",
+	CodeAliasNotFound:            "Alias not found",
+	CodeAliasTaken:               "Alias taken",
+	CodeLedgerAccountNotFound:    "Ledger account not found",
+	CodeApprovalRequired:         "Approval required",
+	CodeUnsupportedMediaType:     "Unsupported media type",
+	CodeInternal:                 "Internal server error",
+}
+
+func (c Code) Title() string {
+	if title, ok := titles[c]; ok {
+		return title
+	}
+	return http.StatusText(http.StatusInternalServerError)
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 type AppError struct {
-	Code    int
+	Status  int
+	Code    Code
 	Message string
+	Fields  []FieldError
+	Err     error
 }
 
 func (e AppError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
 	return e.Message
 }
 
-func NewNotFoundError(message string) error {
-	return AppError{
-		Code:    http.StatusNotFound,
-		Message: message,
-	}
+func (e AppError) Unwrap() error {
+	return e.Err
 }
 
-func NewUnexpectedError() error {
-	return AppError{
-		Code:    http.StatusInternalServerError,
-		Message: "unexpected error",
+// Is matches on status and code only, so a wrapped cause does not stop
+// errors.Is(err, errs.NewUnexpectedError()) from matching.
+func (e AppError) Is(target error) bool {
+	t, ok := target.(AppError)
+	if !ok {
+		return false
 	}
+	return e.Status == t.Status && e.Code == t.Code
 }
 
-func NewValidationError(message string) error {
-	return AppError{
-		Code:    http.StatusUnprocessableEntity,
-		Message: message,
-	}
+func (e AppError) WithCause(err error) AppError {
+	e.Err = err
+	return e
 }
 
-func NewBadRequest(message string) error {
+func New(status int, code Code, message string) AppError {
 	return AppError{
-		Code:    http.StatusBadRequest,
+		Status:  status,
+		Code:    code,
 		Message: message,
 	}
 }
+
+func NewNotFoundError(message string) AppError {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func NewWalletNotFoundError() AppError {
+	return New(http.StatusNotFound, CodeWalletNotFound, "wallet not found")
+}
+
+func NewUnexpectedError() AppError {
+	return New(http.StatusInternalServerError, CodeInternal, "unexpected error")
+}
+
+func NewValidationError(message string) AppError {
+	return New(http.StatusUnprocessableEntity, CodeValidationFailed, message)
+}
+
+func NewFieldValidationError(fields []FieldError) AppError {
+	e := New(http.StatusUnprocessableEntity, CodeValidationFailed, "request validation failed")
+	e.Fields = fields
+	return e
+}
+
+func NewBadRequest(message string) AppError {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func NewInvalidIDError() AppError {
+	return New(http.StatusBadRequest, CodeInvalidID, "id must be number")
+}
+
+func NewInvalidRequestBodyError() AppError {
+	return New(http.StatusBadRequest, CodeInvalidRequestBody, "request body incorrect format")
+}
+
+func NewInsufficientFundsError() AppError {
+	return New(http.StatusBadRequest, CodeInsufficientFunds, "balance not enough")
+}
+
+func NewInvalidOperationError(message string) AppError {
+	return New(http.StatusBadRequest, CodeInvalidOperation, message)
+}
+
+func NewInvalidStatusError(message string) AppError {
+	return New(http.StatusBadRequest, CodeInvalidStatus, message)
+}
+
+func NewRateLimitedError() AppError {
+	return New(http.StatusTooManyRequests, CodeRateLimited, "rate limit exceeded, retry later")
+}
+
+func NewPreconditionFailedError() AppError {
+	return New(http.StatusPreconditionFailed, CodePreconditionFailed, "wallet has been modified, reload and retry")
+}
+
+func NewWalletClosedError() AppError {
+	return New(http.StatusConflict, CodeWalletClosed, "wallet is closed and read-only")
+}
+
+func NewBalanceNotZeroError() AppError {
+	return New(http.StatusConflict, CodeBalanceNotZero, "wallet balance must be zero or a destination wallet given")
+}
+
+func NewInvalidDestinationError(message string) AppError {
+	return New(http.StatusUnprocessableEntity, CodeInvalidDestination, message)
+}
+
+func NewUnauthorizedError() AppError {
+	return New(http.StatusUnauthorized, CodeUnauthorized, "missing or invalid api key")
+}
+
+func NewForbiddenError(message string) AppError {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NewTenantNotFoundError() AppError {
+	return New(http.StatusForbidden, CodeTenantNotFound, "tenant not found")
+}
+
+func NewLimitExceededError(message string) AppError {
+	return New(http.StatusUnprocessableEntity, CodeLimitExceeded, message)
+}
+
+func NewPocketNotFoundError() AppError {
+	return New(http.StatusNotFound, CodePocketNotFound, "pocket not found")
+}
+
+func NewPocketNameTakenError() AppError {
+	return New(http.StatusConflict, CodePocketNameTaken, "wallet already has an open pocket with this name")
+}
+
+func NewWalletInactiveError() AppError {
+	return New(http.StatusConflict, CodeWalletInactive, "wallet and pocket must be active")
+}
+
+func NewApprovalNotFoundError() AppError {
+	return New(http.StatusNotFound, CodeApprovalNotFound, "approval not found")
+}
+
+func NewApprovalNotPendingError() AppError {
+	return New(http.StatusConflict, CodeApprovalNotPending, "approval has already been decided")
+}
+
+func NewApprovalExpiredError() AppError {
+	return New(http.StatusConflict, CodeApprovalExpired, "approval has expired")
+}
+
+func NewTransactionDeniedError() AppError {
+	return New(http.StatusUnprocessableEntity, CodeTransactionDenied, "transaction declined by risk checks")
+}
+
+func NewTransactionNotFoundError() AppError {
+	return New(http.StatusNotFound, CodeTransactionNotFound, "transaction not found")
+}
+
+func NewTransactionReversedError() AppError {
+	return New(http.StatusConflict, CodeTransactionReversed, "transaction has already been fully reversed")
+}
+
+func NewTransactionNotReversibleError() AppError {
+	return New(http.StatusConflict, CodeTransactionNotReversible, "reversals, fees and transfers cannot be reversed")
+}
+
+func NewReversalExceedsOriginalError() AppError {
+	return New(http.StatusUnprocessableEntity, CodeReversalExceedsOriginal, "total reversed would exceed the original amount")
+}
+
+func NewInterestNotConfiguredError() AppError {
+	return New(http.StatusConflict, CodeInterestNotConfigured, "tenant has no interest rate")
+}
+
+func NewPromptPayNotConfiguredError() AppError {
+	return New(http.StatusConflict, CodePromptPayNotConfigured, "tenant has no PromptPay biller id")
+}
+
+func NewBatchNotFoundError() AppError {
+	return New(http.StatusNotFound, CodeBatchNotFound, "batch not found")
+}
+
+func NewAliasNotFoundError() AppError {
+	return New(http.StatusNotFound, CodeAliasNotFound, "alias not found")
+}
+
+func NewAliasTakenError() AppError {
+	return New(http.StatusConflict, CodeAliasTaken, "alias is already registered to a wallet")
+}
+
+func NewLedgerAccountNotFoundError() AppError {
+	return New(http.StatusNotFound, CodeLedgerAccountNotFound, "ledger account not found")
+}
+
+func NewApprovalRequiredError(message string) AppError {
+	return New(http.StatusUnprocessableEntity, CodeApprovalRequired, message)
+}
+
+func NewUnsupportedMediaTypeError(message string) AppError {
+	return New(http.StatusUnsupportedMediaType, CodeUnsupportedMediaType, message)
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+const subscriberBuffer = 64
+
+type subscriber struct {
+	tenantID string
+	walletID int64
+	ch       chan repository.WalletEvent
+}
+
+// Broker fans committed wallet events out to in-process subscribers. A
+// subscriber that falls too far behind is dropped; its channel is closed
+// and the client is expected to reconnect with Last-Event-ID.
+type Broker struct {
+	mu          sync.Mutex
+	nextID      int
+	subscribers map[int]*subscriber
+}
+
+func NewBroker() *Broker {
+	return &Broker{subscribers: map[int]*subscriber{}}
+}
+
+// Subscribe registers for events of walletID within tenantID, or every
+// wallet of the tenant when walletID is 0. The returned function
+// unsubscribes and must be called.
+func (b *Broker) Subscribe(tenantID string, walletID int64) (<-chan repository.WalletEvent, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	s := &subscriber{tenantID: tenantID, walletID: walletID, ch: make(chan repository.WalletEvent, subscriberBuffer)}
+	b.subscribers[id] = s
+
+	return s.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[id]; ok {
+			delete(b.subscribers, id)
+			close(s.ch)
+		}
+	}
+}
+
+func (b *Broker) Publish(event repository.WalletEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, s := range b.subscribers {
+		if s.tenantID != event.TenantID || (s.walletID != 0 && s.walletID != event.WalletID) {
+			continue
+		}
+		select {
+		case s.ch <- event:
+		default:
+			delete(b.subscribers, id)
+			close(s.ch)
+		}
+	}
+}
+
+// Disconnect drops every subscriber, closing its channel, so clients
+// reconnect with Last-Event-ID and replay what the broker may have missed.
+func (b *Broker) Disconnect() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, s := range b.subscribers {
+		delete(b.subscribers, id)
+		close(s.ch)
+	}
+}
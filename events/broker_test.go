@@ -0,0 +1,101 @@
+//go:build unit
+// +build unit
+
+package events_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/events"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+func TestBroker(t *testing.T) {
+	t.Run("deliver events of subscribed wallet only", func(t *testing.T) {
+		// Arrange
+		broker := events.NewBroker()
+		wallet1, unsubscribe1 := broker.Subscribe("acme", 1)
+		defer unsubscribe1()
+		all, unsubscribeAll := broker.Subscribe("acme", 0)
+		defer unsubscribeAll()
+
+		// Act
+		broker.Publish(repository.WalletEvent{TenantID: "acme", EventID: 1, WalletID: 1})
+		broker.Publish(repository.WalletEvent{TenantID: "acme", EventID: 2, WalletID: 2})
+
+		// Assert
+		assert.Equal(t, int64(1), (<-wallet1).EventID)
+		assert.Len(t, wallet1, 0)
+		assert.Equal(t, int64(1), (<-all).EventID)
+		assert.Equal(t, int64(2), (<-all).EventID)
+	})
+
+	t.Run("deliver events of subscribed tenant only", func(t *testing.T) {
+		// Arrange
+		broker := events.NewBroker()
+		acme, unsubscribeAcme := broker.Subscribe("acme", 0)
+		defer unsubscribeAcme()
+
+		// Act
+		broker.Publish(repository.WalletEvent{TenantID: "globex", EventID: 1, WalletID: 1})
+		broker.Publish(repository.WalletEvent{TenantID: "acme", EventID: 2, WalletID: 1})
+
+		// Assert
+		assert.Equal(t, int64(2), (<-acme).EventID)
+		assert.Len(t, acme, 0)
+	})
+
+	t.Run("unsubscribe closes channel", func(t *testing.T) {
+		// Arrange
+		broker := events.NewBroker()
+		ch, unsubscribe := broker.Subscribe("acme", 1)
+
+		// Act
+		unsubscribe()
+		unsubscribe()
+		broker.Publish(repository.WalletEvent{TenantID: "acme", EventID: 1, WalletID: 1})
+		_, ok := <-ch
+
+		// Assert
+		assert.False(t, ok)
+	})
+
+	t.Run("drop slow subscriber", func(t *testing.T) {
+		// Arrange
+		broker := events.NewBroker()
+		ch, unsubscribe := broker.Subscribe("acme", 1)
+		defer unsubscribe()
+
+		// Act
+		for i := 0; i < 100; i++ {
+			broker.Publish(repository.WalletEvent{TenantID: "acme", EventID: int64(i), WalletID: 1})
+		}
+		count := 0
+		for range ch {
+			count++
+		}
+
+		// Assert
+		assert.Equal(t, 64, count)
+	})
+
+	t.Run("disconnect closes every channel", func(t *testing.T) {
+		// Arrange
+		broker := events.NewBroker()
+		wallet1, unsubscribe1 := broker.Subscribe("acme", 1)
+		defer unsubscribe1()
+		globex, unsubscribeGlobex := broker.Subscribe("globex", 0)
+		defer unsubscribeGlobex()
+
+		// Act
+		broker.Disconnect()
+		broker.Publish(repository.WalletEvent{TenantID: "acme", EventID: 1, WalletID: 1})
+		_, wallet1Open := <-wallet1
+		_, globexOpen := <-globex
+
+		// Assert
+		assert.False(t, wallet1Open)
+		assert.False(t, globexOpen)
+	})
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/logs"
+	"github.com/topnarapat/go-wallet/repository"
+	"go.uber.org/zap"
+)
+
+// Channel is the Postgres NOTIFY channel the wallet_events trigger publishes to.
+const Channel = "wallet_events"
+
+type notification struct {
+	EventID   int64     `json:"event_id"`
+	TenantID  string    `json:"tenant_id"`
+	WalletID  int64     `json:"wallet_id"`
+	Type      string    `json:"event_type"`
+	Amount    float64   `json:"amount"`
+	Balance   float64   `json:"balance"`
+	Status    string    `json:"wallet_status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Listen relays NOTIFY payloads from every instance's commits into broker
+// until ctx is cancelled. Notifications sent while the connection was down
+// are lost, so after a reconnect every subscriber is dropped to resync from
+// the event history.
+func Listen(ctx context.Context, dsn string, broker *Broker) error {
+	listener := pq.NewListener(dsn, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logs.Error(err, zap.String("listener", Channel))
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(Channel)
+	if err != nil {
+		return err
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ping.C:
+			go listener.Ping()
+		case n := <-listener.Notify:
+			if n == nil {
+				broker.Disconnect()
+				continue
+			}
+			event := notification{}
+			if err := json.Unmarshal([]byte(n.Extra), &event); err != nil {
+				logs.Error(err, zap.String("listener", Channel))
+				continue
+			}
+			broker.Publish(repository.WalletEvent(event))
+		}
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/labstack/echo/v4 v4.10.0
 	github.com/lib/pq v1.10.7
 	github.com/stretchr/testify v1.8.1
+	github.com/swaggo/files/v2 v2.0.2
 	go.uber.org/zap v1.24.0
 )
 
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type aliasHandler struct {
+	aliasSrv service.AliasService
+}
+
+func NewAliasHandler(aliasSrv service.AliasService) aliasHandler {
+	return aliasHandler{aliasSrv: aliasSrv}
+}
+
+func (h aliasHandler) ListAliases(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	aliases, err := h.aliasSrv.ListAliases(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, aliases)
+}
+
+// CreateAlias registers an alias for the wallet. Only admins may, as the
+// caller vouches that the owner proved the phone number or email is
+// theirs; the caller is recorded as having verified it.
+func (h aliasHandler) CreateAlias(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	request := service.AliasRequest{}
+	err = bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+	request.VerifiedBy = principalID(c)
+
+	created, err := h.aliasSrv.CreateAlias(auth.TenantID(c), int64(id), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, created)
+}
+
+func (h aliasHandler) DeleteAlias(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+	aliasID, err := strconv.Atoi(c.Param("alias_id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	err = h.aliasSrv.DeleteAlias(auth.TenantID(c), int64(id), int64(aliasID))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ResolveAlias answers GET /aliases/:alias with a masked summary of the
+// wallet the alias points at. The router leaves path parameters escaped,
+// so an alias sent as %2B66812345678 is unescaped here.
+func (h aliasHandler) ResolveAlias(c echo.Context) error {
+	value, err := url.PathUnescape(c.Param("alias"))
+	if err != nil {
+		value = c.Param("alias")
+	}
+
+	resolution, err := h.aliasSrv.ResolveAlias(auth.TenantID(c), value)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, resolution)
+}
@@ -0,0 +1,180 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestCreateAlias(t *testing.T) {
+	t.Run("register alias as verified by caller", func(t *testing.T) {
+		// Arrange
+		aliasService := service.NewAliasServiceMock()
+		aliasService.On("CreateAlias", "acme", int64(1), service.AliasRequest{Alias: "0812345678", VerifiedBy: "ops"}).Return(&service.AliasResponse{
+			AliasID:    5,
+			WalletID:   1,
+			Type:       "phone",
+			Alias:      "+66812345678",
+			VerifiedBy: "ops",
+			CreatedAt:  time.Date(2023, time.May, 2, 9, 0, 0, 0, time.UTC),
+		}, nil)
+
+		aliasHandler := handler.NewAliasHandler(aliasService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"alias":"0812345678"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+		c.SetPath("/wallet/:id/aliases")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `{"alias_id":5,"wallet_id":1,"type":"phone","alias":"+66812345678","verified_by":"ops","created_at":"2023-05-02T09:00:00Z"}`
+
+		// Assert
+		if assert.NoError(t, aliasHandler.CreateAlias(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		// Arrange
+		aliasService := service.NewAliasServiceMock()
+		aliasHandler := handler.NewAliasHandler(aliasService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"alias":"0812345678"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		auth.SetPrincipal(c, auth.Principal{ID: "maker", TenantID: "acme"}, "acme")
+		c.SetPath("/wallet/:id/aliases")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, aliasHandler.CreateAlias(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			aliasService.AssertNotCalled(t, "CreateAlias", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("alias taken", func(t *testing.T) {
+		// Arrange
+		aliasService := service.NewAliasServiceMock()
+		aliasService.On("CreateAlias", "acme", int64(1), service.AliasRequest{Alias: "@somchai", VerifiedBy: "ops"}).Return(&service.AliasResponse{}, errs.NewAliasTakenError())
+
+		aliasHandler := handler.NewAliasHandler(aliasService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"alias":"@somchai"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+		c.SetPath("/wallet/:id/aliases")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, aliasHandler.CreateAlias(c)) {
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Contains(t, rec.Body.String(), string(errs.CodeAliasTaken))
+		}
+	})
+}
+
+func TestDeleteAlias(t *testing.T) {
+	t.Run("remove alias", func(t *testing.T) {
+		// Arrange
+		aliasService := service.NewAliasServiceMock()
+		aliasService.On("DeleteAlias", "acme", int64(1), int64(5)).Return(nil)
+
+		aliasHandler := handler.NewAliasHandler(aliasService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodDelete, "/", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+		c.SetPath("/wallet/:id/aliases/:alias_id")
+		c.SetParamNames("id", "alias_id")
+		c.SetParamValues("1", "5")
+
+		// Assert
+		if assert.NoError(t, aliasHandler.DeleteAlias(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			aliasService.AssertExpectations(t)
+		}
+	})
+}
+
+func TestResolveAlias(t *testing.T) {
+	t.Run("escaped phone number", func(t *testing.T) {
+		// Arrange
+		aliasService := service.NewAliasServiceMock()
+		aliasService.On("ResolveAlias", auth.DefaultTenant, "+66812345678").Return(&service.AliasResolution{
+			Type:          "phone",
+			Alias:         "+66812345678",
+			AccountNumber: "xxx-x-xx789-7",
+			DisplayName:   "S****** J*****",
+			Currency:      "THB",
+			Status:        "Active",
+		}, nil)
+
+		aliasHandler := handler.NewAliasHandler(aliasService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/aliases/:alias")
+		c.SetParamNames("alias")
+		c.SetParamValues("%2B66812345678")
+
+		expected := `{"type":"phone","alias":"+66812345678","account_number":"xxx-x-xx789-7","display_name":"S****** J*****","currency":"THB","status":"Active"}`
+
+		// Assert
+		if assert.NoError(t, aliasHandler.ResolveAlias(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("alias not found", func(t *testing.T) {
+		// Arrange
+		aliasService := service.NewAliasServiceMock()
+		aliasService.On("ResolveAlias", auth.DefaultTenant, "@somchai").Return(&service.AliasResolution{}, errs.NewAliasNotFoundError())
+
+		aliasHandler := handler.NewAliasHandler(aliasService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/aliases/:alias")
+		c.SetParamNames("alias")
+		c.SetParamValues("@somchai")
+
+		// Assert
+		if assert.NoError(t, aliasHandler.ResolveAlias(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Contains(t, rec.Body.String(), string(errs.CodeAliasNotFound))
+		}
+	})
+}
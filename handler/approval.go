@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type approvalHandler struct {
+	approvalSrv service.ApprovalService
+}
+
+func NewApprovalHandler(approvalSrv service.ApprovalService) approvalHandler {
+	return approvalHandler{approvalSrv: approvalSrv}
+}
+
+func (h approvalHandler) ListApprovals(c echo.Context) error {
+	filter := service.ListApprovalsRequest{}
+	err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter)
+	if err != nil {
+		return handlerError(c, errs.NewBadRequest("query parameters incorrect format"))
+	}
+
+	approvals, err := h.approvalSrv.ListApprovals(auth.TenantID(c), filter)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, approvals)
+}
+
+func (h approvalHandler) GetApproval(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	approval, err := h.approvalSrv.GetApproval(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, approval)
+}
+
+func (h approvalHandler) Approve(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	approver, err := approverID(c)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	approval, err := h.approvalSrv.Approve(auth.TenantID(c), int64(id), approver)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, approval)
+}
+
+func (h approvalHandler) Reject(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	approver, err := approverID(c)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	request := service.RejectApprovalRequest{}
+	err = bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	approval, err := h.approvalSrv.Reject(auth.TenantID(c), int64(id), approver, request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, approval)
+}
+
+// approverID returns the caller's principal id if it may decide approvals.
+func approverID(c echo.Context) (string, error) {
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok || !principal.HasRole(auth.RoleApprover) {
+		return "", errs.NewForbiddenError("approver role required")
+	}
+
+	return principal.ID, nil
+}
+
+// principalID returns the caller's principal id, or "" when the request was
+// not authenticated.
+func principalID(c echo.Context) string {
+	principal, _ := auth.PrincipalFrom(c)
+	return principal.ID
+}
+
+// heldForApproval writes 202 Accepted with the pending approval when err
+// reports that the change was held for a second approver.
+func heldForApproval(c echo.Context, err error) (bool, error) {
+	var held service.ApprovalRequiredError
+	if !errors.As(err, &held) {
+		return false, nil
+	}
+
+	return true, c.JSON(http.StatusAccepted, held.Approval)
+}
@@ -0,0 +1,114 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestApprove(t *testing.T) {
+	t.Run("approve as approver", func(t *testing.T) {
+		// Arrange
+		var id int64 = 7
+		approvalService := service.NewApprovalServiceMock()
+		approvalService.On("Approve", "acme", id, "checker").Return(&service.ApprovalResponse{
+			ApprovalID: id,
+			Status:     "Approved",
+		}, nil)
+
+		approvalHandler := handler.NewApprovalHandler(approvalService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/approvals/:id/approve")
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+		auth.SetPrincipal(c, auth.Principal{ID: "checker", TenantID: "acme", Roles: []string{auth.RoleApprover}}, "acme")
+
+		// Assert
+		if assert.NoError(t, approvalHandler.Approve(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"status":"Approved"`)
+		}
+	})
+
+	t.Run("approve without approver role", func(t *testing.T) {
+		// Arrange
+		approvalService := service.NewApprovalServiceMock()
+
+		approvalHandler := handler.NewApprovalHandler(approvalService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/approvals/:id/approve")
+		c.SetParamNames("id")
+		c.SetParamValues("7")
+		auth.SetPrincipal(c, auth.Principal{ID: "maker", TenantID: "acme"}, "acme")
+
+		// Assert
+		if assert.NoError(t, approvalHandler.Approve(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			assert.Contains(t, rec.Body.String(), string(errs.CodeForbidden))
+			approvalService.AssertNotCalled(t, "Approve")
+		}
+	})
+}
+
+func TestAddBalanceHeldForApproval(t *testing.T) {
+	t.Run("large adjustment returns pending approval", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.AddWalletRequest{Balance: 50000, Operation: "Add", RequestedBy: "maker"}
+		walletService := service.NewWalletServiceMock()
+		walletService.On("SetWalletBalance", "acme", id, request).Return(&service.WalletResponse{}, service.ApprovalRequiredError{Approval: service.ApprovalResponse{
+			ApprovalID:  7,
+			WalletID:    1,
+			Action:      "balance",
+			Operation:   "Add",
+			Amount:      50000,
+			Status:      "Pending",
+			RequestedBy: "maker",
+			CreatedAt:   time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+			ExpiresAt:   time.Date(2023, time.January, 28, 12, 30, 0, 0, time.UTC),
+		}})
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"balance":50000,"operation":"Add"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		auth.SetPrincipal(c, auth.Principal{ID: "maker", TenantID: "acme"}, "acme")
+
+		expected := `{"approval_id":7,"wallet_id":1,"action":"balance","operation":"Add","amount":50000,"status":"Pending","requested_by":"maker","created_at":"2023-01-27T12:30:00Z","expires_at":"2023-01-28T12:30:00Z"}`
+
+		// Assert
+		if assert.NoError(t, walletHandler.AddBalance(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/logs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type balanceHandler struct {
+	balanceSrv service.BalanceService
+}
+
+func NewBalanceHandler(balanceSrv service.BalanceService) balanceHandler {
+	return balanceHandler{balanceSrv: balanceSrv}
+}
+
+// AsOf answers GET /wallet/:id?as_of= with the wallet's balance at that
+// time and leaves requests without as_of to next.
+func (h balanceHandler) AsOf(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if _, ok := c.QueryParams()["as_of"]; !ok {
+			return next(c)
+		}
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return handlerError(c, errs.NewInvalidIDError())
+		}
+
+		balance, err := h.balanceSrv.GetBalanceAsOf(auth.TenantID(c), int64(id), c.QueryParam("as_of"))
+		if err != nil {
+			return handlerError(c, err)
+		}
+
+		return c.JSON(http.StatusOK, balance)
+	}
+}
+
+func (h balanceHandler) GetBalanceReport(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	asOf := c.QueryParam("as_of")
+	switch c.QueryParam("format") {
+	case "", service.FormatJSON:
+		report, err := h.balanceSrv.GetBalanceReport(auth.TenantID(c), asOf)
+		if err != nil {
+			return handlerError(c, err)
+		}
+		return c.JSON(http.StatusOK, report)
+	case service.FormatCSV:
+	default:
+		return handlerError(c, errs.NewFieldValidationError([]errs.FieldError{{Field: "format", Message: "must be one of json, csv"}}))
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, mimeTextCSV+"; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="balances.%s"`, service.FormatCSV))
+
+	err := h.balanceSrv.ExportBalanceReport(auth.TenantID(c), asOf, res)
+	if err != nil {
+		if !res.Committed {
+			return handlerError(c, err)
+		}
+		// The status has been sent; all that is left is to cut the body short.
+		logs.Error(err)
+	}
+	return nil
+}
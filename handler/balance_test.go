@@ -0,0 +1,112 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestBalanceAsOf(t *testing.T) {
+	t.Run("balance at a point in time", func(t *testing.T) {
+		// Arrange
+		balanceService := service.NewBalanceServiceMock()
+		balanceService.On("GetBalanceAsOf", auth.DefaultTenant, int64(42), "2023-03-31T23:59:59+07:00").Return(&service.BalanceResponse{
+			WalletID: 42,
+			AsOf:     time.Date(2023, time.March, 31, 23, 59, 59, 0, time.FixedZone("ICT", 7*60*60)),
+			Currency: "THB",
+			Balance:  1250.5,
+			Status:   "Active",
+			Version:  17,
+		}, nil)
+
+		next := func(c echo.Context) error { return c.NoContent(http.StatusTeapot) }
+		balanceHandler := handler.NewBalanceHandler(balanceService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/wallet/42?as_of=2023-03-31T23:59:59%2B07:00", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("42")
+
+		expected := `{"wallet_id":42,"as_of":"2023-03-31T23:59:59+07:00","currency":"THB","balance":1250.5,"status":"Active","version":17}`
+
+		// Assert
+		if assert.NoError(t, balanceHandler.AsOf(next)(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("current wallet without as_of", func(t *testing.T) {
+		// Arrange
+		balanceService := service.NewBalanceServiceMock()
+		next := func(c echo.Context) error { return c.NoContent(http.StatusTeapot) }
+		balanceHandler := handler.NewBalanceHandler(balanceService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/wallet/42", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, balanceHandler.AsOf(next)(c)) {
+			assert.Equal(t, http.StatusTeapot, rec.Code)
+			balanceService.AssertNotCalled(t, "GetBalanceAsOf", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestGetBalanceReport(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		// Arrange
+		body := "wallet_id,currency,balance,status,version\n42,THB,1250.50,Active,17\n"
+		balanceService := service.NewBalanceServiceMock()
+		balanceService.On("ExportBalanceReport", "acme", "2023-03-31", mock.Anything).Return(body, nil)
+
+		balanceHandler := handler.NewBalanceHandler(balanceService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/balances?as_of=2023-03-31&format=csv", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, balanceHandler.GetBalanceReport(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, `attachment; filename="balances.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+			assert.Equal(t, body, rec.Body.String())
+		}
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		// Arrange
+		balanceService := service.NewBalanceServiceMock()
+		balanceHandler := handler.NewBalanceHandler(balanceService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/balances?as_of=2023-03-31", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		auth.SetPrincipal(c, auth.Principal{ID: "maker", TenantID: "acme"}, "acme")
+
+		// Assert
+		if assert.NoError(t, balanceHandler.GetBalanceReport(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			balanceService.AssertNotCalled(t, "GetBalanceReport", mock.Anything, mock.Anything)
+		}
+	})
+}
@@ -0,0 +1,282 @@
+package handler
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+	"github.com/topnarapat/go-wallet/validate"
+)
+
+const mimeTextCSV = "text/csv"
+
+// batchColumns are the CSV columns a batch may have; the first three are
+// required.
+var batchColumns = []string{"operation", "wallet_id", "amount", "to_account_number", "to_alias", "reference"}
+
+var batchResultColumns = []string{"position", "operation", "wallet_id", "to_wallet_id", "amount", "reference", "status", "fee", "transaction_id", "error_code", "error_message"}
+
+type batchHandler struct {
+	batchSrv service.BatchService
+}
+
+func NewBatchHandler(batchSrv service.BatchService) batchHandler {
+	return batchHandler{batchSrv: batchSrv}
+}
+
+// CreateBatch accepts the batch for background processing and points to
+// where its progress can be polled.
+func (h batchHandler) CreateBatch(c echo.Context) error {
+	request, err := bindBatch(c)
+	if err != nil {
+		return handlerError(c, err)
+	}
+	request.RequestedBy = principalID(c)
+
+	batch, err := h.batchSrv.CreateBatch(auth.TenantID(c), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/batches/%d", batch.BatchID))
+	return c.JSON(http.StatusAccepted, batch)
+}
+
+func (h batchHandler) GetBatch(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	batch, err := h.batchSrv.GetBatch(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, batch)
+}
+
+// DownloadResults writes one CSV row per item with its outcome so far.
+func (h batchHandler) DownloadResults(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	results, err := h.batchSrv.GetBatchResults(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, mimeTextCSV+"; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="batch-%d-results.csv"`, id))
+	c.Response().WriteHeader(http.StatusOK)
+
+	w := csv.NewWriter(c.Response())
+	w.Write(batchResultColumns)
+	for _, r := range results {
+		toWalletID, transactionID := "", ""
+		if r.ToWalletID != 0 {
+			toWalletID = strconv.FormatInt(r.ToWalletID, 10)
+		}
+		if r.TransactionID != nil {
+			transactionID = strconv.FormatInt(*r.TransactionID, 10)
+		}
+		w.Write([]string{
+			strconv.Itoa(r.Position),
+			r.Operation,
+			strconv.FormatInt(r.WalletID, 10),
+			toWalletID,
+			strconv.FormatFloat(r.Amount, 'f', 2, 64),
+			r.Reference,
+			r.Status,
+			strconv.FormatFloat(r.Fee, 'f', 2, 64),
+			transactionID,
+			r.ErrorCode,
+			r.ErrorMessage,
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// bindBatch reads a batch from a JSON body, a CSV body, or a CSV file
+// uploaded in the multipart field "file". CSV batches take their mode from
+// the "mode" query or form parameter. Item problems are reported by
+// position, items[0] being the first item or CSV row after the header.
+func bindBatch(c echo.Context) (service.CreateBatchRequest, error) {
+	request := service.CreateBatchRequest{}
+	fields := []errs.FieldError{}
+
+	var body io.Reader
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case echo.MIMEApplicationJSON:
+		err := bindJSON(c, &request)
+		if err != nil {
+			var appErr errs.AppError
+			if !errors.As(err, &appErr) || appErr.Code != errs.CodeValidationFailed {
+				return request, err
+			}
+			fields = append(fields, appErr.Fields...)
+		}
+	case mimeTextCSV:
+		request.Mode = c.QueryParam("mode")
+		body = c.Request().Body
+	case echo.MIMEMultipartForm:
+		request.Mode = c.FormValue("mode")
+		header, err := c.FormFile("file")
+		if err != nil {
+			return request, errs.NewFieldValidationError([]errs.FieldError{{Field: "file", Message: "is required"}})
+		}
+		file, err := header.Open()
+		if err != nil {
+			return request, errs.NewInvalidRequestBodyError().WithCause(err)
+		}
+		defer file.Close()
+		body = file
+	default:
+		return request, errs.NewUnsupportedMediaTypeError("content type must be application/json, text/csv or multipart/form-data")
+	}
+
+	if body != nil {
+		items, csvFields, err := readBatchCSV(body)
+		if err != nil {
+			return request, err
+		}
+		if items == nil && len(csvFields) > 0 {
+			return request, errs.NewFieldValidationError(csvFields)
+		}
+		request.Items = items
+		fields = append(fields, csvFields...)
+		fields = append(fields, validationFields(request)...)
+	}
+
+	if len(request.Items) <= service.MaxBatchItems {
+		for i, item := range request.Items {
+			for _, field := range validationFields(item) {
+				field.Field = fmt.Sprintf("items[%d].%s", i, field.Field)
+				// A CSV value that failed to parse is already reported.
+				if !hasField(fields, field.Field) {
+					fields = append(fields, field)
+				}
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return request, errs.NewFieldValidationError(fields)
+	}
+	return request, nil
+}
+
+// readBatchCSV parses batch items from CSV whose header row names the
+// columns, in any order. Values that are not numbers where numbers are
+// expected are reported as field errors; reading stops one item past the
+// most a batch may hold.
+func readBatchCSV(r io.Reader) ([]service.BatchItemRequest, []errs.FieldError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errs.NewInvalidRequestBodyError().WithCause(err)
+	}
+
+	fields := []errs.FieldError{}
+	columns := map[string]int{}
+	for i, name := range header {
+		// Spreadsheets often start the file with a byte order mark.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !containsFold(batchColumns, name) {
+			fields = append(fields, errs.FieldError{Field: name, Message: "is not allowed"})
+			continue
+		}
+		columns[name] = i
+	}
+	for _, name := range batchColumns[:3] {
+		if _, ok := columns[name]; !ok {
+			fields = append(fields, errs.FieldError{Field: name, Message: "column is required"})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, fields, nil
+	}
+
+	items := []service.BatchItemRequest{}
+	for len(items) <= service.MaxBatchItems {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errs.NewInvalidRequestBodyError().WithCause(err)
+		}
+
+		position := len(items)
+		value := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		integer := func(name string) int64 {
+			s := value(name)
+			if s == "" {
+				return 0
+			}
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				fields = append(fields, errs.FieldError{Field: fmt.Sprintf("items[%d].%s", position, name), Message: "must be a whole number"})
+			}
+			return n
+		}
+
+		item := service.BatchItemRequest{
+			Operation:       value("operation"),
+			WalletID:        integer("wallet_id"),
+			ToAccountNumber: value("to_account_number"),
+			ToAlias:         value("to_alias"),
+			Reference:       value("reference"),
+		}
+		if s := value("amount"); s != "" {
+			item.Amount, err = strconv.ParseFloat(s, 64)
+			if err != nil {
+				fields = append(fields, errs.FieldError{Field: fmt.Sprintf("items[%d].amount", position), Message: "must be a number"})
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, fields, nil
+}
+
+// validationFields returns the validation rule violations of v.
+func validationFields(v interface{}) []errs.FieldError {
+	var appErr errs.AppError
+	if err := validate.Struct(v); errors.As(err, &appErr) {
+		return appErr.Fields
+	}
+	return nil
+}
+
+func hasField(fields []errs.FieldError, name string) bool {
+	for _, f := range fields {
+		if f.Field == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestCreateBatch(t *testing.T) {
+	accepted := &service.BatchResponse{BatchID: 3, Mode: "best_effort", Status: "Pending", TotalItems: 2, CreatedAt: time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC)}
+	items := []service.BatchItemRequest{
+		{Operation: "Add", WalletID: 1, Amount: 1500, Reference: "payroll"},
+		{Operation: "Transfer", WalletID: 1, ToAccountNumber: "123-4-56789-7", Amount: 250.5},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		// Arrange
+		batchService := service.NewBatchServiceMock()
+		batchService.On("CreateBatch", auth.DefaultTenant, service.CreateBatchRequest{Mode: "best_effort", Items: items}).Return(accepted, nil)
+
+		batchHandler := handler.NewBatchHandler(batchService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/batches", strings.NewReader(`{"mode":"best_effort","items":[{"operation":"Add","wallet_id":1,"amount":1500,"reference":"payroll"},{"operation":"Transfer","wallet_id":1,"to_account_number":"123-4-56789-7","amount":250.5}]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		expected := `{"batch_id":3,"mode":"best_effort","status":"Pending","total_items":2,"processed_items":0,"succeeded_items":0,"failed_items":0,"skipped_items":0,"created_at":"2023-03-31T09:00:00Z"}`
+
+		// Assert
+		if assert.NoError(t, batchHandler.CreateBatch(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.Equal(t, "/batches/3", rec.Header().Get(echo.HeaderLocation))
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("csv body", func(t *testing.T) {
+		// Arrange
+		batchService := service.NewBatchServiceMock()
+		batchService.On("CreateBatch", auth.DefaultTenant, service.CreateBatchRequest{Mode: "best_effort", Items: items}).Return(accepted, nil)
+
+		batchHandler := handler.NewBatchHandler(batchService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/batches?mode=best_effort", strings.NewReader("\ufeffOperation,wallet_id,to_account_number,amount,reference\nAdd,1,,1500,payroll\nTransfer,1,123-4-56789-7,250.50,\n"))
+		req.Header.Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, batchHandler.CreateBatch(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		}
+	})
+
+	t.Run("csv upload", func(t *testing.T) {
+		// Arrange
+		batchService := service.NewBatchServiceMock()
+		batchService.On("CreateBatch", auth.DefaultTenant, service.CreateBatchRequest{Mode: "best_effort", Items: items}).Return(accepted, nil)
+
+		batchHandler := handler.NewBatchHandler(batchService)
+
+		body := &bytes.Buffer{}
+		form := multipart.NewWriter(body)
+		form.WriteField("mode", "best_effort")
+		file, _ := form.CreateFormFile("file", "payroll.csv")
+		file.Write([]byte("operation,wallet_id,amount,reference,to_account_number\nAdd,1,1500,payroll,\nTransfer,1,250.5,,123-4-56789-7\n"))
+		form.Close()
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/batches", body)
+		req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, batchHandler.CreateBatch(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		}
+	})
+
+	t.Run("csv values by position", func(t *testing.T) {
+		// Arrange
+		batchService := service.NewBatchServiceMock()
+		batchHandler := handler.NewBatchHandler(batchService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/batches?mode=best_effort", strings.NewReader("operation,wallet_id,amount\nAdd,1,ten\nRefund,1,10\n"))
+		req.Header.Set(echo.HeaderContentType, "text/csv")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, batchHandler.CreateBatch(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), `{"field":"items[0].amount","message":"must be a number"}`)
+			assert.Contains(t, rec.Body.String(), `{"field":"items[1].operation","message":"must be one of Add, Deduct, Transfer"}`)
+			assert.NotContains(t, rec.Body.String(), `"items[0].amount","message":"is required"`)
+			batchService.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("csv columns", func(t *testing.T) {
+		// Arrange
+		batchHandler := handler.NewBatchHandler(service.NewBatchServiceMock())
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/batches?mode=best_effort", strings.NewReader("operation,wallet,amount,to_wallet_id\nAdd,1,10,\n"))
+		req.Header.Set(echo.HeaderContentType, "text/csv")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		expected := `[{"field":"wallet","message":"is not allowed"},{"field":"to_wallet_id","message":"is not allowed"},{"field":"wallet_id","message":"column is required"}]`
+
+		// Assert
+		if assert.NoError(t, batchHandler.CreateBatch(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), expected)
+		}
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		// Arrange
+		batchHandler := handler.NewBatchHandler(service.NewBatchServiceMock())
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/batches", strings.NewReader("<batch/>"))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationXML)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, batchHandler.CreateBatch(c)) {
+			assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"UNSUPPORTED_MEDIA_TYPE"`)
+		}
+	})
+}
+
+func TestDownloadBatchResults(t *testing.T) {
+	t.Run("csv of item outcomes", func(t *testing.T) {
+		// Arrange
+		var id int64 = 3
+		transactionID := int64(41)
+		batchService := service.NewBatchServiceMock()
+		batchService.On("GetBatchResults", auth.DefaultTenant, id).Return([]service.BatchItemResponse{
+			{Position: 0, Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 250.5, Reference: "rent", Status: "Succeeded", Fee: 5, TransactionID: &transactionID},
+			{Position: 1, Operation: "Deduct", WalletID: 2, Amount: 500, Status: "Failed", ErrorCode: "INSUFFICIENT_FUNDS", ErrorMessage: "balance not enough"},
+		}, nil)
+
+		batchHandler := handler.NewBatchHandler(batchService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/batches/:id/results")
+		c.SetParamNames("id")
+		c.SetParamValues("3")
+
+		expected := "position,operation,wallet_id,to_wallet_id,amount,reference,status,fee,transaction_id,error_code,error_message\n" +
+			"0,Transfer,1,2,250.50,rent,Succeeded,5.00,41,,\n" +
+			"1,Deduct,2,,500.00,,Failed,0.00,,INSUFFICIENT_FUNDS,balance not enough\n"
+
+		// Assert
+		if assert.NoError(t, batchHandler.DownloadResults(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, `attachment; filename="batch-3-results.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+			assert.Equal(t, expected, rec.Body.String())
+		}
+	})
+
+	t.Run("batch not found", func(t *testing.T) {
+		// Arrange
+		var id int64 = 3
+		batchService := service.NewBatchServiceMock()
+		batchService.On("GetBatchResults", auth.DefaultTenant, id).Return([]service.BatchItemResponse{}, errs.NewBatchNotFoundError())
+
+		batchHandler := handler.NewBatchHandler(batchService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/batches/:id/results")
+		c.SetParamNames("id")
+		c.SetParamValues("3")
+
+		// Assert
+		if assert.NoError(t, batchHandler.DownloadResults(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"BATCH_NOT_FOUND"`)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/validate"
+)
+
+// bindJSON decodes the request body into i and runs the DTO's validation
+// rules. Fields the DTO does not declare are reported together with any
+// rule violations so clients see every problem in one response.
+func bindJSON(c echo.Context, i interface{}) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return errs.NewInvalidRequestBodyError().WithCause(err)
+	}
+
+	err = json.Unmarshal(body, i)
+	if err != nil {
+		return errs.NewInvalidRequestBodyError().WithCause(err)
+	}
+
+	fields := unknownFields(body, i)
+
+	err = validate.Struct(i)
+	if err != nil {
+		var appErr errs.AppError
+		if !errors.As(err, &appErr) {
+			return err
+		}
+		fields = append(fields, appErr.Fields...)
+	}
+
+	if len(fields) > 0 {
+		return errs.NewFieldValidationError(fields)
+	}
+	return nil
+}
+
+func unknownFields(body []byte, i interface{}) []errs.FieldError {
+	raw := map[string]json.RawMessage{}
+	if json.Unmarshal(body, &raw) != nil {
+		return nil
+	}
+
+	typ := reflect.TypeOf(i)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	known := []string{}
+	for n := 0; n < typ.NumField(); n++ {
+		field := typ.Field(n)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		known = append(known, name)
+	}
+
+	keys := []string{}
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := []errs.FieldError{}
+	for _, key := range keys {
+		if !containsFold(known, key) {
+			fields = append(fields, errs.FieldError{Field: key, Message: "is not allowed"})
+		}
+	}
+	return fields
+}
+
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
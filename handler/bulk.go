@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/logs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+const mimeApplicationNDJSON = "application/x-ndjson"
+
+type bulkWalletHandler struct {
+	bulkSrv service.BulkWalletService
+}
+
+func NewBulkWalletHandler(bulkSrv service.BulkWalletService) bulkWalletHandler {
+	return bulkWalletHandler{bulkSrv: bulkSrv}
+}
+
+// ImportWallets imports the CSV or NDJSON body, or with ?dry_run=true only
+// reports what is wrong with it.
+func (h bulkWalletHandler) ImportWallets(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	request := service.ImportWalletsRequest{Body: c.Request().Body}
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	switch mediaType {
+	case mimeTextCSV:
+		request.Format = service.FormatCSV
+	case mimeApplicationNDJSON, "application/ndjson":
+		request.Format = service.FormatNDJSON
+	default:
+		return handlerError(c, errs.NewUnsupportedMediaTypeError("content type must be text/csv or application/x-ndjson"))
+	}
+
+	if s := c.QueryParam("dry_run"); s != "" {
+		dryRun, err := strconv.ParseBool(s)
+		if err != nil {
+			return handlerError(c, errs.NewFieldValidationError([]errs.FieldError{{Field: "dry_run", Message: "must be true or false"}}))
+		}
+		request.DryRun = dryRun
+	}
+
+	report, err := h.bulkSrv.ImportWallets(auth.TenantID(c), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	if report.DryRun {
+		return c.JSON(http.StatusOK, report)
+	}
+	return c.JSON(http.StatusCreated, report)
+}
+
+// ExportWallets streams every wallet of the tenant as CSV, or as NDJSON with
+// ?format=ndjson.
+func (h bulkWalletHandler) ExportWallets(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	format := c.QueryParam("format")
+	contentType := ""
+	switch format {
+	case "", service.FormatCSV:
+		format, contentType = service.FormatCSV, mimeTextCSV+"; charset=utf-8"
+	case service.FormatNDJSON:
+		contentType = mimeApplicationNDJSON
+	default:
+		return handlerError(c, errs.NewFieldValidationError([]errs.FieldError{{Field: "format", Message: "must be one of csv, ndjson"}}))
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, contentType)
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="wallets.%s"`, format))
+
+	err := h.bulkSrv.ExportWallets(auth.TenantID(c), format, res)
+	if err != nil {
+		if !res.Committed {
+			return handlerError(c, err)
+		}
+		// The status has been sent; all that is left is to cut the body short.
+		logs.Error(err)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func newAdminContext(req *http.Request, rec *httptest.ResponseRecorder) echo.Context {
+	c := echo.New().NewContext(req, rec)
+	auth.SetPrincipal(c, auth.Principal{ID: "ops", TenantID: "acme", Roles: []string{auth.RoleAdmin}}, "acme")
+	return c
+}
+
+func TestImportWallets(t *testing.T) {
+	csvRequest := mock.MatchedBy(func(r service.ImportWalletsRequest) bool { return r.Format == "csv" && !r.DryRun })
+
+	t.Run("import", func(t *testing.T) {
+		// Arrange
+		bulkService := service.NewBulkWalletServiceMock()
+		bulkService.On("ImportWallets", "acme", csvRequest).Return(&service.ImportReport{Rows: 1, Imported: 1, TotalBalance: 150, Errors: []errs.FieldError{}, WalletIDs: []int64{41}}, nil)
+
+		bulkHandler := handler.NewBulkWalletHandler(bulkService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodPost, "/admin/wallets/import", strings.NewReader("balance,created_at\n150,2019-05-01T09:30:00Z\n"))
+		req.Header.Set(echo.HeaderContentType, "text/csv")
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		expected := `{"dry_run":false,"rows":1,"imported":1,"total_balance":150,"errors":[],"wallet_ids":[41]}`
+
+		// Assert
+		if assert.NoError(t, bulkHandler.ImportWallets(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		// Arrange
+		bulkService := service.NewBulkWalletServiceMock()
+		bulkService.On("ImportWallets", "acme", mock.MatchedBy(func(r service.ImportWalletsRequest) bool { return r.Format == "ndjson" && r.DryRun })).
+			Return(&service.ImportReport{DryRun: true, Rows: 1, Errors: []errs.FieldError{{Field: "rows[0].created_at", Message: "is required"}}}, nil)
+
+		bulkHandler := handler.NewBulkWalletHandler(bulkService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodPost, "/admin/wallets/import?dry_run=true", strings.NewReader(`{"balance":150}`))
+		req.Header.Set(echo.HeaderContentType, "application/x-ndjson")
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, bulkHandler.ImportWallets(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"errors":[{"field":"rows[0].created_at","message":"is required"}]`)
+		}
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		// Arrange
+		bulkService := service.NewBulkWalletServiceMock()
+		bulkHandler := handler.NewBulkWalletHandler(bulkService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodPost, "/admin/wallets/import", strings.NewReader("balance,created_at\n"))
+		req.Header.Set(echo.HeaderContentType, "text/csv")
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		auth.SetPrincipal(c, auth.Principal{ID: "maker", TenantID: "acme"}, "acme")
+
+		// Assert
+		if assert.NoError(t, bulkHandler.ImportWallets(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			bulkService.AssertNotCalled(t, "ImportWallets", mock.Anything, mock.Anything)
+		}
+	})
+
+	t.Run("unsupported content type", func(t *testing.T) {
+		// Arrange
+		bulkHandler := handler.NewBulkWalletHandler(service.NewBulkWalletServiceMock())
+
+		// Act
+		req := httptest.NewRequest(http.MethodPost, "/admin/wallets/import", strings.NewReader(`[{"balance":150}]`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, bulkHandler.ImportWallets(c)) {
+			assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+		}
+	})
+}
+
+func TestExportWallets(t *testing.T) {
+	t.Run("ndjson", func(t *testing.T) {
+		// Arrange
+		body := `{"wallet_id":1,"currency":"THB","balance":1000,"pocket_balance":0,"status":"Active","version":1,"created_at":"2023-01-27T12:30:00Z","metadata":{},"labels":{}}` + "\n"
+		bulkService := service.NewBulkWalletServiceMock()
+		bulkService.On("ExportWallets", "acme", "ndjson", mock.Anything).Return(body, nil)
+
+		bulkHandler := handler.NewBulkWalletHandler(bulkService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/wallets/export?format=ndjson", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, bulkHandler.ExportWallets(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "application/x-ndjson", rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, `attachment; filename="wallets.ndjson"`, rec.Header().Get(echo.HeaderContentDisposition))
+			assert.Equal(t, body, rec.Body.String())
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		// Arrange
+		bulkHandler := handler.NewBulkWalletHandler(service.NewBulkWalletServiceMock())
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/wallets/export?format=xlsx", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, bulkHandler.ExportWallets(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), `{"field":"format","message":"must be one of csv, ndjson"}`)
+		}
+	})
+}
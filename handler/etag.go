@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/errs"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setETag(c echo.Context, version int64) {
+	if version != 0 {
+		c.Response().Header().Set(HeaderETag, etag(version))
+	}
+}
+
+// ifMatchVersion returns the wallet version the client expects from the
+// If-Match header, or 0 when the header is absent or "*".
+func ifMatchVersion(c echo.Context) (int64, error) {
+	value := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	value = strings.TrimPrefix(value, "W/")
+	version, err := strconv.ParseInt(strings.Trim(value, `"`), 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errs.NewPreconditionFailedError()
+	}
+
+	return version, nil
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+const (
+	HeaderLastEventID   = "Last-Event-ID"
+	MIMETextEventStream = "text/event-stream"
+)
+
+type eventHandler struct {
+	eventSrv  service.EventService
+	heartbeat time.Duration
+}
+
+func NewEventHandler(eventSrv service.EventService) eventHandler {
+	return eventHandler{eventSrv: eventSrv, heartbeat: 15 * time.Second}
+}
+
+func (h eventHandler) StreamWalletEvents(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	return h.stream(c, int64(id))
+}
+
+func (h eventHandler) StreamAllEvents(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	return h.stream(c, 0)
+}
+
+// stream subscribes before replaying history after Last-Event-ID so nothing
+// committed in between is lost; live events already replayed are skipped.
+// Event ids follow commit order, so one at or below the last id sent has
+// been sent already.
+func (h eventHandler) stream(c echo.Context, walletID int64) error {
+	lastEventID, err := lastEventID(c)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	events, unsubscribe, err := h.eventSrv.Subscribe(auth.TenantID(c), walletID)
+	if err != nil {
+		return handlerError(c, err)
+	}
+	defer unsubscribe()
+
+	history := []service.EventResponse{}
+	if lastEventID > 0 {
+		history, err = h.eventSrv.EventsAfter(auth.TenantID(c), walletID, lastEventID)
+		if err != nil {
+			return handlerError(c, err)
+		}
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, MIMETextEventStream)
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	fmt.Fprint(res, "retry: 3000\n\n")
+
+	sent := lastEventID
+	for _, event := range history {
+		if err := writeEvent(res, event); err != nil {
+			return nil
+		}
+		sent = event.EventID
+	}
+	res.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(res, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			res.Flush()
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if event.EventID <= sent {
+				continue
+			}
+			if err := writeEvent(res, event); err != nil {
+				return nil
+			}
+			sent = event.EventID
+			res.Flush()
+		}
+	}
+}
+
+func writeEvent(res *echo.Response, event service.EventResponse) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(res, "id: %d\nevent: %s\ndata: %s\n\n", event.EventID, event.Type, data)
+	return err
+}
+
+func lastEventID(c echo.Context) (int64, error) {
+	value := c.Request().Header.Get(HeaderLastEventID)
+	if value == "" {
+		value = c.QueryParam("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errs.NewBadRequest("Last-Event-ID must be an event id")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,120 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestStreamWalletEvents(t *testing.T) {
+	createdAt := time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC)
+
+	t.Run("resume from last event id", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		live := make(chan service.EventResponse, 2)
+		live <- service.EventResponse{EventID: 11, WalletID: id, Type: "credited", Amount: 100, Balance: 1100, Status: "Active", CreatedAt: createdAt}
+		live <- service.EventResponse{EventID: 12, WalletID: id, Type: "debited", Amount: 50, Balance: 1050, Status: "Active", CreatedAt: createdAt}
+		close(live)
+
+		eventService := service.NewEventServiceMock()
+		eventService.On("Subscribe", auth.DefaultTenant, id).Return((<-chan service.EventResponse)(live), func() {}, nil)
+		eventService.On("EventsAfter", auth.DefaultTenant, id, int64(10)).Return([]service.EventResponse{
+			{EventID: 11, WalletID: id, Type: "credited", Amount: 100, Balance: 1100, Status: "Active", CreatedAt: createdAt},
+		}, nil)
+
+		eventHandler := handler.NewEventHandler(eventService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(handler.HeaderLastEventID, "10")
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/events")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := "retry: 3000\n\n" +
+			"id: 11\nevent: credited\ndata: {\"event_id\":11,\"wallet_id\":1,\"type\":\"credited\",\"amount\":100,\"balance\":1100,\"status\":\"Active\",\"created_at\":\"2023-01-27T12:30:00Z\"}\n\n" +
+			"id: 12\nevent: debited\ndata: {\"event_id\":12,\"wallet_id\":1,\"type\":\"debited\",\"amount\":50,\"balance\":1050,\"status\":\"Active\",\"created_at\":\"2023-01-27T12:30:00Z\"}\n\n"
+
+		// Assert
+		if assert.NoError(t, eventHandler.StreamWalletEvents(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, handler.MIMETextEventStream, rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, expected, rec.Body.String())
+		}
+	})
+
+	t.Run("wallet not found", func(t *testing.T) {
+		// Arrange
+		var id int64 = 99
+		eventService := service.NewEventServiceMock()
+		eventService.On("Subscribe", auth.DefaultTenant, id).Return((<-chan service.EventResponse)(nil), func() {}, errs.NewWalletNotFoundError())
+
+		eventHandler := handler.NewEventHandler(eventService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/events")
+		c.SetParamNames("id")
+		c.SetParamValues("99")
+
+		// Assert
+		if assert.NoError(t, eventHandler.StreamWalletEvents(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+
+	t.Run("malformed last event id", func(t *testing.T) {
+		// Arrange
+		eventService := service.NewEventServiceMock()
+		eventHandler := handler.NewEventHandler(eventService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/events?last_event_id=abc", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, eventHandler.StreamAllEvents(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+}
+
+func TestStreamAllEvents(t *testing.T) {
+	t.Run("admin only", func(t *testing.T) {
+		// Arrange
+		eventService := service.NewEventServiceMock()
+		eventHandler := handler.NewEventHandler(eventService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/events", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		auth.SetPrincipal(c, auth.Principal{ID: "maker", TenantID: "acme"}, "acme")
+
+		// Assert
+		if assert.NoError(t, eventHandler.StreamAllEvents(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			eventService.AssertNotCalled(t, "Subscribe", mock.Anything, mock.Anything)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type feeHandler struct {
+	feeSrv service.FeeService
+}
+
+func NewFeeHandler(feeSrv service.FeeService) feeHandler {
+	return feeHandler{feeSrv: feeSrv}
+}
+
+func (h feeHandler) Quote(c echo.Context) error {
+	request := service.FeeQuoteRequest{}
+	err := (&echo.DefaultBinder{}).BindQueryParams(c, &request)
+	if err != nil {
+		return handlerError(c, errs.NewBadRequest("query parameters incorrect format"))
+	}
+
+	quote, err := h.feeSrv.Quote(auth.TenantID(c), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, quote)
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type interestHandler struct {
+	interestSrv service.InterestService
+}
+
+func NewInterestHandler(interestSrv service.InterestService) interestHandler {
+	return interestHandler{interestSrv: interestSrv}
+}
+
+func (h interestHandler) AccrueInterest(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	request := service.AccrueInterestRequest{}
+	err := bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	accrual, err := h.interestSrv.AccrueInterest(auth.TenantID(c), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, accrual)
+}
+
+func (h interestHandler) PostInterest(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	request := service.PostInterestRequest{}
+	err := bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	postings, err := h.interestSrv.PostInterest(auth.TenantID(c), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, postings)
+}
+
+// requireAdmin rejects callers without the admin role.
+func requireAdmin(c echo.Context) error {
+	principal, ok := auth.PrincipalFrom(c)
+	if !ok || !principal.HasRole(auth.RoleAdmin) {
+		return errs.NewForbiddenError("admin role required")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/logs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type ledgerHandler struct {
+	ledgerSrv service.LedgerService
+}
+
+func NewLedgerHandler(ledgerSrv service.LedgerService) ledgerHandler {
+	return ledgerHandler{ledgerSrv: ledgerSrv}
+}
+
+func (h ledgerHandler) GetChartOfAccounts(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	accounts, err := h.ledgerSrv.GetChartOfAccounts(auth.TenantID(c))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, accounts)
+}
+
+func (h ledgerHandler) GetTrialBalance(c echo.Context) error {
+	tenantID, from, to := auth.TenantID(c), c.QueryParam("from"), c.QueryParam("to")
+	return ledgerReport(c, "trial-balance", func() (interface{}, error) {
+		return h.ledgerSrv.GetTrialBalance(tenantID, from, to)
+	}, func(w io.Writer) error {
+		return h.ledgerSrv.ExportTrialBalance(tenantID, from, to, w)
+	})
+}
+
+func (h ledgerHandler) GetAccountActivity(c echo.Context) error {
+	request := service.AccountActivityRequest{}
+	err := (&echo.DefaultBinder{}).BindQueryParams(c, &request)
+	if err != nil {
+		return handlerError(c, errs.NewBadRequest("query parameters incorrect format"))
+	}
+	request.Code = c.Param("code")
+
+	tenantID := auth.TenantID(c)
+	return ledgerReport(c, "account-"+request.Code, func() (interface{}, error) {
+		return h.ledgerSrv.GetAccountActivity(tenantID, request)
+	}, func(w io.Writer) error {
+		return h.ledgerSrv.ExportAccountActivity(tenantID, request, w)
+	})
+}
+
+func (h ledgerHandler) GetBalanceSheet(c echo.Context) error {
+	tenantID, asOf := auth.TenantID(c), c.QueryParam("as_of")
+	return ledgerReport(c, "balance-sheet", func() (interface{}, error) {
+		return h.ledgerSrv.GetBalanceSheet(tenantID, asOf)
+	}, func(w io.Writer) error {
+		return h.ledgerSrv.ExportBalanceSheet(tenantID, asOf, w)
+	})
+}
+
+// ledgerReport answers an admin's request for a report with report as JSON,
+// or with export's CSV, named after name, for ?format=csv.
+func ledgerReport(c echo.Context, name string, report func() (interface{}, error), export func(io.Writer) error) error {
+	if err := requireAdmin(c); err != nil {
+		return handlerError(c, err)
+	}
+
+	switch c.QueryParam("format") {
+	case "", service.FormatJSON:
+		body, err := report()
+		if err != nil {
+			return handlerError(c, err)
+		}
+		return c.JSON(http.StatusOK, body)
+	case service.FormatCSV:
+	default:
+		return handlerError(c, errs.NewFieldValidationError([]errs.FieldError{{Field: "format", Message: "must be one of json, csv"}}))
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, mimeTextCSV+"; charset=utf-8")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, name, service.FormatCSV))
+
+	err := export(res)
+	if err != nil {
+		if !res.Committed {
+			return handlerError(c, err)
+		}
+		// The status has been sent; all that is left is to cut the body short.
+		logs.Error(err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestGetChartOfAccounts(t *testing.T) {
+	t.Run("chart of accounts", func(t *testing.T) {
+		// Arrange
+		walletID := int64(42)
+		ledgerService := service.NewLedgerServiceMock()
+		ledgerService.On("GetChartOfAccounts", "acme").Return([]service.LedgerAccountResponse{
+			{Code: "1000", Name: "Cash", Type: "Asset", Currency: "THB", Debits: 1250.5, Balance: 1250.5},
+			{Code: "2000-42", Name: "Wallet 1234567897", Type: "Liability", Currency: "THB", WalletID: &walletID, Credits: 1250.5, Balance: 1250.5},
+		}, nil)
+
+		ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/ledger/accounts", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		expected := `[{"code":"1000","name":"Cash","type":"Asset","currency":"THB","debits":1250.5,"credits":0,"balance":1250.5},` +
+			`{"code":"2000-42","name":"Wallet 1234567897","type":"Liability","currency":"THB","wallet_id":42,"debits":0,"credits":1250.5,"balance":1250.5}]`
+
+		// Assert
+		if assert.NoError(t, ledgerHandler.GetChartOfAccounts(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("admin only", func(t *testing.T) {
+		// Arrange
+		ledgerService := service.NewLedgerServiceMock()
+		ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/ledger/accounts", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		auth.SetPrincipal(c, auth.Principal{ID: "maker", TenantID: "acme"}, "acme")
+
+		// Assert
+		if assert.NoError(t, ledgerHandler.GetChartOfAccounts(c)) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+			ledgerService.AssertNotCalled(t, "GetChartOfAccounts", mock.Anything)
+		}
+	})
+}
+
+func TestGetTrialBalance(t *testing.T) {
+	t.Run("json", func(t *testing.T) {
+		// Arrange
+		ledgerService := service.NewLedgerServiceMock()
+		ledgerService.On("GetTrialBalance", "acme", "2023-03-01", "2023-03-31").Return(&service.TrialBalance{
+			From:     "2023-03-01",
+			To:       "2023-03-31",
+			Accounts: []service.TrialBalanceLine{{Code: "1000", Name: "Cash", Type: "Asset", Currency: "THB", Debits: 500, ClosingBalance: 500}},
+			Totals:   []service.TrialBalanceTotal{{Currency: "THB", Debits: 500, Credits: 500, DebitBalances: 500, CreditBalances: 500}},
+		}, nil)
+
+		ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/ledger/trial-balance?from=2023-03-01&to=2023-03-31", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		expected := `{"from":"2023-03-01","to":"2023-03-31",` +
+			`"accounts":[{"code":"1000","name":"Cash","type":"Asset","currency":"THB","opening_balance":0,"debits":500,"credits":0,"closing_balance":500}],` +
+			`"totals":[{"currency":"THB","debits":500,"credits":500,"debit_balances":500,"credit_balances":500}]}`
+
+		// Assert
+		if assert.NoError(t, ledgerHandler.GetTrialBalance(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		// Arrange
+		ledgerService := service.NewLedgerServiceMock()
+		ledgerService.On("ExportTrialBalance", "acme", "", "", mock.Anything).Return("currency,code\n", nil)
+
+		ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/ledger/trial-balance?format=csv", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, ledgerHandler.GetTrialBalance(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, `attachment; filename="trial-balance.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+			assert.Equal(t, "currency,code\n", rec.Body.String())
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		// Arrange
+		ledgerService := service.NewLedgerServiceMock()
+		ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/admin/ledger/trial-balance?format=xlsx", nil)
+		rec := httptest.NewRecorder()
+		c := newAdminContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, ledgerHandler.GetTrialBalance(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			ledgerService.AssertNotCalled(t, "GetTrialBalance", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestGetAccountActivity(t *testing.T) {
+	// Arrange
+	ledgerService := service.NewLedgerServiceMock()
+	ledgerService.On("ExportAccountActivity", "acme", service.AccountActivityRequest{Code: "1000", Currency: "THB", From: "2023-03-01"}, mock.Anything).Return("entry_id\n", nil)
+
+	ledgerHandler := handler.NewLedgerHandler(ledgerService)
+
+	// Act
+	req := httptest.NewRequest(http.MethodGet, "/admin/ledger/accounts/1000/activity?currency=THB&from=2023-03-01&format=csv", nil)
+	rec := httptest.NewRecorder()
+	c := newAdminContext(req, rec)
+	c.SetParamNames("code")
+	c.SetParamValues("1000")
+
+	// Assert
+	if assert.NoError(t, ledgerHandler.GetAccountActivity(c)) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, `attachment; filename="account-1000.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+		assert.Equal(t, "entry_id\n", rec.Body.String())
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type pocketHandler struct {
+	pocketSrv service.PocketService
+}
+
+func NewPocketHandler(pocketSrv service.PocketService) pocketHandler {
+	return pocketHandler{pocketSrv: pocketSrv}
+}
+
+func (h pocketHandler) ListPockets(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	pockets, err := h.pocketSrv.ListPockets(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, pockets)
+}
+
+func (h pocketHandler) CreatePocket(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	request := service.PocketRequest{}
+	err = bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	pocket, err := h.pocketSrv.CreatePocket(auth.TenantID(c), int64(id), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, pocket)
+}
+
+func (h pocketHandler) MovePocketFunds(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+	pocketID, err := strconv.Atoi(c.Param("pocket_id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	request := service.PocketMoveRequest{}
+	err = bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	pocket, err := h.pocketSrv.MovePocketFunds(auth.TenantID(c), int64(id), int64(pocketID), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, pocket)
+}
+
+func (h pocketHandler) ClosePocket(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+	pocketID, err := strconv.Atoi(c.Param("pocket_id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	pocket, err := h.pocketSrv.ClosePocket(auth.TenantID(c), int64(id), int64(pocketID))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, pocket)
+}
@@ -0,0 +1,164 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestCreatePocket(t *testing.T) {
+	t.Run("create pocket success", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		pocketService := service.NewPocketServiceMock()
+		pocketService.On("CreatePocket", auth.DefaultTenant, id, service.PocketRequest{Name: "rent"}).Return(&service.PocketResponse{
+			PocketID:  3,
+			WalletID:  1,
+			Name:      "rent",
+			Balance:   0,
+			Status:    "Active",
+			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+		}, nil)
+
+		pocketHandler := handler.NewPocketHandler(pocketService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"rent"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/pockets")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `{"pocket_id":3,"wallet_id":1,"name":"rent","balance":0,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+
+		// Assert
+		if assert.NoError(t, pocketHandler.CreatePocket(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("create pocket without name", func(t *testing.T) {
+		// Arrange
+		pocketService := service.NewPocketServiceMock()
+
+		pocketHandler := handler.NewPocketHandler(pocketService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/pockets")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, pocketHandler.CreatePocket(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			pocketService.AssertNotCalled(t, "CreatePocket")
+		}
+	})
+}
+
+func TestMovePocketFunds(t *testing.T) {
+	t.Run("move funds into pocket", func(t *testing.T) {
+		// Arrange
+		var id, pocketID int64 = 1, 3
+		request := service.PocketMoveRequest{Amount: 500, Direction: service.DirectionToPocket}
+		pocketService := service.NewPocketServiceMock()
+		pocketService.On("MovePocketFunds", auth.DefaultTenant, id, pocketID, request).Return(&service.PocketResponse{
+			PocketID:  3,
+			WalletID:  1,
+			Name:      "rent",
+			Balance:   500,
+			Status:    "Active",
+			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+		}, nil)
+
+		pocketHandler := handler.NewPocketHandler(pocketService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":500,"direction":"ToPocket"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/pockets/:pocket_id/moves")
+		c.SetParamNames("id", "pocket_id")
+		c.SetParamValues("1", "3")
+
+		expected := `{"pocket_id":3,"wallet_id":1,"name":"rent","balance":500,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+
+		// Assert
+		if assert.NoError(t, pocketHandler.MovePocketFunds(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("move funds with invalid pocket id", func(t *testing.T) {
+		// Arrange
+		pocketService := service.NewPocketServiceMock()
+
+		pocketHandler := handler.NewPocketHandler(pocketService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":500,"direction":"ToPocket"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/pockets/:pocket_id/moves")
+		c.SetParamNames("id", "pocket_id")
+		c.SetParamValues("1", "abc")
+
+		// Assert
+		if assert.NoError(t, pocketHandler.MovePocketFunds(c)) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), string(errs.CodeInvalidID))
+		}
+	})
+
+	t.Run("move funds from frozen wallet", func(t *testing.T) {
+		// Arrange
+		var id, pocketID int64 = 1, 3
+		request := service.PocketMoveRequest{Amount: 500, Direction: service.DirectionToWallet}
+		pocketService := service.NewPocketServiceMock()
+		pocketService.On("MovePocketFunds", auth.DefaultTenant, id, pocketID, request).Return(&service.PocketResponse{}, errs.NewWalletInactiveError())
+
+		pocketHandler := handler.NewPocketHandler(pocketService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":500,"direction":"ToWallet"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/pockets/:pocket_id/moves")
+		c.SetParamNames("id", "pocket_id")
+		c.SetParamValues("1", "3")
+
+		// Assert
+		if assert.NoError(t, pocketHandler.MovePocketFunds(c)) {
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Contains(t, rec.Body.String(), string(errs.CodeWalletInactive))
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/logs"
+	"go.uber.org/zap"
+)
+
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail"`
+	Code      string            `json:"code"`
+	Instance  string            `json:"instance,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    []errs.FieldError `json:"errors,omitempty"`
+}
+
+func problemType(code errs.Code) string {
+	return "urn:go-wallet:problem:" + string(code)
+}
+
+func handlerError(c echo.Context, err error) error {
+	var appErr errs.AppError
+	if !errors.As(err, &appErr) {
+		appErr = errs.NewUnexpectedError().WithCause(err)
+	}
+
+	requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+	if appErr.Status >= http.StatusInternalServerError || appErr.Err != nil {
+		logs.Error(err,
+			zap.String("code", string(appErr.Code)),
+			zap.String("request_id", requestID),
+			zap.String("path", c.Request().URL.Path),
+		)
+	}
+
+	problem := Problem{
+		Type:      problemType(appErr.Code),
+		Title:     appErr.Code.Title(),
+		Status:    appErr.Status,
+		Detail:    appErr.Message,
+		Code:      string(appErr.Code),
+		Instance:  c.Request().URL.Path,
+		RequestID: requestID,
+		Errors:    appErr.Fields,
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+	return c.JSON(appErr.Status, problem)
+}
+
+// HTTPErrorHandler renders errors that escape handlers and middleware, such
+// as unknown routes or rate limit rejections, in the same problem format.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		err = fromHTTPError(he)
+	}
+
+	if err := handlerError(c, err); err != nil {
+		logs.Error(err)
+	}
+}
+
+func fromHTTPError(he *echo.HTTPError) error {
+	message := http.StatusText(he.Code)
+	if m, ok := he.Message.(string); ok {
+		message = m
+	}
+
+	switch he.Code {
+	case http.StatusNotFound:
+		return errs.New(he.Code, errs.CodeRouteNotFound, message)
+	case http.StatusMethodNotAllowed:
+		return errs.New(he.Code, errs.CodeMethodNotAllowed, message)
+	case http.StatusTooManyRequests:
+		return errs.NewRateLimitedError()
+	}
+
+	if he.Code < http.StatusInternalServerError {
+		return errs.New(he.Code, errs.CodeBadRequest, message)
+	}
+	return errs.NewUnexpectedError().WithCause(he)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/qr"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+// qrScale is the width in pixels of a QR code module, which makes the
+// usual PromptPay code a little over 300 pixels wide.
+const qrScale = 8
+
+type promptPayHandler struct {
+	promptPaySrv service.PromptPayService
+}
+
+func NewPromptPayHandler(promptPaySrv service.PromptPayService) promptPayHandler {
+	return promptPayHandler{promptPaySrv: promptPaySrv}
+}
+
+// CreateQR answers GET /wallet/:id/promptpay with the payload as JSON or,
+// with ?format=png, drawn as a QR code.
+func (h promptPayHandler) CreateQR(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	request := service.PromptPayRequest{}
+	err = (&echo.DefaultBinder{}).BindQueryParams(c, &request)
+	if err != nil {
+		return handlerError(c, errs.NewBadRequest("query parameters incorrect format"))
+	}
+	if fields := validationFields(request); len(fields) > 0 {
+		return handlerError(c, errs.NewFieldValidationError(fields))
+	}
+
+	format := c.QueryParam("format")
+	if format != "" && format != service.FormatJSON && format != service.FormatPNG {
+		return handlerError(c, errs.NewFieldValidationError([]errs.FieldError{{Field: "format", Message: "must be one of json, png"}}))
+	}
+
+	created, err := h.promptPaySrv.CreateQR(auth.TenantID(c), int64(id), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+	if format != service.FormatPNG {
+		return c.JSON(http.StatusOK, created)
+	}
+
+	code, err := qr.Encode([]byte(created.Payload))
+	if err != nil {
+		return handlerError(c, errs.NewUnexpectedError().WithCause(err))
+	}
+	image, err := code.PNG(qrScale)
+	if err != nil {
+		return handlerError(c, errs.NewUnexpectedError().WithCause(err))
+	}
+
+	return c.Blob(http.StatusOK, "image/png", image)
+}
+
+func (h promptPayHandler) ParsePayload(c echo.Context) error {
+	request := service.ParsePromptPayRequest{}
+	err := bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	fields, err := h.promptPaySrv.ParsePayload(request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, fields)
+}
@@ -0,0 +1,122 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"bytes"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestCreatePromptPayQR(t *testing.T) {
+	payload := "00020101021230530016A00000067701011201150105550123456010210123456789753037645406150.005802TH5904ACME62090805TOPUP6304506D"
+
+	t.Run("json", func(t *testing.T) {
+		// Arrange
+		promptPayService := service.NewPromptPayServiceMock()
+		promptPayService.On("CreateQR", auth.DefaultTenant, int64(1), service.PromptPayRequest{Amount: 150}).Return(&service.PromptPayQR{
+			WalletID: 1, Purpose: "topup", Amount: 150, Payload: payload,
+		}, nil)
+
+		promptPayHandler := handler.NewPromptPayHandler(promptPayService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/?amount=150", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/promptpay")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `{"wallet_id":1,"purpose":"topup","amount":150,"payload":"` + payload + `"}`
+
+		// Assert
+		if assert.NoError(t, promptPayHandler.CreateQR(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("png", func(t *testing.T) {
+		// Arrange
+		promptPayService := service.NewPromptPayServiceMock()
+		promptPayService.On("CreateQR", auth.DefaultTenant, int64(1), service.PromptPayRequest{Amount: 150}).Return(&service.PromptPayQR{
+			WalletID: 1, Purpose: "topup", Amount: 150, Payload: payload,
+		}, nil)
+
+		promptPayHandler := handler.NewPromptPayHandler(promptPayService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/?amount=150&format=png", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/promptpay")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, promptPayHandler.CreateQR(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "image/png", rec.Header().Get(echo.HeaderContentType))
+			_, err := png.Decode(bytes.NewReader(rec.Body.Bytes()))
+			assert.NoError(t, err)
+		}
+	})
+
+	t.Run("invalid amount", func(t *testing.T) {
+		// Arrange
+		promptPayService := service.NewPromptPayServiceMock()
+		promptPayHandler := handler.NewPromptPayHandler(promptPayService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/?amount=1.005", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/promptpay")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, promptPayHandler.CreateQR(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), string(errs.CodeValidationFailed))
+			promptPayService.AssertNotCalled(t, "CreateQR", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
+}
+
+func TestParsePromptPay(t *testing.T) {
+	// Arrange
+	promptPayService := service.NewPromptPayServiceMock()
+	promptPayService.On("ParsePayload", service.ParsePromptPayRequest{Payload: "0002010102112937"}).Return(&service.PromptPayFields{}, errs.NewFieldValidationError([]errs.FieldError{{Field: "payload", Message: "is not a well-formed EMVCo QR payload"}}))
+
+	promptPayHandler := handler.NewPromptPayHandler(promptPayService)
+
+	// Act
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"payload":"0002010102112937"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	// Assert
+	if assert.NoError(t, promptPayHandler.ParsePayload(c)) {
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), "is not a well-formed EMVCo QR payload")
+	}
+}
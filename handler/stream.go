@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type streamHandler struct {
+	streamSrv service.StreamService
+}
+
+func NewStreamHandler(streamSrv service.StreamService) streamHandler {
+	return streamHandler{streamSrv: streamSrv}
+}
+
+func (h streamHandler) GetWalletHistory(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	history, err := h.streamSrv.GetWalletHistory(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, history)
+}
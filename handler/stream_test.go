@@ -0,0 +1,74 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestGetWalletHistory(t *testing.T) {
+	t.Run("history", func(t *testing.T) {
+		// Arrange
+		streamService := service.NewStreamServiceMock()
+		streamService.On("GetWalletHistory", auth.DefaultTenant, int64(1)).Return([]service.WalletHistoryEvent{{
+			Version:    2,
+			Type:       "FundsCredited",
+			RecordedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+			Data:       json.RawMessage(`{"amount":150}`),
+			Balance:    1150,
+			Status:     "Active",
+		}}, nil)
+
+		streamHandler := handler.NewStreamHandler(streamService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/wallet/:id/history")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `[{"version":2,"type":"FundsCredited","recorded_at":"2023-01-27T12:30:00Z","data":{"amount":150},"balance":1150,"status":"Active"}]`
+
+		// Assert
+		if assert.NoError(t, streamHandler.GetWalletHistory(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("wallet not found", func(t *testing.T) {
+		// Arrange
+		streamService := service.NewStreamServiceMock()
+		streamService.On("GetWalletHistory", auth.DefaultTenant, int64(9)).Return([]service.WalletHistoryEvent(nil), errs.NewWalletNotFoundError())
+
+		streamHandler := handler.NewStreamHandler(streamService)
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetPath("/wallet/:id/history")
+		c.SetParamNames("id")
+		c.SetParamValues("9")
+
+		// Assert
+		if assert.NoError(t, streamHandler.GetWalletHistory(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+}
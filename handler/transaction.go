@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type transactionHandler struct {
+	transactionSrv service.TransactionService
+}
+
+func NewTransactionHandler(transactionSrv service.TransactionService) transactionHandler {
+	return transactionHandler{transactionSrv: transactionSrv}
+}
+
+func (h transactionHandler) ListTransactions(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	transactions, err := h.transactionSrv.ListTransactions(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, transactions)
+}
+
+func (h transactionHandler) GetTransaction(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	transaction, err := h.transactionSrv.GetTransaction(auth.TenantID(c), int64(id))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, transaction)
+}
+
+func (h transactionHandler) ReverseTransaction(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	request := service.ReverseTransactionRequest{}
+	err = bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	reversal, err := h.transactionSrv.ReverseTransaction(auth.TenantID(c), int64(id), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, reversal)
+}
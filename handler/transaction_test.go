@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestReverseTransaction(t *testing.T) {
+	t.Run("partial refund of a debit", func(t *testing.T) {
+		// Arrange
+		var id int64 = 12
+		original := id
+		transactionService := service.NewTransactionServiceMock()
+		transactionService.On("ReverseTransaction", auth.DefaultTenant, id, service.ReverseTransactionRequest{Amount: 150, Reason: "order 1042 refunded"}).Return(&service.TransactionResponse{
+			TransactionID: 13,
+			WalletID:      1,
+			Operation:     "Add",
+			Amount:        150,
+			Balance:       650,
+			ReversalOf:    &original,
+			Reason:        "order 1042 refunded",
+			CreatedAt:     time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+		}, nil)
+
+		transactionHandler := handler.NewTransactionHandler(transactionService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":150,"reason":"order 1042 refunded"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/transactions/:id/reverse")
+		c.SetParamNames("id")
+		c.SetParamValues("12")
+
+		expected := `{"transaction_id":13,"wallet_id":1,"operation":"Add","amount":150,"balance":650,"reversal_of":12,"reason":"order 1042 refunded","created_at":"2023-01-27T12:30:00Z"}`
+
+		// Assert
+		if assert.NoError(t, transactionHandler.ReverseTransaction(c)) {
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("reverse already reversed transaction", func(t *testing.T) {
+		// Arrange
+		var id int64 = 12
+		transactionService := service.NewTransactionServiceMock()
+		transactionService.On("ReverseTransaction", auth.DefaultTenant, id, service.ReverseTransactionRequest{}).Return(&service.TransactionResponse{}, errs.NewTransactionReversedError())
+
+		transactionHandler := handler.NewTransactionHandler(transactionService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/transactions/:id/reverse")
+		c.SetParamNames("id")
+		c.SetParamValues("12")
+
+		// Assert
+		if assert.NoError(t, transactionHandler.ReverseTransaction(c)) {
+			assert.Equal(t, http.StatusConflict, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"code":"TRANSACTION_REVERSED"`)
+		}
+	})
+
+	t.Run("reverse with negative amount", func(t *testing.T) {
+		// Arrange
+		transactionService := service.NewTransactionServiceMock()
+
+		transactionHandler := handler.NewTransactionHandler(transactionService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":-5}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/transactions/:id/reverse")
+		c.SetParamNames("id")
+		c.SetParamValues("12")
+
+		// Assert
+		if assert.NoError(t, transactionHandler.ReverseTransaction(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			transactionService.AssertNotCalled(t, "ReverseTransaction")
+		}
+	})
+}
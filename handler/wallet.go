@@ -5,33 +5,83 @@ import (
 	"strconv"
 
 	"github.com/labstack/echo/v4"
+	"github.com/topnarapat/go-wallet/auth"
 	"github.com/topnarapat/go-wallet/errs"
 	"github.com/topnarapat/go-wallet/service"
 )
 
+// HeaderDeprecation marks responses to requests that named a wallet by its
+// numeric id.
+const HeaderDeprecation = "Deprecation"
+
 type walletHandler struct {
 	walletSrv service.WalletService
 }
 
-type Err struct {
-	Message string `json:"message"`
-}
-
 func NewWalletHandler(walletSrv service.WalletService) walletHandler {
 	return walletHandler{walletSrv: walletSrv}
 }
 
-func handlerError(c echo.Context, err error) error {
-	switch e := err.(type) {
-	case errs.AppError:
-		return c.JSON(e.Code, Err{Message: e.Message})
-	default:
-		return c.JSON(http.StatusInternalServerError, e)
+// ResolveID lets the wallet routes take the wallet's public id in :id,
+// replacing it with the numeric id the handlers parse. Numeric ids are still
+// accepted, and flagged as deprecated, while allowNumeric is set; otherwise
+// they are not found, like any id the tenant has no wallet for.
+func (h walletHandler) ResolveID(allowNumeric bool) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			id := c.Param("id")
+			if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+				if !allowNumeric {
+					return handlerError(c, errs.NewWalletNotFoundError())
+				}
+				c.Response().Header().Set(HeaderDeprecation, "true")
+				return next(c)
+			}
+			walletID, err := h.walletSrv.FindWalletID(auth.TenantID(c), id)
+			if err != nil {
+				return handlerError(c, err)
+			}
+
+			values := c.ParamValues()
+			for i, name := range c.ParamNames() {
+				if name == "id" {
+					values[i] = strconv.FormatInt(walletID, 10)
+				}
+			}
+			c.SetParamValues(values...)
+			return next(c)
+		}
+	}
+}
+
+// WalletKey gives the numeric id of the wallet named in :id, however it was
+// named, for the rate limiter to key its wallet bucket on. It gives nothing
+// for an id ResolveID would not find, so no wallet's budget is spent on it.
+func (h walletHandler) WalletKey(allowNumeric bool) func(echo.Context) string {
+	return func(c echo.Context) string {
+		id := c.Param("id")
+		if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+			if !allowNumeric {
+				return ""
+			}
+			return id
+		}
+		walletID, err := h.walletSrv.FindWalletID(auth.TenantID(c), id)
+		if err != nil {
+			return ""
+		}
+		return strconv.FormatInt(walletID, 10)
 	}
 }
 
 func (h walletHandler) ListWallets(c echo.Context) error {
-	wallets, err := h.walletSrv.ListAllWallets()
+	filter := service.ListWalletsRequest{}
+	err := (&echo.DefaultBinder{}).BindQueryParams(c, &filter)
+	if err != nil {
+		return handlerError(c, errs.NewBadRequest("query parameters incorrect format"))
+	}
+
+	wallets, err := h.walletSrv.ListAllWallets(auth.TenantID(c), filter)
 	if err != nil {
 		return handlerError(c, err)
 	}
@@ -39,71 +89,160 @@ func (h walletHandler) ListWallets(c echo.Context) error {
 	return c.JSON(http.StatusOK, wallets)
 }
 
+// LookupAccount answers GET /accounts/:number with a masked summary of the
+// wallet the account number belongs to.
+func (h walletHandler) LookupAccount(c echo.Context) error {
+	summary, err := h.walletSrv.LookupAccount(auth.TenantID(c), c.Param("number"))
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
 func (h walletHandler) GetWallet(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return handlerError(c, errs.NewBadRequest("id must be number"))
+		return handlerError(c, errs.NewInvalidIDError())
 	}
 
-	wallet, err := h.walletSrv.GetWalletDetail(int64(id))
+	wallet, err := h.walletSrv.GetWalletDetail(auth.TenantID(c), int64(id))
 	if err != nil {
 		return handlerError(c, err)
 	}
 
+	setETag(c, wallet.Version)
+	if wallet.Version != 0 && c.Request().Header.Get(HeaderIfNoneMatch) == etag(wallet.Version) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	return c.JSON(http.StatusOK, wallet)
 }
 
 func (h walletHandler) CreateWallet(c echo.Context) error {
 	balance := service.WalletRequest{}
-	err := c.Bind(&balance)
+	err := bindJSON(c, &balance)
 	if err != nil {
-		return handlerError(c, errs.NewBadRequest("request body incorrect format"))
+		return handlerError(c, err)
 	}
 
-	wallet, err := h.walletSrv.CreateWallet(balance)
+	wallet, err := h.walletSrv.CreateWallet(auth.TenantID(c), balance)
 	if err != nil {
 		return handlerError(c, err)
 	}
 
+	setETag(c, wallet.Version)
 	return c.JSON(http.StatusCreated, wallet)
 }
 
 func (h walletHandler) AddBalance(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return handlerError(c, errs.NewBadRequest("id must be number"))
+		return handlerError(c, errs.NewInvalidIDError())
 	}
 
 	amount := service.AddWalletRequest{}
-	err = c.Bind(&amount)
+	err = bindJSON(c, &amount)
 	if err != nil {
-		return handlerError(c, errs.NewBadRequest("request body incorrect format"))
+		return handlerError(c, err)
 	}
 
-	wallet, err := h.walletSrv.SetWalletBalance(int64(id), amount)
+	amount.ExpectedVersion, err = ifMatchVersion(c)
 	if err != nil {
 		return handlerError(c, err)
 	}
+	amount.RequestedBy = principalID(c)
 
+	wallet, err := h.walletSrv.SetWalletBalance(auth.TenantID(c), int64(id), amount)
+	if held, err := heldForApproval(c, err); held {
+		return err
+	}
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	setETag(c, wallet.Version)
 	return c.JSON(http.StatusOK, wallet)
 }
 
 func (h walletHandler) ChangeStatus(c echo.Context) error {
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return handlerError(c, errs.NewBadRequest("id must be number"))
+		return handlerError(c, errs.NewInvalidIDError())
 	}
 
 	status := service.StatusWalletRequest{}
-	err = c.Bind(&status)
+	err = bindJSON(c, &status)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	status.ExpectedVersion, err = ifMatchVersion(c)
+	if err != nil {
+		return handlerError(c, err)
+	}
+	status.RequestedBy = principalID(c)
+
+	wallet, err := h.walletSrv.SetStatusWallet(auth.TenantID(c), int64(id), status)
+	if held, err := heldForApproval(c, err); held {
+		return err
+	}
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	setETag(c, wallet.Version)
+	return c.JSON(http.StatusOK, wallet)
+}
+
+func (h walletHandler) CloseWallet(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	request := service.CloseWalletRequest{}
+	err = bindJSON(c, &request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	request.ExpectedVersion, err = ifMatchVersion(c)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	wallet, err := h.walletSrv.CloseWallet(auth.TenantID(c), int64(id), request)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	setETag(c, wallet.Version)
+	return c.JSON(http.StatusOK, wallet)
+}
+
+func (h walletHandler) UpdateWallet(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		return handlerError(c, errs.NewBadRequest("request body incorrect format"))
+		return handlerError(c, errs.NewInvalidIDError())
+	}
+
+	patch := service.PatchWalletRequest{}
+	err = bindJSON(c, &patch)
+	if err != nil {
+		return handlerError(c, err)
+	}
+
+	patch.ExpectedVersion, err = ifMatchVersion(c)
+	if err != nil {
+		return handlerError(c, err)
 	}
 
-	wallet, err := h.walletSrv.SetStatusWallet(int64(id), status)
+	wallet, err := h.walletSrv.UpdateWallet(auth.TenantID(c), int64(id), patch)
 	if err != nil {
 		return handlerError(c, err)
 	}
 
+	setETag(c, wallet.Version)
 	return c.JSON(http.StatusOK, wallet)
 }
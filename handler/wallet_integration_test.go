@@ -4,6 +4,7 @@
 package handler_test
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"fmt"
@@ -20,11 +21,24 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/topnarapat/go-wallet/handler"
 	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
 	"github.com/topnarapat/go-wallet/service"
 )
 
 const serverPort = 2565
 
+func defaultRiskEngine() *risk.Engine {
+	rules, err := risk.LoadRules(bytes.NewReader(risk.DefaultRules))
+	if err != nil {
+		log.Fatal(err)
+	}
+	engine, err := risk.NewEngine(rules)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return engine
+}
+
 func TestGetAllWalletsIntegration(t *testing.T) {
 	eh := echo.New()
 	go func(e *echo.Echo) {
@@ -34,7 +48,7 @@ func TestGetAllWalletsIntegration(t *testing.T) {
 		}
 
 		walletRepo := repository.NewWalletRepository(db)
-		walletSrv := service.NewWalletService(walletRepo)
+		walletSrv := service.NewWalletService(walletRepo, repository.NewTenantRepository(db), repository.NewApprovalRepository(db), service.NewRiskService(repository.NewRiskRepository(db), defaultRiskEngine()), repository.NewFeeRepository(db))
 		walletHandler := handler.NewWalletHandler(walletSrv)
 
 		e.GET("/wallet", walletHandler.ListWallets)
@@ -66,7 +80,7 @@ func TestGetAllWalletsIntegration(t *testing.T) {
 	resp.Body.Close()
 
 	// Assertions
-	expected := `[{"wallet_id":1,"balance":1000,"status":"Active","created_at":"2023-01-27T12:30:00Z"},{"wallet_id":2,"balance":2000,"status":"Active","created_at":"2023-01-27T12:30:00Z"},{"wallet_id":3,"balance":3000,"status":"Active","created_at":"2023-01-27T12:30:00Z"},{"wallet_id":4,"balance":4000,"status":"Active","created_at":"2023-01-27T12:30:00Z"},{"wallet_id":5,"balance":5000,"status":"Active","created_at":"2023-01-27T12:30:00Z"}]`
+	expected := `[{"wallet_id":1,"balance":1000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{}},{"wallet_id":2,"balance":2000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{}},{"wallet_id":3,"balance":3000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{}},{"wallet_id":4,"balance":4000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{}},{"wallet_id":5,"balance":5000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{}}]`
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -88,7 +102,7 @@ func TestGetWalletIntegration(t *testing.T) {
 		}
 
 		walletRepo := repository.NewWalletRepository(db)
-		walletSrv := service.NewWalletService(walletRepo)
+		walletSrv := service.NewWalletService(walletRepo, repository.NewTenantRepository(db), repository.NewApprovalRepository(db), service.NewRiskService(repository.NewRiskRepository(db), defaultRiskEngine()), repository.NewFeeRepository(db))
 		walletHandler := handler.NewWalletHandler(walletSrv)
 
 		e.GET("/wallet/:id", walletHandler.GetWallet)
@@ -120,7 +134,7 @@ func TestGetWalletIntegration(t *testing.T) {
 	resp.Body.Close()
 
 	// Assertions
-	expected := `{"wallet_id":1,"balance":1000,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+	expected := `{"wallet_id":1,"balance":1000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{}}`
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -142,7 +156,7 @@ func TestCreateWalletIntegration(t *testing.T) {
 		}
 
 		walletRepo := repository.NewWalletRepository(db)
-		walletSrv := service.NewWalletService(walletRepo)
+		walletSrv := service.NewWalletService(walletRepo, repository.NewTenantRepository(db), repository.NewApprovalRepository(db), service.NewRiskService(repository.NewRiskRepository(db), defaultRiskEngine()), repository.NewFeeRepository(db))
 		walletHandler := handler.NewWalletHandler(walletSrv)
 
 		e.POST("/wallet", walletHandler.CreateWallet)
@@ -190,7 +204,7 @@ func TestAddBalanceIntegration(t *testing.T) {
 		}
 
 		walletRepo := repository.NewWalletRepository(db)
-		walletSrv := service.NewWalletService(walletRepo)
+		walletSrv := service.NewWalletService(walletRepo, repository.NewTenantRepository(db), repository.NewApprovalRepository(db), service.NewRiskService(repository.NewRiskRepository(db), defaultRiskEngine()), repository.NewFeeRepository(db))
 		walletHandler := handler.NewWalletHandler(walletSrv)
 
 		e.PUT("/wallet/:id", walletHandler.AddBalance)
@@ -222,7 +236,7 @@ func TestAddBalanceIntegration(t *testing.T) {
 	resp.Body.Close()
 
 	// Assertions
-	expected := `{"wallet_id":1,"balance":2000,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+	expected := `{"wallet_id":1,"balance":2000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{}}`
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -244,7 +258,7 @@ func TestChangeStatusIntegration(t *testing.T) {
 		}
 
 		walletRepo := repository.NewWalletRepository(db)
-		walletSrv := service.NewWalletService(walletRepo)
+		walletSrv := service.NewWalletService(walletRepo, repository.NewTenantRepository(db), repository.NewApprovalRepository(db), service.NewRiskService(repository.NewRiskRepository(db), defaultRiskEngine()), repository.NewFeeRepository(db))
 		walletHandler := handler.NewWalletHandler(walletSrv)
 
 		e.PUT("/wallet/:id/status", walletHandler.ChangeStatus)
@@ -276,7 +290,7 @@ func TestChangeStatusIntegration(t *testing.T) {
 	resp.Body.Close()
 
 	// Assertions
-	expected := `{"wallet_id":2,"balance":2000,"status":"Deactive","created_at":"2023-01-27T12:30:00Z"}`
+	expected := `{"wallet_id":2,"balance":2000,"currency":"THB","status":"Deactive","created_at":"2023-01-27T12:30:00Z","metadata":{}}`
 
 	if assert.NoError(t, err) {
 		assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -4,6 +4,7 @@
 package handler_test
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/auth"
 	"github.com/topnarapat/go-wallet/errs"
 	"github.com/topnarapat/go-wallet/handler"
 	"github.com/topnarapat/go-wallet/service"
@@ -22,8 +24,8 @@ func TestListWallets(t *testing.T) {
 	t.Run("get all wallet success", func(t *testing.T) {
 		// Arrange
 		walletService := service.NewWalletServiceMock()
-		walletService.On("ListAllWallets").Return([]service.WalletResponse{
-			{WalletID: 1, Balance: 500, Status: "Active", CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC)},
+		walletService.On("ListAllWallets", auth.DefaultTenant, service.ListWalletsRequest{}).Return([]service.WalletResponse{
+			{WalletID: 1, Balance: 500, Currency: "THB", Status: "Active", CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC)},
 		}, nil)
 
 		walletHandler := handler.NewWalletHandler(walletService)
@@ -34,7 +36,7 @@ func TestListWallets(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		expected := `[{"wallet_id":1,"balance":500,"status":"Active","created_at":"2023-01-27T12:30:00Z"}]`
+		expected := `[{"wallet_id":1,"balance":500,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z"}]`
 
 		// Assert
 		if assert.NoError(t, walletHandler.ListWallets(c)) {
@@ -46,7 +48,7 @@ func TestListWallets(t *testing.T) {
 	t.Run("get all wallet error", func(t *testing.T) {
 		// Arrange
 		walletService := service.NewWalletServiceMock()
-		walletService.On("ListAllWallets").Return([]service.WalletResponse{}, errors.New(""))
+		walletService.On("ListAllWallets", auth.DefaultTenant, service.ListWalletsRequest{}).Return([]service.WalletResponse{}, errors.New(""))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -68,9 +70,10 @@ func TestGetWallet(t *testing.T) {
 		// Arrange
 		var id int64 = 1
 		walletService := service.NewWalletServiceMock()
-		walletService.On("GetWalletDetail", id).Return(&service.WalletResponse{
+		walletService.On("GetWalletDetail", auth.DefaultTenant, id).Return(&service.WalletResponse{
 			WalletID:  id,
 			Balance:   500,
+			Currency:  "THB",
 			Status:    "Active",
 			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
 		}, nil)
@@ -86,7 +89,7 @@ func TestGetWallet(t *testing.T) {
 		c.SetParamNames("id")
 		c.SetParamValues("1")
 
-		expected := `{"wallet_id":1,"balance":500,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+		expected := `{"wallet_id":1,"balance":500,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z"}`
 
 		// Assert
 		if assert.NoError(t, walletHandler.GetWallet(c)) {
@@ -98,7 +101,7 @@ func TestGetWallet(t *testing.T) {
 	t.Run("id must be number", func(t *testing.T) {
 		// Arrange
 		walletService := service.NewWalletServiceMock()
-		walletService.On("GetWalletDetail", "abc").Return(&service.WalletResponse{}, errors.New("id must be number"))
+		walletService.On("GetWalletDetail", auth.DefaultTenant, "abc").Return(&service.WalletResponse{}, errors.New("id must be number"))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -121,7 +124,7 @@ func TestGetWallet(t *testing.T) {
 		// Arrange
 		var id int64 = 1
 		walletService := service.NewWalletServiceMock()
-		walletService.On("GetWalletDetail", id).Return(&service.WalletResponse{}, errs.NewNotFoundError("not found wallet"))
+		walletService.On("GetWalletDetail", auth.DefaultTenant, id).Return(&service.WalletResponse{}, errs.NewNotFoundError("not found wallet"))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -141,6 +144,180 @@ func TestGetWallet(t *testing.T) {
 	})
 }
 
+func TestResolveWalletID(t *testing.T) {
+	const publicID = "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3"
+
+	resolve := func(walletService service.WalletService, allowNumeric bool, id string) (*httptest.ResponseRecorder, string) {
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/pockets/:pocket_id/close")
+		c.SetParamNames("id", "pocket_id")
+		c.SetParamValues(id, "3")
+
+		var seen string
+		next := func(c echo.Context) error {
+			seen = c.Param("id")
+			return c.NoContent(http.StatusNoContent)
+		}
+		if err := walletHandler.ResolveID(allowNumeric)(next)(c); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, "3", c.Param("pocket_id"))
+		return rec, seen
+	}
+
+	t.Run("public id", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletService.On("FindWalletID", auth.DefaultTenant, publicID).Return(int64(42), nil)
+
+		// Act
+		rec, seen := resolve(walletService, false, publicID)
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "42", seen)
+		assert.Empty(t, rec.Header().Get(handler.HeaderDeprecation))
+	})
+
+	t.Run("unknown public id", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletService.On("FindWalletID", auth.DefaultTenant, publicID).Return(int64(0), errs.NewWalletNotFoundError())
+
+		// Act
+		rec, seen := resolve(walletService, true, publicID)
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, seen)
+	})
+
+	t.Run("numeric id during transition", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+
+		// Act
+		rec, seen := resolve(walletService, true, "42")
+
+		// Assert
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "42", seen)
+		assert.Equal(t, "true", rec.Header().Get(handler.HeaderDeprecation))
+		walletService.AssertNotCalled(t, "FindWalletID")
+	})
+
+	t.Run("numeric id after transition", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+
+		// Act
+		rec, seen := resolve(walletService, false, "42")
+
+		// Assert
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Contains(t, rec.Body.String(), string(errs.CodeWalletNotFound))
+		assert.Empty(t, seen)
+	})
+}
+
+func TestWalletKey(t *testing.T) {
+	const publicID = "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3"
+
+	walletService := service.NewWalletServiceMock()
+	walletService.On("FindWalletID", auth.DefaultTenant, publicID).Return(int64(42), nil)
+	walletService.On("FindWalletID", auth.DefaultTenant, "wal_unknown").Return(int64(0), errs.NewWalletNotFoundError())
+	walletHandler := handler.NewWalletHandler(walletService)
+
+	cases := []struct {
+		name         string
+		allowNumeric bool
+		id           string
+		key          string
+	}{
+		{"public id", false, publicID, "42"},
+		{"numeric id during transition", true, "42", "42"},
+		{"numeric id after transition", false, "42", ""},
+		{"unknown public id", true, "wal_unknown", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			ctx := e.NewContext(req, httptest.NewRecorder())
+			ctx.SetPath("/wallet/:id")
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(c.id)
+
+			// Act
+			key := walletHandler.WalletKey(c.allowNumeric)(ctx)
+
+			// Assert
+			assert.Equal(t, c.key, key)
+		})
+	}
+}
+
+func TestLookupAccount(t *testing.T) {
+	t.Run("summary", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletService.On("LookupAccount", auth.DefaultTenant, "123-4-56789-7").Return(&service.AccountSummary{
+			AccountNumber: "xxx-x-xx789-7",
+			DisplayName:   "S****** J*****",
+			Currency:      "THB",
+			Status:        "Active",
+		}, nil)
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/accounts/:number")
+		c.SetParamNames("number")
+		c.SetParamValues("123-4-56789-7")
+
+		expected := `{"account_number":"xxx-x-xx789-7","display_name":"S****** J*****","currency":"THB","status":"Active"}`
+
+		// Assert
+		if assert.NoError(t, walletHandler.LookupAccount(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("check digit does not match", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletService.On("LookupAccount", auth.DefaultTenant, "123-4-56788-7").Return((*service.AccountSummary)(nil), errs.NewFieldValidationError([]errs.FieldError{{Field: "account_number", Message: "check digit does not match"}}))
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/accounts/:number")
+		c.SetParamNames("number")
+		c.SetParamValues("123-4-56788-7")
+
+		// Assert
+		if assert.NoError(t, walletHandler.LookupAccount(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), "check digit does not match")
+		}
+	})
+}
+
 func TestCreateWallet(t *testing.T) {
 	t.Run("create wallet success", func(t *testing.T) {
 		// Arrange
@@ -148,9 +325,10 @@ func TestCreateWallet(t *testing.T) {
 			Balance: 1000,
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("CreateWallet", balance).Return(&service.WalletResponse{
+		walletService.On("CreateWallet", auth.DefaultTenant, balance).Return(&service.WalletResponse{
 			WalletID:  1,
 			Balance:   1000,
+			Currency:  "THB",
 			Status:    "Active",
 			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
 		}, nil)
@@ -165,7 +343,7 @@ func TestCreateWallet(t *testing.T) {
 		rec := httptest.NewRecorder()
 		c := e.NewContext(req, rec)
 
-		expected := `{"wallet_id":1,"balance":1000,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+		expected := `{"wallet_id":1,"balance":1000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z"}`
 
 		// Assert
 		if assert.NoError(t, walletHandler.CreateWallet(c)) {
@@ -180,7 +358,7 @@ func TestCreateWallet(t *testing.T) {
 			Balance: 1000,
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("CreateWallet", balance).Return(&service.WalletResponse{}, errors.New(""))
+		walletService.On("CreateWallet", auth.DefaultTenant, balance).Return(&service.WalletResponse{}, errors.New(""))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -204,7 +382,7 @@ func TestCreateWallet(t *testing.T) {
 			Balance: 1000,
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("CreateWallet", balance).Return(&service.WalletResponse{}, errors.New(""))
+		walletService.On("CreateWallet", auth.DefaultTenant, balance).Return(&service.WalletResponse{}, errors.New(""))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -232,9 +410,10 @@ func TestAddBalance(t *testing.T) {
 			Operation: "Add",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetWalletBalance", id, balance).Return(&service.WalletResponse{
+		walletService.On("SetWalletBalance", auth.DefaultTenant, id, balance).Return(&service.WalletResponse{
 			WalletID:  1,
 			Balance:   2000,
+			Currency:  "THB",
 			Status:    "Active",
 			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
 		}, nil)
@@ -252,7 +431,7 @@ func TestAddBalance(t *testing.T) {
 		c.SetParamNames("id")
 		c.SetParamValues("1")
 
-		expected := `{"wallet_id":1,"balance":2000,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+		expected := `{"wallet_id":1,"balance":2000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z"}`
 
 		// Assert
 		if assert.NoError(t, walletHandler.AddBalance(c)) {
@@ -269,9 +448,10 @@ func TestAddBalance(t *testing.T) {
 			Operation: "Deduct",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetWalletBalance", id, balance).Return(&service.WalletResponse{
+		walletService.On("SetWalletBalance", auth.DefaultTenant, id, balance).Return(&service.WalletResponse{
 			WalletID:  1,
 			Balance:   2000,
+			Currency:  "THB",
 			Status:    "Active",
 			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
 		}, nil)
@@ -289,7 +469,7 @@ func TestAddBalance(t *testing.T) {
 		c.SetParamNames("id")
 		c.SetParamValues("1")
 
-		expected := `{"wallet_id":1,"balance":2000,"status":"Active","created_at":"2023-01-27T12:30:00Z"}`
+		expected := `{"wallet_id":1,"balance":2000,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z"}`
 
 		// Assert
 		if assert.NoError(t, walletHandler.AddBalance(c)) {
@@ -305,7 +485,7 @@ func TestAddBalance(t *testing.T) {
 			Operation: "Deduct",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetWalletBalance", "abc", balance).Return(&service.WalletResponse{}, errors.New("id must be number"))
+		walletService.On("SetWalletBalance", auth.DefaultTenant, "abc", balance).Return(&service.WalletResponse{}, errors.New("id must be number"))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -331,7 +511,7 @@ func TestAddBalance(t *testing.T) {
 		var id int64 = 1
 		balance := service.AddWalletRequest{}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetWalletBalance", id, balance).Return(&service.WalletResponse{}, errors.New("request body incorrect format"))
+		walletService.On("SetWalletBalance", auth.DefaultTenant, id, balance).Return(&service.WalletResponse{}, errors.New("request body incorrect format"))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -360,7 +540,7 @@ func TestAddBalance(t *testing.T) {
 			Operation: "Add",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetWalletBalance", id, balance).Return(&service.WalletResponse{}, errors.New(""))
+		walletService.On("SetWalletBalance", auth.DefaultTenant, id, balance).Return(&service.WalletResponse{}, errors.New(""))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -390,9 +570,10 @@ func TestChangeStatus(t *testing.T) {
 			Status: "Deactive",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetStatusWallet", id, request).Return(&service.WalletResponse{
+		walletService.On("SetStatusWallet", auth.DefaultTenant, id, request).Return(&service.WalletResponse{
 			WalletID:  1,
 			Balance:   2000,
+			Currency:  "THB",
 			Status:    "Deactive",
 			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
 		}, nil)
@@ -410,7 +591,7 @@ func TestChangeStatus(t *testing.T) {
 		c.SetParamNames("id")
 		c.SetParamValues("1")
 
-		expected := `{"wallet_id":1,"balance":2000,"status":"Deactive","created_at":"2023-01-27T12:30:00Z"}`
+		expected := `{"wallet_id":1,"balance":2000,"currency":"THB","status":"Deactive","created_at":"2023-01-27T12:30:00Z"}`
 
 		// Assert
 		if assert.NoError(t, walletHandler.ChangeStatus(c)) {
@@ -425,7 +606,7 @@ func TestChangeStatus(t *testing.T) {
 			Status: "Deactive",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetStatusWallet", "abc", request).Return(&service.WalletResponse{}, errs.NewBadRequest("id must be number"))
+		walletService.On("SetStatusWallet", auth.DefaultTenant, "abc", request).Return(&service.WalletResponse{}, errs.NewBadRequest("id must be number"))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -453,7 +634,7 @@ func TestChangeStatus(t *testing.T) {
 			Status: "Deactive",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetStatusWallet", id, request).Return(&service.WalletResponse{}, errs.NewBadRequest("request body incorrect format"))
+		walletService.On("SetStatusWallet", auth.DefaultTenant, id, request).Return(&service.WalletResponse{}, errs.NewBadRequest("request body incorrect format"))
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -481,7 +662,7 @@ func TestChangeStatus(t *testing.T) {
 			Status: "Deactive",
 		}
 		walletService := service.NewWalletServiceMock()
-		walletService.On("SetStatusWallet", id, request).Return(&service.WalletResponse{}, errs.NewUnexpectedError())
+		walletService.On("SetStatusWallet", auth.DefaultTenant, id, request).Return(&service.WalletResponse{}, errs.NewUnexpectedError())
 
 		walletHandler := handler.NewWalletHandler(walletService)
 
@@ -502,3 +683,427 @@ func TestChangeStatus(t *testing.T) {
 		}
 	})
 }
+
+func TestProblemResponse(t *testing.T) {
+	t.Run("app error rendered as problem", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletService := service.NewWalletServiceMock()
+		walletService.On("GetWalletDetail", auth.DefaultTenant, id).Return(&service.WalletResponse{}, errs.NewWalletNotFoundError())
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/wallet/1", nil)
+		rec := httptest.NewRecorder()
+		rec.Header().Set(echo.HeaderXRequestID, "req-1")
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `{"type":"urn:go-wallet:problem:WALLET_NOT_FOUND","title":"Wallet not found","status":404,"detail":"wallet not found","code":"WALLET_NOT_FOUND","instance":"/wallet/1","request_id":"req-1"}`
+
+		// Assert
+		if assert.NoError(t, walletHandler.GetWallet(c)) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			assert.Equal(t, handler.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("non app error rendered as internal error", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletService.On("ListAllWallets", auth.DefaultTenant, service.ListWalletsRequest{}).Return([]service.WalletResponse{}, errors.New("connection refused"))
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/wallet", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		expected := `{"type":"urn:go-wallet:problem:INTERNAL_ERROR","title":"Internal server error","status":500,"detail":"unexpected error","code":"INTERNAL_ERROR","instance":"/wallet"}`
+
+		// Assert
+		if assert.NoError(t, walletHandler.ListWallets(c)) {
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+}
+
+func TestRequestValidation(t *testing.T) {
+	t.Run("create wallet with negative balance", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"balance":-1}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, walletHandler.CreateWallet(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"errors":[{"field":"balance","message":"must be at least 0"}]`)
+			walletService.AssertNotCalled(t, "CreateWallet")
+		}
+	})
+
+	t.Run("add balance returns all field errors", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"balance":0,"operation":"Move","currency":"THB"}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `"errors":[{"field":"currency","message":"is not allowed"},{"field":"balance","message":"is required"},{"field":"operation","message":"must be one of Add, Deduct"}]`
+
+		// Assert
+		if assert.NoError(t, walletHandler.AddBalance(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), expected)
+			walletService.AssertNotCalled(t, "SetWalletBalance")
+		}
+	})
+
+	t.Run("change status requires status", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/status")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, walletHandler.ChangeStatus(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"errors":[{"field":"status","message":"is required"}]`)
+		}
+	})
+}
+
+func TestETag(t *testing.T) {
+	t.Run("get wallet returns etag", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletService := service.NewWalletServiceMock()
+		walletService.On("GetWalletDetail", auth.DefaultTenant, id).Return(&service.WalletResponse{
+			WalletID:  id,
+			Balance:   500,
+			Currency:  "THB",
+			Status:    "Active",
+			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+			Version:   7,
+		}, nil)
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, walletHandler.GetWallet(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, `"7"`, rec.Header().Get(handler.HeaderETag))
+		}
+	})
+
+	t.Run("change status passes if-match version", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.StatusWalletRequest{
+			Status:          "Deactive",
+			ExpectedVersion: 7,
+		}
+		walletService := service.NewWalletServiceMock()
+		walletService.On("SetStatusWallet", auth.DefaultTenant, id, request).Return(&service.WalletResponse{
+			WalletID:  1,
+			Balance:   2000,
+			Currency:  "THB",
+			Status:    "Deactive",
+			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+			Version:   8,
+		}, nil)
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"status":"Deactive"}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(handler.HeaderIfMatch, `"7"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/status")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, walletHandler.ChangeStatus(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, `"8"`, rec.Header().Get(handler.HeaderETag))
+		}
+	})
+
+	t.Run("add balance with version moved", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		balance := service.AddWalletRequest{
+			Balance:         1000,
+			Operation:       "Add",
+			ExpectedVersion: 7,
+		}
+		walletService := service.NewWalletServiceMock()
+		walletService.On("SetWalletBalance", auth.DefaultTenant, id, balance).Return(&service.WalletResponse{}, errs.NewPreconditionFailedError())
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"balance":1000,"operation":"Add"}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(handler.HeaderIfMatch, `"7"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, walletHandler.AddBalance(c)) {
+			assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		}
+	})
+
+	t.Run("malformed if-match", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"balance":1000,"operation":"Add"}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(handler.HeaderIfMatch, `"abc"`)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, walletHandler.AddBalance(c)) {
+			assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+			walletService.AssertNotCalled(t, "SetWalletBalance")
+		}
+	})
+}
+
+func TestCloseWallet(t *testing.T) {
+	t.Run("close wallet success", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		closedAt := time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC)
+		request := service.CloseWalletRequest{Reason: "migrated", DestinationAccountNumber: "123-4-56789-7"}
+		walletService := service.NewWalletServiceMock()
+		walletService.On("CloseWallet", auth.DefaultTenant, id, request).Return(&service.WalletResponse{
+			WalletID:      1,
+			Balance:       0,
+			Currency:      "THB",
+			Status:        "Closed",
+			CreatedAt:     time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+			ClosedAt:      &closedAt,
+			ClosureReason: "migrated",
+			Version:       4,
+		}, nil)
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"reason":"migrated","destination_account_number":"123-4-56789-7"}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/close")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `{"wallet_id":1,"balance":0,"currency":"THB","status":"Closed","created_at":"2023-01-27T12:30:00Z","closed_at":"2023-03-31T09:00:00Z","closure_reason":"migrated"}`
+
+		// Assert
+		if assert.NoError(t, walletHandler.CloseWallet(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+			assert.Equal(t, `"4"`, rec.Header().Get(handler.HeaderETag))
+		}
+	})
+
+	t.Run("close wallet requires reason", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/close")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, walletHandler.CloseWallet(c)) {
+			assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+			walletService.AssertNotCalled(t, "CloseWallet")
+		}
+	})
+
+	t.Run("close wallet with balance left", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.CloseWalletRequest{Reason: "customer request"}
+		walletService := service.NewWalletServiceMock()
+		walletService.On("CloseWallet", auth.DefaultTenant, id, request).Return(&service.WalletResponse{}, errs.NewBalanceNotZeroError())
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"reason":"customer request"}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id/close")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		// Assert
+		if assert.NoError(t, walletHandler.CloseWallet(c)) {
+			assert.Equal(t, http.StatusConflict, rec.Code)
+		}
+	})
+
+	t.Run("list wallets including closed", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletService.On("ListAllWallets", auth.DefaultTenant, service.ListWalletsRequest{IncludeClosed: true}).Return([]service.WalletResponse{}, nil)
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/wallet?include_closed=true", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, walletHandler.ListWallets(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			walletService.AssertExpectations(t)
+		}
+	})
+}
+
+func TestUpdateWallet(t *testing.T) {
+	t.Run("patch labels success", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		team := "payments"
+		request := service.PatchWalletRequest{
+			Labels: map[string]*string{"team": &team, "legacy": nil},
+		}
+		walletService := service.NewWalletServiceMock()
+		walletService.On("UpdateWallet", auth.DefaultTenant, id, request).Return(&service.WalletResponse{
+			WalletID:  1,
+			Balance:   500,
+			Currency:  "THB",
+			Status:    "Active",
+			CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC),
+			Metadata:  json.RawMessage(`{}`),
+			Labels:    map[string]string{"team": "payments"},
+			Version:   3,
+		}, nil)
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		r := `{"labels":{"team":"payments","legacy":null}}`
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(r))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/wallet/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		expected := `{"wallet_id":1,"balance":500,"currency":"THB","status":"Active","created_at":"2023-01-27T12:30:00Z","metadata":{},"labels":{"team":"payments"}}`
+
+		// Assert
+		if assert.NoError(t, walletHandler.UpdateWallet(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, expected, strings.TrimSpace(rec.Body.String()))
+		}
+	})
+
+	t.Run("list wallets by label", func(t *testing.T) {
+		// Arrange
+		walletService := service.NewWalletServiceMock()
+		walletService.On("ListAllWallets", auth.DefaultTenant, service.ListWalletsRequest{Labels: []string{"team:payments", "env:prod"}}).Return([]service.WalletResponse{}, nil)
+
+		walletHandler := handler.NewWalletHandler(walletService)
+
+		// Act
+		e := echo.New()
+		req := httptest.NewRequest(http.MethodGet, "/wallet?label=team:payments&label=env:prod", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assert
+		if assert.NoError(t, walletHandler.ListWallets(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			walletService.AssertExpectations(t)
+		}
+	})
+}
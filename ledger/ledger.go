@@ -0,0 +1,139 @@
+// Package ledger is the double-entry side of the wallets. Every wallet is a
+// liability account, money the tenant owes its holder, and every movement of
+// money is a journal entry whose debits equal its credits, so what the
+// wallets hold is always matched by cash, suspense or the income and expense
+// it came from. Nothing here touches the database.
+package ledger
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Account types. Assets and expenses grow with debits; liabilities, equity
+// and revenue grow with credits.
+const (
+	Asset     = "Asset"
+	Liability = "Liability"
+	Equity    = "Equity"
+	Revenue   = "Revenue"
+	Expense   = "Expense"
+)
+
+// The chart of accounts. Each tenant has these system accounts in every
+// currency it holds, and one account per wallet under Wallets.
+const (
+	Cash = "1000"
+	// Suspense holds balances whose source is not known yet, such as those
+	// brought in by an import.
+	Suspense = "1900"
+	Wallets  = "2000"
+	// FeePayouts is what the tenant has paid itself out of fee revenue into
+	// the fee rules' revenue wallets.
+	FeePayouts      = "3100"
+	FeesRevenue     = "4000"
+	InterestExpense = "5000"
+)
+
+// Account is one account of the chart.
+type Account struct {
+	Code string
+	Name string
+	Type string
+}
+
+// SystemAccounts are the accounts every tenant has besides its wallets.
+var SystemAccounts = []Account{
+	{Code: Cash, Name: "Cash", Type: Asset},
+	{Code: Suspense, Name: "Suspense", Type: Asset},
+	{Code: FeePayouts, Name: "Fee payouts", Type: Equity},
+	{Code: FeesRevenue, Name: "Fees revenue", Type: Revenue},
+	{Code: InterestExpense, Name: "Interest expense", Type: Expense},
+}
+
+var (
+	// ErrUnbalanced is returned for an entry whose debits and credits do not
+	// net to zero.
+	ErrUnbalanced = errors.New("ledger: entry debits do not equal credits")
+	// ErrInvalidLine is returned for an entry with fewer than two lines, or
+	// a line without an account or with other than exactly one of a debit
+	// and a credit.
+	ErrInvalidLine = errors.New("ledger: invalid entry line")
+)
+
+// Line is one leg of an entry: a debit or a credit to an account.
+type Line struct {
+	Account string
+	Debit   float64
+	Credit  float64
+}
+
+// Entry is a journal entry in one currency. TransactionID is the wallet
+// transaction it records, if any.
+type Entry struct {
+	Currency      string
+	Description   string
+	TransactionID *int64
+	Lines         []Line
+}
+
+// Transfer is an entry moving amount from one account to another: a debit
+// to debit and a credit to credit.
+func Transfer(currency string, description string, debit string, credit string, amount float64) Entry {
+	return Entry{
+		Currency:    currency,
+		Description: description,
+		Lines:       []Line{{Account: debit, Debit: amount}, {Account: credit, Credit: amount}},
+	}
+}
+
+// Validate refuses an entry whose lines do not net to zero to the cent.
+func (e Entry) Validate() error {
+	if len(e.Lines) < 2 {
+		return ErrInvalidLine
+	}
+
+	var net int64
+	for _, l := range e.Lines {
+		debit, credit := cents(l.Debit), cents(l.Credit)
+		if l.Account == "" || debit < 0 || credit < 0 || (debit == 0) == (credit == 0) {
+			return ErrInvalidLine
+		}
+		net += debit - credit
+	}
+	if net != 0 {
+		return ErrUnbalanced
+	}
+
+	return nil
+}
+
+// WalletAccount is the code of the wallet's account: Wallets, a dash and the
+// wallet id.
+func WalletAccount(walletID int64) string {
+	return Wallets + "-" + strconv.FormatInt(walletID, 10)
+}
+
+// WalletID returns the wallet whose account code is given, if it is a
+// wallet's.
+func WalletID(code string) (int64, bool) {
+	if !strings.HasPrefix(code, Wallets+"-") {
+		return 0, false
+	}
+	walletID, err := strconv.ParseInt(strings.TrimPrefix(code, Wallets+"-"), 10, 64)
+	if err != nil || walletID <= 0 {
+		return 0, false
+	}
+	return walletID, true
+}
+
+// DebitNormal reports whether accounts of the type grow with debits.
+func DebitNormal(accountType string) bool {
+	return accountType == Asset || accountType == Expense
+}
+
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
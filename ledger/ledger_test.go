@@ -0,0 +1,69 @@
+//go:build unit
+// +build unit
+
+package ledger_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/ledger"
+)
+
+func TestValidate(t *testing.T) {
+	type testCase struct {
+		name  string
+		lines []ledger.Line
+		err   error
+	}
+
+	cases := []testCase{
+		{name: "two legs", lines: []ledger.Line{{Account: ledger.Cash, Debit: 100}, {Account: "2000-1", Credit: 100}}},
+		{name: "split legs", lines: []ledger.Line{{Account: "2000-1", Debit: 100.25}, {Account: ledger.Cash, Credit: 100}, {Account: "2000-2", Credit: 0.25}}},
+		{name: "float noise", lines: []ledger.Line{{Account: "2000-1", Debit: 0.1 + 0.2}, {Account: ledger.Cash, Credit: 0.3}}},
+		{name: "off by a cent", lines: []ledger.Line{{Account: ledger.Cash, Debit: 100}, {Account: "2000-1", Credit: 99.99}}, err: ledger.ErrUnbalanced},
+		{name: "debits only", lines: []ledger.Line{{Account: ledger.Cash, Debit: 100}, {Account: "2000-1", Debit: 100}}, err: ledger.ErrUnbalanced},
+		{name: "single leg", lines: []ledger.Line{{Account: ledger.Cash, Debit: 100}}, err: ledger.ErrInvalidLine},
+		{name: "no account", lines: []ledger.Line{{Account: ledger.Cash, Debit: 100}, {Credit: 100}}, err: ledger.ErrInvalidLine},
+		{name: "debit and credit", lines: []ledger.Line{{Account: ledger.Cash, Debit: 100, Credit: 100}, {Account: "2000-1", Debit: 100}, {Account: "2000-2", Credit: 100}}, err: ledger.ErrInvalidLine},
+		{name: "zero line", lines: []ledger.Line{{Account: ledger.Cash, Debit: 100}, {Account: "2000-1", Credit: 100}, {Account: "2000-2"}}, err: ledger.ErrInvalidLine},
+		{name: "negative", lines: []ledger.Line{{Account: ledger.Cash, Debit: -100}, {Account: "2000-1", Credit: -100}}, err: ledger.ErrInvalidLine},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			err := ledger.Entry{Currency: "THB", Lines: c.lines}.Validate()
+
+			// Assert
+			assert.Equal(t, c.err, err)
+		})
+	}
+}
+
+func TestTransfer(t *testing.T) {
+	// Act
+	entry := ledger.Transfer("THB", "deposit", ledger.Cash, ledger.WalletAccount(42), 150)
+
+	// Assert
+	assert.NoError(t, entry.Validate())
+	assert.Equal(t, []ledger.Line{{Account: "1000", Debit: 150}, {Account: "2000-42", Credit: 150}}, entry.Lines)
+}
+
+func TestWalletID(t *testing.T) {
+	t.Run("wallet account", func(t *testing.T) {
+		// Act
+		id, ok := ledger.WalletID(ledger.WalletAccount(42))
+
+		// Assert
+		assert.True(t, ok)
+		assert.Equal(t, int64(42), id)
+	})
+
+	t.Run("system accounts", func(t *testing.T) {
+		for _, code := range []string{ledger.Cash, ledger.Wallets, "2000-", "2000-x", "2000-0"} {
+			_, ok := ledger.WalletID(code)
+			assert.False(t, ok, code)
+		}
+	})
+}
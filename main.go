@@ -1,21 +1,31 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	_ "github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/events"
 	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/logs"
+	"github.com/topnarapat/go-wallet/openapi"
+	"github.com/topnarapat/go-wallet/ratelimit"
 	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
 	"github.com/topnarapat/go-wallet/service"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -27,19 +37,81 @@ func main() {
 	db.SetMaxOpenConns(10)
 	db.SetConnMaxIdleTime(10)
 
-	e := echo.New()
-	e.Use(middleware.Logger())
-	e.Use(middleware.Recover())
+	if len(os.Args) > 1 {
+		os.Exit(newCLI(db).run(os.Args[1:]))
+	}
+
+	listenCtx, stopListening := context.WithCancel(context.Background())
+	defer stopListening()
+	broker := events.NewBroker()
+	go func() {
+		if err := events.Listen(listenCtx, os.Getenv("DATABASE_URL"), broker); err != nil {
+			logs.Error(err, zap.String("listener", events.Channel))
+		}
+	}()
 
 	walletRepositoryDB := repository.NewWalletRepository(db)
-	walletService := service.NewWalletService(walletRepositoryDB)
-	walletHandler := handler.NewWalletHandler(walletService)
+	eventRepositoryDB := repository.NewEventRepository(db)
+	tenantRepositoryDB := repository.NewTenantRepository(db)
+	pocketRepositoryDB := repository.NewPocketRepository(db)
+	approvalRepositoryDB := repository.NewApprovalRepository(db)
+	riskService := service.NewRiskService(repository.NewRiskRepository(db), riskEngine())
+	feeRepositoryDB := repository.NewFeeRepository(db)
+	aliasRepositoryDB := repository.NewAliasRepository(db)
+	walletService := service.NewWalletService(walletRepositoryDB, tenantRepositoryDB, approvalRepositoryDB, riskService, feeRepositoryDB)
+	eventService := service.NewEventService(eventRepositoryDB, walletRepositoryDB, broker)
+	pocketService := service.NewPocketService(pocketRepositoryDB, walletRepositoryDB)
+	approvalService := service.NewApprovalService(approvalRepositoryDB, walletService)
+	transactionService := service.NewTransactionService(repository.NewTransactionRepository(db), walletRepositoryDB, riskService)
+	feeService := service.NewFeeService(feeRepositoryDB, walletRepositoryDB, tenantRepositoryDB)
+	batchService := service.NewBatchService(repository.NewBatchRepository(db), walletRepositoryDB, tenantRepositoryDB, riskService, feeRepositoryDB, aliasRepositoryDB)
+	bulkWalletService := service.NewBulkWalletService(walletRepositoryDB, tenantRepositoryDB)
+	streamService := service.NewStreamService(repository.NewStreamRepository(db))
+	location := businessLocation()
+	interestService := service.NewInterestService(repository.NewInterestRepository(db), tenantRepositoryDB, location)
+	balanceService := service.NewBalanceService(repository.NewBalanceRepository(db), location)
+	aliasService := service.NewAliasService(aliasRepositoryDB, walletRepositoryDB)
+	promptPayService := service.NewPromptPayService(walletRepositoryDB, tenantRepositoryDB)
+	ledgerService := service.NewLedgerService(repository.NewLedgerRepository(db), location)
+	go func() {
+		// Runs are idempotent per date and catch up on missed days, so
+		// checking hourly only makes sure the day's run happens soon after
+		// midnight in the business timezone.
+		tick := time.Tick(time.Hour)
+		for {
+			if err := interestService.RunDaily(time.Now().In(location)); err != nil {
+				logs.Error(err, zap.String("job", "interest"))
+			}
+			<-tick
+		}
+	}()
+	go func() {
+		// Like interest, snapshots skip days already taken, so an hourly
+		// check is enough.
+		tick := time.Tick(time.Hour)
+		for {
+			if err := balanceService.SnapshotDaily(time.Now()); err != nil {
+				logs.Error(err, zap.String("job", "balance snapshots"))
+			}
+			<-tick
+		}
+	}()
+	go func() {
+		for range time.Tick(time.Second) {
+			if err := batchService.ProcessBatches(); err != nil {
+				logs.Error(err, zap.String("job", "batches"))
+			}
+		}
+	}()
+	go func() {
+		for range time.Tick(time.Minute) {
+			if _, err := approvalService.ExpireApprovals(); err != nil {
+				logs.Error(err, zap.String("job", "approvals"))
+			}
+		}
+	}()
 
-	e.GET("/wallet", walletHandler.ListWallets)
-	e.GET("/wallet/:id", walletHandler.GetWallet)
-	e.POST("/wallet", walletHandler.CreateWallet)
-	e.PUT("/wallet/:id", walletHandler.AddBalance)
-	e.PUT("/wallet/:id/status", walletHandler.ChangeStatus)
+	e := newServer(walletService, eventService, pocketService, approvalService, transactionService, feeService, interestService, batchService, bulkWalletService, streamService, balanceService, aliasService, promptPayService, ledgerService, allowNumericWalletIDs(), authConfig(db), rateLimitConfig(db))
 
 	go func() {
 		if err := e.Start(":2565"); err != nil && err != http.ErrServerClosed {
@@ -56,3 +128,221 @@ func main() {
 		e.Logger.Fatal(err)
 	}
 }
+
+func newServer(walletService service.WalletService, eventService service.EventService, pocketService service.PocketService, approvalService service.ApprovalService, transactionService service.TransactionService, feeService service.FeeService, interestService service.InterestService, batchService service.BatchService, bulkWalletService service.BulkWalletService, streamService service.StreamService, balanceService service.BalanceService, aliasService service.AliasService, promptPayService service.PromptPayService, ledgerService service.LedgerService, allowNumericWalletIDs bool, authentication auth.Config, rateLimit ratelimit.Config) *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	e.Use(middleware.Logger())
+	e.Use(middleware.Recover())
+	e.Use(middleware.RequestID())
+	e.Use(auth.Middleware(authentication))
+
+	walletHandler := handler.NewWalletHandler(walletService)
+	rateLimit.WalletKey = walletHandler.WalletKey(allowNumericWalletIDs)
+	e.Use(ratelimit.Middleware(rateLimit))
+
+	eventHandler := handler.NewEventHandler(eventService)
+	pocketHandler := handler.NewPocketHandler(pocketService)
+	approvalHandler := handler.NewApprovalHandler(approvalService)
+	transactionHandler := handler.NewTransactionHandler(transactionService)
+	feeHandler := handler.NewFeeHandler(feeService)
+	interestHandler := handler.NewInterestHandler(interestService)
+	batchHandler := handler.NewBatchHandler(batchService)
+	bulkWalletHandler := handler.NewBulkWalletHandler(bulkWalletService)
+	streamHandler := handler.NewStreamHandler(streamService)
+	balanceHandler := handler.NewBalanceHandler(balanceService)
+	aliasHandler := handler.NewAliasHandler(aliasService)
+	promptPayHandler := handler.NewPromptPayHandler(promptPayService)
+	ledgerHandler := handler.NewLedgerHandler(ledgerService)
+	walletID := walletHandler.ResolveID(allowNumericWalletIDs)
+
+	e.GET("/wallet", walletHandler.ListWallets)
+	e.GET("/wallet/:id", walletHandler.GetWallet, walletID, balanceHandler.AsOf)
+	e.POST("/wallet", walletHandler.CreateWallet)
+	e.PUT("/wallet/:id", walletHandler.AddBalance, walletID)
+	e.PATCH("/wallet/:id", walletHandler.UpdateWallet, walletID)
+	e.PUT("/wallet/:id/status", walletHandler.ChangeStatus, walletID)
+	e.POST("/wallet/:id/close", walletHandler.CloseWallet, walletID)
+	e.GET("/wallet/:id/pockets", pocketHandler.ListPockets, walletID)
+	e.POST("/wallet/:id/pockets", pocketHandler.CreatePocket, walletID)
+	e.POST("/wallet/:id/pockets/:pocket_id/moves", pocketHandler.MovePocketFunds, walletID)
+	e.POST("/wallet/:id/pockets/:pocket_id/close", pocketHandler.ClosePocket, walletID)
+	e.GET("/wallet/:id/aliases", aliasHandler.ListAliases, walletID)
+	e.POST("/wallet/:id/aliases", aliasHandler.CreateAlias, walletID)
+	e.DELETE("/wallet/:id/aliases/:alias_id", aliasHandler.DeleteAlias, walletID)
+	e.GET("/wallet/:id/promptpay", promptPayHandler.CreateQR, walletID)
+	e.GET("/wallet/:id/transactions", transactionHandler.ListTransactions, walletID)
+	e.GET("/wallet/:id/events", eventHandler.StreamWalletEvents, walletID)
+	e.GET("/wallet/:id/history", streamHandler.GetWalletHistory, walletID)
+	e.GET("/accounts/:number", walletHandler.LookupAccount)
+	e.GET("/aliases/:alias", aliasHandler.ResolveAlias)
+	e.POST("/promptpay/parse", promptPayHandler.ParsePayload)
+	e.GET("/admin/events", eventHandler.StreamAllEvents)
+	e.GET("/approvals", approvalHandler.ListApprovals)
+	e.GET("/approvals/:id", approvalHandler.GetApproval)
+	e.POST("/approvals/:id/approve", approvalHandler.Approve)
+	e.POST("/approvals/:id/reject", approvalHandler.Reject)
+	e.GET("/transactions/:id", transactionHandler.GetTransaction)
+	e.POST("/transactions/:id/reverse", transactionHandler.ReverseTransaction)
+	e.GET("/fees/quote", feeHandler.Quote)
+	e.POST("/admin/interest/accruals", interestHandler.AccrueInterest)
+	e.POST("/admin/interest/postings", interestHandler.PostInterest)
+	e.POST("/batches", batchHandler.CreateBatch)
+	e.GET("/batches/:id", batchHandler.GetBatch)
+	e.GET("/batches/:id/results", batchHandler.DownloadResults)
+	e.POST("/admin/wallets/import", bulkWalletHandler.ImportWallets)
+	e.GET("/admin/wallets/export", bulkWalletHandler.ExportWallets)
+	e.GET("/admin/balances", balanceHandler.GetBalanceReport)
+	e.GET("/admin/ledger/accounts", ledgerHandler.GetChartOfAccounts)
+	e.GET("/admin/ledger/accounts/:code/activity", ledgerHandler.GetAccountActivity)
+	e.GET("/admin/ledger/trial-balance", ledgerHandler.GetTrialBalance)
+	e.GET("/admin/ledger/balance-sheet", ledgerHandler.GetBalanceSheet)
+
+	openapi.Register(e)
+
+	return e
+}
+
+func authConfig(db *sql.DB) auth.Config {
+	return auth.Config{
+		Skipper: func(c echo.Context) bool {
+			return c.Path() == "/openapi.json" || c.Path() == "/docs" || c.Path() == "/docs/*"
+		},
+		Store:          auth.NewPostgresKeyStore(db),
+		AllowAnonymous: os.Getenv("AUTH_ALLOW_ANONYMOUS") == "true",
+	}
+}
+
+// allowNumericWalletIDs reports whether wallet routes still take numeric
+// ids, from ALLOW_NUMERIC_WALLET_IDS; true when unset, until clients have
+// moved to public ids.
+func allowNumericWalletIDs() bool {
+	value := os.Getenv("ALLOW_NUMERIC_WALLET_IDS")
+	if value == "" {
+		return true
+	}
+
+	allow, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatal("parse ALLOW_NUMERIC_WALLET_IDS error", err)
+	}
+	return allow
+}
+
+// businessLocation is the timezone business dates are counted in, from
+// BUSINESS_TIMEZONE; UTC when unset.
+func businessLocation() *time.Location {
+	name := os.Getenv("BUSINESS_TIMEZONE")
+	if name == "" {
+		return time.UTC
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.Fatal("load business timezone error", err)
+	}
+	return location
+}
+
+// riskEngine loads the rules in RISK_RULES_FILE, or the built-in defaults
+// when it is unset. A bad rule stops startup rather than letting debits
+// through unscreened.
+func riskEngine() *risk.Engine {
+	var rules []risk.Rule
+	var err error
+	if path := os.Getenv("RISK_RULES_FILE"); path != "" {
+		f, openErr := os.Open(path)
+		if openErr != nil {
+			log.Fatal("open risk rules error", openErr)
+		}
+		rules, err = risk.LoadRules(f)
+		f.Close()
+	} else {
+		rules, err = risk.LoadRules(bytes.NewReader(risk.DefaultRules))
+	}
+	if err != nil {
+		log.Fatal("load risk rules error", err)
+	}
+
+	engine, err := risk.NewEngine(rules)
+	if err != nil {
+		log.Fatal("compile risk rules error", err)
+	}
+	return engine
+}
+
+func rateLimitConfig(db *sql.DB) ratelimit.Config {
+	var store ratelimit.Store
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		store = ratelimit.NewPostgresStore(db)
+	} else {
+		memoryStore := ratelimit.NewMemoryStore()
+		go func() {
+			for range time.Tick(10 * time.Minute) {
+				memoryStore.Cleanup(time.Hour)
+			}
+		}()
+		store = memoryStore
+	}
+
+	return ratelimit.Config{
+		Store: store,
+		Default: ratelimit.Policy{
+			Client: ratelimit.PerMinute(600),
+			IP:     ratelimit.PerMinute(300),
+		},
+		Routes: map[string]ratelimit.Policy{
+			ratelimit.Route(http.MethodPost, "/wallet"): {
+				Client: ratelimit.PerMinute(60),
+				IP:     ratelimit.PerMinute(30),
+			},
+			ratelimit.Route(http.MethodPut, "/wallet/:id"): {
+				Client: ratelimit.PerMinute(120),
+				IP:     ratelimit.PerMinute(60),
+				Wallet: ratelimit.PerMinute(30),
+			},
+			ratelimit.Route(http.MethodPatch, "/wallet/:id"): {
+				Client: ratelimit.PerMinute(120),
+				IP:     ratelimit.PerMinute(60),
+				Wallet: ratelimit.PerMinute(30),
+			},
+			ratelimit.Route(http.MethodPut, "/wallet/:id/status"): {
+				Client: ratelimit.PerMinute(60),
+				IP:     ratelimit.PerMinute(30),
+				Wallet: ratelimit.PerMinute(10),
+			},
+			ratelimit.Route(http.MethodPost, "/wallet/:id/pockets/:pocket_id/moves"): {
+				Client: ratelimit.PerMinute(120),
+				IP:     ratelimit.PerMinute(60),
+				Wallet: ratelimit.PerMinute(30),
+			},
+			// Tight enough that guessing account numbers gets nowhere.
+			ratelimit.Route(http.MethodGet, "/accounts/:number"): {
+				Client: ratelimit.PerMinute(30),
+				IP:     ratelimit.PerMinute(10),
+			},
+			// Likewise for walking phone numbers to find who has a wallet.
+			ratelimit.Route(http.MethodGet, "/aliases/:alias"): {
+				Client: ratelimit.PerMinute(30),
+				IP:     ratelimit.PerMinute(10),
+			},
+			ratelimit.Route(http.MethodPost, "/transactions/:id/reverse"): {
+				Client: ratelimit.PerMinute(60),
+				IP:     ratelimit.PerMinute(30),
+			},
+			ratelimit.Route(http.MethodPost, "/batches"): {
+				Client: ratelimit.PerMinute(10),
+				IP:     ratelimit.PerMinute(5),
+			},
+			ratelimit.Route(http.MethodPost, "/admin/wallets/import"): {
+				Client: ratelimit.PerMinute(10),
+				IP:     ratelimit.PerMinute(5),
+			},
+			ratelimit.Route(http.MethodPost, "/wallet/:id/close"): {
+				Client: ratelimit.PerMinute(30),
+				IP:     ratelimit.PerMinute(10),
+				Wallet: ratelimit.PerMinute(5),
+			},
+		},
+	}
+}
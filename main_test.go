@@ -0,0 +1,327 @@
+//go:build unit
+// +build unit
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/topnarapat/go-wallet/auth"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/handler"
+	"github.com/topnarapat/go-wallet/openapi"
+	"github.com/topnarapat/go-wallet/ratelimit"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+type specSchema struct {
+	Ref        string                `json:"$ref"`
+	Type       string                `json:"type"`
+	Format     string                `json:"format"`
+	Properties map[string]specSchema `json:"properties"`
+	Items      *specSchema           `json:"items"`
+	OneOf      []specSchema          `json:"oneOf"`
+}
+
+type spec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]specSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// schemaTypes maps every component schema in the spec to the Go type that is
+// bound from or serialised into it.
+var schemaTypes = map[string]reflect.Type{
+	"WalletRequest":             reflect.TypeOf(service.WalletRequest{}),
+	"AddWalletRequest":          reflect.TypeOf(service.AddWalletRequest{}),
+	"StatusWalletRequest":       reflect.TypeOf(service.StatusWalletRequest{}),
+	"CloseWalletRequest":        reflect.TypeOf(service.CloseWalletRequest{}),
+	"PatchWalletRequest":        reflect.TypeOf(service.PatchWalletRequest{}),
+	"WalletResponse":            reflect.TypeOf(service.WalletResponse{}),
+	"Problem":                   reflect.TypeOf(handler.Problem{}),
+	"FieldError":                reflect.TypeOf(errs.FieldError{}),
+	"EventResponse":             reflect.TypeOf(service.EventResponse{}),
+	"PocketRequest":             reflect.TypeOf(service.PocketRequest{}),
+	"PocketMoveRequest":         reflect.TypeOf(service.PocketMoveRequest{}),
+	"PocketResponse":            reflect.TypeOf(service.PocketResponse{}),
+	"RejectApprovalRequest":     reflect.TypeOf(service.RejectApprovalRequest{}),
+	"ApprovalResponse":          reflect.TypeOf(service.ApprovalResponse{}),
+	"ReverseTransactionRequest": reflect.TypeOf(service.ReverseTransactionRequest{}),
+	"TransactionResponse":       reflect.TypeOf(service.TransactionResponse{}),
+	"FeeQuoteResponse":          reflect.TypeOf(service.FeeQuoteResponse{}),
+	"AccrueInterestRequest":     reflect.TypeOf(service.AccrueInterestRequest{}),
+	"PostInterestRequest":       reflect.TypeOf(service.PostInterestRequest{}),
+	"InterestAccrualResponse":   reflect.TypeOf(service.InterestAccrualResponse{}),
+	"InterestPostingResponse":   reflect.TypeOf(service.InterestPostingResponse{}),
+	"CreateBatchRequest":        reflect.TypeOf(service.CreateBatchRequest{}),
+	"BatchItemRequest":          reflect.TypeOf(service.BatchItemRequest{}),
+	"BatchResponse":             reflect.TypeOf(service.BatchResponse{}),
+	"ImportWalletRow":           reflect.TypeOf(service.ImportWalletRow{}),
+	"ImportReport":              reflect.TypeOf(service.ImportReport{}),
+	"WalletExport":              reflect.TypeOf(service.WalletExport{}),
+	"WalletHistoryEvent":        reflect.TypeOf(service.WalletHistoryEvent{}),
+	"BalanceResponse":           reflect.TypeOf(service.BalanceResponse{}),
+	"BalanceReport":             reflect.TypeOf(service.BalanceReport{}),
+	"AccountSummary":            reflect.TypeOf(service.AccountSummary{}),
+	"AliasRequest":              reflect.TypeOf(service.AliasRequest{}),
+	"AliasResponse":             reflect.TypeOf(service.AliasResponse{}),
+	"AliasResolution":           reflect.TypeOf(service.AliasResolution{}),
+	"PromptPayQR":               reflect.TypeOf(service.PromptPayQR{}),
+	"ParsePromptPayRequest":     reflect.TypeOf(service.ParsePromptPayRequest{}),
+	"PromptPayFields":           reflect.TypeOf(service.PromptPayFields{}),
+	"LedgerAccountResponse":     reflect.TypeOf(service.LedgerAccountResponse{}),
+	"TrialBalance":              reflect.TypeOf(service.TrialBalance{}),
+	"TrialBalanceLine":          reflect.TypeOf(service.TrialBalanceLine{}),
+	"TrialBalanceTotal":         reflect.TypeOf(service.TrialBalanceTotal{}),
+	"AccountActivity":           reflect.TypeOf(service.AccountActivity{}),
+	"AccountActivityLine":       reflect.TypeOf(service.AccountActivityLine{}),
+	"BalanceSheet":              reflect.TypeOf(service.BalanceSheet{}),
+	"BalanceSheetCurrency":      reflect.TypeOf(service.BalanceSheetCurrency{}),
+	"BalanceSheetLine":          reflect.TypeOf(service.BalanceSheetLine{}),
+}
+
+func loadSpec(t *testing.T) spec {
+	s := spec{}
+	require.NoError(t, json.Unmarshal(openapi.Spec, &s))
+	return s
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// specPath writes an Echo route path the way openapi.json does; a trailing
+// wildcard is the {file} parameter.
+func specPath(path string) string {
+	path = pathParam.ReplaceAllString(path, "{$1}")
+	if strings.HasSuffix(path, "/*") {
+		path = strings.TrimSuffix(path, "*") + "{file}"
+	}
+	return path
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	s := loadSpec(t)
+	e := newServer(service.NewWalletServiceMock(), service.NewEventServiceMock(), service.NewPocketServiceMock(), service.NewApprovalServiceMock(), service.NewTransactionServiceMock(), service.NewFeeServiceMock(), service.NewInterestServiceMock(), service.NewBatchServiceMock(), service.NewBulkWalletServiceMock(), service.NewStreamServiceMock(), service.NewBalanceServiceMock(), service.NewAliasServiceMock(), service.NewPromptPayServiceMock(), service.NewLedgerServiceMock(), true, auth.Config{AllowAnonymous: true}, ratelimit.Config{})
+
+	registered := map[string]bool{}
+	for _, route := range e.Routes() {
+		path := specPath(route.Path)
+		registered[strings.ToLower(route.Method)+" "+path] = true
+
+		operations, ok := s.Paths[path]
+		if !assert.Truef(t, ok, "route %s %s missing from openapi.json", route.Method, route.Path) {
+			continue
+		}
+		_, ok = operations[strings.ToLower(route.Method)]
+		assert.Truef(t, ok, "route %s %s missing from openapi.json", route.Method, route.Path)
+	}
+
+	for path, operations := range s.Paths {
+		for method := range operations {
+			if method == "parameters" {
+				continue
+			}
+			assert.Truef(t, registered[method+" "+path], "openapi.json documents %s %s, which has no route", strings.ToUpper(method), path)
+		}
+	}
+}
+
+// TestOpenAPIRefs checks every $ref in the operations, their parameters,
+// request bodies and responses, points at something in the document.
+func TestOpenAPIRefs(t *testing.T) {
+	var document interface{}
+	require.NoError(t, json.Unmarshal(openapi.Spec, &document))
+
+	var walk func(at string, node interface{})
+	walk = func(at string, node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			if ref, ok := node["$ref"].(string); ok {
+				_, found := resolveRef(document, ref)
+				assert.Truef(t, found, "%s refers to %s, which is not in openapi.json", at, ref)
+			}
+			for key, value := range node {
+				walk(at+"/"+key, value)
+			}
+		case []interface{}:
+			for i, value := range node {
+				walk(at+"/"+strconv.Itoa(i), value)
+			}
+		}
+	}
+	walk("#", document)
+}
+
+// TestOpenAPIBodies checks each operation's JSON request body and responses
+// name their schema by $ref, so TestOpenAPISchemas holds it to a Go type.
+func TestOpenAPIBodies(t *testing.T) {
+	type content map[string]struct {
+		Schema *specSchema `json:"schema"`
+	}
+	type operation struct {
+		RequestBody *struct {
+			Content content `json:"content"`
+		} `json:"requestBody"`
+		Responses map[string]struct {
+			Content content `json:"content"`
+		} `json:"responses"`
+	}
+
+	s := loadSpec(t)
+	for path, operations := range s.Paths {
+		for method, raw := range operations {
+			if method == "parameters" {
+				continue
+			}
+			op := operation{}
+			require.NoError(t, json.Unmarshal(raw, &op))
+
+			at := strings.ToUpper(method) + " " + path
+			if op.RequestBody != nil {
+				assertBodyRef(t, at+" request", op.RequestBody.Content[echo.MIMEApplicationJSON].Schema)
+			}
+			for code, response := range op.Responses {
+				assertBodyRef(t, at+" "+code, response.Content[echo.MIMEApplicationJSON].Schema)
+			}
+		}
+	}
+}
+
+// assertBodyRef checks a body's schema, if it has one, is a component, an
+// array of one or a choice between them.
+func assertBodyRef(t *testing.T, at string, schema *specSchema) {
+	if schema == nil {
+		return
+	}
+
+	refs := []string{schema.Ref}
+	if schema.Items != nil {
+		refs = []string{schema.Items.Ref}
+	}
+	if len(schema.OneOf) > 0 {
+		refs = nil
+		for _, choice := range schema.OneOf {
+			refs = append(refs, choice.Ref)
+		}
+	}
+
+	for _, ref := range refs {
+		if assert.Truef(t, strings.HasPrefix(ref, "#/components/schemas/"), "%s body has an inline schema", at) {
+			_, ok := schemaTypes[strings.TrimPrefix(ref, "#/components/schemas/")]
+			assert.Truef(t, ok, "%s body refers to %s, which has no Go type in schemaTypes", at, ref)
+		}
+	}
+}
+
+// resolveRef follows a local reference such as #/components/schemas/Problem.
+func resolveRef(document interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, false
+	}
+
+	node := document
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if node, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	s := loadSpec(t)
+
+	for name, schema := range s.Components.Schemas {
+		typ, ok := schemaTypes[name]
+		if !assert.Truef(t, ok, "schema %s has no Go type in schemaTypes", name) {
+			continue
+		}
+		assertSchema(t, s, name, schema, typ)
+	}
+
+	for name := range schemaTypes {
+		_, ok := s.Components.Schemas[name]
+		assert.Truef(t, ok, "schema %s missing from openapi.json", name)
+	}
+}
+
+func assertSchema(t *testing.T, s spec, path string, schema specSchema, typ reflect.Type) {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+		assert.Equalf(t, schemaTypes[name], typ, "%s refers to %s", path, name)
+		return
+	}
+
+	switch {
+	case typ == reflect.TypeOf(time.Time{}):
+		assert.Equalf(t, "string", schema.Type, "%s type", path)
+		assert.Equalf(t, "date-time", schema.Format, "%s format", path)
+	case typ == reflect.TypeOf(json.RawMessage{}):
+	case typ.Kind() == reflect.Struct:
+		assert.Equalf(t, "object", schema.Type, "%s type", path)
+		fields := jsonFields(typ)
+		for name, field := range fields {
+			prop, ok := schema.Properties[name]
+			if assert.Truef(t, ok, "%s.%s missing from openapi.json", path, name) {
+				assertSchema(t, s, path+"."+name, prop, field)
+			}
+		}
+		for name := range schema.Properties {
+			_, ok := fields[name]
+			assert.Truef(t, ok, "%s.%s is not a field of %s", path, name, typ)
+		}
+	case typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array:
+		assert.Equalf(t, "array", schema.Type, "%s type", path)
+		if assert.NotNilf(t, schema.Items, "%s items", path) {
+			assertSchema(t, s, path+"[]", *schema.Items, typ.Elem())
+		}
+	case typ.Kind() == reflect.Map || typ.Kind() == reflect.Interface:
+		assert.Equalf(t, "object", schema.Type, "%s type", path)
+	case typ.Kind() == reflect.Bool:
+		assert.Equalf(t, "boolean", schema.Type, "%s type", path)
+	case typ.Kind() == reflect.String:
+		assert.Equalf(t, "string", schema.Type, "%s type", path)
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		assert.Equalf(t, "number", schema.Type, "%s type", path)
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		assert.Equalf(t, "integer", schema.Type, "%s type", path)
+	default:
+		t.Errorf("%s has unsupported Go type %s", path, typ)
+	}
+}
+
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
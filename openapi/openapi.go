@@ -0,0 +1,27 @@
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+func Register(e *echo.Echo) {
+	e.GET("/openapi.json", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, Spec)
+	})
+	e.GET("/docs", func(c echo.Context) error {
+		return c.Blob(http.StatusOK, echo.MIMETextHTMLCharsetUTF8, swaggerUI)
+	})
+	// Swagger UI's scripts and styles are built into the binary, so the
+	// page works offline and without a third-party CDN.
+	e.GET("/docs/*", echo.StaticDirectoryHandler(swaggerFiles.FS, false))
+}
@@ -0,0 +1,317 @@
+// Package promptpay builds and reads Thai QR payment payloads: EMVCo
+// merchant-presented QR data carrying a PromptPay credit transfer, to a
+// mobile number, national id or e-wallet id, or a PromptPay bill payment,
+// to a biller with references.
+//
+// A payload is a run of fields, each a two-digit tag, a two-digit length
+// and the value, ending with a CRC-16 of everything before it.
+package promptpay
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// AIDCreditTransfer and AIDBillPayment identify PromptPay in the
+	// merchant account fields, tags 29 and 30.
+	AIDCreditTransfer = "A000000677010111"
+	AIDBillPayment    = "A000000677010112"
+
+	CurrencyTHB = "764"
+	CountryTH   = "TH"
+)
+
+// Proxy types, what a payment is addressed to.
+const (
+	ProxyMobile     = "mobile"
+	ProxyNationalID = "national_id"
+	ProxyEWallet    = "ewallet"
+	ProxyBiller     = "biller"
+)
+
+const (
+	tagFormat            = "00"
+	tagPointOfInitiation = "01"
+	tagCreditTransfer    = "29"
+	tagBillPayment       = "30"
+	tagCurrency          = "53"
+	tagAmount            = "54"
+	tagCountry           = "58"
+	tagMerchantName      = "59"
+	tagMerchantCity      = "60"
+	tagAdditionalData    = "62"
+	tagCRC               = "63"
+
+	// Sub-tags of the merchant account fields.
+	subAID        = "00"
+	subMobile     = "01"
+	subNationalID = "02"
+	subEWallet    = "03"
+	subBillerID   = "01"
+	subRef1       = "02"
+	subRef2       = "03"
+
+	// Sub-tag of the additional data field.
+	subPurpose = "08"
+
+	// Points of initiation: a static code may be paid any number of times,
+	// a dynamic one is for a single payment of a set amount.
+	initiationStatic  = "11"
+	initiationDynamic = "12"
+)
+
+var (
+	ErrFormat       = errors.New("is not a well-formed EMVCo QR payload")
+	ErrCRC          = errors.New("checksum does not match")
+	ErrNotPromptPay = errors.New("is not a PromptPay payload")
+)
+
+// Payload is a PromptPay payment. An Amount of 0 leaves the payer to enter
+// it.
+type Payload struct {
+	// Dynamic marks a code for a single payment. Encode sets it for a code
+	// with an amount.
+	Dynamic   bool
+	ProxyType string
+	// ProxyID is a Thai mobile number in E.164 form, a 13-digit national
+	// or tax id, a 15-digit e-wallet id, or a 15-digit biller id.
+	ProxyID      string
+	Ref1         string
+	Ref2         string
+	Amount       float64
+	Currency     string
+	Country      string
+	MerchantName string
+	MerchantCity string
+	Purpose      string
+}
+
+// Encode writes p as a payload ready to draw as a QR code.
+func Encode(p Payload) (string, error) {
+	account, err := merchantAccount(p)
+	if err != nil {
+		return "", err
+	}
+	if p.Amount < 0 || p.Amount >= 1e10 {
+		return "", fmt.Errorf("promptpay: amount %v out of range", p.Amount)
+	}
+	if p.Currency == "" {
+		p.Currency = CurrencyTHB
+	}
+	if p.Country == "" {
+		p.Country = CountryTH
+	}
+
+	var b strings.Builder
+	field(&b, tagFormat, "01")
+	if p.Dynamic || p.Amount > 0 {
+		field(&b, tagPointOfInitiation, initiationDynamic)
+	} else {
+		field(&b, tagPointOfInitiation, initiationStatic)
+	}
+	b.WriteString(account)
+	field(&b, tagCurrency, p.Currency)
+	if p.Amount > 0 {
+		field(&b, tagAmount, strconv.FormatFloat(p.Amount, 'f', 2, 64))
+	}
+	field(&b, tagCountry, p.Country)
+	if p.MerchantName != "" {
+		field(&b, tagMerchantName, truncate(p.MerchantName, 25))
+	}
+	if p.MerchantCity != "" {
+		field(&b, tagMerchantCity, truncate(p.MerchantCity, 15))
+	}
+	if p.Purpose != "" {
+		var additional strings.Builder
+		field(&additional, subPurpose, truncate(p.Purpose, 25))
+		field(&b, tagAdditionalData, additional.String())
+	}
+
+	b.WriteString(tagCRC + "04")
+	return b.String() + fmt.Sprintf("%04X", CRC16([]byte(b.String()))), nil
+}
+
+func merchantAccount(p Payload) (string, error) {
+	var account strings.Builder
+	switch p.ProxyType {
+	case ProxyMobile:
+		// PromptPay mobile numbers are Thai: +66 and nine digits, sent as
+		// 0066 and the nine digits.
+		digits := strings.TrimPrefix(p.ProxyID, "+66")
+		if !strings.HasPrefix(p.ProxyID, "+66") || !isDigits(digits) || len(digits) != 9 {
+			return "", fmt.Errorf("promptpay: mobile number %q must be +66 and nine digits", p.ProxyID)
+		}
+		field(&account, subAID, AIDCreditTransfer)
+		field(&account, subMobile, "0066"+digits)
+	case ProxyNationalID, ProxyEWallet:
+		sub, length := subNationalID, 13
+		if p.ProxyType == ProxyEWallet {
+			sub, length = subEWallet, 15
+		}
+		if !isDigits(p.ProxyID) || len(p.ProxyID) != length {
+			return "", fmt.Errorf("promptpay: %s must be %d digits", p.ProxyType, length)
+		}
+		field(&account, subAID, AIDCreditTransfer)
+		field(&account, sub, p.ProxyID)
+	case ProxyBiller:
+		if !isDigits(p.ProxyID) || len(p.ProxyID) != 15 {
+			return "", fmt.Errorf("promptpay: biller id must be 15 digits")
+		}
+		if p.Ref1 == "" || len(p.Ref1) > 20 || len(p.Ref2) > 20 {
+			return "", fmt.Errorf("promptpay: ref1 is required and references are at most 20 characters")
+		}
+		field(&account, subAID, AIDBillPayment)
+		field(&account, subBillerID, p.ProxyID)
+		field(&account, subRef1, p.Ref1)
+		if p.Ref2 != "" {
+			field(&account, subRef2, p.Ref2)
+		}
+	default:
+		return "", fmt.Errorf("promptpay: unknown proxy type %q", p.ProxyType)
+	}
+
+	var b strings.Builder
+	if p.ProxyType == ProxyBiller {
+		field(&b, tagBillPayment, account.String())
+	} else {
+		field(&b, tagCreditTransfer, account.String())
+	}
+	return b.String(), nil
+}
+
+// Parse reads a PromptPay payload, checking its CRC. Mobile numbers are
+// returned in E.164 form.
+func Parse(s string) (Payload, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 8 || s[len(s)-8:len(s)-4] != tagCRC+"04" {
+		return Payload{}, ErrFormat
+	}
+	crc, err := strconv.ParseUint(s[len(s)-4:], 16, 16)
+	if err != nil {
+		return Payload{}, ErrFormat
+	}
+	fields, err := parseFields(s[:len(s)-8])
+	if err != nil {
+		return Payload{}, err
+	}
+	if fields[tagFormat] != "01" {
+		return Payload{}, ErrFormat
+	}
+	if uint16(crc) != CRC16([]byte(s[:len(s)-4])) {
+		return Payload{}, ErrCRC
+	}
+
+	p := Payload{
+		Dynamic:      fields[tagPointOfInitiation] == initiationDynamic,
+		Currency:     fields[tagCurrency],
+		Country:      fields[tagCountry],
+		MerchantName: fields[tagMerchantName],
+		MerchantCity: fields[tagMerchantCity],
+	}
+	if amount, ok := fields[tagAmount]; ok {
+		p.Amount, err = strconv.ParseFloat(amount, 64)
+		if err != nil || p.Amount < 0 {
+			return Payload{}, ErrFormat
+		}
+	}
+	if additional, ok := fields[tagAdditionalData]; ok {
+		sub, err := parseFields(additional)
+		if err != nil {
+			return Payload{}, err
+		}
+		p.Purpose = sub[subPurpose]
+	}
+
+	switch {
+	case fields[tagCreditTransfer] != "":
+		sub, err := parseFields(fields[tagCreditTransfer])
+		if err != nil {
+			return Payload{}, err
+		}
+		if sub[subAID] != AIDCreditTransfer {
+			return Payload{}, ErrNotPromptPay
+		}
+		switch {
+		case sub[subMobile] != "":
+			p.ProxyType = ProxyMobile
+			p.ProxyID = "+" + strings.TrimLeft(sub[subMobile], "0")
+		case sub[subNationalID] != "":
+			p.ProxyType, p.ProxyID = ProxyNationalID, sub[subNationalID]
+		case sub[subEWallet] != "":
+			p.ProxyType, p.ProxyID = ProxyEWallet, sub[subEWallet]
+		default:
+			return Payload{}, ErrNotPromptPay
+		}
+	case fields[tagBillPayment] != "":
+		sub, err := parseFields(fields[tagBillPayment])
+		if err != nil {
+			return Payload{}, err
+		}
+		if sub[subAID] != AIDBillPayment || sub[subBillerID] == "" {
+			return Payload{}, ErrNotPromptPay
+		}
+		p.ProxyType, p.ProxyID = ProxyBiller, sub[subBillerID]
+		p.Ref1, p.Ref2 = sub[subRef1], sub[subRef2]
+	default:
+		return Payload{}, ErrNotPromptPay
+	}
+
+	return p, nil
+}
+
+// parseFields splits s into its tagged fields.
+func parseFields(s string) (map[string]string, error) {
+	fields := map[string]string{}
+	for len(s) > 0 {
+		if len(s) < 4 || !isDigits(s[:4]) {
+			return nil, ErrFormat
+		}
+		length, _ := strconv.Atoi(s[2:4])
+		if len(s) < 4+length {
+			return nil, ErrFormat
+		}
+		fields[s[:2]] = s[4 : 4+length]
+		s = s[4+length:]
+	}
+	return fields, nil
+}
+
+func field(b *strings.Builder, tag string, value string) {
+	fmt.Fprintf(b, "%s%02d%s", tag, len(value), value)
+}
+
+// CRC16 is the CRC-16/CCITT-FALSE checksum EMVCo payloads end with:
+// polynomial 0x1021, starting from 0xFFFF.
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
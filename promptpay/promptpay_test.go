@@ -0,0 +1,121 @@
+//go:build unit
+// +build unit
+
+package promptpay_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/promptpay"
+)
+
+func TestCRC16(t *testing.T) {
+	// Act
+	crc := promptpay.CRC16([]byte("123456789"))
+
+	// Assert
+	assert.Equal(t, uint16(0x29b1), crc)
+}
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		name     string
+		payload  promptpay.Payload
+		expected string
+	}{
+		{
+			name:     "mobile without amount",
+			payload:  promptpay.Payload{ProxyType: promptpay.ProxyMobile, ProxyID: "+66812345678"},
+			expected: "00020101021129370016A0000006770101110113006681234567853037645802TH6304",
+		},
+		{
+			name:     "bill payment with amount",
+			payload:  promptpay.Payload{ProxyType: promptpay.ProxyBiller, ProxyID: "010555012345601", Ref1: "1234567897", Amount: 150, MerchantName: "ACME", Purpose: "TOPUP"},
+			expected: "00020101021230530016A00000067701011201150105550123456010210123456789753037645406150.005802TH5904ACME62090805TOPUP6304",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			payload, err := promptpay.Encode(c.payload)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, payload[:len(payload)-4])
+		})
+	}
+
+	t.Run("invalid mobile number", func(t *testing.T) {
+		// Act
+		_, err := promptpay.Encode(promptpay.Payload{ProxyType: promptpay.ProxyMobile, ProxyID: "+14155550100"})
+
+		// Assert
+		assert.Error(t, err)
+	})
+}
+
+func TestParse(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		// Arrange
+		given := promptpay.Payload{
+			Dynamic:      true,
+			ProxyType:    promptpay.ProxyBiller,
+			ProxyID:      "010555012345601",
+			Ref1:         "1234567897",
+			Ref2:         "INV001",
+			Amount:       99.5,
+			Currency:     promptpay.CurrencyTHB,
+			Country:      promptpay.CountryTH,
+			MerchantName: "ACME",
+			Purpose:      "PAYMENT",
+		}
+		payload, _ := promptpay.Encode(given)
+
+		// Act
+		parsed, err := promptpay.Parse(payload)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, given, parsed)
+	})
+
+	t.Run("mobile", func(t *testing.T) {
+		// Arrange
+		payload, _ := promptpay.Encode(promptpay.Payload{ProxyType: promptpay.ProxyMobile, ProxyID: "+66812345678"})
+
+		// Act
+		parsed, err := promptpay.Parse(payload)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.False(t, parsed.Dynamic)
+		assert.Equal(t, promptpay.ProxyMobile, parsed.ProxyType)
+		assert.Equal(t, "+66812345678", parsed.ProxyID)
+	})
+
+	cases := []struct {
+		name    string
+		payload string
+		err     error
+	}{
+		{name: "checksum", payload: "00020101021129370016A0000006770101110113006681234567853037645802TH63040000", err: promptpay.ErrCRC},
+		{name: "truncated field", payload: "000201010211293700166304ABCD", err: promptpay.ErrFormat},
+		{name: "no CRC", payload: "000201010211", err: promptpay.ErrFormat},
+		{name: "not PromptPay", payload: withCRC("000201010211261500110123456789053037645802TH6304"), err: promptpay.ErrNotPromptPay},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			_, err := promptpay.Parse(c.payload)
+
+			// Assert
+			assert.Equal(t, c.err, err)
+		})
+	}
+}
+
+func withCRC(s string) string {
+	return s + fmt.Sprintf("%04X", promptpay.CRC16([]byte(s)))
+}
@@ -0,0 +1,74 @@
+//go:build unit
+// +build unit
+
+package qr_test
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/qr"
+)
+
+func TestEncode(t *testing.T) {
+	cases := []struct {
+		name    string
+		length  int
+		version int
+	}{
+		{name: "fits version 1", length: 14, version: 1},
+		{name: "just over version 1", length: 15, version: 2},
+		{name: "PromptPay bill payment", length: 105, version: 6},
+		{name: "fills version 10", length: 213, version: 10},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			code, err := qr.Encode([]byte(strings.Repeat("x", c.length)))
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, c.version, code.Version)
+			assert.Equal(t, 17+4*c.version, code.Size)
+		})
+	}
+
+	t.Run("finder patterns", func(t *testing.T) {
+		// Act
+		code, _ := qr.Encode([]byte("hello"))
+
+		// Assert
+		for _, corner := range [][2]int{{0, 0}, {code.Size - 7, 0}, {0, code.Size - 7}} {
+			x, y := corner[0], corner[1]
+			assert.True(t, code.Dark(x, y))
+			assert.False(t, code.Dark(x+1, y+1))
+			assert.True(t, code.Dark(x+3, y+3))
+		}
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		// Act
+		_, err := qr.Encode([]byte(strings.Repeat("x", 214)))
+
+		// Assert
+		assert.Equal(t, qr.ErrTooLong, err)
+	})
+}
+
+func TestPNG(t *testing.T) {
+	// Arrange
+	code, _ := qr.Encode([]byte("hello"))
+
+	// Act
+	b, err := code.PNG(4)
+
+	// Assert
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(b))
+	if assert.NoError(t, err) {
+		assert.Equal(t, (21+2*qr.QuietZone)*4, img.Bounds().Dx())
+	}
+}
@@ -0,0 +1,57 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]level
+	now     func() time.Time
+}
+
+func NewMemoryStore() *memoryStore {
+	return &memoryStore{
+		buckets: map[string]level{},
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Take(buckets ...Bucket) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	levels := make([]level, len(buckets))
+	for i, b := range buckets {
+		l, ok := s.buckets[b.Key]
+		if !ok {
+			l = level{tokens: b.Limit.capacity(), updatedAt: now}
+		}
+		levels[i] = l
+	}
+
+	levels, results := takeAll(buckets, levels, now)
+	for i, b := range buckets {
+		s.buckets[b.Key] = levels[i]
+	}
+	return results, nil
+}
+
+// Cleanup drops buckets that have not been touched for longer than maxIdle.
+func (s *memoryStore) Cleanup(maxIdle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) > maxIdle {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func (s *memoryStore) SetClock(now func() time.Time) {
+	s.now = now
+}
@@ -0,0 +1,168 @@
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/logs"
+	"go.uber.org/zap"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRetryAfter         = "Retry-After"
+	HeaderAPIKey             = "X-API-Key"
+)
+
+// Policy holds the limits applied to a single route. A zero Limit disables
+// that bucket; Wallet is only consulted for mutating requests.
+type Policy struct {
+	Client Limit
+	IP     Limit
+	Wallet Limit
+}
+
+// Config sets the limits per route, falling back to Default, and where the
+// buckets are kept.
+type Config struct {
+	Skipper     middleware.Skipper
+	Store       Store
+	Default     Policy
+	Routes      map[string]Policy
+	ClientKey   func(c echo.Context) string
+	WalletParam string
+	// WalletKey names the wallet a mutation changes, by default the
+	// WalletParam path value. Where one wallet can be named by several ids,
+	// it must give them all the same key, or each id gets a bucket of its
+	// own.
+	WalletKey func(c echo.Context) string
+}
+
+func Route(method, path string) string {
+	return method + " " + path
+}
+
+func Middleware(config Config) echo.MiddlewareFunc {
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+	if config.ClientKey == nil {
+		config.ClientKey = APIKeyClient
+	}
+	if config.WalletParam == "" {
+		config.WalletParam = "id"
+	}
+	if config.WalletKey == nil {
+		config.WalletKey = func(c echo.Context) string {
+			return c.Param(config.WalletParam)
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			route := Route(c.Request().Method, c.Path())
+			policy, ok := config.Routes[route]
+			if !ok {
+				policy = config.Default
+			}
+
+			var buckets []Bucket
+			add := func(limit Limit, key string) {
+				if limit.IsZero() || key == "" {
+					return
+				}
+				buckets = append(buckets, Bucket{Key: route + "|" + key, Limit: limit})
+			}
+
+			if client := config.ClientKey(c); client != "" {
+				add(policy.Client, "client:"+client)
+			}
+			add(policy.IP, "ip:"+c.RealIP())
+			if isMutating(c.Request().Method) {
+				if id := config.WalletKey(c); id != "" {
+					add(policy.Wallet, "wallet:"+id)
+				}
+			}
+
+			if len(buckets) == 0 {
+				return next(c)
+			}
+
+			results, err := config.Store.Take(buckets...)
+			if err != nil {
+				logs.Error(err, zap.String("route", route))
+				return next(c)
+			}
+
+			result := tightest(results)
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				header.Set(HeaderRetryAfter, strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				return errs.NewRateLimitedError()
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// APIKeyClient identifies the caller by a digest of its API key so raw keys
+// never end up in the bucket store.
+func APIKeyClient(c echo.Context) string {
+	key := c.Request().Header.Get(HeaderAPIKey)
+	if key == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// tightest picks the result to report: the denial with the longest wait if
+// any bucket refused, otherwise the bucket with the fewest tokens left.
+func tightest(results []Result) Result {
+	var picked *Result
+	for i := range results {
+		r := &results[i]
+		switch {
+		case picked == nil:
+			picked = r
+		case !r.Allowed && (picked.Allowed || r.RetryAfter > picked.RetryAfter):
+			picked = r
+		case r.Allowed && picked.Allowed && r.Remaining < picked.Remaining:
+			picked = r
+		}
+	}
+	return *picked
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
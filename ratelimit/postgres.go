@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+)
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore keeps buckets in the rate_limit_buckets table so every
+// instance behind the load balancer shares the same counters.
+func NewPostgresStore(db *sql.DB) Store {
+	return postgresStore{db: db}
+}
+
+func (s postgresStore) Take(buckets ...Bucket) ([]Result, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Rows are locked in key order so two requests sharing buckets cannot
+	// deadlock.
+	order := make([]int, len(buckets))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return buckets[order[i]].Key < buckets[order[j]].Key })
+
+	levels := make([]level, len(buckets))
+	var now time.Time
+	for _, i := range order {
+		_, err = tx.Exec("INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_at) VALUES ($1, $2, now()) ON CONFLICT (bucket_key) DO NOTHING", buckets[i].Key, buckets[i].Limit.capacity())
+		if err != nil {
+			return nil, err
+		}
+
+		row := tx.QueryRow("SELECT tokens, updated_at, now() FROM rate_limit_buckets WHERE bucket_key=$1 FOR UPDATE", buckets[i].Key)
+		err = row.Scan(&levels[i].tokens, &levels[i].updatedAt, &now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	levels, results := takeAll(buckets, levels, now)
+	for i, b := range buckets {
+		_, err = tx.Exec("UPDATE rate_limit_buckets SET tokens=$2, updated_at=$3 WHERE bucket_key=$1", b.Key, levels[i].tokens, levels[i].updatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, tx.Commit()
+}
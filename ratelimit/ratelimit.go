@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Limit describes a token bucket that refills Requests tokens every Period
+// and holds at most Burst tokens.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+func PerSecond(n int) Limit {
+	return Limit{Requests: n, Period: time.Second, Burst: n}
+}
+
+func PerMinute(n int) Limit {
+	return Limit{Requests: n, Period: time.Minute, Burst: n}
+}
+
+func (l Limit) IsZero() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+func (l Limit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Bucket is the token bucket stored under Key, refilled at Limit.
+type Bucket struct {
+	Key   string
+	Limit Limit
+}
+
+// Store keeps token buckets. Take takes a token from every bucket given if
+// each has one to spare, and from none of them otherwise, so a request one
+// bucket refuses does not use up the others; it returns one result per
+// bucket, in order.
+type Store interface {
+	Take(buckets ...Bucket) ([]Result, error)
+}
+
+// level is a bucket's token count as of updatedAt.
+type level struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// takeAll refills the buckets, last seen at levels, to now and takes a token
+// from each if every one allows it. It returns the buckets' new levels and
+// their results.
+func takeAll(buckets []Bucket, levels []level, now time.Time) ([]level, []Result) {
+	tokens := make([]float64, len(buckets))
+	allowed := true
+	for i, b := range buckets {
+		tokens[i] = refill(b.Limit, levels[i].tokens, levels[i].updatedAt, now)
+		allowed = allowed && tokens[i] >= 1
+	}
+
+	next := make([]level, len(buckets))
+	results := make([]Result, len(buckets))
+	for i, b := range buckets {
+		if allowed {
+			tokens[i]--
+		}
+		next[i] = level{tokens: tokens[i], updatedAt: now}
+		results[i] = result(b.Limit, tokens[i], allowed)
+	}
+	return next, results
+}
+
+// refill applies the token bucket algorithm to a bucket last seen at
+// updatedAt holding tokens, returning its token count at now.
+func refill(limit Limit, tokens float64, updatedAt, now time.Time) float64 {
+	elapsed := now.Sub(updatedAt).Seconds()
+	if elapsed > 0 {
+		tokens = math.Min(limit.capacity(), tokens+elapsed*limit.ratePerSecond())
+	}
+	return tokens
+}
+
+// result reports on a bucket left holding tokens once the request was
+// allowed, or refused.
+func result(limit Limit, tokens float64, allowed bool) Result {
+	capacity := limit.capacity()
+	rate := limit.ratePerSecond()
+
+	r := Result{Allowed: allowed, Limit: int(capacity)}
+	if !allowed && tokens < 1 {
+		r.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	r.Remaining = int(math.Floor(tokens))
+	r.Reset = secondsToDuration((capacity - tokens) / rate)
+	return r
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
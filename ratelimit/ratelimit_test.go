@@ -0,0 +1,234 @@
+//go:build unit
+// +build unit
+
+package ratelimit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/ratelimit"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	take := func(store ratelimit.Store, key string, limit ratelimit.Limit) ratelimit.Result {
+		results, _ := store.Take(ratelimit.Bucket{Key: key, Limit: limit})
+		return results[0]
+	}
+
+	t.Run("deny when bucket is empty", func(t *testing.T) {
+		// Arrange
+		now := time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC)
+		store := ratelimit.NewMemoryStore()
+		store.SetClock(func() time.Time { return now })
+		limit := ratelimit.PerMinute(2)
+
+		// Act
+		first := take(store, "k", limit)
+		second := take(store, "k", limit)
+		third := take(store, "k", limit)
+
+		// Assert
+		assert.True(t, first.Allowed)
+		assert.Equal(t, 1, first.Remaining)
+		assert.True(t, second.Allowed)
+		assert.Equal(t, 0, second.Remaining)
+		assert.False(t, third.Allowed)
+		assert.Equal(t, 30*time.Second, third.RetryAfter)
+	})
+
+	t.Run("refill over time", func(t *testing.T) {
+		// Arrange
+		now := time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC)
+		store := ratelimit.NewMemoryStore()
+		store.SetClock(func() time.Time { return now })
+		limit := ratelimit.PerMinute(1)
+
+		// Act
+		first := take(store, "k", limit)
+		denied := take(store, "k", limit)
+		now = now.Add(time.Minute)
+		refilled := take(store, "k", limit)
+
+		// Assert
+		assert.True(t, first.Allowed)
+		assert.False(t, denied.Allowed)
+		assert.True(t, refilled.Allowed)
+	})
+
+	t.Run("take from every bucket or none", func(t *testing.T) {
+		// Arrange
+		store := ratelimit.NewMemoryStore()
+		roomy := ratelimit.Bucket{Key: "roomy", Limit: ratelimit.PerMinute(2)}
+		tight := ratelimit.Bucket{Key: "tight", Limit: ratelimit.PerMinute(1)}
+
+		// Act
+		first, _ := store.Take(roomy, tight)
+		denied, _ := store.Take(roomy, tight)
+		alone := take(store, "roomy", roomy.Limit)
+
+		// Assert
+		assert.True(t, first[0].Allowed)
+		assert.False(t, denied[0].Allowed)
+		assert.False(t, denied[1].Allowed)
+		assert.Equal(t, 1, denied[0].Remaining)
+		assert.True(t, alone.Allowed, "the refused request left roomy's token")
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		// Arrange
+		store := ratelimit.NewMemoryStore()
+		limit := ratelimit.PerMinute(1)
+
+		// Act
+		a := take(store, "a", limit)
+		b := take(store, "b", limit)
+
+		// Assert
+		assert.True(t, a.Allowed)
+		assert.True(t, b.Allowed)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	newServer := func(config ratelimit.Config) *echo.Echo {
+		e := echo.New()
+		e.Use(ratelimit.Middleware(config))
+		ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+		e.PUT("/wallet/:id", ok)
+		e.GET("/wallet/:id", ok)
+		e.HTTPErrorHandler = func(err error, c echo.Context) {
+			if appErr, ok := err.(errs.AppError); ok {
+				c.NoContent(appErr.Status)
+				return
+			}
+			e.DefaultHTTPErrorHandler(err, c)
+		}
+		return e
+	}
+
+	t.Run("set rate limit headers", func(t *testing.T) {
+		// Arrange
+		e := newServer(ratelimit.Config{
+			Default: ratelimit.Policy{IP: ratelimit.PerMinute(10)},
+		})
+
+		// Act
+		req := httptest.NewRequest(http.MethodGet, "/wallet/1", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		// Assert
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "10", rec.Header().Get(ratelimit.HeaderRateLimitLimit))
+		assert.Equal(t, "9", rec.Header().Get(ratelimit.HeaderRateLimitRemaining))
+		assert.Equal(t, "6", rec.Header().Get(ratelimit.HeaderRateLimitReset))
+	})
+
+	t.Run("limit mutations per wallet", func(t *testing.T) {
+		// Arrange
+		e := newServer(ratelimit.Config{
+			Routes: map[string]ratelimit.Policy{
+				ratelimit.Route(http.MethodPut, "/wallet/:id"): {
+					IP:     ratelimit.PerMinute(100),
+					Wallet: ratelimit.PerMinute(1),
+				},
+			},
+		})
+
+		// Act
+		codes := []int{}
+		for _, path := range []string{"/wallet/1", "/wallet/1", "/wallet/2"} {
+			req := httptest.NewRequest(http.MethodPut, path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			codes = append(codes, rec.Code)
+			if rec.Code == http.StatusTooManyRequests {
+				assert.Equal(t, "60", rec.Header().Get(ratelimit.HeaderRetryAfter))
+			}
+		}
+
+		// Assert
+		assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK}, codes)
+	})
+
+	t.Run("one bucket per wallet key", func(t *testing.T) {
+		// Arrange
+		e := newServer(ratelimit.Config{
+			Routes: map[string]ratelimit.Policy{
+				ratelimit.Route(http.MethodPut, "/wallet/:id"): {
+					IP:     ratelimit.PerMinute(100),
+					Wallet: ratelimit.PerMinute(1),
+				},
+			},
+			WalletKey: func(c echo.Context) string {
+				if c.Param("id") == "wal_a" {
+					return "1"
+				}
+				return c.Param("id")
+			},
+		})
+
+		// Act
+		codes := []int{}
+		for _, path := range []string{"/wallet/1", "/wallet/wal_a"} {
+			req := httptest.NewRequest(http.MethodPut, path, nil)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			codes = append(codes, rec.Code)
+		}
+
+		// Assert
+		assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+	})
+
+	t.Run("wallet denial keeps the client budget", func(t *testing.T) {
+		// Arrange
+		e := newServer(ratelimit.Config{
+			Routes: map[string]ratelimit.Policy{
+				ratelimit.Route(http.MethodPut, "/wallet/:id"): {
+					Client: ratelimit.PerMinute(2),
+					Wallet: ratelimit.PerMinute(1),
+				},
+			},
+		})
+
+		// Act
+		codes := []int{}
+		for _, path := range []string{"/wallet/1", "/wallet/1", "/wallet/2", "/wallet/3"} {
+			req := httptest.NewRequest(http.MethodPut, path, nil)
+			req.Header.Set(ratelimit.HeaderAPIKey, "key-a")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			codes = append(codes, rec.Code)
+		}
+
+		// Assert
+		assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK, http.StatusTooManyRequests}, codes)
+	})
+
+	t.Run("limit per api client", func(t *testing.T) {
+		// Arrange
+		e := newServer(ratelimit.Config{
+			Default: ratelimit.Policy{Client: ratelimit.PerMinute(1)},
+		})
+
+		// Act
+		codes := []int{}
+		for _, key := range []string{"key-a", "key-a", "key-b"} {
+			req := httptest.NewRequest(http.MethodGet, "/wallet/1", nil)
+			req.Header.Set(ratelimit.HeaderAPIKey, key)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			codes = append(codes, rec.Code)
+		}
+
+		// Assert
+		assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK}, codes)
+	})
+}
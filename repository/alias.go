@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrAliasNotFound is returned when the wallet has no such alias.
+	ErrAliasNotFound = errors.New("alias not found")
+	// ErrAliasTaken is returned when the alias already points at a wallet
+	// of the tenant.
+	ErrAliasTaken = errors.New("alias already registered")
+)
+
+// AliasRepository manages the alias directory. Aliases are unique within a
+// tenant and stored in normal form, see package alias.
+type AliasRepository interface {
+	GetAliases(string, int64) ([]Alias, error)
+	CreateAlias(string, NewAlias) (*Alias, error)
+	DeleteAlias(string, int64, int64) error
+	FindAliases(string, []string) (map[string]int64, error)
+}
+
+type Alias struct {
+	AliasID    int64     `db:"alias_id"`
+	WalletID   int64     `db:"wallet_id"`
+	Type       string    `db:"alias_type"`
+	Value      string    `db:"alias_value"`
+	VerifiedBy string    `db:"verified_by"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+type NewAlias struct {
+	WalletID   int64
+	Type       string
+	Value      string
+	VerifiedBy string
+}
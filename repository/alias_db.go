@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+const aliasColumns = "alias_id, wallet_id, alias_type, alias_value, verified_by, created_at"
+
+type aliasRepository struct {
+	db *sql.DB
+}
+
+func NewAliasRepository(db *sql.DB) AliasRepository {
+	return aliasRepository{db: db}
+}
+
+func scanAlias(row scanner) (*Alias, error) {
+	alias := Alias{}
+	err := row.Scan(&alias.AliasID, &alias.WalletID, &alias.Type, &alias.Value, &alias.VerifiedBy, &alias.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &alias, nil
+}
+
+func (r aliasRepository) GetAliases(tenantID string, walletID int64) ([]Alias, error) {
+	rows, err := r.db.Query("SELECT "+aliasColumns+" FROM wallet_aliases WHERE tenant_id=$1 AND wallet_id=$2 ORDER BY alias_id", tenantID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	aliases := []Alias{}
+	for rows.Next() {
+		a, err := scanAlias(rows)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, *a)
+	}
+
+	return aliases, rows.Err()
+}
+
+// CreateAlias points the alias at the wallet, which must not be closed.
+func (r aliasRepository) CreateAlias(tenantID string, a NewAlias) (*Alias, error) {
+	row := r.db.QueryRow("INSERT INTO wallet_aliases (tenant_id, wallet_id, alias_type, alias_value, verified_by) SELECT tenant_id, wallet_id, $3, $4, $5 FROM wallets WHERE tenant_id=$1 AND wallet_id=$2 AND wallet_status<>'Closed' RETURNING "+aliasColumns, tenantID, a.WalletID, a.Type, a.Value, a.VerifiedBy)
+	alias, err := scanAlias(row)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrAliasTaken
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		var status string
+		err = r.db.QueryRow("SELECT wallet_status FROM wallets WHERE tenant_id=$1 AND wallet_id=$2", tenantID, a.WalletID).Scan(&status)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrWalletClosed
+	}
+
+	return alias, err
+}
+
+func (r aliasRepository) DeleteAlias(tenantID string, walletID int64, aliasID int64) error {
+	result, err := r.db.Exec("DELETE FROM wallet_aliases WHERE tenant_id=$1 AND wallet_id=$2 AND alias_id=$3", tenantID, walletID, aliasID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAliasNotFound
+	}
+
+	return nil
+}
+
+// FindAliases returns the ids of the wallets the tenant's aliases point at,
+// keyed by alias; aliases nobody registered are left out.
+func (r aliasRepository) FindAliases(tenantID string, values []string) (map[string]int64, error) {
+	rows, err := r.db.Query("SELECT alias_value, wallet_id FROM wallet_aliases WHERE tenant_id=$1 AND alias_value = ANY($2)", tenantID, pq.Array(values))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := map[string]int64{}
+	for rows.Next() {
+		var value string
+		var id int64
+		if err = rows.Scan(&value, &id); err != nil {
+			return nil, err
+		}
+		ids[value] = id
+	}
+
+	return ids, rows.Err()
+}
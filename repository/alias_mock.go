@@ -0,0 +1,31 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type aliasRepositoryMock struct {
+	mock.Mock
+}
+
+func NewAliasRepositoryMock() *aliasRepositoryMock {
+	return &aliasRepositoryMock{}
+}
+
+func (r *aliasRepositoryMock) GetAliases(tenantID string, walletID int64) ([]Alias, error) {
+	args := r.Called(tenantID, walletID)
+	return args.Get(0).([]Alias), args.Error(1)
+}
+
+func (r *aliasRepositoryMock) CreateAlias(tenantID string, a NewAlias) (*Alias, error) {
+	args := r.Called(tenantID, a)
+	return args.Get(0).(*Alias), args.Error(1)
+}
+
+func (r *aliasRepositoryMock) DeleteAlias(tenantID string, walletID int64, aliasID int64) error {
+	args := r.Called(tenantID, walletID, aliasID)
+	return args.Error(0)
+}
+
+func (r *aliasRepositoryMock) FindAliases(tenantID string, values []string) (map[string]int64, error) {
+	args := r.Called(tenantID, values)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ApprovalPending  = "Pending"
+	ApprovalApproved = "Approved"
+	ApprovalRejected = "Rejected"
+	ApprovalExpired  = "Expired"
+	ApprovalFailed   = "Failed"
+
+	ActionBalance = "balance"
+	ActionStatus  = "status"
+)
+
+var (
+	// ErrApprovalNotPending is returned when deciding an approval that has
+	// already been approved, rejected or failed.
+	ErrApprovalNotPending = errors.New("approval not pending")
+	// ErrApprovalExpired is returned when deciding an approval after its
+	// expiry.
+	ErrApprovalExpired = errors.New("approval expired")
+	// ErrSelfApproval is returned when the requester tries to decide their
+	// own approval.
+	ErrSelfApproval = errors.New("approver must differ from requester")
+)
+
+type ApprovalRepository interface {
+	GetApprovals(string, string) ([]Approval, error)
+	GetApproval(string, int64) (*Approval, error)
+	CreateApproval(string, NewApproval) (*Approval, error)
+	DecideApproval(string, ApprovalDecision) (*Approval, error)
+	ApplyApproval(string, ApprovedChange) (*Wallet, error)
+	ExpireApprovals() (int64, error)
+}
+
+type Approval struct {
+	ApprovalID   int64      `db:"approval_id"`
+	TenantID     string     `db:"tenant_id"`
+	WalletID     int64      `db:"wallet_id"`
+	Action       string     `db:"action"`
+	Operation    string     `db:"operation"`
+	Amount       float64    `db:"amount"`
+	WalletStatus string     `db:"wallet_status"`
+	Status       string     `db:"approval_status"`
+	RequestedBy  string     `db:"requested_by"`
+	DecidedBy    string     `db:"decided_by"`
+	Reason       string     `db:"reason"`
+	CreatedAt    time.Time  `db:"created_at"`
+	ExpiresAt    time.Time  `db:"expires_at"`
+	DecidedAt    *time.Time `db:"decided_at"`
+}
+
+type NewApproval struct {
+	WalletID     int64
+	Action       string
+	Operation    string
+	Amount       float64
+	WalletStatus string
+	RequestedBy  string
+	Reason       string
+	TTL          time.Duration
+}
+
+// ApprovalDecision moves a pending approval to Status, which is
+// ApprovalRejected, or ApprovalFailed when its change no longer applies.
+type ApprovalDecision struct {
+	ApprovalID int64
+	Status     string
+	DecidedBy  string
+	Reason     string
+}
+
+// ApprovedChange approves an approval and applies its held change. Fee and
+// RevenueWalletID price a held deduction.
+type ApprovedChange struct {
+	ApprovalID      int64
+	DecidedBy       string
+	Fee             float64
+	RevenueWalletID int64
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+)
+
+const approvalColumns = "approval_id, tenant_id, wallet_id, action, operation, amount, wallet_status, approval_status, requested_by, decided_by, reason, created_at, expires_at, decided_at"
+
+type approvalRepository struct {
+	db *sql.DB
+}
+
+func NewApprovalRepository(db *sql.DB) ApprovalRepository {
+	return approvalRepository{db: db}
+}
+
+func scanApproval(row scanner) (*Approval, error) {
+	a := Approval{}
+	err := row.Scan(&a.ApprovalID, &a.TenantID, &a.WalletID, &a.Action, &a.Operation, &a.Amount, &a.WalletStatus, &a.Status, &a.RequestedBy, &a.DecidedBy, &a.Reason, &a.CreatedAt, &a.ExpiresAt, &a.DecidedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// GetApprovals lists the tenant's approvals, newest first. An empty status
+// returns approvals in every status.
+func (r approvalRepository) GetApprovals(tenantID string, status string) ([]Approval, error) {
+	rows, err := r.db.Query("SELECT "+approvalColumns+" FROM approvals WHERE tenant_id=$1 AND ($2='' OR approval_status=$2) ORDER BY approval_id DESC", tenantID, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []Approval{}
+	for rows.Next() {
+		a, err := scanApproval(rows)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, *a)
+	}
+
+	return approvals, rows.Err()
+}
+
+func (r approvalRepository) GetApproval(tenantID string, id int64) (*Approval, error) {
+	return scanApproval(r.db.QueryRow("SELECT "+approvalColumns+" FROM approvals WHERE tenant_id=$1 AND approval_id=$2", tenantID, id))
+}
+
+func (r approvalRepository) CreateApproval(tenantID string, a NewApproval) (*Approval, error) {
+	row := r.db.QueryRow("INSERT INTO approvals (tenant_id, wallet_id, action, operation, amount, wallet_status, requested_by, reason, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now() + make_interval(secs => $9)) RETURNING "+approvalColumns,
+		tenantID, a.WalletID, a.Action, a.Operation, a.Amount, a.WalletStatus, a.RequestedBy, a.Reason, a.TTL.Seconds())
+	return scanApproval(row)
+}
+
+// DecideApproval records the decision only while the approval is pending,
+// unexpired and decided by someone other than its requester, so concurrent
+// deciders cannot both decide it.
+func (r approvalRepository) DecideApproval(tenantID string, d ApprovalDecision) (*Approval, error) {
+	row := r.db.QueryRow("UPDATE approvals SET approval_status=$3, decided_by=$4, reason=$5, decided_at=now() WHERE tenant_id=$1 AND approval_id=$2 AND approval_status='Pending' AND expires_at>now() AND requested_by<>$4 RETURNING "+approvalColumns,
+		tenantID, d.ApprovalID, d.Status, d.DecidedBy, d.Reason)
+	approval, err := scanApproval(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, r.decideMiss(tenantID, d)
+	}
+
+	return approval, err
+}
+
+// ApplyApproval approves the approval and applies its held change in one
+// database transaction. The approval row stays locked until the change is
+// applied, and if the change fails the approval is left pending.
+func (r approvalRepository) ApplyApproval(tenantID string, c ApprovedChange) (*Wallet, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	d := ApprovalDecision{ApprovalID: c.ApprovalID, Status: ApprovalApproved, DecidedBy: c.DecidedBy}
+	approval, err := scanApproval(tx.QueryRow("UPDATE approvals SET approval_status=$3, decided_by=$4, reason=$5, decided_at=now() WHERE tenant_id=$1 AND approval_id=$2 AND approval_status='Pending' AND expires_at>now() AND requested_by<>$4 RETURNING "+approvalColumns,
+		tenantID, d.ApprovalID, d.Status, d.DecidedBy, d.Reason))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, r.decideMiss(tenantID, d)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var wallet *Wallet
+	switch {
+	case approval.Action == ActionStatus:
+		wallet, err = setStatusWallet(tx, tenantID, approval.WalletID, approval.WalletStatus, 0)
+	case approval.Operation == TransactionDeduct && c.Fee > 0:
+		wallet, err = deductWithFee(tx, tenantID, FeeDeduction{WalletID: approval.WalletID, Amount: approval.Amount, Fee: c.Fee, RevenueWalletID: c.RevenueWalletID})
+	case approval.Operation == TransactionDeduct:
+		wallet, err = setBalance(tx, tenantID, approval.WalletID, -approval.Amount, 0)
+	default:
+		wallet, err = setBalance(tx, tenantID, approval.WalletID, approval.Amount, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, tx.Commit()
+}
+
+// ExpireApprovals marks every pending approval past its expiry as expired.
+func (r approvalRepository) ExpireApprovals() (int64, error) {
+	result, err := r.db.Exec("UPDATE approvals SET approval_status='Expired', decided_at=now() WHERE approval_status='Pending' AND expires_at<=now()")
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// decideMiss explains why a decision matched no pending approval.
+func (r approvalRepository) decideMiss(tenantID string, d ApprovalDecision) error {
+	approval, err := r.GetApproval(tenantID, d.ApprovalID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case approval.Status != ApprovalPending:
+		return ErrApprovalNotPending
+	case approval.RequestedBy == d.DecidedBy:
+		return ErrSelfApproval
+	}
+
+	return ErrApprovalExpired
+}
@@ -0,0 +1,41 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type approvalRepositoryMock struct {
+	mock.Mock
+}
+
+func NewApprovalRepositoryMock() *approvalRepositoryMock {
+	return &approvalRepositoryMock{}
+}
+
+func (r *approvalRepositoryMock) GetApprovals(tenantID string, status string) ([]Approval, error) {
+	args := r.Called(tenantID, status)
+	return args.Get(0).([]Approval), args.Error(1)
+}
+
+func (r *approvalRepositoryMock) GetApproval(tenantID string, id int64) (*Approval, error) {
+	args := r.Called(tenantID, id)
+	return args.Get(0).(*Approval), args.Error(1)
+}
+
+func (r *approvalRepositoryMock) CreateApproval(tenantID string, a NewApproval) (*Approval, error) {
+	args := r.Called(tenantID, a)
+	return args.Get(0).(*Approval), args.Error(1)
+}
+
+func (r *approvalRepositoryMock) DecideApproval(tenantID string, d ApprovalDecision) (*Approval, error) {
+	args := r.Called(tenantID, d)
+	return args.Get(0).(*Approval), args.Error(1)
+}
+
+func (r *approvalRepositoryMock) ApplyApproval(tenantID string, c ApprovedChange) (*Wallet, error) {
+	args := r.Called(tenantID, c)
+	return args.Get(0).(*Wallet), args.Error(1)
+}
+
+func (r *approvalRepositoryMock) ExpireApprovals() (int64, error) {
+	args := r.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrBeforeStream is returned when asking for a balance before the event
+// store started of a wallet whose stream began then, holding the state it
+// had rather than its history.
+var ErrBeforeStream = errors.New("balance before the wallet's stream started")
+
+// BalanceRepository answers what wallets held at a point in time by
+// replaying their streams, starting from the latest daily snapshot at or
+// before it. A wallet's pockets are not part of its balance.
+type BalanceRepository interface {
+	GetBalanceAt(string, int64, time.Time) (*Balance, error)
+	GetBalancesAt(string, time.Time, func(Balance) error) error
+	SnapshotBalances(time.Time, time.Time) (int64, error)
+}
+
+// Balance is a wallet's state at a point in time; Version is the stream
+// version it had reached.
+type Balance struct {
+	WalletID int64
+	TenantID string
+	Currency string
+	Balance  float64
+	Status   string
+	Version  int64
+}
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/aggregate"
+)
+
+// snapshotChunk caps how many snapshots one INSERT carries.
+const snapshotChunk = 10000
+
+// balanceScope selects the wallets of a point-in-time query, $1 to $4 being
+// the tenant, the wallet, the time and a date on which wallets must not
+// have been snapshotted yet; empty values select everything. snap holds
+// each wallet's latest snapshot at or before the time.
+const balanceScope = `WITH scope AS (
+		SELECT w.wallet_id, w.tenant_id, w.currency FROM wallets w
+		WHERE ($1='' OR w.tenant_id=$1) AND ($2=0 OR w.wallet_id=$2)
+			AND ($4='' OR NOT EXISTS (SELECT 1 FROM balance_snapshots d WHERE d.wallet_id = w.wallet_id AND d.snapshot_date::text=$4))
+	), snap AS (
+		SELECT DISTINCT ON (s.wallet_id) s.wallet_id, s.balance, s.wallet_status, s.version
+		FROM balance_snapshots s JOIN scope ON scope.wallet_id = s.wallet_id
+		WHERE s.taken_at <= $3
+		ORDER BY s.wallet_id, s.taken_at DESC
+	) `
+
+type balanceRepository struct {
+	db *sql.DB
+}
+
+func NewBalanceRepository(db *sql.DB) BalanceRepository {
+	return balanceRepository{db: db}
+}
+
+// GetBalanceAt returns sql.ErrNoRows when the tenant has no such wallet or
+// it had not been created yet at that time, and ErrBeforeStream when its
+// history does not reach back that far.
+func (r balanceRepository) GetBalanceAt(tenantID string, walletID int64, at time.Time) (*Balance, error) {
+	var balance *Balance
+	err := r.balancesAt(tenantID, walletID, at, "", func(b Balance) error {
+		balance = &b
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if balance == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	return balance, nil
+}
+
+// GetBalancesAt calls each for every wallet the tenant had at that time, in
+// id order, stopping at the first error. It returns ErrBeforeStream before
+// calling each when the history of any of them does not reach back that
+// far.
+func (r balanceRepository) GetBalancesAt(tenantID string, at time.Time, each func(Balance) error) error {
+	return r.balancesAt(tenantID, 0, at, "", each)
+}
+
+// SnapshotBalances records every wallet's balance at takenAt, the end of the
+// business day date, for the wallets not yet snapshotted that day. It
+// returns how many it recorded.
+func (r balanceRepository) SnapshotBalances(date time.Time, takenAt time.Time) (int64, error) {
+	day := date.Format(dateLayout)
+	balances := []Balance{}
+	err := r.balancesAt("", 0, takenAt, day, func(b Balance) error {
+		balances = append(balances, b)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var recorded int64
+	for start := 0; start < len(balances); start += snapshotChunk {
+		chunk := balances[start:]
+		if len(chunk) > snapshotChunk {
+			chunk = chunk[:snapshotChunk]
+		}
+
+		walletIDs := make([]int64, len(chunk))
+		tenantIDs := make([]string, len(chunk))
+		amounts := make([]float64, len(chunk))
+		statuses := make([]string, len(chunk))
+		versions := make([]int64, len(chunk))
+		for i, b := range chunk {
+			walletIDs[i], tenantIDs[i], amounts[i], statuses[i], versions[i] = b.WalletID, b.TenantID, b.Balance, b.Status, b.Version
+		}
+
+		result, err := r.db.Exec(`INSERT INTO balance_snapshots (wallet_id, snapshot_date, tenant_id, taken_at, balance, wallet_status, version)
+			SELECT s.wallet_id, $1, s.tenant_id, $2, s.balance, s.wallet_status, s.version
+			FROM unnest($3::int[], $4::text[], $5::float8[], $6::text[], $7::bigint[]) AS s (wallet_id, tenant_id, balance, wallet_status, version)
+			ON CONFLICT (wallet_id, snapshot_date) DO NOTHING`,
+			day, takenAt.UTC(), pq.Array(walletIDs), pq.Array(tenantIDs), pq.Array(amounts), pq.Array(statuses), pq.Array(versions))
+		if err != nil {
+			return recorded, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return recorded, err
+		}
+		recorded += n
+	}
+
+	return recorded, nil
+}
+
+// balancesAt starts every wallet in scope from its latest snapshot, or from
+// nothing, and applies its events up to at. Both reads share one snapshot
+// of the database so a wallet written meanwhile cannot be half seen.
+func (r balanceRepository) balancesAt(tenantID string, walletID int64, at time.Time, unsnapshotted string, each func(Balance) error) error {
+	tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	args := []interface{}{tenantID, walletID, at.UTC(), unsnapshotted}
+	if unsnapshotted == "" {
+		// Snapshots taken that early are left alone: they hold the state
+		// the stream starts from, which is still right after the start.
+		var before bool
+		err = tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM event_store e, event_store_start s
+			WHERE ($1='' OR e.tenant_id=$1) AND ($2=0 OR e.stream_id=$2) AND e.event_type='WalletCreated'
+				AND e.recorded_at <= $3 AND e.recorded_at < s.started_at AND $3 < s.started_at)`, tenantID, walletID, at.UTC()).Scan(&before)
+		if err != nil {
+			return err
+		}
+		if before {
+			return ErrBeforeStream
+		}
+	}
+	wallets, err := balanceStarts(tx, args)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(balanceScope+`SELECT e.stream_id, e.tenant_id, e.stream_version, e.event_type, e.data, e.recorded_at
+		FROM scope JOIN event_store e ON e.stream_id = scope.wallet_id
+		LEFT JOIN snap ON snap.wallet_id = scope.wallet_id
+		WHERE e.stream_version > COALESCE(snap.version, 0) AND (e.recorded_at <= $3 OR e.event_type='WalletCreated')
+		ORDER BY e.stream_id, e.stream_version`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var next *aggregate.Event
+	advance := func() error {
+		next = nil
+		if rows.Next() {
+			e, err := scanEvent(rows)
+			if err != nil {
+				return err
+			}
+			next = e
+		}
+		return rows.Err()
+	}
+
+	if err = advance(); err != nil {
+		return err
+	}
+	for _, w := range wallets {
+		for next != nil && next.WalletID <= w.WalletID {
+			if next.WalletID == w.WalletID {
+				if err = w.Apply(*next); err != nil {
+					return err
+				}
+			}
+			if err = advance(); err != nil {
+				return err
+			}
+		}
+
+		// An imported wallet's stream may be recorded after the time it
+		// says it was created.
+		if w.Version == 0 || w.CreatedAt.After(at) {
+			continue
+		}
+		err = each(Balance{WalletID: w.WalletID, TenantID: w.TenantID, Currency: w.Currency, Balance: w.Balance, Status: w.Status, Version: w.Version})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// balanceStarts returns the wallets in scope in id order, each at its latest
+// snapshot or, without one, empty at version 0.
+func balanceStarts(tx *sql.Tx, args []interface{}) ([]*aggregate.Wallet, error) {
+	rows, err := tx.Query(balanceScope+`SELECT scope.wallet_id, scope.tenant_id, scope.currency, snap.balance, snap.wallet_status, snap.version
+		FROM scope LEFT JOIN snap ON snap.wallet_id = scope.wallet_id
+		ORDER BY scope.wallet_id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	wallets := []*aggregate.Wallet{}
+	for rows.Next() {
+		w := &aggregate.Wallet{}
+		var balance sql.NullFloat64
+		var status sql.NullString
+		var version sql.NullInt64
+		err = rows.Scan(&w.WalletID, &w.TenantID, &w.Currency, &balance, &status, &version)
+		if err != nil {
+			return nil, err
+		}
+		w.Balance, w.Status, w.Version = balance.Float64, status.String, version.Int64
+		wallets = append(wallets, w)
+	}
+
+	return wallets, rows.Err()
+}
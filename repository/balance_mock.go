@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type balanceRepositoryMock struct {
+	mock.Mock
+}
+
+func NewBalanceRepositoryMock() *balanceRepositoryMock {
+	return &balanceRepositoryMock{}
+}
+
+func (r *balanceRepositoryMock) GetBalanceAt(tenantID string, walletID int64, at time.Time) (*Balance, error) {
+	args := r.Called(tenantID, walletID, at)
+	return args.Get(0).(*Balance), args.Error(1)
+}
+
+// GetBalancesAt calls each with the balances the mock returns.
+func (r *balanceRepositoryMock) GetBalancesAt(tenantID string, at time.Time, each func(Balance) error) error {
+	args := r.Called(tenantID, at)
+	for _, b := range args.Get(0).([]Balance) {
+		if err := each(b); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (r *balanceRepositoryMock) SnapshotBalances(date time.Time, takenAt time.Time) (int64, error) {
+	args := r.Called(date, takenAt)
+	return args.Get(0).(int64), args.Error(1)
+}
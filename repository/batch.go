@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	BatchAllOrNothing = "all_or_nothing"
+	BatchBestEffort   = "best_effort"
+
+	BatchPending    = "Pending"
+	BatchProcessing = "Processing"
+	BatchCompleted  = "Completed"
+	BatchFailed     = "Failed"
+
+	BatchItemPending   = "Pending"
+	BatchItemSucceeded = "Succeeded"
+	BatchItemFailed    = "Failed"
+	BatchItemSkipped   = "Skipped"
+
+	BatchAdd      = "Add"
+	BatchDeduct   = "Deduct"
+	BatchTransfer = "Transfer"
+)
+
+// ErrBatchNotFound is returned when the batch does not exist for the tenant.
+var ErrBatchNotFound = errors.New("batch not found")
+
+// BatchItemError names the item ApplyBatchItems stopped at. Nothing passed
+// to that call was applied.
+type BatchItemError struct {
+	ItemID int64
+	Err    error
+}
+
+func (e BatchItemError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.ItemID, e.Err)
+}
+
+func (e BatchItemError) Unwrap() error {
+	return e.Err
+}
+
+type BatchRepository interface {
+	CreateBatch(string, NewBatch) (*Batch, error)
+	GetBatch(string, int64) (*Batch, error)
+	GetBatchItems(string, int64) ([]BatchItem, error)
+	ClaimBatch(time.Duration) (*Batch, error)
+	ApplyBatchItems(string, int64, []BatchPosting) error
+	FailBatchItems(string, int64, []BatchItemFailure) error
+	FinishBatch(string, int64, string) (*Batch, error)
+}
+
+// Batch counts its items by status so progress can be read without loading
+// them.
+type Batch struct {
+	BatchID     int64      `db:"batch_id"`
+	TenantID    string     `db:"tenant_id"`
+	Mode        string     `db:"mode"`
+	Status      string     `db:"batch_status"`
+	RequestedBy string     `db:"requested_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+	CompletedAt *time.Time `db:"completed_at"`
+	Items       int64      `db:"-"`
+	Succeeded   int64      `db:"-"`
+	Failed      int64      `db:"-"`
+	Skipped     int64      `db:"-"`
+}
+
+type BatchItem struct {
+	ItemID        int64      `db:"item_id"`
+	BatchID       int64      `db:"batch_id"`
+	Position      int        `db:"position"`
+	Operation     string     `db:"operation"`
+	WalletID      int64      `db:"wallet_id"`
+	ToWalletID    int64      `db:"to_wallet_id"`
+	Amount        float64    `db:"amount"`
+	Reference     string     `db:"reference"`
+	Status        string     `db:"item_status"`
+	Fee           float64    `db:"fee"`
+	TransactionID *int64     `db:"transaction_id"`
+	ErrorCode     string     `db:"error_code"`
+	ErrorMessage  string     `db:"error_message"`
+	ProcessedAt   *time.Time `db:"processed_at"`
+}
+
+type NewBatch struct {
+	Mode        string
+	RequestedBy string
+	Items       []NewBatchItem
+}
+
+type NewBatchItem struct {
+	Operation  string
+	WalletID   int64
+	ToWalletID int64
+	Amount     float64
+	Reference  string
+}
+
+// BatchPosting is a batch item checked and ready to apply: Amount moves into
+// WalletID for Add, out of it for Deduct, and from it to ToWalletID for
+// Transfer. A debit is charged Fee on top, credited to RevenueWalletID.
+// Reference becomes the reason of the transactions recorded.
+type BatchPosting struct {
+	ItemID          int64
+	Operation       string
+	WalletID        int64
+	ToWalletID      int64
+	Amount          float64
+	Fee             float64
+	RevenueWalletID int64
+	Reference       string
+}
+
+type BatchItemFailure struct {
+	ItemID  int64
+	Code    string
+	Message string
+}
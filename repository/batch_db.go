@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/ledger"
+)
+
+const batchColumns = "batch_id, tenant_id, mode, batch_status, requested_by, created_at, completed_at, " +
+	"(SELECT COUNT(*) FROM batch_items i WHERE i.batch_id = batches.batch_id), " +
+	"(SELECT COUNT(*) FROM batch_items i WHERE i.batch_id = batches.batch_id AND i.item_status='Succeeded'), " +
+	"(SELECT COUNT(*) FROM batch_items i WHERE i.batch_id = batches.batch_id AND i.item_status='Failed'), " +
+	"(SELECT COUNT(*) FROM batch_items i WHERE i.batch_id = batches.batch_id AND i.item_status='Skipped')"
+
+const batchItemColumns = "item_id, batch_id, position, operation, wallet_id, COALESCE(to_wallet_id, 0), amount, reference, item_status, fee, transaction_id, error_code, error_message, processed_at"
+
+type batchRepository struct {
+	db *sql.DB
+}
+
+func NewBatchRepository(db *sql.DB) BatchRepository {
+	return batchRepository{db: db}
+}
+
+func scanBatch(row scanner) (*Batch, error) {
+	b := Batch{}
+	err := row.Scan(&b.BatchID, &b.TenantID, &b.Mode, &b.Status, &b.RequestedBy, &b.CreatedAt, &b.CompletedAt, &b.Items, &b.Succeeded, &b.Failed, &b.Skipped)
+	if err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func scanBatchItem(row scanner) (*BatchItem, error) {
+	i := BatchItem{}
+	err := row.Scan(&i.ItemID, &i.BatchID, &i.Position, &i.Operation, &i.WalletID, &i.ToWalletID, &i.Amount, &i.Reference, &i.Status, &i.Fee, &i.TransactionID, &i.ErrorCode, &i.ErrorMessage, &i.ProcessedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &i, nil
+}
+
+// CreateBatch stores the batch and its items, numbered from 0 in the order
+// given, for the background worker to process.
+func (r batchRepository) CreateBatch(tenantID string, b NewBatch) (*Batch, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow("INSERT INTO batches (tenant_id, mode, requested_by) VALUES ($1, $2, $3) RETURNING batch_id", tenantID, b.Mode, b.RequestedBy).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = copyRows(tx, pq.CopyIn("batch_items", "batch_id", "position", "operation", "wallet_id", "to_wallet_id", "amount", "reference"), func(exec func(...interface{}) error) error {
+		for position, item := range b.Items {
+			var toWalletID interface{}
+			if item.ToWalletID != 0 {
+				toWalletID = item.ToWalletID
+			}
+			if err := exec(id, position, item.Operation, item.WalletID, toWalletID, item.Amount, item.Reference); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := scanBatch(tx.QueryRow("SELECT "+batchColumns+" FROM batches WHERE batch_id=$1", id))
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, tx.Commit()
+}
+
+func (r batchRepository) GetBatch(tenantID string, id int64) (*Batch, error) {
+	batch, err := scanBatch(r.db.QueryRow("SELECT "+batchColumns+" FROM batches WHERE tenant_id=$1 AND batch_id=$2", tenantID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrBatchNotFound
+	}
+
+	return batch, err
+}
+
+// GetBatchItems lists the batch's items in submission order.
+func (r batchRepository) GetBatchItems(tenantID string, id int64) ([]BatchItem, error) {
+	rows, err := r.db.Query("SELECT "+batchItemColumns+" FROM batch_items WHERE batch_id=(SELECT batch_id FROM batches WHERE tenant_id=$1 AND batch_id=$2) ORDER BY position", tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []BatchItem{}
+	for rows.Next() {
+		i, err := scanBatchItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *i)
+	}
+
+	return items, rows.Err()
+}
+
+// ClaimBatch hands the oldest pending batch of any tenant to the caller, or
+// a batch whose worker has not reported progress for staleAfter, and returns
+// sql.ErrNoRows when there is none. Concurrent workers never claim the same
+// batch.
+func (r batchRepository) ClaimBatch(staleAfter time.Duration) (*Batch, error) {
+	return scanBatch(r.db.QueryRow("UPDATE batches SET batch_status='Processing', claimed_at=now() WHERE batch_id=("+
+		"SELECT batch_id FROM batches WHERE batch_status='Pending' OR (batch_status='Processing' AND claimed_at < now() - make_interval(secs => $1)) "+
+		"ORDER BY batch_id LIMIT 1 FOR UPDATE SKIP LOCKED) RETURNING "+batchColumns, staleAfter.Seconds()))
+}
+
+// ApplyBatchItems applies the postings in order in one database transaction
+// and marks their items succeeded. When a posting fails nothing is applied
+// and the error is a BatchItemError naming its item.
+func (r batchRepository) ApplyBatchItems(tenantID string, batchID int64, postings []BatchPosting) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, p := range postings {
+		transaction, err := applyPosting(tx, tenantID, p)
+		if err != nil {
+			return BatchItemError{ItemID: p.ItemID, Err: err}
+		}
+
+		// The status guard stops a worker that lost its claim from applying
+		// an item a second time.
+		result, err := tx.Exec("UPDATE batch_items SET item_status='Succeeded', fee=$3, transaction_id=$4, processed_at=now() WHERE batch_id=$1 AND item_id=$2 AND item_status='Pending'", batchID, p.ItemID, p.Fee, transaction.TransactionID)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("batch item %d is no longer pending", p.ItemID)
+		}
+	}
+
+	_, err = tx.Exec("UPDATE batches SET claimed_at=now() WHERE tenant_id=$1 AND batch_id=$2", tenantID, batchID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r batchRepository) FailBatchItems(tenantID string, batchID int64, failures []BatchItemFailure) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, f := range failures {
+		_, err = tx.Exec("UPDATE batch_items SET item_status='Failed', error_code=$3, error_message=$4, processed_at=now() WHERE batch_id=$1 AND item_id=$2 AND item_status='Pending'", batchID, f.ItemID, f.Code, f.Message)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE batches SET claimed_at=now() WHERE tenant_id=$1 AND batch_id=$2", tenantID, batchID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FinishBatch sets the batch's final status and marks the items it never
+// reached as skipped.
+func (r batchRepository) FinishBatch(tenantID string, batchID int64, status string) (*Batch, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE batch_items SET item_status='Skipped', processed_at=now() WHERE batch_id=(SELECT batch_id FROM batches WHERE tenant_id=$1 AND batch_id=$2) AND item_status='Pending'", tenantID, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	batch, err := scanBatch(tx.QueryRow("UPDATE batches SET batch_status=$3, completed_at=now() WHERE tenant_id=$1 AND batch_id=$2 RETURNING "+batchColumns, tenantID, batchID, status))
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, tx.Commit()
+}
+
+// applyPosting moves the posting's money within tx, locking every wallet it
+// touches in id order, and returns the transaction recorded on WalletID.
+func applyPosting(tx *sql.Tx, tenantID string, p BatchPosting) (*Transaction, error) {
+	ids := []int64{p.WalletID}
+	if p.Operation == BatchTransfer {
+		ids = append(ids, p.ToWalletID)
+	}
+	if p.Fee > 0 {
+		ids = append(ids, p.RevenueWalletID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	_, err := tx.Exec("SELECT wallet_id FROM wallets WHERE tenant_id=$1 AND wallet_id = ANY($2) ORDER BY wallet_id FOR UPDATE", tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := loadWallet(tx, tenantID, p.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status == StatusClosed {
+		return nil, ErrWalletClosed
+	}
+
+	if p.Operation == BatchAdd {
+		err = wallet.Credit(p.Amount, p.Reference)
+		if err != nil {
+			return nil, err
+		}
+		err = saveWallet(tx, wallet)
+		if err != nil {
+			return nil, err
+		}
+		credit, err := insertTransaction(tx, tenantID, entry{WalletID: p.WalletID, Amount: p.Amount, Balance: wallet.Balance, Reason: p.Reference})
+		if err != nil {
+			return nil, err
+		}
+		return credit, postEntry(tx, tenantID, cashEntry(wallet, p.Amount, credit))
+	}
+
+	if cents(wallet.Balance) < cents(p.Amount)+cents(p.Fee) {
+		return nil, ErrInsufficientFunds
+	}
+	err = wallet.Debit(p.Amount, p.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	var counterparty *int64
+	if p.Operation == BatchTransfer {
+		counterparty = &p.ToWalletID
+	}
+	debit, err := insertTransaction(tx, tenantID, entry{WalletID: p.WalletID, Amount: -p.Amount, Balance: wallet.Balance, Counterparty: counterparty, Reason: p.Reference})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Operation == BatchTransfer {
+		destination, err := loadWallet(tx, tenantID, p.ToWalletID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDestinationUnavailable
+		}
+		if err != nil {
+			return nil, err
+		}
+		if destination.Status != StatusActive || destination.Currency != wallet.Currency {
+			return nil, ErrDestinationUnavailable
+		}
+
+		err = destination.Credit(p.Amount, p.Reference)
+		if err != nil {
+			return nil, err
+		}
+		err = saveWallet(tx, destination)
+		if err != nil {
+			return nil, err
+		}
+		_, err = insertTransaction(tx, tenantID, entry{WalletID: p.ToWalletID, Amount: p.Amount, Balance: destination.Balance, Counterparty: &p.WalletID, Reason: p.Reference})
+		if err != nil {
+			return nil, err
+		}
+
+		e := ledger.Transfer(wallet.Currency, "transfer", ledger.WalletAccount(p.WalletID), ledger.WalletAccount(p.ToWalletID), p.Amount)
+		e.TransactionID = &debit.TransactionID
+		err = postEntry(tx, tenantID, e)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err = postEntry(tx, tenantID, cashEntry(wallet, -p.Amount, debit))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if p.Fee > 0 {
+		err = chargeFee(tx, tenantID, wallet, p.Fee, p.RevenueWalletID, debit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return debit, saveWallet(tx, wallet)
+}
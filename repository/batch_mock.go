@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type batchRepositoryMock struct {
+	mock.Mock
+}
+
+func NewBatchRepositoryMock() *batchRepositoryMock {
+	return &batchRepositoryMock{}
+}
+
+func (r *batchRepositoryMock) CreateBatch(tenantID string, b NewBatch) (*Batch, error) {
+	args := r.Called(tenantID, b)
+	return args.Get(0).(*Batch), args.Error(1)
+}
+
+func (r *batchRepositoryMock) GetBatch(tenantID string, id int64) (*Batch, error) {
+	args := r.Called(tenantID, id)
+	return args.Get(0).(*Batch), args.Error(1)
+}
+
+func (r *batchRepositoryMock) GetBatchItems(tenantID string, id int64) ([]BatchItem, error) {
+	args := r.Called(tenantID, id)
+	return args.Get(0).([]BatchItem), args.Error(1)
+}
+
+func (r *batchRepositoryMock) ClaimBatch(staleAfter time.Duration) (*Batch, error) {
+	args := r.Called(staleAfter)
+	return args.Get(0).(*Batch), args.Error(1)
+}
+
+func (r *batchRepositoryMock) ApplyBatchItems(tenantID string, batchID int64, postings []BatchPosting) error {
+	args := r.Called(tenantID, batchID, postings)
+	return args.Error(0)
+}
+
+func (r *batchRepositoryMock) FailBatchItems(tenantID string, batchID int64, failures []BatchItemFailure) error {
+	args := r.Called(tenantID, batchID, failures)
+	return args.Error(0)
+}
+
+func (r *batchRepositoryMock) FinishBatch(tenantID string, batchID int64, status string) (*Batch, error) {
+	args := r.Called(tenantID, batchID, status)
+	return args.Get(0).(*Batch), args.Error(1)
+}
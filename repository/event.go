@@ -0,0 +1,29 @@
+package repository
+
+import "time"
+
+const (
+	EventCreated       = "created"
+	EventCredited      = "credited"
+	EventDebited       = "debited"
+	EventStatusChanged = "status_changed"
+)
+
+type EventRepository interface {
+	GetEventsAfter(tenantID string, walletID int64, afterID int64, limit int) ([]WalletEvent, error)
+}
+
+// WalletEvent is one committed change to a wallet. Events are written by a
+// trigger on the wallets table, so every repository mutation produces them.
+// EventID is the event's position, given as its transaction commits, so a
+// tenant's event ids grow in the order the events become visible.
+type WalletEvent struct {
+	EventID   int64     `db:"position"`
+	TenantID  string    `db:"tenant_id"`
+	WalletID  int64     `db:"wallet_id"`
+	Type      string    `db:"event_type"`
+	Amount    float64   `db:"amount"`
+	Balance   float64   `db:"balance"`
+	Status    string    `db:"wallet_status"`
+	CreatedAt time.Time `db:"created_at"`
+}
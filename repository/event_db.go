@@ -0,0 +1,33 @@
+package repository
+
+import "database/sql"
+
+type eventRepository struct {
+	db *sql.DB
+}
+
+func NewEventRepository(db *sql.DB) EventRepository {
+	return eventRepository{db: db}
+}
+
+// GetEventsAfter returns events positioned after afterID in commit order. A
+// walletID of 0 returns events for every wallet of the tenant.
+func (r eventRepository) GetEventsAfter(tenantID string, walletID int64, afterID int64, limit int) ([]WalletEvent, error) {
+	rows, err := r.db.Query("SELECT position, tenant_id, wallet_id, event_type, amount, balance, wallet_status, created_at FROM wallet_events WHERE tenant_id=$1 AND ($2=0 OR wallet_id=$2) AND position>$3 ORDER BY position LIMIT $4", tenantID, walletID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []WalletEvent{}
+	for rows.Next() {
+		e := WalletEvent{}
+		err = rows.Scan(&e.EventID, &e.TenantID, &e.WalletID, &e.Type, &e.Amount, &e.Balance, &e.Status, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
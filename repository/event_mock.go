@@ -0,0 +1,16 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type eventRepositoryMock struct {
+	mock.Mock
+}
+
+func NewEventRepositoryMock() *eventRepositoryMock {
+	return &eventRepositoryMock{}
+}
+
+func (r *eventRepositoryMock) GetEventsAfter(tenantID string, walletID int64, afterID int64, limit int) ([]WalletEvent, error) {
+	args := r.Called(tenantID, walletID, afterID, limit)
+	return args.Get(0).([]WalletEvent), args.Error(1)
+}
@@ -0,0 +1,47 @@
+package repository
+
+import "errors"
+
+// ErrFeeWalletUnavailable is returned when a fee rule's revenue wallet does
+// not exist, is not active or holds another currency.
+var ErrFeeWalletUnavailable = errors.New("fee revenue wallet unavailable")
+
+type FeeRepository interface {
+	GetFeeRule(string, string, string) (*FeeRule, error)
+}
+
+// FeeRule prices one operation type for a tenant. A rule with an empty
+// Currency applies to every currency without a rule of its own.
+//
+// The fee is Flat plus Percent of the amount, or taken from the first tier
+// whose UpTo is at least the amount when Tiers is set, and then held between
+// MinFee and MaxFee.
+type FeeRule struct {
+	FeeRuleID       int64     `db:"fee_rule_id"`
+	TenantID        string    `db:"tenant_id"`
+	Operation       string    `db:"operation"`
+	Currency        string    `db:"currency"`
+	Flat            float64   `db:"flat"`
+	Percent         float64   `db:"percent"`
+	MinFee          *float64  `db:"min_fee"`
+	MaxFee          *float64  `db:"max_fee"`
+	Tiers           []FeeTier `db:"tiers"`
+	RevenueWalletID int64     `db:"revenue_wallet_id"`
+}
+
+// FeeTier prices amounts up to UpTo; a nil UpTo is unbounded.
+type FeeTier struct {
+	UpTo    *float64 `json:"up_to"`
+	Flat    float64  `json:"flat"`
+	Percent float64  `json:"percent"`
+}
+
+// FeeDeduction deducts Amount from the wallet and charges Fee on top of it,
+// crediting the fee to RevenueWalletID.
+type FeeDeduction struct {
+	WalletID        int64
+	Amount          float64
+	Fee             float64
+	RevenueWalletID int64
+	Version         int64
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+type feeRepository struct {
+	db *sql.DB
+}
+
+func NewFeeRepository(db *sql.DB) FeeRepository {
+	return feeRepository{db: db}
+}
+
+// GetFeeRule returns the tenant's rule for the operation, preferring one
+// for the currency over the catch-all rule.
+func (r feeRepository) GetFeeRule(tenantID string, operation string, currency string) (*FeeRule, error) {
+	rule := FeeRule{}
+	var minFee, maxFee sql.NullFloat64
+	var tiers []byte
+	err := r.db.QueryRow("SELECT fee_rule_id, tenant_id, operation, currency, flat, percent, min_fee, max_fee, tiers, revenue_wallet_id FROM fee_rules WHERE tenant_id=$1 AND operation=$2 AND (currency=$3 OR currency='') ORDER BY currency DESC LIMIT 1", tenantID, operation, currency).
+		Scan(&rule.FeeRuleID, &rule.TenantID, &rule.Operation, &rule.Currency, &rule.Flat, &rule.Percent, &minFee, &maxFee, &tiers, &rule.RevenueWalletID)
+	if err != nil {
+		return nil, err
+	}
+	if minFee.Valid {
+		rule.MinFee = &minFee.Float64
+	}
+	if maxFee.Valid {
+		rule.MaxFee = &maxFee.Float64
+	}
+	err = json.Unmarshal(tiers, &rule.Tiers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rule, nil
+}
@@ -0,0 +1,16 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type feeRepositoryMock struct {
+	mock.Mock
+}
+
+func NewFeeRepositoryMock() *feeRepositoryMock {
+	return &feeRepositoryMock{}
+}
+
+func (r *feeRepositoryMock) GetFeeRule(tenantID string, operation string, currency string) (*FeeRule, error) {
+	args := r.Called(tenantID, operation, currency)
+	return args.Get(0).(*FeeRule), args.Error(1)
+}
@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrBeforeLedger is returned when accruing a day on which a wallet held
+// money from before the ledger started, which the ledger only records from
+// then on.
+var ErrBeforeLedger = errors.New("balance before the ledger started")
+
+// ErrPeriodPosted is returned when accruing a day of a month whose interest
+// has already been posted to the tenant's wallets, as the accrual would
+// never be paid.
+var ErrPeriodPosted = errors.New("interest period already posted")
+
+type InterestRepository interface {
+	AccrueInterest(string, InterestAccrual) (int64, error)
+	PostInterest(string, time.Time) ([]InterestPosting, error)
+	GetInterestProgress(string) (*InterestProgress, error)
+}
+
+// InterestProgress is how far a tenant's interest has got: the last day
+// accrued and the last month posted, nil before the first, and the months
+// with accruals not yet posted, oldest first.
+type InterestProgress struct {
+	LastAccrued *time.Time
+	LastPosted  *time.Time
+	Unposted    []time.Time
+}
+
+// InterestAccrual accrues one day of interest at Rate percent a year, a
+// year being DaysInYear days under the tenant's day-count convention, on
+// the balance at Until, the end of Date.
+type InterestAccrual struct {
+	Date       time.Time
+	Until      time.Time
+	Rate       float64
+	DayCount   string
+	DaysInYear float64
+}
+
+// InterestPosting is the interest credited to a wallet for one month.
+// Amount is what was accrued plus what was carried in, truncated to cents;
+// the remainder is carried into the next month so nothing is lost to
+// rounding.
+type InterestPosting struct {
+	PostingID     int64     `db:"posting_id"`
+	WalletID      int64     `db:"wallet_id"`
+	Period        time.Time `db:"period"`
+	Accrued       float64   `db:"accrued"`
+	CarriedIn     float64   `db:"carried_in"`
+	Amount        float64   `db:"amount"`
+	CarryOut      float64   `db:"carry_out"`
+	TransactionID *int64    `db:"transaction_id"`
+}
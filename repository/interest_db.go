@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/topnarapat/go-wallet/ledger"
+)
+
+const dateLayout = "2006-01-02"
+
+type interestRepository struct {
+	db *sql.DB
+}
+
+func NewInterestRepository(db *sql.DB) InterestRepository {
+	return interestRepository{db: db}
+}
+
+// AccrueInterest records a day's interest for every active wallet of the
+// tenant with money in it at the end of the day, pockets included, as its
+// ledger account holds it. Amounts are kept as NUMERIC without rounding. A
+// wallet accrues at most once per date, so the run can be repeated; it
+// returns how many wallets accrued this time. A day of a month already
+// posted gives ErrPeriodPosted.
+func (r interestRepository) AccrueInterest(tenantID string, a InterestAccrual) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var posted bool
+	err = lockInterest(tx, tenantID)
+	if err == nil {
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM interest_postings WHERE tenant_id=$1 AND period = date_trunc('month', $2::date)::date)",
+			tenantID, a.Date.Format(dateLayout)).Scan(&posted)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if posted {
+		return 0, ErrPeriodPosted
+	}
+
+	// Wallets that held money when the ledger started opened with it in a
+	// single entry without a transaction, so their balance before then is
+	// not known.
+	var before bool
+	err = tx.QueryRow(`SELECT EXISTS (
+			SELECT 1 FROM wallets w
+			JOIN ledger_accounts a ON a.wallet_id = w.wallet_id,
+			LATERAL (SELECT e.posted_at, e.transaction_id, e.description FROM journal_lines l JOIN journal_entries e ON e.entry_id = l.entry_id
+				WHERE l.account_id = a.account_id ORDER BY e.entry_id LIMIT 1) first
+			WHERE w.tenant_id=$1 AND w.created_at < $2::timestamptz AND first.posted_at >= $2::timestamptz
+				AND first.transaction_id IS NULL AND first.description='opening balance')`,
+		tenantID, a.Until.UTC()).Scan(&before)
+	if err != nil {
+		return 0, err
+	}
+	if before {
+		return 0, ErrBeforeLedger
+	}
+
+	result, err := tx.Exec(`INSERT INTO interest_accruals (tenant_id, wallet_id, accrual_date, balance, rate, day_count, amount)
+		SELECT w.tenant_id, w.wallet_id, $2, b.total, $3, $4, b.total * $3::numeric / 100 / $5::numeric
+		FROM wallets w
+		JOIN ledger_accounts a ON a.wallet_id = w.wallet_id,
+		LATERAL (SELECT COALESCE(SUM(l.credit - l.debit), 0) AS total FROM journal_lines l JOIN journal_entries e ON e.entry_id = l.entry_id
+			WHERE l.account_id = a.account_id AND e.posted_at < $6::timestamptz) b
+		WHERE w.tenant_id=$1 AND w.wallet_status='Active' AND b.total > 0 AND w.created_at::date <= $2
+		ON CONFLICT (wallet_id, accrual_date) DO NOTHING`,
+		tenantID, a.Date.Format(dateLayout), a.Rate, a.DayCount, a.DaysInYear, a.Until.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+// PostInterest credits each wallet with the interest it accrued in the month
+// starting at period. A wallet is posted at most once per period, so the
+// run can be repeated; it returns only the postings made this time. Closed
+// wallets are not posted.
+func (r interestRepository) PostInterest(tenantID string, period time.Time) ([]InterestPosting, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err = lockInterest(tx, tenantID); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(`WITH due AS (
+			SELECT a.wallet_id, SUM(a.amount) AS accrued,
+				COALESCE((SELECT p.carry_out FROM interest_postings p WHERE p.wallet_id = a.wallet_id AND p.period < $2 ORDER BY p.period DESC LIMIT 1), 0) AS carried_in
+			FROM interest_accruals a
+			JOIN wallets w ON w.wallet_id = a.wallet_id AND w.wallet_status <> 'Closed'
+			WHERE a.tenant_id=$1 AND a.accrual_date >= $2 AND a.accrual_date < ($2::date + interval '1 month')
+			GROUP BY a.wallet_id
+		)
+		INSERT INTO interest_postings (tenant_id, wallet_id, period, accrued, carried_in, amount, carry_out)
+		SELECT $1, wallet_id, $2, accrued, carried_in, trunc(accrued + carried_in, 2), accrued + carried_in - trunc(accrued + carried_in, 2)
+		FROM due
+		ON CONFLICT (wallet_id, period) DO NOTHING
+		RETURNING posting_id, wallet_id, period, accrued, carried_in, amount, carry_out`, tenantID, period.Format(dateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	postings := []InterestPosting{}
+	for rows.Next() {
+		p := InterestPosting{}
+		err = rows.Scan(&p.PostingID, &p.WalletID, &p.Period, &p.Accrued, &p.CarriedIn, &p.Amount, &p.CarryOut)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		postings = append(postings, p)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, p := range postings {
+		if p.Amount == 0 {
+			continue
+		}
+
+		reason := "interest " + p.Period.Format("2006-01")
+		wallet, err := loadWallet(tx, tenantID, p.WalletID)
+		if err != nil {
+			return nil, err
+		}
+		err = wallet.Credit(p.Amount, reason)
+		if err != nil {
+			return nil, err
+		}
+		err = saveWallet(tx, wallet)
+		if err != nil {
+			return nil, err
+		}
+		transaction, err := insertTransaction(tx, tenantID, entry{WalletID: p.WalletID, Amount: p.Amount, Balance: wallet.Balance, Reason: reason})
+		if err != nil {
+			return nil, err
+		}
+		err = postEntry(tx, tenantID, walletEntry(wallet, ledger.InterestExpense, p.Amount, reason, transaction))
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec("UPDATE interest_postings SET transaction_id=$2 WHERE posting_id=$1", p.PostingID, transaction.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		postings[i].TransactionID = &transaction.TransactionID
+	}
+
+	return postings, tx.Commit()
+}
+
+func (r interestRepository) GetInterestProgress(tenantID string) (*InterestProgress, error) {
+	progress := &InterestProgress{Unposted: []time.Time{}}
+	var lastAccrued, lastPosted sql.NullTime
+	err := r.db.QueryRow(`SELECT (SELECT MAX(accrual_date) FROM interest_accruals WHERE tenant_id=$1),
+		(SELECT MAX(period) FROM interest_postings WHERE tenant_id=$1)`, tenantID).Scan(&lastAccrued, &lastPosted)
+	if err != nil {
+		return nil, err
+	}
+	if lastAccrued.Valid {
+		progress.LastAccrued = &lastAccrued.Time
+	}
+	if lastPosted.Valid {
+		progress.LastPosted = &lastPosted.Time
+	}
+
+	rows, err := r.db.Query(`SELECT DISTINCT date_trunc('month', a.accrual_date)::date AS period FROM interest_accruals a
+		WHERE a.tenant_id=$1 AND NOT EXISTS (SELECT 1 FROM interest_postings p WHERE p.tenant_id=$1 AND p.period = date_trunc('month', a.accrual_date)::date)
+		ORDER BY period`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var period time.Time
+		if err = rows.Scan(&period); err != nil {
+			return nil, err
+		}
+		progress.Unposted = append(progress.Unposted, period)
+	}
+
+	return progress, rows.Err()
+}
+
+// lockInterest queues the tenant's accruals and postings behind each other,
+// so a posting cannot miss an accrual committed while it sums the month.
+// The tenant's row is locked without blocking the wallets referencing it.
+func lockInterest(tx *sql.Tx, tenantID string) error {
+	_, err := tx.Exec("SELECT tenant_id FROM tenants WHERE tenant_id=$1 FOR NO KEY UPDATE", tenantID)
+	return err
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type interestRepositoryMock struct {
+	mock.Mock
+}
+
+func NewInterestRepositoryMock() *interestRepositoryMock {
+	return &interestRepositoryMock{}
+}
+
+func (r *interestRepositoryMock) AccrueInterest(tenantID string, a InterestAccrual) (int64, error) {
+	args := r.Called(tenantID, a)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *interestRepositoryMock) GetInterestProgress(tenantID string) (*InterestProgress, error) {
+	args := r.Called(tenantID)
+	return args.Get(0).(*InterestProgress), args.Error(1)
+}
+
+func (r *interestRepositoryMock) PostInterest(tenantID string, period time.Time) ([]InterestPosting, error) {
+	args := r.Called(tenantID, period)
+	return args.Get(0).([]InterestPosting), args.Error(1)
+}
@@ -0,0 +1,53 @@
+package repository
+
+import "time"
+
+// LedgerRepository reads the double-entry ledger. Entries are posted by the
+// other repositories, in the database transaction that moves the money; see
+// package ledger.
+type LedgerRepository interface {
+	GetAccounts(string) ([]LedgerAccount, error)
+	GetAccountTotals(string, LedgerFilter) ([]AccountTotals, error)
+	GetJournalLines(string, int64, time.Time, time.Time, func(JournalLine) error) error
+}
+
+// LedgerAccount is an account of the tenant's chart with the totals of
+// every line posted to it. WalletID is set on wallet accounts.
+type LedgerAccount struct {
+	AccountID int64   `db:"account_id"`
+	Code      string  `db:"account_code"`
+	Name      string  `db:"account_name"`
+	Type      string  `db:"account_type"`
+	Currency  string  `db:"currency"`
+	WalletID  *int64  `db:"wallet_id"`
+	Debits    float64 `db:"debits"`
+	Credits   float64 `db:"credits"`
+}
+
+// LedgerFilter selects accounts by code and currency, empty meaning any,
+// and the period [From, To) their totals are taken over.
+type LedgerFilter struct {
+	Code     string
+	Currency string
+	From     time.Time
+	To       time.Time
+}
+
+// AccountTotals is an account with the totals of the lines posted to it
+// before the period, Opening, and within it, Debits and Credits.
+type AccountTotals struct {
+	LedgerAccount
+	OpeningDebits  float64
+	OpeningCredits float64
+}
+
+// JournalLine is one line posted to an account, with the entry it is part
+// of.
+type JournalLine struct {
+	EntryID       int64     `db:"entry_id"`
+	PostedAt      time.Time `db:"posted_at"`
+	Description   string    `db:"description"`
+	TransactionID *int64    `db:"transaction_id"`
+	Debit         float64   `db:"debit"`
+	Credit        float64   `db:"credit"`
+}
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/aggregate"
+	"github.com/topnarapat/go-wallet/ledger"
+)
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) LedgerRepository {
+	return ledgerRepository{db: db}
+}
+
+// GetAccounts lists the tenant's accounts by currency and code.
+func (r ledgerRepository) GetAccounts(tenantID string) ([]LedgerAccount, error) {
+	rows, err := r.db.Query(`SELECT a.account_id, a.account_code, a.account_name, a.account_type, a.currency, a.wallet_id,
+			COALESCE(sum(l.debit), 0), COALESCE(sum(l.credit), 0)
+		FROM ledger_accounts a LEFT JOIN journal_lines l ON l.account_id = a.account_id
+		WHERE a.tenant_id=$1
+		GROUP BY a.account_id
+		ORDER BY a.currency, a.wallet_id NULLS FIRST, a.account_code`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []LedgerAccount{}
+	for rows.Next() {
+		a := LedgerAccount{}
+		err = rows.Scan(&a.AccountID, &a.Code, &a.Name, &a.Type, &a.Currency, &a.WalletID, &a.Debits, &a.Credits)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, rows.Err()
+}
+
+// GetAccountTotals lists the tenant's accounts the filter selects, ordered
+// like GetAccounts, with their totals before and within the period.
+func (r ledgerRepository) GetAccountTotals(tenantID string, f LedgerFilter) ([]AccountTotals, error) {
+	rows, err := r.db.Query(`SELECT a.account_id, a.account_code, a.account_name, a.account_type, a.currency, a.wallet_id,
+			COALESCE(sum(l.debit) FILTER (WHERE e.posted_at < $4), 0), COALESCE(sum(l.credit) FILTER (WHERE e.posted_at < $4), 0),
+			COALESCE(sum(l.debit) FILTER (WHERE e.posted_at >= $4), 0), COALESCE(sum(l.credit) FILTER (WHERE e.posted_at >= $4), 0)
+		FROM ledger_accounts a
+			LEFT JOIN (journal_lines l JOIN journal_entries e ON e.entry_id = l.entry_id AND e.posted_at < $5) ON l.account_id = a.account_id
+		WHERE a.tenant_id=$1 AND ($2='' OR a.account_code=$2) AND ($3='' OR a.currency=$3)
+		GROUP BY a.account_id
+		ORDER BY a.currency, a.wallet_id NULLS FIRST, a.account_code`, tenantID, f.Code, f.Currency, f.From, f.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []AccountTotals{}
+	for rows.Next() {
+		a := AccountTotals{}
+		err = rows.Scan(&a.AccountID, &a.Code, &a.Name, &a.Type, &a.Currency, &a.WalletID, &a.OpeningDebits, &a.OpeningCredits, &a.Debits, &a.Credits)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, rows.Err()
+}
+
+// GetJournalLines calls each for every line posted to the account in
+// [from, to), in the order they were posted, stopping at the first error.
+func (r ledgerRepository) GetJournalLines(tenantID string, accountID int64, from time.Time, to time.Time, each func(JournalLine) error) error {
+	rows, err := r.db.Query(`SELECT e.entry_id, e.posted_at, e.description, e.transaction_id, l.debit, l.credit
+		FROM journal_lines l JOIN journal_entries e ON e.entry_id = l.entry_id
+		WHERE e.tenant_id=$1 AND l.account_id=$2 AND e.posted_at >= $3 AND e.posted_at < $4
+		ORDER BY e.posted_at, e.entry_id, l.line_id`, tenantID, accountID, from, to)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		line := JournalLine{}
+		err = rows.Scan(&line.EntryID, &line.PostedAt, &line.Description, &line.TransactionID, &line.Debit, &line.Credit)
+		if err != nil {
+			return err
+		}
+		if err = each(line); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// postEntry records a journal entry made by the current database
+// transaction, opening the accounts it names on first use. An unbalanced
+// entry is refused here and, failing that, when the transaction commits.
+func postEntry(tx *sql.Tx, tenantID string, e ledger.Entry) error {
+	err := e.Validate()
+	if err != nil {
+		return err
+	}
+
+	var entryID int64
+	err = tx.QueryRow("INSERT INTO journal_entries (tenant_id, currency, description, transaction_id) VALUES ($1, $2, $3, $4) RETURNING entry_id",
+		tenantID, e.Currency, e.Description, e.TransactionID).Scan(&entryID)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range e.Lines {
+		accountID, err := ledgerAccountID(tx, tenantID, e.Currency, l.Account)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO journal_lines (entry_id, account_id, debit, credit) VALUES ($1, $2, $3, $4)",
+			entryID, accountID, float64(cents(l.Debit))/100, float64(cents(l.Credit))/100)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postImports posts the imported wallets' balances, which the caller has
+// already written to their rows, each as an entry from suspense into the
+// wallet dated when the wallet was created. It opens the accounts and
+// writes the entries and their lines a statement each, however many
+// wallets there are.
+func postImports(tx *sql.Tx, tenantID string, wallets []*aggregate.Wallet) error {
+	ids := []int64{}
+	currencies := map[string]bool{}
+	for _, w := range wallets {
+		if w.Balance > 0 {
+			ids = append(ids, w.WalletID)
+			currencies[w.Currency] = true
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	for currency := range currencies {
+		if _, err := ledgerAccountID(tx, tenantID, currency, ledger.Suspense); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(`INSERT INTO ledger_accounts (tenant_id, currency, account_code, account_name, account_type, wallet_id)
+		SELECT tenant_id, currency, $3 || '-' || wallet_id, 'Wallet ' || account_number, $4, wallet_id FROM wallets WHERE tenant_id=$1 AND wallet_id = ANY($2)
+		ON CONFLICT DO NOTHING`, tenantID, pq.Array(ids), ledger.Wallets, ledger.Liability)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`WITH imported AS MATERIALIZED (
+			SELECT w.wallet_id, w.currency, w.created_at, round(w.balance::numeric, 2) AS amount,
+				nextval(pg_get_serial_sequence('journal_entries', 'entry_id')) AS entry_id
+			FROM wallets w WHERE w.tenant_id=$1 AND w.wallet_id = ANY($2)
+		), entries AS (
+			INSERT INTO journal_entries (entry_id, tenant_id, currency, description, posted_at)
+			SELECT entry_id, $1, currency, 'import', created_at AT TIME ZONE 'UTC' FROM imported
+		)
+		INSERT INTO journal_lines (entry_id, account_id, debit, credit)
+		SELECT i.entry_id, s.account_id, i.amount, 0 FROM imported i
+			JOIN ledger_accounts s ON s.tenant_id=$1 AND s.currency = i.currency AND s.account_code=$3
+		UNION ALL
+		SELECT i.entry_id, a.account_id, 0, i.amount FROM imported i
+			JOIN ledger_accounts a ON a.wallet_id = i.wallet_id`, tenantID, pq.Array(ids), ledger.Suspense)
+	return err
+}
+
+// ledgerAccountID returns the id of the tenant's account with the code in
+// currency, opening it if it is a system account or a wallet's account not
+// opened yet.
+func ledgerAccountID(tx *sql.Tx, tenantID string, currency string, code string) (int64, error) {
+	const find = "SELECT account_id FROM ledger_accounts WHERE tenant_id=$1 AND currency=$2 AND account_code=$3"
+
+	var id int64
+	err := tx.QueryRow(find, tenantID, currency, code).Scan(&id)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return id, err
+	}
+
+	if walletID, ok := ledger.WalletID(code); ok {
+		_, err = tx.Exec(`INSERT INTO ledger_accounts (tenant_id, currency, account_code, account_name, account_type, wallet_id)
+			SELECT tenant_id, currency, $3, 'Wallet ' || account_number, $4, wallet_id FROM wallets WHERE tenant_id=$1 AND currency=$2 AND wallet_id=$5
+			ON CONFLICT DO NOTHING`, tenantID, currency, code, ledger.Liability, walletID)
+	} else {
+		account, ok := systemAccount(code)
+		if !ok {
+			return 0, fmt.Errorf("ledger: no account %s", code)
+		}
+		_, err = tx.Exec("INSERT INTO ledger_accounts (tenant_id, currency, account_code, account_name, account_type) VALUES ($1, $2, $3, $4, $5) ON CONFLICT DO NOTHING",
+			tenantID, currency, account.Code, account.Name, account.Type)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Another transaction may have opened it first; either way it is there
+	// now, unless a wallet's account was asked for in the wrong currency.
+	err = tx.QueryRow(find, tenantID, currency, code).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("ledger: no account %s in %s", code, currency)
+	}
+	return id, err
+}
+
+func systemAccount(code string) (ledger.Account, bool) {
+	for _, a := range ledger.SystemAccounts {
+		if a.Code == code {
+			return a, true
+		}
+	}
+	return ledger.Account{}, false
+}
+
+// walletEntry is an entry moving amount between the wallet's account and
+// another: into the wallet for a positive amount, out of it for a negative
+// one.
+func walletEntry(w *aggregate.Wallet, other string, amount float64, description string, transaction *Transaction) ledger.Entry {
+	e := ledger.Transfer(w.Currency, description, other, ledger.WalletAccount(w.WalletID), amount)
+	if amount < 0 {
+		e = ledger.Transfer(w.Currency, description, ledger.WalletAccount(w.WalletID), other, -amount)
+	}
+	if transaction != nil {
+		e.TransactionID = &transaction.TransactionID
+	}
+	return e
+}
+
+// cashEntry is a deposit from cash into the wallet, or a withdrawal to cash
+// for a negative amount.
+func cashEntry(w *aggregate.Wallet, amount float64, transaction *Transaction) ledger.Entry {
+	if amount < 0 {
+		return walletEntry(w, ledger.Cash, amount, "withdrawal", transaction)
+	}
+	return walletEntry(w, ledger.Cash, amount, "deposit", transaction)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ledgerRepositoryMock struct {
+	mock.Mock
+}
+
+func NewLedgerRepositoryMock() *ledgerRepositoryMock {
+	return &ledgerRepositoryMock{}
+}
+
+func (r *ledgerRepositoryMock) GetAccounts(tenantID string) ([]LedgerAccount, error) {
+	args := r.Called(tenantID)
+	return args.Get(0).([]LedgerAccount), args.Error(1)
+}
+
+func (r *ledgerRepositoryMock) GetAccountTotals(tenantID string, f LedgerFilter) ([]AccountTotals, error) {
+	args := r.Called(tenantID, f)
+	return args.Get(0).([]AccountTotals), args.Error(1)
+}
+
+// GetJournalLines calls each with the lines the mock returns.
+func (r *ledgerRepositoryMock) GetJournalLines(tenantID string, accountID int64, from time.Time, to time.Time, each func(JournalLine) error) error {
+	args := r.Called(tenantID, accountID, from, to)
+	for _, l := range args.Get(0).([]JournalLine) {
+		if err := each(l); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/topnarapat/go-wallet/aggregate"
+)
+
+var (
+	// ErrPocketNotFound is returned when the pocket does not exist under the
+	// wallet.
+	ErrPocketNotFound = errors.New("pocket not found")
+	// ErrPocketNameTaken is returned when an open pocket of the wallet
+	// already uses the name.
+	ErrPocketNameTaken = errors.New("pocket name already in use")
+	// ErrInsufficientFunds is returned when a debit or move would take the
+	// source below zero.
+	ErrInsufficientFunds = aggregate.ErrInsufficientFunds
+	// ErrInactive is returned when the wallet or pocket of a move is not
+	// active.
+	ErrInactive = errors.New("wallet or pocket is not active")
+)
+
+// PocketRepository manages pockets of a wallet. Every method is scoped to
+// the tenant and the parent wallet, so a pocket id of another wallet behaves
+// as if it did not exist.
+type PocketRepository interface {
+	GetPockets(string, int64) ([]Pocket, error)
+	CreatePocket(string, NewPocket) (*Pocket, error)
+	MovePocketFunds(string, PocketMove) (*Pocket, error)
+	ClosePocket(string, int64, int64) (*Pocket, error)
+}
+
+type Pocket struct {
+	PocketID  int64      `db:"pocket_id"`
+	WalletID  int64      `db:"wallet_id"`
+	Name      string     `db:"pocket_name"`
+	Balance   float64    `db:"balance"`
+	Status    string     `db:"pocket_status"`
+	CreatedAt time.Time  `db:"created_at"`
+	ClosedAt  *time.Time `db:"closed_at"`
+}
+
+type NewPocket struct {
+	WalletID int64
+	Name     string
+}
+
+// PocketMove moves Amount from the wallet into the pocket; a negative
+// Amount moves funds back to the wallet.
+type PocketMove struct {
+	WalletID int64
+	PocketID int64
+	Amount   float64
+}
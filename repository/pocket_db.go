@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/aggregate"
+)
+
+const pocketColumns = "pocket_id, wallet_id, pocket_name, balance, pocket_status, created_at, closed_at"
+
+type pocketRepository struct {
+	db *sql.DB
+}
+
+func NewPocketRepository(db *sql.DB) PocketRepository {
+	return pocketRepository{db: db}
+}
+
+func scanPocket(row scanner) (*Pocket, error) {
+	pocket := Pocket{}
+	err := row.Scan(&pocket.PocketID, &pocket.WalletID, &pocket.Name, &pocket.Balance, &pocket.Status, &pocket.CreatedAt, &pocket.ClosedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pocket, nil
+}
+
+func (r pocketRepository) GetPockets(tenantID string, walletID int64) ([]Pocket, error) {
+	rows, err := r.db.Query("SELECT "+pocketColumns+" FROM pockets WHERE wallet_id=(SELECT wallet_id FROM wallets WHERE tenant_id=$1 AND wallet_id=$2) ORDER BY pocket_id", tenantID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pockets := []Pocket{}
+	for rows.Next() {
+		p, err := scanPocket(rows)
+		if err != nil {
+			return nil, err
+		}
+		pockets = append(pockets, *p)
+	}
+
+	return pockets, rows.Err()
+}
+
+// CreatePocket opens a pocket with the wallet's current status, so a pocket
+// created under a deactivated wallet starts deactivated too.
+func (r pocketRepository) CreatePocket(tenantID string, p NewPocket) (*Pocket, error) {
+	row := r.db.QueryRow("INSERT INTO pockets (wallet_id, pocket_name, pocket_status) SELECT wallet_id, $3, wallet_status FROM wallets WHERE tenant_id=$1 AND wallet_id=$2 AND wallet_status<>'Closed' RETURNING "+pocketColumns, tenantID, p.WalletID, p.Name)
+	pocket, err := scanPocket(row)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, ErrPocketNameTaken
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		var status string
+		err = r.db.QueryRow("SELECT wallet_status FROM wallets WHERE tenant_id=$1 AND wallet_id=$2", tenantID, p.WalletID).Scan(&status)
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrWalletClosed
+	}
+
+	return pocket, err
+}
+
+// MovePocketFunds moves money between a wallet and one of its pockets in a
+// single transaction. The wallet row is always locked before the pocket.
+func (r pocketRepository) MovePocketFunds(tenantID string, m PocketMove) (*Pocket, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	wallet, pocket, err := lockPocket(tx, tenantID, m.WalletID, m.PocketID)
+	if err != nil {
+		return nil, err
+	}
+	if m.Amount < 0 && pocket.Balance < -m.Amount {
+		return nil, ErrInsufficientFunds
+	}
+
+	err = adjust(wallet, -m.Amount, "pocket")
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	pocket, err = scanPocket(tx.QueryRow("UPDATE pockets SET balance=balance+$2 WHERE pocket_id=$1 RETURNING "+pocketColumns, m.PocketID, m.Amount))
+	if err != nil {
+		return nil, err
+	}
+
+	return pocket, tx.Commit()
+}
+
+// ClosePocket sweeps the pocket's balance back into its wallet and closes it.
+func (r pocketRepository) ClosePocket(tenantID string, walletID int64, pocketID int64) (*Pocket, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	wallet, pocket, err := lockPocket(tx, tenantID, walletID, pocketID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pocket.Balance != 0 {
+		err = wallet.Credit(pocket.Balance, "pocket")
+		if err != nil {
+			return nil, err
+		}
+		err = saveWallet(tx, wallet)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	pocket, err = scanPocket(tx.QueryRow("UPDATE pockets SET balance=0, pocket_status='Closed', closed_at=now() WHERE pocket_id=$1 RETURNING "+pocketColumns, pocketID))
+	if err != nil {
+		return nil, err
+	}
+
+	return pocket, tx.Commit()
+}
+
+// lockPocket locks the wallet and then the pocket, and checks both are
+// active. It returns the wallet's aggregate alongside the pocket.
+func lockPocket(tx *sql.Tx, tenantID string, walletID int64, pocketID int64) (*aggregate.Wallet, *Pocket, error) {
+	wallet, err := loadWallet(tx, tenantID, walletID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if wallet.Status == StatusClosed {
+		return nil, nil, ErrWalletClosed
+	}
+
+	pocket, err := scanPocket(tx.QueryRow("SELECT "+pocketColumns+" FROM pockets WHERE wallet_id=$1 AND pocket_id=$2 FOR UPDATE", walletID, pocketID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, ErrPocketNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if wallet.Status != StatusActive || pocket.Status != StatusActive {
+		return nil, nil, ErrInactive
+	}
+
+	return wallet, pocket, nil
+}
@@ -0,0 +1,31 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type pocketRepositoryMock struct {
+	mock.Mock
+}
+
+func NewPocketRepositoryMock() *pocketRepositoryMock {
+	return &pocketRepositoryMock{}
+}
+
+func (r *pocketRepositoryMock) GetPockets(tenantID string, walletID int64) ([]Pocket, error) {
+	args := r.Called(tenantID, walletID)
+	return args.Get(0).([]Pocket), args.Error(1)
+}
+
+func (r *pocketRepositoryMock) CreatePocket(tenantID string, p NewPocket) (*Pocket, error) {
+	args := r.Called(tenantID, p)
+	return args.Get(0).(*Pocket), args.Error(1)
+}
+
+func (r *pocketRepositoryMock) MovePocketFunds(tenantID string, m PocketMove) (*Pocket, error) {
+	args := r.Called(tenantID, m)
+	return args.Get(0).(*Pocket), args.Error(1)
+}
+
+func (r *pocketRepositoryMock) ClosePocket(tenantID string, walletID int64, pocketID int64) (*Pocket, error) {
+	args := r.Called(tenantID, walletID, pocketID)
+	return args.Get(0).(*Pocket), args.Error(1)
+}
@@ -0,0 +1,36 @@
+package repository
+
+import "encoding/json"
+
+type RiskRepository interface {
+	GetWalletActivity(string, int64) (*WalletActivity, error)
+	RecordEvaluations(string, []RiskEvaluation) error
+}
+
+// WalletActivity summarises a wallet's recent debits for the risk rules.
+// Averages and maxima are 0 when the wallet has no debits in the window.
+type WalletActivity struct {
+	AgeHours       float64
+	DebitCount1h   int64
+	DebitAmount1h  float64
+	DebitCount24h  int64
+	DebitAmount24h float64
+	DebitCount30d  int64
+	AvgDebit30d    float64
+	MaxDebit30d    float64
+}
+
+// RiskEvaluation records one rule evaluated for one debit, together with
+// the decision the engine reached and the facts it was given.
+type RiskEvaluation struct {
+	WalletID   int64           `db:"wallet_id"`
+	Operation  string          `db:"operation"`
+	Amount     float64         `db:"amount"`
+	Rule       string          `db:"rule_name"`
+	Expression string          `db:"expression"`
+	Outcome    string          `db:"outcome"`
+	Matched    bool            `db:"matched"`
+	Error      string          `db:"error"`
+	Decision   string          `db:"decision"`
+	Facts      json.RawMessage `db:"facts"`
+}
@@ -0,0 +1,61 @@
+package repository
+
+import "database/sql"
+
+type riskRepository struct {
+	db *sql.DB
+}
+
+func NewRiskRepository(db *sql.DB) RiskRepository {
+	return riskRepository{db: db}
+}
+
+// GetWalletActivity aggregates the wallet's debits over the last 30 days,
+// with the 1 hour and 24 hour windows taken from the same scan. Only money
+// leaving the wallet counts: moves into its own pockets record no
+// transaction, and fees are charged on a debit already counted.
+func (r riskRepository) GetWalletActivity(tenantID string, walletID int64) (*WalletActivity, error) {
+	a := WalletActivity{}
+	err := r.db.QueryRow(`SELECT
+		EXTRACT(EPOCH FROM now()::timestamp - w.created_at) / 3600,
+		COUNT(t.transaction_id) FILTER (WHERE t.created_at > now() - interval '1 hour'),
+		COALESCE(SUM(t.amount) FILTER (WHERE t.created_at > now() - interval '1 hour'), 0),
+		COUNT(t.transaction_id) FILTER (WHERE t.created_at > now() - interval '24 hours'),
+		COALESCE(SUM(t.amount) FILTER (WHERE t.created_at > now() - interval '24 hours'), 0),
+		COUNT(t.transaction_id),
+		COALESCE(AVG(t.amount), 0),
+		COALESCE(MAX(t.amount), 0)
+		FROM wallets w
+		LEFT JOIN transactions t ON t.tenant_id = w.tenant_id AND t.wallet_id = w.wallet_id AND t.operation = 'Deduct' AND t.fee_of IS NULL AND t.created_at > now() - interval '30 days'
+		WHERE w.tenant_id=$1 AND w.wallet_id=$2
+		GROUP BY w.wallet_id, w.created_at`, tenantID, walletID).
+		Scan(&a.AgeHours, &a.DebitCount1h, &a.DebitAmount1h, &a.DebitCount24h, &a.DebitAmount24h, &a.DebitCount30d, &a.AvgDebit30d, &a.MaxDebit30d)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+func (r riskRepository) RecordEvaluations(tenantID string, evaluations []RiskEvaluation) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare("INSERT INTO risk_evaluations (tenant_id, wallet_id, operation, amount, rule_name, expression, outcome, matched, error, decision, facts) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range evaluations {
+		_, err = stmt.Exec(tenantID, e.WalletID, e.Operation, e.Amount, e.Rule, e.Expression, e.Outcome, e.Matched, e.Error, e.Decision, []byte(e.Facts))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
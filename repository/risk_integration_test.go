@@ -0,0 +1,64 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+func TestGetWalletActivityIntegration(t *testing.T) {
+	db, err := sql.Open("postgres", "postgresql://root:root@db/wallets?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Arrange
+	walletRepo := repository.NewWalletRepository(db)
+	pocketRepo := repository.NewPocketRepository(db)
+	wallet, err := walletRepo.CreateNewWallet("default", repository.NewWallet{Balance: 1000, Currency: "THB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revenue, err := walletRepo.CreateNewWallet("default", repository.NewWallet{Currency: "THB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pocket, err := pocketRepo.CreatePocket("default", repository.NewPocket{WalletID: wallet.WalletID, Name: "savings"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pocketRepo.MovePocketFunds("default", repository.PocketMove{WalletID: wallet.WalletID, PocketID: pocket.PocketID, Amount: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = pocketRepo.MovePocketFunds("default", repository.PocketMove{WalletID: wallet.WalletID, PocketID: pocket.PocketID, Amount: -100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, err = walletRepo.GetWallet("default", wallet.WalletID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = walletRepo.DeductWithFee("default", repository.FeeDeduction{WalletID: wallet.WalletID, Amount: 200, Fee: 5, RevenueWalletID: revenue.WalletID, Version: wallet.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	activity, err := repository.NewRiskRepository(db).GetWalletActivity("default", wallet.WalletID)
+
+	// Assert
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(1), activity.DebitCount1h)
+		assert.Equal(t, 200.0, activity.DebitAmount1h)
+		assert.Equal(t, int64(1), activity.DebitCount30d)
+		assert.Equal(t, 200.0, activity.MaxDebit30d)
+	}
+}
@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type riskRepositoryMock struct {
+	mock.Mock
+}
+
+func NewRiskRepositoryMock() *riskRepositoryMock {
+	return &riskRepositoryMock{}
+}
+
+func (r *riskRepositoryMock) GetWalletActivity(tenantID string, walletID int64) (*WalletActivity, error) {
+	args := r.Called(tenantID, walletID)
+	return args.Get(0).(*WalletActivity), args.Error(1)
+}
+
+func (r *riskRepositoryMock) RecordEvaluations(tenantID string, evaluations []RiskEvaluation) error {
+	args := r.Called(tenantID, evaluations)
+	return args.Error(0)
+}
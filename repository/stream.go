@@ -0,0 +1,12 @@
+package repository
+
+import "github.com/topnarapat/go-wallet/aggregate"
+
+// StreamRepository reads the wallet streams in the event store and rebuilds
+// the wallets read model from them. Wallets are written only by appending
+// to their stream; see loadWallet and saveWallet.
+type StreamRepository interface {
+	GetStream(string, int64) ([]aggregate.Event, error)
+	GetStreamIDs(string) ([]int64, error)
+	RebuildWallet(int64) (bool, error)
+}
@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/aggregate"
+)
+
+const streamColumns = "stream_id, tenant_id, stream_version, event_type, data, recorded_at"
+
+// snapshotEvery is how many events a stream grows by between snapshots.
+const snapshotEvery = 100
+
+type streamRepository struct {
+	db *sql.DB
+}
+
+func NewStreamRepository(db *sql.DB) StreamRepository {
+	return streamRepository{db: db}
+}
+
+func scanEvent(row scanner) (*aggregate.Event, error) {
+	e := aggregate.Event{}
+	var data []byte
+	err := row.Scan(&e.WalletID, &e.TenantID, &e.Version, &e.Type, &data, &e.RecordedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// GetStream returns the wallet's events in order, or sql.ErrNoRows when the
+// tenant has no such wallet.
+func (r streamRepository) GetStream(tenantID string, walletID int64) ([]aggregate.Event, error) {
+	rows, err := r.db.Query("SELECT "+streamColumns+" FROM event_store WHERE tenant_id=$1 AND stream_id=$2 ORDER BY stream_version", tenantID, walletID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStream(rows)
+}
+
+// GetStreamIDs lists the wallets of the tenant, or of every tenant when it
+// is empty, that have a stream.
+func (r streamRepository) GetStreamIDs(tenantID string) ([]int64, error) {
+	rows, err := r.db.Query("SELECT DISTINCT stream_id FROM event_store WHERE $1='' OR tenant_id=$1 ORDER BY stream_id", tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// RebuildWallet replays the wallet's stream and rewrites its row and labels
+// when they differ from the result. It reports whether they did.
+func (r streamRepository) RebuildWallet(walletID int64) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	current, err := scanWallet(tx.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE wallet_id=$1 FOR UPDATE", walletID))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	rows, err := tx.Query("SELECT "+streamColumns+" FROM event_store WHERE stream_id=$1 ORDER BY stream_version", walletID)
+	if err != nil {
+		return false, err
+	}
+	events, err := scanStream(rows)
+	if err != nil {
+		return false, err
+	}
+
+	w, err := aggregate.Rebuild(events)
+	if err != nil {
+		return false, err
+	}
+	if current != nil && projected(current, w) {
+		return false, nil
+	}
+
+	err = project(tx, w, current == nil, true)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func scanStream(rows *sql.Rows) ([]aggregate.Event, error) {
+	defer rows.Close()
+
+	events := []aggregate.Event{}
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return events, nil
+}
+
+// loadWallet locks the wallet's row, so writers of one wallet queue rather
+// than collide, and rebuilds its aggregate from the stream: from the latest
+// snapshot, if there is one, and the events after it.
+func loadWallet(tx *sql.Tx, tenantID string, id int64) (*aggregate.Wallet, error) {
+	var publicID, accountNumber string
+	err := tx.QueryRow("SELECT public_id, account_number FROM wallets WHERE tenant_id=$1 AND wallet_id=$2 FOR UPDATE", tenantID, id).Scan(&publicID, &accountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &aggregate.Wallet{WalletID: id, TenantID: tenantID}
+	var state []byte
+	err = tx.QueryRow("SELECT state FROM stream_snapshots WHERE stream_id=$1", id).Scan(&state)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err == nil {
+		if err = json.Unmarshal(state, w); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query("SELECT "+streamColumns+" FROM event_store WHERE stream_id=$1 AND stream_version>$2 ORDER BY stream_version", id, w.Version)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		e, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		if err = w.Apply(*e); err != nil {
+			return nil, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if w.Version == 0 {
+		return nil, fmt.Errorf("wallet %d has no stream", id)
+	}
+
+	// Streams started before wallets had public ids or account numbers do
+	// not carry them; the row has them.
+	if w.PublicID == "" {
+		w.PublicID = publicID
+	}
+	if w.AccountNumber == "" {
+		w.AccountNumber = accountNumber
+	}
+
+	return w, nil
+}
+
+// saveWallet appends the wallet's new events to its stream and projects
+// them onto the wallets read model. Another writer having appended at the
+// same version first fails with ErrVersionConflict.
+func saveWallet(tx *sql.Tx, w *aggregate.Wallet) error {
+	changes := w.Changes()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	labels := false
+	for _, e := range changes {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO event_store (stream_id, tenant_id, stream_version, event_type, data, recorded_at) VALUES ($1, $2, $3, $4, $5, $6)", e.WalletID, e.TenantID, e.Version, e.Type, string(data), e.RecordedAt)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrVersionConflict
+		}
+		if err != nil {
+			return err
+		}
+
+		labels = labels || e.Type == aggregate.WalletCreated || e.Type == aggregate.WalletUpdated
+	}
+
+	err := project(tx, w, changes[0].Type == aggregate.WalletCreated, labels)
+	if err != nil {
+		return err
+	}
+
+	if w.Version/snapshotEvery > (w.Version-int64(len(changes)))/snapshotEvery {
+		state, err := json.Marshal(w)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO stream_snapshots (stream_id, stream_version, state) VALUES ($1, $2, $3)
+			ON CONFLICT (stream_id) DO UPDATE SET stream_version=EXCLUDED.stream_version, state=EXCLUDED.state, taken_at=EXCLUDED.taken_at
+			WHERE stream_snapshots.stream_version < EXCLUDED.stream_version`, w.WalletID, w.Version, string(state))
+		if err != nil {
+			return err
+		}
+	}
+
+	w.Saved()
+	return nil
+}
+
+// project writes the wallet's state to its row, inserting the row for a new
+// wallet, and replaces its labels when they may have changed.
+func project(tx *sql.Tx, w *aggregate.Wallet, insert bool, labels bool) error {
+	var closedAt, closureReason interface{}
+	if w.ClosedAt != nil {
+		closedAt = w.ClosedAt.UTC()
+	}
+	if w.ClosureReason != "" {
+		closureReason = w.ClosureReason
+	}
+
+	// Streams started before wallets had public ids or account numbers do
+	// not carry them; their row keeps, or is given, its own.
+	query := "UPDATE wallets SET tenant_id=$2, currency=$3, balance=$4, wallet_status=$5, version=$6, created_at=$7, closed_at=$8, closure_reason=$9, metadata=$10, " +
+		"public_id=COALESCE(NULLIF($11, ''), public_id), account_number=COALESCE(NULLIF($12, ''), account_number) WHERE wallet_id=$1"
+	if insert {
+		query = "INSERT INTO wallets (wallet_id, tenant_id, currency, balance, wallet_status, version, created_at, closed_at, closure_reason, metadata, public_id, account_number) " +
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, COALESCE(NULLIF($11, ''), 'wal_' || encode(gen_random_bytes(16), 'hex')), COALESCE(NULLIF($12, ''), new_account_number()))"
+	}
+	_, err := tx.Exec(query, w.WalletID, w.TenantID, w.Currency, w.Balance, w.Status, w.Version, w.CreatedAt.UTC(), closedAt, closureReason, string(w.Metadata), w.PublicID, w.AccountNumber)
+	if err != nil || !labels {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM wallet_labels WHERE wallet_id=$1", w.WalletID)
+	if err != nil {
+		return err
+	}
+	return setLabels(tx, w.WalletID, w.Labels)
+}
+
+// projected reports whether the row already shows the wallet's state.
+func projected(row *Wallet, w *aggregate.Wallet) bool {
+	var rowMetadata, metadata interface{}
+	json.Unmarshal(row.Metadata, &rowMetadata)
+	json.Unmarshal(w.Metadata, &metadata)
+
+	closedAtMatches := row.ClosedAt == nil && w.ClosedAt == nil ||
+		row.ClosedAt != nil && w.ClosedAt != nil && row.ClosedAt.Equal(*w.ClosedAt)
+
+	return (w.PublicID == "" || row.PublicID == w.PublicID) &&
+		(w.AccountNumber == "" || row.AccountNumber == w.AccountNumber) &&
+		row.TenantID == w.TenantID &&
+		row.Currency == w.Currency &&
+		row.Balance == w.Balance &&
+		row.Status == w.Status &&
+		row.Version == w.Version &&
+		row.CreatedAt.Equal(w.CreatedAt) &&
+		closedAtMatches &&
+		row.ClosureReason == w.ClosureReason &&
+		reflect.DeepEqual(rowMetadata, metadata) &&
+		len(row.Labels) == len(w.Labels) && (len(w.Labels) == 0 || reflect.DeepEqual(row.Labels, w.Labels))
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/aggregate"
+)
+
+type streamRepositoryMock struct {
+	mock.Mock
+}
+
+func NewStreamRepositoryMock() *streamRepositoryMock {
+	return &streamRepositoryMock{}
+}
+
+func (r *streamRepositoryMock) GetStream(tenantID string, walletID int64) ([]aggregate.Event, error) {
+	args := r.Called(tenantID, walletID)
+	return args.Get(0).([]aggregate.Event), args.Error(1)
+}
+
+func (r *streamRepositoryMock) GetStreamIDs(tenantID string) ([]int64, error) {
+	args := r.Called(tenantID)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (r *streamRepositoryMock) RebuildWallet(walletID int64) (bool, error) {
+	args := r.Called(walletID)
+	return args.Bool(0), args.Error(1)
+}
@@ -0,0 +1,23 @@
+package repository
+
+// Tenant holds the per-tenant configuration. A nil limit means unlimited.
+type Tenant struct {
+	TenantID             string   `db:"tenant_id"`
+	Name                 string   `db:"tenant_name"`
+	DefaultCurrency      string   `db:"default_currency"`
+	MaxBalance           *float64 `db:"max_balance"`
+	MaxTransactionAmount *float64 `db:"max_transaction_amount"`
+	ApprovalThreshold    *float64 `db:"approval_threshold"`
+	// InterestRate is the annual interest rate in percent paid on wallet
+	// balances; nil pays no interest.
+	InterestRate     *float64 `db:"interest_rate"`
+	InterestDayCount string   `db:"interest_day_count"`
+	// PromptPayBillerID is the biller id wallets are topped up through by
+	// PromptPay; empty when the tenant has none.
+	PromptPayBillerID string `db:"promptpay_biller_id"`
+}
+
+type TenantRepository interface {
+	GetTenant(string) (*Tenant, error)
+	GetTenants() ([]Tenant, error)
+}
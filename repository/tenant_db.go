@@ -0,0 +1,61 @@
+package repository
+
+import "database/sql"
+
+const tenantColumns = "tenant_id, tenant_name, default_currency, max_balance, max_transaction_amount, approval_threshold, interest_rate, interest_day_count, promptpay_biller_id"
+
+type tenantRepository struct {
+	db *sql.DB
+}
+
+func NewTenantRepository(db *sql.DB) TenantRepository {
+	return tenantRepository{db: db}
+}
+
+func (r tenantRepository) GetTenant(id string) (*Tenant, error) {
+	return scanTenant(r.db.QueryRow("SELECT "+tenantColumns+" FROM tenants WHERE tenant_id=$1", id))
+}
+
+func (r tenantRepository) GetTenants() ([]Tenant, error) {
+	rows, err := r.db.Query("SELECT " + tenantColumns + " FROM tenants ORDER BY tenant_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tenants := []Tenant{}
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, *tenant)
+	}
+
+	return tenants, rows.Err()
+}
+
+func scanTenant(row scanner) (*Tenant, error) {
+	tenant := Tenant{}
+	var maxBalance, maxTransaction, approvalThreshold, interestRate sql.NullFloat64
+	var billerID sql.NullString
+	err := row.Scan(&tenant.TenantID, &tenant.Name, &tenant.DefaultCurrency, &maxBalance, &maxTransaction, &approvalThreshold, &interestRate, &tenant.InterestDayCount, &billerID)
+	if err != nil {
+		return nil, err
+	}
+	if maxBalance.Valid {
+		tenant.MaxBalance = &maxBalance.Float64
+	}
+	if maxTransaction.Valid {
+		tenant.MaxTransactionAmount = &maxTransaction.Float64
+	}
+	if approvalThreshold.Valid {
+		tenant.ApprovalThreshold = &approvalThreshold.Float64
+	}
+	if interestRate.Valid {
+		tenant.InterestRate = &interestRate.Float64
+	}
+	tenant.PromptPayBillerID = billerID.String
+
+	return &tenant, nil
+}
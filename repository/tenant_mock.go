@@ -0,0 +1,21 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type tenantRepositoryMock struct {
+	mock.Mock
+}
+
+func NewTenantRepositoryMock() *tenantRepositoryMock {
+	return &tenantRepositoryMock{}
+}
+
+func (r *tenantRepositoryMock) GetTenant(id string) (*Tenant, error) {
+	args := r.Called(id)
+	return args.Get(0).(*Tenant), args.Error(1)
+}
+
+func (r *tenantRepositoryMock) GetTenants() ([]Tenant, error) {
+	args := r.Called()
+	return args.Get(0).([]Tenant), args.Error(1)
+}
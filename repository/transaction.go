@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	TransactionAdd    = "Add"
+	TransactionDeduct = "Deduct"
+)
+
+var (
+	// ErrTransactionNotFound is returned when the transaction does not exist
+	// for the tenant.
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrTransactionReversed is returned when reversing a transaction whose
+	// full amount has already been reversed.
+	ErrTransactionReversed = errors.New("transaction already reversed")
+	// ErrNotReversible is returned when reversing a transaction that is
+	// itself a reversal, a fee or one leg of a transfer.
+	ErrNotReversible = errors.New("transaction is not reversible")
+	// ErrReversalExceedsOriginal is returned when a reversal would take the
+	// total reversed above the original amount.
+	ErrReversalExceedsOriginal = errors.New("reversal exceeds original amount")
+)
+
+type TransactionRepository interface {
+	GetTransactions(string, int64) ([]Transaction, error)
+	GetTransaction(string, int64) (*Transaction, error)
+	ReverseTransaction(string, TransactionReversal) (*Transaction, error)
+}
+
+// Transaction is one balance change of a wallet. Amount is always positive;
+// Operation gives the direction. A reversal moves money the opposite way to
+// the transaction named by ReversalOf; a fee charged on a transaction, and
+// its credit to the revenue wallet, name it in FeeOf. Both legs of a transfer
+// name the other wallet in CounterpartyWalletID.
+type Transaction struct {
+	TransactionID int64   `db:"transaction_id"`
+	TenantID      string  `db:"tenant_id"`
+	WalletID      int64   `db:"wallet_id"`
+	Operation     string  `db:"operation"`
+	Amount        float64 `db:"amount"`
+	Balance       float64 `db:"balance"`
+	ReversalOf    *int64  `db:"reversal_of"`
+	FeeOf         *int64  `db:"fee_of"`
+	// CounterpartyWalletID is the other wallet of a transfer.
+	CounterpartyWalletID *int64    `db:"counterparty_wallet_id"`
+	ReversedAmount       float64   `db:"reversed_amount"`
+	Reason               string    `db:"reason"`
+	CreatedAt            time.Time `db:"created_at"`
+}
+
+// TransactionReversal reverses Amount of the transaction, or everything not
+// yet reversed when Amount is 0.
+type TransactionReversal struct {
+	TransactionID int64
+	Amount        float64
+	Reason        string
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+
+	"github.com/topnarapat/go-wallet/ledger"
+)
+
+const transactionColumns = "transaction_id, tenant_id, wallet_id, operation, amount, balance, reversal_of, fee_of, counterparty_wallet_id, reversed_amount, reason, created_at"
+
+type transactionRepository struct {
+	db *sql.DB
+}
+
+func NewTransactionRepository(db *sql.DB) TransactionRepository {
+	return transactionRepository{db: db}
+}
+
+func scanTransaction(row scanner) (*Transaction, error) {
+	t := Transaction{}
+	err := row.Scan(&t.TransactionID, &t.TenantID, &t.WalletID, &t.Operation, &t.Amount, &t.Balance, &t.ReversalOf, &t.FeeOf, &t.CounterpartyWalletID, &t.ReversedAmount, &t.Reason, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// entry is a balance change about to be recorded. Amount is positive for a
+// credit and negative for a debit; Balance is the wallet's balance after it.
+type entry struct {
+	WalletID     int64
+	Amount       float64
+	Balance      float64
+	ReversalOf   *int64
+	FeeOf        *int64
+	Counterparty *int64
+	Reason       string
+}
+
+// insertTransaction records a balance change made by the current database
+// transaction.
+func insertTransaction(tx *sql.Tx, tenantID string, e entry) (*Transaction, error) {
+	operation := TransactionAdd
+	if e.Amount < 0 {
+		operation = TransactionDeduct
+	}
+
+	return scanTransaction(tx.QueryRow("INSERT INTO transactions (tenant_id, wallet_id, operation, amount, balance, reversal_of, fee_of, counterparty_wallet_id, reason) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING "+transactionColumns,
+		tenantID, e.WalletID, operation, math.Abs(e.Amount), e.Balance, e.ReversalOf, e.FeeOf, e.Counterparty, e.Reason))
+}
+
+// GetTransactions lists the wallet's transactions, newest first.
+func (r transactionRepository) GetTransactions(tenantID string, walletID int64) ([]Transaction, error) {
+	rows, err := r.db.Query("SELECT "+transactionColumns+" FROM transactions WHERE tenant_id=$1 AND wallet_id=$2 ORDER BY transaction_id DESC", tenantID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []Transaction{}
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, *t)
+	}
+
+	return transactions, rows.Err()
+}
+
+func (r transactionRepository) GetTransaction(tenantID string, id int64) (*Transaction, error) {
+	t, err := scanTransaction(r.db.QueryRow("SELECT "+transactionColumns+" FROM transactions WHERE tenant_id=$1 AND transaction_id=$2", tenantID, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+
+	return t, err
+}
+
+// ReverseTransaction moves the reversed amount back and records it as a new
+// transaction linked to the original. The wallet is locked before the
+// original transaction, so concurrent reversals of it serialise and can
+// never reverse more than its amount between them.
+func (r transactionRepository) ReverseTransaction(tenantID string, rev TransactionReversal) (*Transaction, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var walletID int64
+	err = tx.QueryRow("SELECT wallet_id FROM transactions WHERE tenant_id=$1 AND transaction_id=$2", tenantID, rev.TransactionID).Scan(&walletID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := loadWallet(tx, tenantID, walletID)
+	if err != nil {
+		return nil, err
+	}
+	if wallet.Status == StatusClosed {
+		return nil, ErrWalletClosed
+	}
+
+	original, err := scanTransaction(tx.QueryRow("SELECT "+transactionColumns+" FROM transactions WHERE transaction_id=$1 FOR UPDATE", rev.TransactionID))
+	if err != nil {
+		return nil, err
+	}
+	// Reversing one leg of a transfer would leave the other in place.
+	if original.ReversalOf != nil || original.FeeOf != nil || original.CounterpartyWalletID != nil {
+		return nil, ErrNotReversible
+	}
+
+	remaining := cents(original.Amount) - cents(original.ReversedAmount)
+	if remaining <= 0 {
+		return nil, ErrTransactionReversed
+	}
+	amount := rev.Amount
+	if amount == 0 {
+		amount = float64(remaining) / 100
+	}
+	if cents(amount) > remaining {
+		return nil, ErrReversalExceedsOriginal
+	}
+
+	// A reversal moves money the opposite way to the original.
+	delta := amount
+	if original.Operation == TransactionAdd {
+		delta = -amount
+	}
+	err = adjust(wallet, delta, rev.Reason)
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, wallet)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE transactions SET reversed_amount=reversed_amount+$2 WHERE transaction_id=$1", original.TransactionID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal, err := insertTransaction(tx, tenantID, entry{WalletID: walletID, Amount: delta, Balance: wallet.Balance, ReversalOf: &original.TransactionID, Reason: rev.Reason})
+	if err != nil {
+		return nil, err
+	}
+	contra, err := contraAccount(tx, original)
+	if err != nil {
+		return nil, err
+	}
+	err = postEntry(tx, tenantID, walletEntry(wallet, contra, delta, "reversal", reversal))
+	if err != nil {
+		return nil, err
+	}
+
+	return reversal, tx.Commit()
+}
+
+// contraAccount is the account the transaction's entry moved the wallet's
+// money against, so a reversal can move it back. Transactions from before
+// the ledger have no entry and are taken to have been against cash.
+func contraAccount(tx *sql.Tx, t *Transaction) (string, error) {
+	var code string
+	err := tx.QueryRow(`SELECT a.account_code FROM journal_entries e
+			JOIN journal_lines l ON l.entry_id = e.entry_id
+			JOIN ledger_accounts a ON a.account_id = l.account_id
+		WHERE e.transaction_id=$1 AND a.account_code<>$2
+		LIMIT 1`, t.TransactionID, ledger.WalletAccount(t.WalletID)).Scan(&code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ledger.Cash, nil
+	}
+	return code, err
+}
+
+// cents converts an amount to whole cents so sums of amounts compare
+// exactly.
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
@@ -0,0 +1,26 @@
+package repository
+
+import "github.com/stretchr/testify/mock"
+
+type transactionRepositoryMock struct {
+	mock.Mock
+}
+
+func NewTransactionRepositoryMock() *transactionRepositoryMock {
+	return &transactionRepositoryMock{}
+}
+
+func (r *transactionRepositoryMock) GetTransactions(tenantID string, walletID int64) ([]Transaction, error) {
+	args := r.Called(tenantID, walletID)
+	return args.Get(0).([]Transaction), args.Error(1)
+}
+
+func (r *transactionRepositoryMock) GetTransaction(tenantID string, id int64) (*Transaction, error) {
+	args := r.Called(tenantID, id)
+	return args.Get(0).(*Transaction), args.Error(1)
+}
+
+func (r *transactionRepositoryMock) ReverseTransaction(tenantID string, rev TransactionReversal) (*Transaction, error) {
+	args := r.Called(tenantID, rev)
+	return args.Get(0).(*Transaction), args.Error(1)
+}
@@ -1,18 +1,108 @@
 package repository
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
 
+	"github.com/topnarapat/go-wallet/aggregate"
+)
+
+const (
+	StatusActive = aggregate.StatusActive
+	StatusClosed = aggregate.StatusClosed
+)
+
+// PublicIDPrefix starts every public wallet id.
+const PublicIDPrefix = "wal_"
+
+var (
+	// ErrVersionConflict is returned by conditional updates when the wallet
+	// exists but its version no longer matches the expected one.
+	ErrVersionConflict = errors.New("wallet version conflict")
+	// ErrWalletClosed is returned when a closed wallet would be modified.
+	ErrWalletClosed = aggregate.ErrWalletClosed
+	// ErrBalanceNotZero is returned when closing a wallet with funds left
+	// and no destination to sweep them into.
+	ErrBalanceNotZero = aggregate.ErrBalanceNotZero
+	// ErrDestinationUnavailable is returned when the sweep destination does
+	// not exist or cannot receive funds.
+	ErrDestinationUnavailable = errors.New("destination wallet unavailable")
+)
+
+// WalletRepository scopes every query to the tenant given as the first
+// argument; a wallet of another tenant behaves as if it did not exist.
 type WalletRepository interface {
-	GetAllWallets() ([]Wallet, error)
-	GetWallet(int64) (*Wallet, error)
-	CreateNewWallet(float64) (*Wallet, error)
-	SetBalance(int64, float64) (*Wallet, error)
-	SetStatusWallet(int64, string) (*Wallet, error)
+	GetAllWallets(string, WalletFilter) ([]Wallet, error)
+	GetWallet(string, int64) (*Wallet, error)
+	GetWalletID(string, string) (int64, error)
+	FindAccountNumbers(string, []string) (map[string]int64, error)
+	CreateNewWallet(string, NewWallet) (*Wallet, error)
+	SetBalance(string, int64, float64, int64) (*Wallet, error)
+	DeductWithFee(string, FeeDeduction) (*Wallet, error)
+	SetStatusWallet(string, int64, string, int64) (*Wallet, error)
+	CloseWallet(string, CloseWallet) (*Wallet, error)
+	UpdateWallet(string, WalletPatch) (*Wallet, error)
+	ImportWallets(string, []ImportedWallet) ([]int64, error)
+	ExportWallets(string, func(Wallet) error) error
 }
 
 type Wallet struct {
-	WalletID  int64     `db:"wallet_id"`
-	Balance   float64   `db:"balance"`
-	Status    string    `db:"status"`
-	CreatedAt time.Time `db:"created_at"`
+	WalletID      int64             `db:"wallet_id"`
+	PublicID      string            `db:"public_id"`
+	AccountNumber string            `db:"account_number"`
+	TenantID      string            `db:"tenant_id"`
+	Currency      string            `db:"currency"`
+	Balance       float64           `db:"balance"`
+	Status        string            `db:"status"`
+	Version       int64             `db:"version"`
+	CreatedAt     time.Time         `db:"created_at"`
+	ClosedAt      *time.Time        `db:"closed_at"`
+	ClosureReason string            `db:"closure_reason"`
+	Metadata      json.RawMessage   `db:"metadata"`
+	Labels        map[string]string `db:"-"`
+	Pockets       int               `db:"-"`
+	PocketBalance float64           `db:"-"`
+	// TransactionID is the transaction recorded by SetBalance, 0 otherwise.
+	TransactionID int64 `db:"-"`
+}
+
+type WalletFilter struct {
+	IncludeClosed bool
+	Labels        map[string]string
+}
+
+type NewWallet struct {
+	Balance  float64
+	Currency string
+	Metadata json.RawMessage
+	Labels   map[string]string
+}
+
+// ImportedWallet is a wallet brought over from another system with the
+// balance, status and creation time it had there.
+type ImportedWallet struct {
+	Currency  string
+	Balance   float64
+	Status    string
+	CreatedAt time.Time
+	Metadata  json.RawMessage
+	Labels    map[string]string
+}
+
+// WalletPatch merges Metadata into the wallet's metadata at the top level,
+// removing keys set to null, and sets or removes the given labels.
+type WalletPatch struct {
+	WalletID     int64
+	Metadata     json.RawMessage
+	SetLabels    map[string]string
+	RemoveLabels []string
+	Version      int64
+}
+
+type CloseWallet struct {
+	WalletID      int64
+	DestinationID int64
+	Reason        string
+	Version       int64
 }
@@ -1,6 +1,25 @@
 package repository
 
-import "database/sql"
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/topnarapat/go-wallet/account"
+	"github.com/topnarapat/go-wallet/aggregate"
+	"github.com/topnarapat/go-wallet/ledger"
+)
+
+const walletColumns = "wallet_id, public_id, account_number, tenant_id, currency, balance, wallet_status, version, created_at, closed_at, COALESCE(closure_reason, ''), metadata, " +
+	"COALESCE((SELECT jsonb_object_agg(l.label_key, l.label_value) FROM wallet_labels l WHERE l.wallet_id = wallets.wallet_id), '{}'), " +
+	"(SELECT COUNT(*) FROM pockets p WHERE p.wallet_id = wallets.wallet_id AND p.pocket_status <> 'Closed'), " +
+	"COALESCE((SELECT SUM(p.balance) FROM pockets p WHERE p.wallet_id = wallets.wallet_id AND p.pocket_status <> 'Closed'), 0)"
 
 type walletRepository struct {
 	db *sql.DB
@@ -10,65 +29,721 @@ func NewWalletRepository(db *sql.DB) WalletRepository {
 	return walletRepository{db: db}
 }
 
-func (r walletRepository) GetAllWallets() ([]Wallet, error) {
-	rows, err := r.db.Query("SELECT wallet_id, balance, wallet_status, created_at FROM wallets")
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWallet(row scanner) (*Wallet, error) {
+	wallet := Wallet{}
+	var metadata, labels []byte
+	err := row.Scan(&wallet.WalletID, &wallet.PublicID, &wallet.AccountNumber, &wallet.TenantID, &wallet.Currency, &wallet.Balance, &wallet.Status, &wallet.Version, &wallet.CreatedAt, &wallet.ClosedAt, &wallet.ClosureReason, &metadata, &labels, &wallet.Pockets, &wallet.PocketBalance)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet.Metadata = json.RawMessage(metadata)
+	err = json.Unmarshal(labels, &wallet.Labels)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wallet, nil
+}
+
+func (r walletRepository) GetAllWallets(tenantID string, filter WalletFilter) ([]Wallet, error) {
+	conditions := []string{"tenant_id=$1"}
+	args := []interface{}{tenantID}
+	if !filter.IncludeClosed {
+		conditions = append(conditions, "wallet_status <> 'Closed'")
+	}
+
+	keys := make([]string, 0, len(filter.Labels))
+	for key := range filter.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, key, filter.Labels[key])
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM wallet_labels l WHERE l.wallet_id = wallets.wallet_id AND l.label_key=$"+
+			strconv.Itoa(len(args)-1)+" AND l.label_value=$"+strconv.Itoa(len(args))+")")
+	}
+
+	query := "SELECT " + walletColumns + " FROM wallets WHERE " + strings.Join(conditions, " AND ")
+	rows, err := r.db.Query(query+" ORDER BY wallet_id", args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	wallets := []Wallet{}
 	for rows.Next() {
-		w := Wallet{}
-		err = rows.Scan(&w.WalletID, &w.Balance, &w.Status, &w.CreatedAt)
+		w, err := scanWallet(rows)
 		if err != nil {
 			return nil, err
 		}
-		wallets = append(wallets, w)
+		wallets = append(wallets, *w)
 	}
 
-	return wallets, nil
+	return wallets, rows.Err()
 }
 
-func (r walletRepository) GetWallet(id int64) (*Wallet, error) {
-	row := r.db.QueryRow("SELECT wallet_id, balance, wallet_status, created_at FROM wallets WHERE wallet_id=$1", id)
-	wallet := Wallet{}
-	err := row.Scan(&wallet.WalletID, &wallet.Balance, &wallet.Status, &wallet.CreatedAt)
+func (r walletRepository) GetWallet(tenantID string, id int64) (*Wallet, error) {
+	row := r.db.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE tenant_id=$1 AND wallet_id=$2", tenantID, id)
+	return scanWallet(row)
+}
+
+// GetWalletID finds the internal id of the wallet with the public id.
+func (r walletRepository) GetWalletID(tenantID string, publicID string) (int64, error) {
+	var id int64
+	err := r.db.QueryRow("SELECT wallet_id FROM wallets WHERE tenant_id=$1 AND public_id=$2", tenantID, publicID).Scan(&id)
+	return id, err
+}
+
+// FindAccountNumbers returns the ids of the tenant's wallets with those
+// account numbers, keyed by number; numbers no wallet has are left out.
+func (r walletRepository) FindAccountNumbers(tenantID string, numbers []string) (map[string]int64, error) {
+	rows, err := r.db.Query("SELECT account_number, wallet_id FROM wallets WHERE tenant_id=$1 AND account_number = ANY($2)", tenantID, pq.Array(numbers))
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &wallet, nil
+	ids := map[string]int64{}
+	for rows.Next() {
+		var number string
+		var id int64
+		if err = rows.Scan(&number, &id); err != nil {
+			return nil, err
+		}
+		ids[number] = id
+	}
+
+	return ids, rows.Err()
 }
 
-func (r walletRepository) CreateNewWallet(amount float64) (*Wallet, error) {
-	row := r.db.QueryRow("INSERT INTO wallets (balance) values ($1) RETURNING wallet_id, balance, wallet_status, created_at", amount)
-	wallet := Wallet{}
-	err := row.Scan(&wallet.WalletID, &wallet.Balance, &wallet.Status, &wallet.CreatedAt)
+func (r walletRepository) CreateNewWallet(tenantID string, w NewWallet) (*Wallet, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return &wallet, nil
+	var id int64
+	err = tx.QueryRow("SELECT nextval(pg_get_serial_sequence('wallets', 'wallet_id'))").Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers, err := newAccountNumbers(tx, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := aggregate.Create(id, tenantID, aggregate.Opening{PublicID: newPublicID(), AccountNumber: numbers[0], Currency: w.Currency, Balance: w.Balance, Metadata: w.Metadata, Labels: w.Labels})
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, created)
+	if err != nil {
+		return nil, err
+	}
+	if created.Balance > 0 {
+		err = postEntry(tx, tenantID, walletEntry(created, ledger.Cash, created.Balance, "opening balance", nil))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wallet, err := scanWallet(tx.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE wallet_id=$1", id))
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, tx.Commit()
 }
 
-func (r walletRepository) SetBalance(id int64, balance float64) (*Wallet, error) {
-	row := r.db.QueryRow("UPDATE wallets SET balance=balance+$2 WHERE wallet_id=$1 RETURNING wallet_id, balance, wallet_status, created_at", id, balance)
-	wallet := Wallet{}
-	err := row.Scan(&wallet.WalletID, &wallet.Balance, &wallet.Status, &wallet.CreatedAt)
+// SetBalance adds balance to the wallet and records it as a transaction.
+// When version is non-zero the update only applies if the wallet is still at
+// that version.
+func (r walletRepository) SetBalance(tenantID string, id int64, balance float64, version int64) (*Wallet, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return &wallet, nil
+	wallet, err := setBalance(tx, tenantID, id, balance, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, tx.Commit()
 }
 
-func (r walletRepository) SetStatusWallet(id int64, status string) (*Wallet, error) {
-	row := r.db.QueryRow("UPDATE wallets SET wallet_status=$2 WHERE wallet_id=$1 RETURNING wallet_id, balance, wallet_status, created_at", id, status)
-	wallet := Wallet{}
-	err := row.Scan(&wallet.WalletID, &wallet.Balance, &wallet.Status, &wallet.CreatedAt)
+func setBalance(tx *sql.Tx, tenantID string, id int64, balance float64, version int64) (*Wallet, error) {
+	w, err := loadWallet(tx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	err = expectVersion(w, version)
 	if err != nil {
 		return nil, err
 	}
 
-	return &wallet, nil
+	err = adjust(w, balance, "")
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err := insertTransaction(tx, tenantID, entry{WalletID: id, Amount: balance, Balance: w.Balance})
+	if err != nil {
+		return nil, err
+	}
+	err = postEntry(tx, tenantID, cashEntry(w, balance, transaction))
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := scanWallet(tx.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE wallet_id=$1", id))
+	if err != nil {
+		return nil, err
+	}
+	wallet.TransactionID = transaction.TransactionID
+
+	return wallet, nil
+}
+
+// DeductWithFee deducts the amount and the fee from the wallet and credits
+// the fee to the revenue wallet in one transaction. The fee is recorded as
+// two transactions of its own, both linked to the deduction by fee_of.
+func (r walletRepository) DeductWithFee(tenantID string, d FeeDeduction) (*Wallet, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	wallet, err := deductWithFee(tx, tenantID, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, tx.Commit()
+}
+
+func deductWithFee(tx *sql.Tx, tenantID string, d FeeDeduction) (*Wallet, error) {
+	ids := []int64{d.WalletID, d.RevenueWalletID}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	_, err := tx.Exec("SELECT wallet_id FROM wallets WHERE tenant_id=$1 AND wallet_id = ANY($2) ORDER BY wallet_id FOR UPDATE", tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := loadWallet(tx, tenantID, d.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	err = expectVersion(source, d.Version)
+	if err != nil {
+		return nil, err
+	}
+	if cents(source.Balance) < cents(d.Amount)+cents(d.Fee) {
+		return nil, ErrInsufficientFunds
+	}
+
+	err = source.Debit(d.Amount, "")
+	if err != nil {
+		return nil, err
+	}
+	deduction, err := insertTransaction(tx, tenantID, entry{WalletID: d.WalletID, Amount: -d.Amount, Balance: source.Balance})
+	if err != nil {
+		return nil, err
+	}
+	err = postEntry(tx, tenantID, cashEntry(source, -d.Amount, deduction))
+	if err != nil {
+		return nil, err
+	}
+	err = chargeFee(tx, tenantID, source, d.Fee, d.RevenueWalletID, deduction)
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := scanWallet(tx.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE wallet_id=$1", d.WalletID))
+	if err != nil {
+		return nil, err
+	}
+	wallet.TransactionID = deduction.TransactionID
+
+	return wallet, nil
+}
+
+// chargeFee debits the fee charged on the transaction feeOf from the
+// charged wallet, which the caller saves, and credits it to the revenue
+// wallet. Both sides are recorded as transactions. The charge is posted to
+// Fees revenue, and the revenue wallet's credit as a separate payout of it,
+// so the fee stays in the tenant's income.
+func chargeFee(tx *sql.Tx, tenantID string, charged *aggregate.Wallet, fee float64, revenueWalletID int64, feeOf *Transaction) error {
+	err := charged.Debit(fee, "fee")
+	if err != nil {
+		return err
+	}
+	charge, err := insertTransaction(tx, tenantID, entry{WalletID: charged.WalletID, Amount: -fee, Balance: charged.Balance, FeeOf: &feeOf.TransactionID, Reason: "fee"})
+	if err != nil {
+		return err
+	}
+
+	revenue, err := loadWallet(tx, tenantID, revenueWalletID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrFeeWalletUnavailable
+	}
+	if err != nil {
+		return err
+	}
+	if revenue.Status != StatusActive || revenue.Currency != charged.Currency {
+		return ErrFeeWalletUnavailable
+	}
+
+	err = revenue.Credit(fee, "fee")
+	if err != nil {
+		return err
+	}
+	err = saveWallet(tx, revenue)
+	if err != nil {
+		return err
+	}
+	credit, err := insertTransaction(tx, tenantID, entry{WalletID: revenueWalletID, Amount: fee, Balance: revenue.Balance, FeeOf: &feeOf.TransactionID, Reason: "fee"})
+	if err != nil {
+		return err
+	}
+
+	err = postEntry(tx, tenantID, walletEntry(charged, ledger.FeesRevenue, -fee, "fee", charge))
+	if err != nil {
+		return err
+	}
+	return postEntry(tx, tenantID, walletEntry(revenue, ledger.FeePayouts, fee, "fee payout", credit))
+}
+
+// SetStatusWallet changes the wallet's status and cascades it to the
+// wallet's open pockets.
+func (r walletRepository) SetStatusWallet(tenantID string, id int64, status string, version int64) (*Wallet, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	wallet, err := setStatusWallet(tx, tenantID, id, status, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, tx.Commit()
+}
+
+func setStatusWallet(tx *sql.Tx, tenantID string, id int64, status string, version int64) (*Wallet, error) {
+	w, err := loadWallet(tx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	err = expectVersion(w, version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.ChangeStatus(status)
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE pockets SET pocket_status=$2 WHERE wallet_id=$1 AND pocket_status<>'Closed'", id, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanWallet(tx.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE wallet_id=$1", id))
+}
+
+// CloseWallet empties the wallet's pockets into it, sweeps the remaining
+// balance into the destination wallet and closes the source wallet and its
+// pockets in a single transaction.
+func (r walletRepository) CloseWallet(tenantID string, c CloseWallet) (*Wallet, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := []int64{c.WalletID}
+	if c.DestinationID != 0 {
+		ids = append(ids, c.DestinationID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	_, err = tx.Exec("SELECT wallet_id FROM wallets WHERE tenant_id=$1 AND wallet_id = ANY($2) ORDER BY wallet_id FOR UPDATE", tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := loadWallet(tx, tenantID, c.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	err = expectVersion(source, c.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	var pocketBalance float64
+	err = tx.QueryRow("SELECT COALESCE(SUM(balance), 0) FROM pockets WHERE wallet_id=$1 AND pocket_status<>'Closed'", c.WalletID).Scan(&pocketBalance)
+	if err != nil {
+		return nil, err
+	}
+	if pocketBalance != 0 {
+		err = source.Credit(pocketBalance, "pocket")
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = tx.Exec("UPDATE pockets SET balance=0, pocket_status='Closed', closed_at=now() WHERE wallet_id=$1 AND pocket_status<>'Closed'", c.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	if source.Balance != 0 {
+		if c.DestinationID == 0 {
+			return nil, ErrBalanceNotZero
+		}
+
+		destination, err := loadWallet(tx, tenantID, c.DestinationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDestinationUnavailable
+		}
+		if err != nil {
+			return nil, err
+		}
+		if destination.Status != StatusActive || destination.Currency != source.Currency {
+			return nil, ErrDestinationUnavailable
+		}
+
+		// The sweep is a transfer, recorded on both wallets' transactions.
+		amount := source.Balance
+		err = source.Debit(amount, "closure")
+		if err != nil {
+			return nil, err
+		}
+		debit, err := insertTransaction(tx, tenantID, entry{WalletID: source.WalletID, Amount: -amount, Balance: source.Balance, Counterparty: &destination.WalletID, Reason: "closure"})
+		if err != nil {
+			return nil, err
+		}
+
+		err = destination.Credit(amount, "closure")
+		if err != nil {
+			return nil, err
+		}
+		err = saveWallet(tx, destination)
+		if err != nil {
+			return nil, err
+		}
+		_, err = insertTransaction(tx, tenantID, entry{WalletID: destination.WalletID, Amount: amount, Balance: destination.Balance, Counterparty: &source.WalletID, Reason: "closure"})
+		if err != nil {
+			return nil, err
+		}
+
+		e := ledger.Transfer(source.Currency, "closure", ledger.WalletAccount(source.WalletID), ledger.WalletAccount(destination.WalletID), amount)
+		e.TransactionID = &debit.TransactionID
+		err = postEntry(tx, tenantID, e)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = source.Close(c.Reason)
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// A closed wallet cannot be paid, so its aliases are freed for another.
+	_, err = tx.Exec("DELETE FROM wallet_aliases WHERE wallet_id=$1", c.WalletID)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := scanWallet(tx.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE wallet_id=$1", c.WalletID))
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, tx.Commit()
+}
+
+func (r walletRepository) UpdateWallet(tenantID string, p WalletPatch) (*Wallet, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	w, err := loadWallet(tx, tenantID, p.WalletID)
+	if err != nil {
+		return nil, err
+	}
+	err = expectVersion(w, p.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Update(p.Metadata, p.SetLabels, p.RemoveLabels)
+	if err != nil {
+		return nil, err
+	}
+	err = saveWallet(tx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	wallet, err := scanWallet(tx.QueryRow("SELECT "+walletColumns+" FROM wallets WHERE wallet_id=$1", p.WalletID))
+	if err != nil {
+		return nil, err
+	}
+
+	return wallet, tx.Commit()
+}
+
+// ImportWallets creates the wallets, with their labels and the first event
+// of their streams, using COPY in one database transaction and returns
+// their new ids in the order given.
+func (r walletRepository) ImportWallets(tenantID string, wallets []ImportedWallet) ([]int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// COPY cannot return the ids it assigns, so they are drawn up front.
+	var ids []int64
+	err = tx.QueryRow("SELECT array_agg(nextval(pg_get_serial_sequence('wallets', 'wallet_id'))) FROM generate_series(1, $1)", len(wallets)).Scan(pq.Array(&ids))
+	if err != nil {
+		return nil, err
+	}
+
+	numbers, err := newAccountNumbers(tx, len(wallets))
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]*aggregate.Wallet, len(wallets))
+	for i, w := range wallets {
+		created[i], err = aggregate.Create(ids[i], tenantID, aggregate.Opening{PublicID: newPublicID(), AccountNumber: numbers[i], Currency: w.Currency, Balance: w.Balance, Status: w.Status, CreatedAt: w.CreatedAt, Metadata: w.Metadata, Labels: w.Labels})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = copyRows(tx, pq.CopyIn("event_store", "stream_id", "tenant_id", "stream_version", "event_type", "data", "recorded_at"), func(exec func(...interface{}) error) error {
+		for _, w := range created {
+			e := w.Changes()[0]
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := exec(e.WalletID, e.TenantID, e.Version, e.Type, string(data), e.RecordedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = copyRows(tx, pq.CopyIn("wallets", "wallet_id", "public_id", "account_number", "tenant_id", "currency", "balance", "wallet_status", "version", "created_at", "metadata"), func(exec func(...interface{}) error) error {
+		for _, w := range created {
+			if err := exec(w.WalletID, w.PublicID, w.AccountNumber, w.TenantID, w.Currency, w.Balance, w.Status, w.Version, w.CreatedAt, string(w.Metadata)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Imported balances come from outside the ledger, so they are held
+	// against suspense until they are matched with the cash behind them.
+	err = postImports(tx, tenantID, created)
+	if err != nil {
+		return nil, err
+	}
+
+	err = copyRows(tx, pq.CopyIn("wallet_labels", "wallet_id", "label_key", "label_value"), func(exec func(...interface{}) error) error {
+		for _, w := range created {
+			for key, value := range w.Labels {
+				if err := exec(w.WalletID, key, value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, tx.Commit()
+}
+
+// ExportWallets calls each for every wallet of the tenant, closed ones
+// included, in id order, stopping at the first error.
+func (r walletRepository) ExportWallets(tenantID string, each func(Wallet) error) error {
+	rows, err := r.db.Query("SELECT "+walletColumns+" FROM wallets WHERE tenant_id=$1 ORDER BY wallet_id", tenantID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		w, err := scanWallet(rows)
+		if err != nil {
+			return err
+		}
+		if err = each(*w); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// copyRows runs a COPY statement, with rows sending each row to it.
+func copyRows(tx *sql.Tx, query string, rows func(exec func(...interface{}) error) error) error {
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+
+	err = rows(func(values ...interface{}) error {
+		_, err := stmt.Exec(values...)
+		return err
+	})
+	if err == nil {
+		// Exec without values flushes the buffered rows.
+		_, err = stmt.Exec()
+	}
+	closeErr := stmt.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func setLabels(tx *sql.Tx, id int64, labels map[string]string) error {
+	for key, value := range labels {
+		_, err := tx.Exec("INSERT INTO wallet_labels (wallet_id, label_key, label_value) VALUES ($1, $2, $3) ON CONFLICT (wallet_id, label_key) DO UPDATE SET label_value=EXCLUDED.label_value", id, key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newPublicID makes the identifier a wallet is known by outside: a prefix
+// and 128 random bits, so ids cannot be guessed from one another.
+func newPublicID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return PublicIDPrefix + hex.EncodeToString(b)
+}
+
+// expectVersion checks a command may run on the wallet: it must not be
+// closed and, when version is non-zero, must still be at that version.
+func expectVersion(w *aggregate.Wallet, version int64) error {
+	if w.Status == StatusClosed {
+		return ErrWalletClosed
+	}
+	if version != 0 && w.Version != version {
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// adjust credits a positive amount to the wallet and debits a negative one.
+func adjust(w *aggregate.Wallet, amount float64, reason string) error {
+	if amount < 0 {
+		return w.Debit(-amount, reason)
+	}
+
+	return w.Credit(amount, reason)
+}
+
+// newAccountNumbers draws n account numbers that neither repeat nor belong
+// to a wallet yet, redrawing the few that do.
+func newAccountNumbers(tx *sql.Tx, n int) ([]string, error) {
+	numbers := make([]string, n)
+	drawn := map[string]bool{}
+	redraw := make([]int, n)
+	for i := range redraw {
+		redraw[i] = i
+	}
+
+	for len(redraw) > 0 {
+		for _, i := range redraw {
+			number := account.New()
+			for drawn[number] {
+				number = account.New()
+			}
+			drawn[number] = true
+			numbers[i] = number
+		}
+
+		candidates := make([]string, len(redraw))
+		for j, i := range redraw {
+			candidates[j] = numbers[i]
+		}
+		rows, err := tx.Query("SELECT account_number FROM wallets WHERE account_number = ANY($1)", pq.Array(candidates))
+		if err != nil {
+			return nil, err
+		}
+		taken := map[string]bool{}
+		for rows.Next() {
+			var number string
+			if err = rows.Scan(&number); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			taken[number] = true
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+
+		next := redraw[:0]
+		for _, i := range redraw {
+			if taken[numbers[i]] {
+				next = append(next, i)
+			}
+		}
+		redraw = next
+	}
+
+	return numbers, nil
 }
@@ -0,0 +1,53 @@
+//go:build integration
+// +build integration
+
+package repository_test
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+func TestCloseWalletIntegration(t *testing.T) {
+	db, err := sql.Open("postgres", "postgresql://root:root@db/wallets?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Arrange
+	walletRepo := repository.NewWalletRepository(db)
+	source, err := walletRepo.CreateNewWallet("default", repository.NewWallet{Balance: 250, Currency: "THB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	destination, err := walletRepo.CreateNewWallet("default", repository.NewWallet{Balance: 100, Currency: "THB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Act
+	_, err = walletRepo.CloseWallet("default", repository.CloseWallet{WalletID: source.WalletID, DestinationID: destination.WalletID, Reason: "customer request", Version: source.Version})
+
+	// Assert
+	if assert.NoError(t, err) {
+		transactionRepo := repository.NewTransactionRepository(db)
+		debits, err := transactionRepo.GetTransactions("default", source.WalletID)
+		assert.NoError(t, err)
+		credits, err := transactionRepo.GetTransactions("default", destination.WalletID)
+		assert.NoError(t, err)
+
+		assert.Equal(t, repository.TransactionDeduct, debits[0].Operation)
+		assert.Equal(t, 250.0, debits[0].Amount)
+		assert.Equal(t, 0.0, debits[0].Balance)
+		assert.Equal(t, &destination.WalletID, debits[0].CounterpartyWalletID)
+		assert.Equal(t, repository.TransactionAdd, credits[0].Operation)
+		assert.Equal(t, 250.0, credits[0].Amount)
+		assert.Equal(t, 350.0, credits[0].Balance)
+		assert.Equal(t, &source.WalletID, credits[0].CounterpartyWalletID)
+	}
+}
@@ -10,27 +10,67 @@ func NewWalletRepositoryMock() *walletRepositoryMock {
 	return &walletRepositoryMock{}
 }
 
-func (r *walletRepositoryMock) GetAllWallets() ([]Wallet, error) {
-	args := r.Called()
+func (r *walletRepositoryMock) GetAllWallets(tenantID string, filter WalletFilter) ([]Wallet, error) {
+	args := r.Called(tenantID, filter)
 	return args.Get(0).([]Wallet), args.Error(1)
 }
 
-func (r *walletRepositoryMock) GetWallet(id int64) (*Wallet, error) {
-	args := r.Called(id)
+func (r *walletRepositoryMock) GetWallet(tenantID string, id int64) (*Wallet, error) {
+	args := r.Called(tenantID, id)
 	return args.Get(0).(*Wallet), args.Error(1)
 }
 
-func (r *walletRepositoryMock) CreateNewWallet(amount float64) (*Wallet, error) {
-	args := r.Called(amount)
+func (r *walletRepositoryMock) GetWalletID(tenantID string, publicID string) (int64, error) {
+	args := r.Called(tenantID, publicID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (r *walletRepositoryMock) FindAccountNumbers(tenantID string, numbers []string) (map[string]int64, error) {
+	args := r.Called(tenantID, numbers)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (r *walletRepositoryMock) CreateNewWallet(tenantID string, w NewWallet) (*Wallet, error) {
+	args := r.Called(tenantID, w)
 	return args.Get(0).(*Wallet), args.Error(1)
 }
 
-func (r *walletRepositoryMock) SetBalance(id int64, amount float64) (*Wallet, error) {
-	args := r.Called(id, amount)
+func (r *walletRepositoryMock) SetBalance(tenantID string, id int64, amount float64, version int64) (*Wallet, error) {
+	args := r.Called(tenantID, id, amount, version)
 	return args.Get(0).(*Wallet), args.Error(1)
 }
 
-func (r *walletRepositoryMock) SetStatusWallet(id int64, status string) (*Wallet, error) {
-	args := r.Called(id, status)
+func (r *walletRepositoryMock) DeductWithFee(tenantID string, d FeeDeduction) (*Wallet, error) {
+	args := r.Called(tenantID, d)
 	return args.Get(0).(*Wallet), args.Error(1)
 }
+
+func (r *walletRepositoryMock) SetStatusWallet(tenantID string, id int64, status string, version int64) (*Wallet, error) {
+	args := r.Called(tenantID, id, status, version)
+	return args.Get(0).(*Wallet), args.Error(1)
+}
+
+func (r *walletRepositoryMock) CloseWallet(tenantID string, c CloseWallet) (*Wallet, error) {
+	args := r.Called(tenantID, c)
+	return args.Get(0).(*Wallet), args.Error(1)
+}
+
+func (r *walletRepositoryMock) UpdateWallet(tenantID string, p WalletPatch) (*Wallet, error) {
+	args := r.Called(tenantID, p)
+	return args.Get(0).(*Wallet), args.Error(1)
+}
+
+func (r *walletRepositoryMock) ImportWallets(tenantID string, wallets []ImportedWallet) ([]int64, error) {
+	args := r.Called(tenantID, wallets)
+	return args.Get(0).([]int64), args.Error(1)
+}
+
+func (r *walletRepositoryMock) ExportWallets(tenantID string, each func(Wallet) error) error {
+	args := r.Called(tenantID)
+	for _, wallet := range args.Get(0).([]Wallet) {
+		if err := each(wallet); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
@@ -0,0 +1,147 @@
+// Package risk decides whether a debit may go ahead by evaluating
+// configurable rules over the transaction and the wallet's recent history.
+package risk
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+const (
+	Allow  = "allow"
+	Review = "review"
+	Deny   = "deny"
+)
+
+// Variables lists the facts every evaluation provides, with a description
+// of each. Rules may only refer to these.
+var Variables = map[string]string{
+	"operation":        "Deduct, Transfer, Close or Reverse",
+	"amount":           "amount being debited",
+	"balance":          "wallet balance before the debit",
+	"wallet_age_hours": "hours since the wallet was created",
+	"debit_count_1h":   "debits from the wallet in the last hour",
+	"debit_amount_1h":  "total debited in the last hour",
+	"debit_count_24h":  "debits from the wallet in the last 24 hours",
+	"debit_amount_24h": "total debited in the last 24 hours",
+	"debit_count_30d":  "debits from the wallet in the last 30 days",
+	"avg_debit_30d":    "average debit over the last 30 days, 0 without history",
+	"max_debit_30d":    "largest debit over the last 30 days, 0 without history",
+}
+
+//go:embed rules.json
+var DefaultRules []byte
+
+type Rule struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	Outcome    string `json:"outcome"`
+}
+
+// Evaluation is the outcome of a single rule for one transaction. Err is
+// set when the expression could not be evaluated, in which case the rule
+// does not match.
+type Evaluation struct {
+	Rule       string
+	Expression string
+	Outcome    string
+	Matched    bool
+	Err        string
+}
+
+// Result carries the decision and every rule evaluation behind it. Rule
+// names the rule that decided, empty when no rule matched.
+type Result struct {
+	Decision    string
+	Rule        string
+	Evaluations []Evaluation
+}
+
+type compiledRule struct {
+	Rule
+	expr Expr
+}
+
+type Engine struct {
+	rules []compiledRule
+}
+
+// LoadRules reads a JSON array of rules.
+func LoadRules(r io.Reader) ([]Rule, error) {
+	rules := []Rule{}
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		return nil, fmt.Errorf("decode risk rules: %w", err)
+	}
+	return rules, nil
+}
+
+// NewEngine compiles rules, rejecting unknown outcomes, syntax errors and
+// references to facts that are not in Variables.
+func NewEngine(rules []Rule) (*Engine, error) {
+	names := map[string]bool{}
+	e := &Engine{}
+	for _, rule := range rules {
+		if rule.Name == "" || names[rule.Name] {
+			return nil, fmt.Errorf("risk rule name %q must be unique and non-empty", rule.Name)
+		}
+		names[rule.Name] = true
+
+		switch rule.Outcome {
+		case Allow, Review, Deny:
+		default:
+			return nil, fmt.Errorf("risk rule %s: outcome must be allow, review or deny", rule.Name)
+		}
+
+		expr, err := Parse(rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("risk rule %s: %w", rule.Name, err)
+		}
+		unknown := []string{}
+		for _, name := range Identifiers(expr) {
+			if _, ok := Variables[name]; !ok {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown)
+			return nil, fmt.Errorf("risk rule %s: unknown facts %v", rule.Name, unknown)
+		}
+
+		e.rules = append(e.rules, compiledRule{Rule: rule, expr: expr})
+	}
+
+	return e, nil
+}
+
+// Evaluate runs every rule so each one's hit rate can be tuned, and decides
+// by the first matching rule in configuration order. Without a match the
+// decision is allow.
+func (e *Engine) Evaluate(facts Facts) Result {
+	result := Result{Decision: Allow}
+	decided := false
+	for _, rule := range e.rules {
+		evaluation := Evaluation{Rule: rule.Name, Expression: rule.Expression, Outcome: rule.Outcome}
+		v, err := rule.expr.Eval(facts)
+		if err != nil {
+			evaluation.Err = err.Error()
+		} else if matched, ok := v.(bool); !ok {
+			evaluation.Err = fmt.Sprintf("expression is %T, not a boolean", v)
+		} else {
+			evaluation.Matched = matched
+		}
+
+		if evaluation.Matched && !decided {
+			decided = true
+			result.Decision = rule.Outcome
+			result.Rule = rule.Name
+		}
+		result.Evaluations = append(result.Evaluations, evaluation)
+	}
+
+	return result
+}
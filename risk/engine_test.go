@@ -0,0 +1,129 @@
+//go:build unit
+// +build unit
+
+package risk_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/topnarapat/go-wallet/risk"
+)
+
+func TestParse(t *testing.T) {
+	facts := risk.Facts{"amount": 500.0, "balance": 1000.0, "operation": "Deduct", "count": 0.0}
+
+	tests := []struct {
+		name string
+		src  string
+		want interface{}
+	}{
+		{"precedence", "1 + 2 * 3", 7.0},
+		{"parentheses", "(1 + 2) * 3", 9.0},
+		{"unary minus", "-amount + 600", 100.0},
+		{"comparison", "amount * 2 >= balance", true},
+		{"logical", "amount > 100 && !(balance < 10) || false", true},
+		{"string equality", "operation == 'Deduct'", true},
+		{"short circuit skips division by zero", "count > 0 && amount / count > 1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			expr, err := risk.Parse(tt.src)
+			require.NoError(t, err)
+
+			// Act
+			got, err := expr.Eval(facts)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("syntax errors", func(t *testing.T) {
+		for _, src := range []string{"", "amount >", "(amount > 1", "amount > 1)", "amount # 1", "'open"} {
+			_, err := risk.Parse(src)
+			assert.Error(t, err, src)
+		}
+	})
+
+	t.Run("evaluation errors", func(t *testing.T) {
+		for _, src := range []string{"amount / count", "operation > 1", "amount && true", "missing > 1"} {
+			expr, err := risk.Parse(src)
+			require.NoError(t, err, src)
+
+			_, err = expr.Eval(facts)
+			assert.Error(t, err, src)
+		}
+	})
+}
+
+func TestNewEngine(t *testing.T) {
+	t.Run("default rules compile", func(t *testing.T) {
+		// Arrange
+		rules, err := risk.LoadRules(bytes.NewReader(risk.DefaultRules))
+		require.NoError(t, err)
+
+		// Act
+		_, err = risk.NewEngine(rules)
+
+		// Assert
+		assert.NoError(t, err)
+	})
+
+	t.Run("reject invalid rules", func(t *testing.T) {
+		tests := map[string]risk.Rule{
+			"unknown outcome": {Name: "r", Expression: "amount > 1", Outcome: "block"},
+			"syntax error":    {Name: "r", Expression: "amount >", Outcome: risk.Deny},
+			"unknown fact":    {Name: "r", Expression: "amout > 1", Outcome: risk.Deny},
+			"missing name":    {Expression: "amount > 1", Outcome: risk.Deny},
+		}
+		for name, rule := range tests {
+			_, err := risk.NewEngine([]risk.Rule{rule})
+			assert.Error(t, err, name)
+		}
+	})
+
+	t.Run("reject unknown fields in rules file", func(t *testing.T) {
+		_, err := risk.LoadRules(strings.NewReader(`[{"name": "r", "expr": "amount > 1", "outcome": "deny"}]`))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	engine, err := risk.NewEngine([]risk.Rule{
+		{Name: "velocity", Expression: "debit_count_1h >= 20", Outcome: risk.Deny},
+		{Name: "broken", Expression: "amount / avg_debit_30d > 10", Outcome: risk.Deny},
+		{Name: "new_wallet", Expression: "wallet_age_hours < 24 && amount > 5000", Outcome: risk.Review},
+		{Name: "large", Expression: "amount > 1000", Outcome: risk.Review},
+	})
+	require.NoError(t, err)
+
+	t.Run("first matching rule decides and every rule is recorded", func(t *testing.T) {
+		// Act
+		result := engine.Evaluate(risk.Facts{"debit_count_1h": 0.0, "amount": 6000.0, "avg_debit_30d": 0.0, "wallet_age_hours": 2.0})
+
+		// Assert
+		assert.Equal(t, risk.Review, result.Decision)
+		assert.Equal(t, "new_wallet", result.Rule)
+		assert.Len(t, result.Evaluations, 4)
+		assert.False(t, result.Evaluations[0].Matched)
+		assert.Equal(t, "division by zero", result.Evaluations[1].Err)
+		assert.True(t, result.Evaluations[2].Matched)
+		assert.True(t, result.Evaluations[3].Matched)
+	})
+
+	t.Run("allow when no rule matches", func(t *testing.T) {
+		// Act
+		result := engine.Evaluate(risk.Facts{"debit_count_1h": 1.0, "amount": 100.0, "avg_debit_30d": 100.0, "wallet_age_hours": 200.0})
+
+		// Assert
+		assert.Equal(t, risk.Allow, result.Decision)
+		assert.Empty(t, result.Rule)
+	})
+}
@@ -0,0 +1,348 @@
+package risk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Facts are the named values an expression is evaluated against. Values are
+// float64, string or bool.
+type Facts map[string]interface{}
+
+// Expr is a compiled rule expression.
+//
+// The language has number, string ('...' or "...") and boolean literals,
+// identifiers naming facts, arithmetic (+ - * /), comparisons
+// (== != < <= > >=), logical operators (&& || !) and parentheses, with the
+// usual precedence.
+type Expr interface {
+	Eval(Facts) (interface{}, error)
+	idents(func(string))
+}
+
+// Parse compiles src into an expression.
+func Parse(src string) (Expr, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	expr, err := p.parse(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.peek().text, p.peek().pos)
+	}
+
+	return expr, nil
+}
+
+// Identifiers returns every fact name the expression refers to.
+func Identifiers(e Expr) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	e.idents(func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "!", "(", ")"}
+
+func tokenize(src string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))):
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(src) && (unicode.IsLetter(rune(src[i])) || unicode.IsDigit(rune(src[i])) || src[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[start:i], pos: start})
+		case c == '\'' || c == '"':
+			start := i
+			end := strings.IndexByte(src[i+1:], byte(c))
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[i+1 : i+1+end], pos: start})
+			i += end + 2
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parse reads a binary expression whose operators bind tighter than minPrec.
+func (p *parser) parse(minPrec int) (Expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokenOp || !ok || prec <= minPrec {
+			return left, nil
+		}
+		p.next()
+
+		right, err := p.parse(prec)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: t.text, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenNumber:
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %d", t.text, t.pos)
+		}
+		return literal{value: f}, nil
+	case tokenString:
+		return literal{value: t.text}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return literal{value: true}, nil
+		case "false":
+			return literal{value: false}, nil
+		}
+		return ident{name: t.text}, nil
+	case tokenOp:
+		switch t.text {
+		case "(":
+			e, err := p.parse(0)
+			if err != nil {
+				return nil, err
+			}
+			if closing := p.next(); closing.text != ")" {
+				return nil, fmt.Errorf("missing ) at offset %d", closing.pos)
+			}
+			return e, nil
+		case "!", "-":
+			operand, err := p.parse(6)
+			if err != nil {
+				return nil, err
+			}
+			return unary{op: t.text, operand: operand}, nil
+		}
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("unexpected %q at offset %d", t.text, t.pos)
+}
+
+type literal struct {
+	value interface{}
+}
+
+func (l literal) Eval(Facts) (interface{}, error) {
+	return l.value, nil
+}
+
+func (l literal) idents(func(string)) {}
+
+type ident struct {
+	name string
+}
+
+func (i ident) Eval(facts Facts) (interface{}, error) {
+	v, ok := facts[i.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fact %q", i.name)
+	}
+	return v, nil
+}
+
+func (i ident) idents(f func(string)) {
+	f(i.name)
+}
+
+type unary struct {
+	op      string
+	operand Expr
+}
+
+func (u unary) Eval(facts Facts) (interface{}, error) {
+	v, err := u.operand.Eval(facts)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.op == "!" {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("! needs a boolean, got %T", v)
+		}
+		return !b, nil
+	}
+
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("- needs a number, got %T", v)
+	}
+	return -f, nil
+}
+
+func (u unary) idents(f func(string)) {
+	u.operand.idents(f)
+}
+
+type binary struct {
+	op          string
+	left, right Expr
+}
+
+func (b binary) Eval(facts Facts) (interface{}, error) {
+	l, err := b.left.Eval(facts)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || short-circuit so a guard such as `count > 0 && total / count > 5`
+	// never evaluates its right-hand side when the guard fails.
+	if b.op == "&&" || b.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s needs booleans, got %T", b.op, l)
+		}
+		if (b.op == "&&" && !lb) || (b.op == "||" && lb) {
+			return lb, nil
+		}
+		r, err := b.right.Eval(facts)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s needs booleans, got %T", b.op, r)
+		}
+		return rb, nil
+	}
+
+	r, err := b.right.Eval(facts)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.op {
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	}
+
+	lf, lok := l.(float64)
+	rf, rok := r.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("%s needs numbers, got %T and %T", b.op, l, r)
+	}
+
+	switch b.op {
+	case "<":
+		return lf < rf, nil
+	case "<=":
+		return lf <= rf, nil
+	case ">":
+		return lf > rf, nil
+	case ">=":
+		return lf >= rf, nil
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	}
+
+	return nil, fmt.Errorf("unknown operator %s", b.op)
+}
+
+func (b binary) idents(f func(string)) {
+	b.left.idents(f)
+	b.right.idents(f)
+}
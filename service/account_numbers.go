@@ -0,0 +1,70 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/topnarapat/go-wallet/account"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+// accountNumberField is an account number as given in a request field.
+type accountNumberField struct {
+	field  string
+	number string
+}
+
+// resolveAccountNumbers checks the account numbers given and finds the
+// tenant's wallets they belong to, returning the wallet ids in the order
+// given, 0 for a number no wallet has. Numbers that are malformed or fail
+// their check digit are reported against their field instead.
+func resolveAccountNumbers(walletRepo repository.WalletRepository, tenantID string, given []accountNumberField) ([]int64, []errs.FieldError, error) {
+	fields := []errs.FieldError{}
+	numbers := make([]string, len(given))
+	for i, g := range given {
+		number, err := account.Parse(g.number)
+		if err != nil {
+			fields = append(fields, errs.FieldError{Field: g.field, Message: err.Error()})
+			continue
+		}
+		numbers[i] = number
+	}
+	if len(fields) > 0 {
+		return nil, fields, nil
+	}
+
+	walletIDs, err := walletRepo.FindAccountNumbers(tenantID, numbers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]int64, len(numbers))
+	for i, number := range numbers {
+		ids[i] = walletIDs[number]
+	}
+	return ids, nil, nil
+}
+
+// newAccountSummary shows the last digits of the account number and the
+// wallet's display name, from its metadata, with all but the first letter
+// of each word hidden.
+func newAccountSummary(wallet *repository.Wallet) *AccountSummary {
+	var metadata struct {
+		DisplayName string `json:"display_name"`
+	}
+	json.Unmarshal(wallet.Metadata, &metadata)
+
+	words := strings.Fields(metadata.DisplayName)
+	for i, word := range words {
+		letters := []rune(word)
+		words[i] = string(letters[0]) + strings.Repeat("*", len(letters)-1)
+	}
+
+	return &AccountSummary{
+		AccountNumber: account.Mask(wallet.AccountNumber),
+		DisplayName:   strings.Join(words, " "),
+		Currency:      wallet.Currency,
+		Status:        wallet.Status,
+	}
+}
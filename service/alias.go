@@ -0,0 +1,38 @@
+package service
+
+import "time"
+
+// AliasRequest registers a phone number, email address or handle the
+// caller has verified belongs to the wallet's owner.
+type AliasRequest struct {
+	Alias string `json:"alias" validate:"required,max=254"`
+
+	VerifiedBy string `json:"-"`
+}
+
+type AliasResponse struct {
+	AliasID    int64     `json:"alias_id"`
+	WalletID   int64     `json:"wallet_id"`
+	Type       string    `json:"type"`
+	Alias      string    `json:"alias"`
+	VerifiedBy string    `json:"verified_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AliasResolution is what a sender is shown to confirm an alias before
+// paying into it, like AccountSummary.
+type AliasResolution struct {
+	Type          string `json:"type"`
+	Alias         string `json:"alias"`
+	AccountNumber string `json:"account_number"`
+	DisplayName   string `json:"display_name,omitempty"`
+	Currency      string `json:"currency"`
+	Status        string `json:"status"`
+}
+
+type AliasService interface {
+	ListAliases(string, int64) ([]AliasResponse, error)
+	CreateAlias(string, int64, AliasRequest) (*AliasResponse, error)
+	DeleteAlias(string, int64, int64) error
+	ResolveAlias(string, string) (*AliasResolution, error)
+}
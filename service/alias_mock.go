@@ -0,0 +1,31 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type aliasServiceMock struct {
+	mock.Mock
+}
+
+func NewAliasServiceMock() *aliasServiceMock {
+	return &aliasServiceMock{}
+}
+
+func (s *aliasServiceMock) ListAliases(tenantID string, walletID int64) ([]AliasResponse, error) {
+	args := s.Called(tenantID, walletID)
+	return args.Get(0).([]AliasResponse), args.Error(1)
+}
+
+func (s *aliasServiceMock) CreateAlias(tenantID string, walletID int64, r AliasRequest) (*AliasResponse, error) {
+	args := s.Called(tenantID, walletID, r)
+	return args.Get(0).(*AliasResponse), args.Error(1)
+}
+
+func (s *aliasServiceMock) DeleteAlias(tenantID string, walletID int64, aliasID int64) error {
+	args := s.Called(tenantID, walletID, aliasID)
+	return args.Error(0)
+}
+
+func (s *aliasServiceMock) ResolveAlias(tenantID string, value string) (*AliasResolution, error) {
+	args := s.Called(tenantID, value)
+	return args.Get(0).(*AliasResolution), args.Error(1)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/topnarapat/go-wallet/alias"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+type aliasService struct {
+	aliasRepo  repository.AliasRepository
+	walletRepo repository.WalletRepository
+}
+
+func NewAliasService(aliasRepo repository.AliasRepository, walletRepo repository.WalletRepository) AliasService {
+	return aliasService{aliasRepo: aliasRepo, walletRepo: walletRepo}
+}
+
+func (s aliasService) ListAliases(tenantID string, walletID int64) ([]AliasResponse, error) {
+	_, err := s.walletRepo.GetWallet(tenantID, walletID)
+	if err != nil {
+		return nil, aliasError(err)
+	}
+
+	aliases, err := s.aliasRepo.GetAliases(tenantID, walletID)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	aliasResponses := []AliasResponse{}
+	for _, a := range aliases {
+		aliasResponses = append(aliasResponses, *newAliasResponse(&a))
+	}
+
+	return aliasResponses, nil
+}
+
+// CreateAlias registers the alias in normal form, so 081-234-5678 and
+// +66812345678 are the same alias and only one wallet can have it.
+func (s aliasService) CreateAlias(tenantID string, walletID int64, r AliasRequest) (*AliasResponse, error) {
+	a, err := alias.Parse(r.Alias)
+	if err != nil {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "alias", Message: err.Error()}})
+	}
+
+	created, err := s.aliasRepo.CreateAlias(tenantID, repository.NewAlias{
+		WalletID:   walletID,
+		Type:       a.Type,
+		Value:      a.Value,
+		VerifiedBy: r.VerifiedBy,
+	})
+	if err != nil {
+		return nil, aliasError(err)
+	}
+
+	return newAliasResponse(created), nil
+}
+
+func (s aliasService) DeleteAlias(tenantID string, walletID int64, aliasID int64) error {
+	err := s.aliasRepo.DeleteAlias(tenantID, walletID, aliasID)
+	if err != nil {
+		return aliasError(err)
+	}
+
+	return nil
+}
+
+// ResolveAlias finds the wallet an alias, written in any of its forms,
+// points at, showing only what confirms the right one.
+func (s aliasService) ResolveAlias(tenantID string, value string) (*AliasResolution, error) {
+	a, err := alias.Parse(value)
+	if err != nil {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "alias", Message: err.Error()}})
+	}
+
+	walletIDs, err := s.aliasRepo.FindAliases(tenantID, []string{a.Value})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+	walletID, ok := walletIDs[a.Value]
+	if !ok {
+		return nil, errs.NewAliasNotFoundError()
+	}
+
+	wallet, err := s.walletRepo.GetWallet(tenantID, walletID)
+	if err != nil {
+		return nil, aliasError(err)
+	}
+
+	summary := newAccountSummary(wallet)
+	return &AliasResolution{
+		Type:          a.Type,
+		Alias:         a.Value,
+		AccountNumber: summary.AccountNumber,
+		DisplayName:   summary.DisplayName,
+		Currency:      summary.Currency,
+		Status:        summary.Status,
+	}, nil
+}
+
+// aliasField is an alias as given in a request field.
+type aliasField struct {
+	field string
+	alias string
+}
+
+// resolveAliases normalises the aliases given and finds the tenant's
+// wallets they point at, returning the wallet ids in the order given, 0 for
+// an alias nobody registered. Aliases that cannot be parsed are reported
+// against their field instead.
+func resolveAliases(aliasRepo repository.AliasRepository, tenantID string, given []aliasField) ([]int64, []errs.FieldError, error) {
+	fields := []errs.FieldError{}
+	values := make([]string, len(given))
+	for i, g := range given {
+		a, err := alias.Parse(g.alias)
+		if err != nil {
+			fields = append(fields, errs.FieldError{Field: g.field, Message: err.Error()})
+			continue
+		}
+		values[i] = a.Value
+	}
+	if len(fields) > 0 {
+		return nil, fields, nil
+	}
+
+	walletIDs, err := aliasRepo.FindAliases(tenantID, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids := make([]int64, len(values))
+	for i, value := range values {
+		ids[i] = walletIDs[value]
+	}
+	return ids, nil, nil
+}
+
+func newAliasResponse(a *repository.Alias) *AliasResponse {
+	return &AliasResponse{
+		AliasID:    a.AliasID,
+		WalletID:   a.WalletID,
+		Type:       a.Type,
+		Alias:      a.Value,
+		VerifiedBy: a.VerifiedBy,
+		CreatedAt:  a.CreatedAt,
+	}
+}
+
+func aliasError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.NewWalletNotFoundError()
+	case errors.Is(err, repository.ErrAliasNotFound):
+		return errs.NewAliasNotFoundError()
+	case errors.Is(err, repository.ErrAliasTaken):
+		return errs.NewAliasTakenError()
+	case errors.Is(err, repository.ErrWalletClosed):
+		return errs.NewWalletClosedError()
+	}
+
+	return errs.NewUnexpectedError().WithCause(err)
+}
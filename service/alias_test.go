@@ -0,0 +1,185 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestListAliases(t *testing.T) {
+	t.Run("list aliases of wallet", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id}, nil)
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("GetAliases", tenantID, id).Return([]repository.Alias{
+			{AliasID: 1, WalletID: id, Type: "phone", Value: "+66812345678", VerifiedBy: "ops"},
+			{AliasID: 2, WalletID: id, Type: "email", Value: "somchai@example.com", VerifiedBy: "ops"},
+		}, nil)
+
+		aliasService := service.NewAliasService(aliasRepo, walletRepo)
+
+		// Act
+		aliases, err := aliasService.ListAliases(tenantID, id)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, aliases, 2)
+		assert.Equal(t, "+66812345678", aliases[0].Alias)
+	})
+
+	t.Run("wallet not found", func(t *testing.T) {
+		// Arrange
+		var id int64 = 9
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{}, sql.ErrNoRows)
+		aliasRepo := repository.NewAliasRepositoryMock()
+
+		aliasService := service.NewAliasService(aliasRepo, walletRepo)
+
+		// Act
+		_, err := aliasService.ListAliases(tenantID, id)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletNotFoundError())
+		aliasRepo.AssertNotCalled(t, "GetAliases", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreateAlias(t *testing.T) {
+	t.Run("register phone in E.164 form", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("CreateAlias", tenantID, repository.NewAlias{WalletID: id, Type: "phone", Value: "+66812345678", VerifiedBy: "ops"}).Return(&repository.Alias{
+			AliasID: 1, WalletID: id, Type: "phone", Value: "+66812345678", VerifiedBy: "ops",
+		}, nil)
+
+		aliasService := service.NewAliasService(aliasRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		created, err := aliasService.CreateAlias(tenantID, id, service.AliasRequest{Alias: "081-234-5678", VerifiedBy: "ops"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "phone", created.Type)
+		assert.Equal(t, "+66812345678", created.Alias)
+	})
+
+	t.Run("alias taken", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("CreateAlias", tenantID, repository.NewAlias{WalletID: id, Type: "email", Value: "somchai@example.com"}).Return(&repository.Alias{}, repository.ErrAliasTaken)
+
+		aliasService := service.NewAliasService(aliasRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := aliasService.CreateAlias(tenantID, id, service.AliasRequest{Alias: "Somchai@Example.com"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewAliasTakenError())
+	})
+
+	t.Run("closed wallet", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("CreateAlias", tenantID, repository.NewAlias{WalletID: id, Type: "handle", Value: "somchai"}).Return(&repository.Alias{}, repository.ErrWalletClosed)
+
+		aliasService := service.NewAliasService(aliasRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := aliasService.CreateAlias(tenantID, id, service.AliasRequest{Alias: "@somchai"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletClosedError())
+	})
+
+	t.Run("invalid alias", func(t *testing.T) {
+		// Arrange
+		aliasRepo := repository.NewAliasRepositoryMock()
+
+		aliasService := service.NewAliasService(aliasRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := aliasService.CreateAlias(tenantID, 1, service.AliasRequest{Alias: "somchai@localhost"})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "alias", Message: "must be an email address"}}), err)
+		aliasRepo.AssertNotCalled(t, "CreateAlias", mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeleteAlias(t *testing.T) {
+	t.Run("alias not found", func(t *testing.T) {
+		// Arrange
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("DeleteAlias", tenantID, int64(1), int64(7)).Return(repository.ErrAliasNotFound)
+
+		aliasService := service.NewAliasService(aliasRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		err := aliasService.DeleteAlias(tenantID, 1, 7)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewAliasNotFoundError())
+	})
+}
+
+func TestResolveAlias(t *testing.T) {
+	t.Run("masked summary", func(t *testing.T) {
+		// Arrange
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("FindAliases", tenantID, []string{"+66812345678"}).Return(map[string]int64{"+66812345678": 42}, nil)
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(42)).Return(&repository.Wallet{
+			WalletID:      42,
+			AccountNumber: "1234567897",
+			Currency:      "THB",
+			Status:        "Active",
+			Metadata:      json.RawMessage(`{"display_name":"Somchai Jaidee"}`),
+		}, nil)
+
+		aliasService := service.NewAliasService(aliasRepo, walletRepo)
+
+		// Act
+		resolution, err := aliasService.ResolveAlias(tenantID, "+66 81 234 5678")
+		expected := &service.AliasResolution{
+			Type:          "phone",
+			Alias:         "+66812345678",
+			AccountNumber: "xxx-x-xx789-7",
+			DisplayName:   "S****** J*****",
+			Currency:      "THB",
+			Status:        "Active",
+		}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, resolution)
+	})
+
+	t.Run("alias not registered", func(t *testing.T) {
+		// Arrange
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("FindAliases", tenantID, []string{"somchai"}).Return(map[string]int64{}, nil)
+
+		aliasService := service.NewAliasService(aliasRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := aliasService.ResolveAlias(tenantID, "@Somchai")
+
+		// Assert
+		assert.Equal(t, errs.NewAliasNotFoundError(), err)
+	})
+}
@@ -0,0 +1,50 @@
+package service
+
+import "time"
+
+// ApprovalTTL is how long a held change waits for a decision before it
+// expires.
+const ApprovalTTL = 24 * time.Hour
+
+type ListApprovalsRequest struct {
+	Status string `query:"status"`
+}
+
+type RejectApprovalRequest struct {
+	Reason string `json:"reason" validate:"required,max=255"`
+}
+
+type ApprovalResponse struct {
+	ApprovalID   int64           `json:"approval_id"`
+	WalletID     int64           `json:"wallet_id"`
+	Action       string          `json:"action"`
+	Operation    string          `json:"operation,omitempty"`
+	Amount       float64         `json:"amount,omitempty"`
+	WalletStatus string          `json:"wallet_status,omitempty"`
+	Status       string          `json:"status"`
+	RequestedBy  string          `json:"requested_by"`
+	DecidedBy    string          `json:"decided_by,omitempty"`
+	Reason       string          `json:"reason,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ExpiresAt    time.Time       `json:"expires_at"`
+	DecidedAt    *time.Time      `json:"decided_at,omitempty"`
+	Wallet       *WalletResponse `json:"wallet,omitempty"`
+}
+
+// ApprovalRequiredError is returned instead of applying a change that needs
+// a second approver. The change has been stored as Approval.
+type ApprovalRequiredError struct {
+	Approval ApprovalResponse
+}
+
+func (e ApprovalRequiredError) Error() string {
+	return "change held for approval"
+}
+
+type ApprovalService interface {
+	ListApprovals(string, ListApprovalsRequest) ([]ApprovalResponse, error)
+	GetApproval(string, int64) (*ApprovalResponse, error)
+	Approve(string, int64, string) (*ApprovalResponse, error)
+	Reject(string, int64, string, RejectApprovalRequest) (*ApprovalResponse, error)
+	ExpireApprovals() (int64, error)
+}
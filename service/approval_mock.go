@@ -0,0 +1,36 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type approvalServiceMock struct {
+	mock.Mock
+}
+
+func NewApprovalServiceMock() *approvalServiceMock {
+	return &approvalServiceMock{}
+}
+
+func (s *approvalServiceMock) ListApprovals(tenantID string, r ListApprovalsRequest) ([]ApprovalResponse, error) {
+	args := s.Called(tenantID, r)
+	return args.Get(0).([]ApprovalResponse), args.Error(1)
+}
+
+func (s *approvalServiceMock) GetApproval(tenantID string, id int64) (*ApprovalResponse, error) {
+	args := s.Called(tenantID, id)
+	return args.Get(0).(*ApprovalResponse), args.Error(1)
+}
+
+func (s *approvalServiceMock) Approve(tenantID string, id int64, approver string) (*ApprovalResponse, error) {
+	args := s.Called(tenantID, id, approver)
+	return args.Get(0).(*ApprovalResponse), args.Error(1)
+}
+
+func (s *approvalServiceMock) Reject(tenantID string, id int64, approver string, r RejectApprovalRequest) (*ApprovalResponse, error) {
+	args := s.Called(tenantID, id, approver, r)
+	return args.Get(0).(*ApprovalResponse), args.Error(1)
+}
+
+func (s *approvalServiceMock) ExpireApprovals() (int64, error) {
+	args := s.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+type approvalService struct {
+	approvalRepo repository.ApprovalRepository
+	walletSrv    WalletService
+}
+
+func NewApprovalService(approvalRepo repository.ApprovalRepository, walletSrv WalletService) ApprovalService {
+	return approvalService{approvalRepo: approvalRepo, walletSrv: walletSrv}
+}
+
+func (s approvalService) ListApprovals(tenantID string, r ListApprovalsRequest) ([]ApprovalResponse, error) {
+	switch r.Status {
+	case "", repository.ApprovalPending, repository.ApprovalApproved, repository.ApprovalRejected, repository.ApprovalExpired, repository.ApprovalFailed:
+	default:
+		return nil, errs.NewBadRequest("status must be one of Pending, Approved, Rejected, Expired, Failed")
+	}
+
+	approvals, err := s.approvalRepo.GetApprovals(tenantID, r.Status)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	approvalResponses := []ApprovalResponse{}
+	for _, approval := range approvals {
+		approvalResponses = append(approvalResponses, *newApprovalResponse(&approval))
+	}
+
+	return approvalResponses, nil
+}
+
+func (s approvalService) GetApproval(tenantID string, id int64) (*ApprovalResponse, error) {
+	approval, err := s.approvalRepo.GetApproval(tenantID, id)
+	if err != nil {
+		return nil, approvalError(err)
+	}
+
+	return newApprovalResponse(approval), nil
+}
+
+// Approve applies the held change through the same wallet checks as the
+// original request, and the change is approved in the database transaction
+// that applies it. If the change no longer applies the approval is marked
+// Failed instead.
+func (s approvalService) Approve(tenantID string, id int64, approver string) (*ApprovalResponse, error) {
+	approval, err := s.approvalRepo.GetApproval(tenantID, id)
+	if err != nil {
+		return nil, approvalError(err)
+	}
+
+	var wallet *WalletResponse
+	switch approval.Action {
+	case repository.ActionBalance:
+		wallet, err = s.walletSrv.SetWalletBalance(tenantID, approval.WalletID, AddWalletRequest{
+			Balance:     approval.Amount,
+			Operation:   approval.Operation,
+			RequestedBy: approval.RequestedBy,
+			ApprovedBy:  approver,
+			ApprovalID:  approval.ApprovalID,
+		})
+	case repository.ActionStatus:
+		wallet, err = s.walletSrv.SetStatusWallet(tenantID, approval.WalletID, StatusWalletRequest{
+			Status:      approval.WalletStatus,
+			RequestedBy: approval.RequestedBy,
+			ApprovedBy:  approver,
+			ApprovalID:  approval.ApprovalID,
+		})
+	default:
+		err = errs.NewInvalidOperationError("unknown approval action " + approval.Action)
+	}
+	if err != nil {
+		return nil, s.fail(tenantID, approval.ApprovalID, approver, err)
+	}
+
+	approval, err = s.approvalRepo.GetApproval(tenantID, id)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	response := newApprovalResponse(approval)
+	response.Wallet = wallet
+	return response, nil
+}
+
+// fail marks the approval Failed, with the refusal as its reason, when its
+// change was refused. Errors about the approval itself and unexpected ones
+// leave it as it is.
+func (s approvalService) fail(tenantID string, id int64, approver string, err error) error {
+	var appErr errs.AppError
+	if !errors.As(err, &appErr) {
+		return err
+	}
+	switch appErr.Code {
+	case errs.CodeApprovalNotFound, errs.CodeApprovalNotPending, errs.CodeApprovalExpired, errs.CodeForbidden, errs.CodeInternal:
+		return err
+	}
+
+	_, failErr := s.approvalRepo.DecideApproval(tenantID, repository.ApprovalDecision{
+		ApprovalID: id,
+		Status:     repository.ApprovalFailed,
+		DecidedBy:  approver,
+		Reason:     appErr.Message,
+	})
+	if failErr != nil {
+		return approvalError(failErr)
+	}
+
+	return err
+}
+
+func (s approvalService) Reject(tenantID string, id int64, approver string, r RejectApprovalRequest) (*ApprovalResponse, error) {
+	approval, err := s.approvalRepo.DecideApproval(tenantID, repository.ApprovalDecision{
+		ApprovalID: id,
+		Status:     repository.ApprovalRejected,
+		DecidedBy:  approver,
+		Reason:     r.Reason,
+	})
+	if err != nil {
+		return nil, approvalError(err)
+	}
+
+	return newApprovalResponse(approval), nil
+}
+
+func (s approvalService) ExpireApprovals() (int64, error) {
+	n, err := s.approvalRepo.ExpireApprovals()
+	if err != nil {
+		return 0, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return n, nil
+}
+
+func newApprovalResponse(approval *repository.Approval) *ApprovalResponse {
+	return &ApprovalResponse{
+		ApprovalID:   approval.ApprovalID,
+		WalletID:     approval.WalletID,
+		Action:       approval.Action,
+		Operation:    approval.Operation,
+		Amount:       approval.Amount,
+		WalletStatus: approval.WalletStatus,
+		Status:       approval.Status,
+		RequestedBy:  approval.RequestedBy,
+		DecidedBy:    approval.DecidedBy,
+		Reason:       approval.Reason,
+		CreatedAt:    approval.CreatedAt,
+		ExpiresAt:    approval.ExpiresAt,
+		DecidedAt:    approval.DecidedAt,
+	}
+}
+
+func approvalError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.NewApprovalNotFoundError()
+	case errors.Is(err, repository.ErrApprovalNotPending):
+		return errs.NewApprovalNotPendingError()
+	case errors.Is(err, repository.ErrApprovalExpired):
+		return errs.NewApprovalExpiredError()
+	case errors.Is(err, repository.ErrSelfApproval):
+		return errs.NewForbiddenError("approver must differ from requester")
+	}
+
+	return errs.NewUnexpectedError().WithCause(err)
+}
@@ -0,0 +1,256 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestHoldForApproval(t *testing.T) {
+	threshold := 10000.0
+	approvalTenant := func() repository.TenantRepository {
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenant", tenantID).Return(&repository.Tenant{TenantID: tenantID, DefaultCurrency: "THB", ApprovalThreshold: &threshold}, nil)
+		return tenantRepo
+	}
+
+	t.Run("hold balance change above threshold", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1000, Status: "Active"}, nil)
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("CreateApproval", tenantID, repository.NewApproval{
+			WalletID:    id,
+			Action:      repository.ActionBalance,
+			Operation:   "Add",
+			Amount:      50000,
+			RequestedBy: "maker",
+			TTL:         service.ApprovalTTL,
+		}).Return(&repository.Approval{ApprovalID: 7, WalletID: id, Action: repository.ActionBalance, Status: repository.ApprovalPending}, nil)
+
+		walletService := service.NewWalletService(walletRepo, approvalTenant(), approvalRepo, newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 50000, Operation: "Add", RequestedBy: "maker"})
+
+		// Assert
+		var held service.ApprovalRequiredError
+		if assert.ErrorAs(t, err, &held) {
+			assert.Equal(t, int64(7), held.Approval.ApprovalID)
+		}
+		walletRepo.AssertNotCalled(t, "SetBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("apply approved balance change", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1000, Status: "Active"}, nil)
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("ApplyApproval", tenantID, repository.ApprovedChange{ApprovalID: 7, DecidedBy: "checker"}).Return(&repository.Wallet{WalletID: id, Balance: 51000, Status: "Active"}, nil)
+
+		walletService := service.NewWalletService(walletRepo, approvalTenant(), approvalRepo, newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 50000, Operation: "Add", RequestedBy: "maker", ApprovedBy: "checker", ApprovalID: 7})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 51000.0, wallet.Balance)
+		walletRepo.AssertNotCalled(t, "SetBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("approval already decided by another approver", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1000, Status: "Active"}, nil)
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("ApplyApproval", tenantID, mock.Anything).Return(&repository.Wallet{}, repository.ErrApprovalNotPending)
+
+		walletService := service.NewWalletService(walletRepo, approvalTenant(), approvalRepo, newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 50000, Operation: "Add", RequestedBy: "maker", ApprovedBy: "checker", ApprovalID: 7})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewApprovalNotPendingError())
+	})
+
+	t.Run("hold status change of wallet above threshold", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 8000, PocketBalance: 4000, Status: "Active"}, nil)
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("CreateApproval", tenantID, mock.Anything).Return(&repository.Approval{ApprovalID: 8, WalletID: id, Action: repository.ActionStatus, WalletStatus: "Deactive"}, nil)
+
+		walletService := service.NewWalletService(walletRepo, approvalTenant(), approvalRepo, newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetStatusWallet(tenantID, id, service.StatusWalletRequest{Status: "Deactive", RequestedBy: "maker"})
+
+		// Assert
+		assert.ErrorAs(t, err, &service.ApprovalRequiredError{})
+		walletRepo.AssertNotCalled(t, "SetStatusWallet", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestApprove(t *testing.T) {
+	held := func(id int64) *repository.Approval {
+		return &repository.Approval{
+			ApprovalID:  id,
+			WalletID:    1,
+			Action:      repository.ActionBalance,
+			Operation:   "Add",
+			Amount:      50000,
+			Status:      repository.ApprovalPending,
+			RequestedBy: "maker",
+		}
+	}
+
+	t.Run("approve applies held change", func(t *testing.T) {
+		// Arrange
+		var id int64 = 7
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("GetApproval", tenantID, id).Return(held(id), nil).Once()
+		approvalRepo.On("GetApproval", tenantID, id).Return(&repository.Approval{
+			ApprovalID:  id,
+			WalletID:    1,
+			Action:      repository.ActionBalance,
+			Operation:   "Add",
+			Amount:      50000,
+			Status:      repository.ApprovalApproved,
+			RequestedBy: "maker",
+			DecidedBy:   "checker",
+		}, nil).Once()
+		walletService := service.NewWalletServiceMock()
+		walletService.On("SetWalletBalance", tenantID, int64(1), service.AddWalletRequest{Balance: 50000, Operation: "Add", RequestedBy: "maker", ApprovedBy: "checker", ApprovalID: id}).Return(&service.WalletResponse{
+			WalletID: 1,
+			Balance:  51000,
+		}, nil)
+
+		approvalService := service.NewApprovalService(approvalRepo, walletService)
+
+		// Act
+		approval, err := approvalService.Approve(tenantID, id, "checker")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, repository.ApprovalApproved, approval.Status)
+		assert.Equal(t, 51000.0, approval.Wallet.Balance)
+	})
+
+	t.Run("mark failed when change no longer applies", func(t *testing.T) {
+		// Arrange
+		var id int64 = 7
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("GetApproval", tenantID, id).Return(held(id), nil)
+		decision := repository.ApprovalDecision{ApprovalID: id, Status: repository.ApprovalFailed, DecidedBy: "checker", Reason: "balance not enough"}
+		approvalRepo.On("DecideApproval", tenantID, decision).Return(&repository.Approval{ApprovalID: id, Status: repository.ApprovalFailed}, nil)
+		walletService := service.NewWalletServiceMock()
+		walletService.On("SetWalletBalance", tenantID, int64(1), mock.Anything).Return(&service.WalletResponse{}, errs.NewInsufficientFundsError())
+
+		approvalService := service.NewApprovalService(approvalRepo, walletService)
+
+		// Act
+		_, err := approvalService.Approve(tenantID, id, "checker")
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewInsufficientFundsError())
+		approvalRepo.AssertCalled(t, "DecideApproval", tenantID, decision)
+	})
+
+	t.Run("leave pending after unexpected error", func(t *testing.T) {
+		// Arrange
+		var id int64 = 7
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("GetApproval", tenantID, id).Return(held(id), nil)
+		walletService := service.NewWalletServiceMock()
+		walletService.On("SetWalletBalance", tenantID, int64(1), mock.Anything).Return(&service.WalletResponse{}, errs.NewUnexpectedError())
+
+		approvalService := service.NewApprovalService(approvalRepo, walletService)
+
+		// Act
+		_, err := approvalService.Approve(tenantID, id, "checker")
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewUnexpectedError())
+		approvalRepo.AssertNotCalled(t, "DecideApproval", mock.Anything, mock.Anything)
+	})
+
+	cases := []struct {
+		name      string
+		walletErr error
+		expected  error
+	}{
+		{name: "approve own request", walletErr: errs.NewForbiddenError("approver must differ from requester"), expected: errs.NewForbiddenError("")},
+		{name: "approve decided request", walletErr: errs.NewApprovalNotPendingError(), expected: errs.NewApprovalNotPendingError()},
+		{name: "approve expired request", walletErr: errs.NewApprovalExpiredError(), expected: errs.NewApprovalExpiredError()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			approvalRepo := repository.NewApprovalRepositoryMock()
+			approvalRepo.On("GetApproval", tenantID, int64(7)).Return(held(7), nil)
+			walletService := service.NewWalletServiceMock()
+			walletService.On("SetWalletBalance", tenantID, int64(1), mock.Anything).Return(&service.WalletResponse{}, c.walletErr)
+
+			approvalService := service.NewApprovalService(approvalRepo, walletService)
+
+			// Act
+			_, err := approvalService.Approve(tenantID, 7, "maker")
+
+			// Assert
+			assert.ErrorIs(t, err, c.expected)
+			approvalRepo.AssertNotCalled(t, "DecideApproval", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("approve missing approval", func(t *testing.T) {
+		// Arrange
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("GetApproval", tenantID, int64(7)).Return(&repository.Approval{}, sql.ErrNoRows)
+		walletService := service.NewWalletServiceMock()
+
+		approvalService := service.NewApprovalService(approvalRepo, walletService)
+
+		// Act
+		_, err := approvalService.Approve(tenantID, 7, "checker")
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewApprovalNotFoundError())
+		walletService.AssertNotCalled(t, "SetWalletBalance", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestReject(t *testing.T) {
+	t.Run("reject held change", func(t *testing.T) {
+		// Arrange
+		var id int64 = 7
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("DecideApproval", tenantID, repository.ApprovalDecision{ApprovalID: id, Status: repository.ApprovalRejected, DecidedBy: "checker", Reason: "wrong amount"}).Return(&repository.Approval{
+			ApprovalID: id,
+			Status:     repository.ApprovalRejected,
+			Reason:     "wrong amount",
+		}, nil)
+
+		approvalService := service.NewApprovalService(approvalRepo, service.NewWalletServiceMock())
+
+		// Act
+		approval, err := approvalService.Reject(tenantID, id, "checker", service.RejectApprovalRequest{Reason: "wrong amount"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, repository.ApprovalRejected, approval.Status)
+	})
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"io"
+	"time"
+)
+
+// BalanceResponse is a wallet's balance and status at AsOf, not counting
+// its pockets.
+type BalanceResponse struct {
+	WalletID int64     `json:"wallet_id"`
+	AsOf     time.Time `json:"as_of"`
+	Currency string    `json:"currency"`
+	Balance  float64   `json:"balance"`
+	Status   string    `json:"status"`
+	Version  int64     `json:"version"`
+}
+
+// BalanceReport lists every wallet the tenant had at AsOf, with the total
+// balance per currency.
+type BalanceReport struct {
+	AsOf    time.Time          `json:"as_of"`
+	Totals  map[string]float64 `json:"totals"`
+	Wallets []BalanceResponse  `json:"wallets"`
+}
+
+// BalanceService answers point-in-time balance queries. The time is an RFC
+// 3339 timestamp, a timestamp without offset in the business timezone, or
+// a date meaning the end of that day in the business timezone.
+type BalanceService interface {
+	GetBalanceAsOf(string, int64, string) (*BalanceResponse, error)
+	GetBalanceReport(string, string) (*BalanceReport, error)
+	ExportBalanceReport(string, string, io.Writer) error
+	SnapshotDaily(time.Time) error
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"io"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type balanceServiceMock struct {
+	mock.Mock
+}
+
+func NewBalanceServiceMock() *balanceServiceMock {
+	return &balanceServiceMock{}
+}
+
+func (s *balanceServiceMock) GetBalanceAsOf(tenantID string, walletID int64, asOf string) (*BalanceResponse, error) {
+	args := s.Called(tenantID, walletID, asOf)
+	return args.Get(0).(*BalanceResponse), args.Error(1)
+}
+
+func (s *balanceServiceMock) GetBalanceReport(tenantID string, asOf string) (*BalanceReport, error) {
+	args := s.Called(tenantID, asOf)
+	return args.Get(0).(*BalanceReport), args.Error(1)
+}
+
+// ExportBalanceReport writes the string the mock returns to w.
+func (s *balanceServiceMock) ExportBalanceReport(tenantID string, asOf string, w io.Writer) error {
+	args := s.Called(tenantID, asOf, w)
+	io.WriteString(w, args.Get(0).(string))
+	return args.Error(1)
+}
+
+func (s *balanceServiceMock) SnapshotDaily(now time.Time) error {
+	args := s.Called(now)
+	return args.Error(0)
+}
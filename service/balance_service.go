@@ -0,0 +1,160 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+const localTimeLayout = "2006-01-02T15:04:05"
+
+var balanceColumns = []string{"wallet_id", "currency", "balance", "status", "version"}
+
+type balanceService struct {
+	balanceRepo repository.BalanceRepository
+	location    *time.Location
+	now         func() time.Time
+}
+
+// NewBalanceService reads times without an offset, and days, in location.
+func NewBalanceService(balanceRepo repository.BalanceRepository, location *time.Location) BalanceService {
+	return balanceService{balanceRepo: balanceRepo, location: location, now: time.Now}
+}
+
+func (s balanceService) GetBalanceAsOf(tenantID string, walletID int64, asOf string) (*BalanceResponse, error) {
+	at, err := s.asOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.balanceRepo.GetBalanceAt(tenantID, walletID, at)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+
+		return nil, balanceError(err)
+	}
+
+	return newBalanceResponse(balance, at), nil
+}
+
+func (s balanceService) GetBalanceReport(tenantID string, asOf string) (*BalanceReport, error) {
+	at, err := s.asOf(asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BalanceReport{AsOf: at, Totals: map[string]float64{}, Wallets: []BalanceResponse{}}
+	err = s.balanceRepo.GetBalancesAt(tenantID, at, func(b repository.Balance) error {
+		report.Wallets = append(report.Wallets, *newBalanceResponse(&b, at))
+		report.Totals[b.Currency] = roundCents(report.Totals[b.Currency] + b.Balance)
+		return nil
+	})
+	if err != nil {
+		return nil, balanceError(err)
+	}
+
+	return report, nil
+}
+
+// ExportBalanceReport writes the report as CSV, one row per wallet, without
+// building it in memory.
+func (s balanceService) ExportBalanceReport(tenantID string, asOf string, w io.Writer) error {
+	at, err := s.asOf(asOf)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err = writer.Write(balanceColumns); err != nil {
+		return err
+	}
+	err = s.balanceRepo.GetBalancesAt(tenantID, at, func(b repository.Balance) error {
+		return writer.Write([]string{
+			strconv.FormatInt(b.WalletID, 10),
+			b.Currency,
+			strconv.FormatFloat(b.Balance, 'f', 2, 64),
+			b.Status,
+			strconv.FormatInt(b.Version, 10),
+		})
+	})
+	if err != nil {
+		return balanceError(err)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// SnapshotDaily snapshots every wallet's balance at the end of yesterday in
+// the business timezone. Wallets already snapshotted that day are left
+// alone, so it may run any number of times.
+func (s balanceService) SnapshotDaily(now time.Time) error {
+	now = now.In(s.location)
+	yesterday := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, s.location)
+
+	_, err := s.balanceRepo.SnapshotBalances(yesterday, endOfDay(yesterday))
+	return err
+}
+
+// asOf parses the point in time a query asks about.
+func (s balanceService) asOf(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errs.NewFieldValidationError([]errs.FieldError{{Field: "as_of", Message: "is required"}})
+	}
+
+	// A '+' left unescaped in a query string arrives as a space.
+	value = strings.Replace(value, " ", "+", 1)
+	at, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		at, err = time.ParseInLocation(localTimeLayout, value, s.location)
+	}
+	if err != nil {
+		var day time.Time
+		day, err = time.ParseInLocation(dateLayout, value, s.location)
+		at = endOfDay(day)
+	}
+	if err != nil {
+		return time.Time{}, errs.NewFieldValidationError([]errs.FieldError{{Field: "as_of", Message: "must be an RFC 3339 timestamp or a YYYY-MM-DD date"}})
+	}
+	if at.After(s.now()) {
+		return time.Time{}, errs.NewFieldValidationError([]errs.FieldError{{Field: "as_of", Message: "must not be in the future"}})
+	}
+
+	return at.In(s.location), nil
+}
+
+// balanceError maps what the repository returns for a point in time it
+// cannot replay to.
+func balanceError(err error) error {
+	if errors.Is(err, repository.ErrBeforeStream) {
+		return errs.NewFieldValidationError([]errs.FieldError{{Field: "as_of", Message: "must not be before the event store started, where the wallet's history begins"}})
+	}
+
+	return errs.NewUnexpectedError().WithCause(err)
+}
+
+// endOfDay is the last instant of the day, to the microsecond Postgres
+// keeps.
+func endOfDay(day time.Time) time.Time {
+	return day.AddDate(0, 0, 1).Add(-time.Microsecond)
+}
+
+func newBalanceResponse(b *repository.Balance, at time.Time) *BalanceResponse {
+	return &BalanceResponse{
+		WalletID: b.WalletID,
+		AsOf:     at,
+		Currency: b.Currency,
+		Balance:  b.Balance,
+		Status:   b.Status,
+		Version:  b.Version,
+	}
+}
@@ -0,0 +1,174 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"bytes"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+var bangkok = time.FixedZone("ICT", 7*60*60)
+
+func TestGetBalanceAsOf(t *testing.T) {
+	cases := []struct {
+		name string
+		asOf string
+		at   time.Time
+	}{
+		{"timestamp with offset", "2023-03-31T23:59:59+07:00", time.Date(2023, time.March, 31, 16, 59, 59, 0, time.UTC)},
+		{"unescaped plus", "2023-03-31T23:59:59 07:00", time.Date(2023, time.March, 31, 16, 59, 59, 0, time.UTC)},
+		{"business time", "2023-03-31T23:59:59", time.Date(2023, time.March, 31, 16, 59, 59, 0, time.UTC)},
+		{"end of business day", "2023-03-31", time.Date(2023, time.March, 31, 16, 59, 59, 999999000, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			balanceRepo := repository.NewBalanceRepositoryMock()
+			balanceRepo.On("GetBalanceAt", tenantID, int64(42), c.at.In(bangkok)).Return(&repository.Balance{WalletID: 42, TenantID: tenantID, Currency: "THB", Balance: 1250.5, Status: "Active", Version: 17}, nil)
+
+			balanceService := service.NewBalanceService(balanceRepo, bangkok)
+
+			// Act
+			balance, err := balanceService.GetBalanceAsOf(tenantID, 42, c.asOf)
+
+			// Assert
+			if assert.NoError(t, err) {
+				assert.Equal(t, 1250.5, balance.Balance)
+				assert.True(t, c.at.Equal(balance.AsOf))
+				assert.Equal(t, bangkok, balance.AsOf.Location())
+			}
+		})
+	}
+
+	t.Run("wallet did not exist yet", func(t *testing.T) {
+		// Arrange
+		balanceRepo := repository.NewBalanceRepositoryMock()
+		balanceRepo.On("GetBalanceAt", tenantID, int64(42), time.Date(2020, time.January, 1, 23, 59, 59, 999999000, bangkok)).Return((*repository.Balance)(nil), sql.ErrNoRows)
+
+		balanceService := service.NewBalanceService(balanceRepo, bangkok)
+
+		// Act
+		_, err := balanceService.GetBalanceAsOf(tenantID, 42, "2020-01-01")
+
+		// Assert
+		assert.Equal(t, errs.NewWalletNotFoundError(), err)
+	})
+
+	t.Run("before the event store started", func(t *testing.T) {
+		// Arrange
+		balanceRepo := repository.NewBalanceRepositoryMock()
+		balanceRepo.On("GetBalanceAt", tenantID, int64(42), time.Date(2023, time.March, 31, 23, 59, 59, 999999000, bangkok)).Return((*repository.Balance)(nil), repository.ErrBeforeStream)
+
+		balanceService := service.NewBalanceService(balanceRepo, bangkok)
+
+		// Act
+		_, err := balanceService.GetBalanceAsOf(tenantID, 42, "2023-03-31")
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "as_of", Message: "must not be before the event store started, where the wallet's history begins"}}), err)
+	})
+
+	invalid := []struct {
+		name    string
+		asOf    string
+		message string
+	}{
+		{"not a time", "last month", "must be an RFC 3339 timestamp or a YYYY-MM-DD date"},
+		{"future", "2999-01-01", "must not be in the future"},
+	}
+	for _, c := range invalid {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			balanceRepo := repository.NewBalanceRepositoryMock()
+			balanceService := service.NewBalanceService(balanceRepo, bangkok)
+
+			// Act
+			_, err := balanceService.GetBalanceAsOf(tenantID, 42, c.asOf)
+
+			// Assert
+			assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "as_of", Message: c.message}}), err)
+			balanceRepo.AssertNotCalled(t, "GetBalanceAt")
+		})
+	}
+}
+
+func TestBalanceReport(t *testing.T) {
+	at := time.Date(2023, time.March, 31, 23, 59, 59, 999999000, bangkok)
+	balances := []repository.Balance{
+		{WalletID: 1, Currency: "THB", Balance: 1000.1, Status: "Active", Version: 4},
+		{WalletID: 2, Currency: "THB", Balance: 250.2, Status: "Closed", Version: 9},
+		{WalletID: 3, Currency: "USD", Balance: 12, Status: "Active", Version: 1},
+	}
+
+	t.Run("totals per currency", func(t *testing.T) {
+		// Arrange
+		balanceRepo := repository.NewBalanceRepositoryMock()
+		balanceRepo.On("GetBalancesAt", tenantID, at).Return(balances, nil)
+
+		balanceService := service.NewBalanceService(balanceRepo, bangkok)
+
+		// Act
+		report, err := balanceService.GetBalanceReport(tenantID, "2023-03-31")
+
+		// Assert
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[string]float64{"THB": 1250.3, "USD": 12}, report.Totals)
+			assert.Len(t, report.Wallets, 3)
+		}
+	})
+
+	t.Run("before the event store started", func(t *testing.T) {
+		// Arrange
+		balanceRepo := repository.NewBalanceRepositoryMock()
+		balanceRepo.On("GetBalancesAt", tenantID, at).Return([]repository.Balance{}, repository.ErrBeforeStream)
+
+		balanceService := service.NewBalanceService(balanceRepo, bangkok)
+
+		// Act
+		_, err := balanceService.GetBalanceReport(tenantID, "2023-03-31")
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "as_of", Message: "must not be before the event store started, where the wallet's history begins"}}), err)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		// Arrange
+		balanceRepo := repository.NewBalanceRepositoryMock()
+		balanceRepo.On("GetBalancesAt", tenantID, at).Return(balances[:2], nil)
+
+		balanceService := service.NewBalanceService(balanceRepo, bangkok)
+		out := &bytes.Buffer{}
+
+		// Act
+		err := balanceService.ExportBalanceReport(tenantID, "2023-03-31", out)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "wallet_id,currency,balance,status,version\n1,THB,1000.10,Active,4\n2,THB,250.20,Closed,9\n", out.String())
+	})
+}
+
+func TestSnapshotDaily(t *testing.T) {
+	// Arrange
+	balanceRepo := repository.NewBalanceRepositoryMock()
+	day := time.Date(2023, time.March, 31, 0, 0, 0, 0, bangkok)
+	balanceRepo.On("SnapshotBalances", day, time.Date(2023, time.March, 31, 23, 59, 59, 999999000, bangkok)).Return(int64(3), nil)
+
+	balanceService := service.NewBalanceService(balanceRepo, bangkok)
+
+	// Act
+	// 01:30 on 1 April in Bangkok is still 31 March in UTC.
+	err := balanceService.SnapshotDaily(time.Date(2023, time.March, 31, 18, 30, 0, 0, time.UTC))
+
+	// Assert
+	assert.NoError(t, err)
+	balanceRepo.AssertExpectations(t)
+}
@@ -0,0 +1,65 @@
+package service
+
+import "time"
+
+// MaxBatchItems caps the items in one batch.
+const MaxBatchItems = 10000
+
+// BatchItemRequest is one adjustment or transfer of a batch. The
+// destination of a Transfer is given by one of ToAccountNumber or ToAlias,
+// never by a bare wallet id, so a mistyped one is caught by its check digit
+// or as no one's alias; both must be empty otherwise.
+type BatchItemRequest struct {
+	Operation       string  `json:"operation" validate:"required,oneof=Add Deduct Transfer"`
+	WalletID        int64   `json:"wallet_id" validate:"required,gt=0"`
+	ToAccountNumber string  `json:"to_account_number" validate:"max=20"`
+	ToAlias         string  `json:"to_alias" validate:"max=254"`
+	Amount          float64 `json:"amount" validate:"required,gt=0,max=10000000,decimals=2"`
+	Reference       string  `json:"reference" validate:"max=255"`
+}
+
+// CreateBatchRequest submits items for background processing. With
+// all_or_nothing either every item is applied or none is; with best_effort
+// each item succeeds or fails on its own.
+type CreateBatchRequest struct {
+	Mode  string             `json:"mode" validate:"required,oneof=all_or_nothing best_effort"`
+	Items []BatchItemRequest `json:"items" validate:"required,max=10000"`
+
+	RequestedBy string `json:"-"`
+}
+
+type BatchResponse struct {
+	BatchID        int64      `json:"batch_id"`
+	Mode           string     `json:"mode"`
+	Status         string     `json:"status"`
+	TotalItems     int64      `json:"total_items"`
+	ProcessedItems int64      `json:"processed_items"`
+	SucceededItems int64      `json:"succeeded_items"`
+	FailedItems    int64      `json:"failed_items"`
+	SkippedItems   int64      `json:"skipped_items"`
+	RequestedBy    string     `json:"requested_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// BatchItemResponse is one row of a batch's result file.
+type BatchItemResponse struct {
+	Position      int     `json:"position"`
+	Operation     string  `json:"operation"`
+	WalletID      int64   `json:"wallet_id"`
+	ToWalletID    int64   `json:"to_wallet_id,omitempty"`
+	Amount        float64 `json:"amount"`
+	Reference     string  `json:"reference,omitempty"`
+	Status        string  `json:"status"`
+	Fee           float64 `json:"fee,omitempty"`
+	TransactionID *int64  `json:"transaction_id,omitempty"`
+	ErrorCode     string  `json:"error_code,omitempty"`
+	ErrorMessage  string  `json:"error_message,omitempty"`
+}
+
+type BatchService interface {
+	CreateBatch(string, CreateBatchRequest) (*BatchResponse, error)
+	GetBatch(string, int64) (*BatchResponse, error)
+	GetBatchResults(string, int64) ([]BatchItemResponse, error)
+	ProcessBatches() error
+}
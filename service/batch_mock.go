@@ -0,0 +1,31 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type batchServiceMock struct {
+	mock.Mock
+}
+
+func NewBatchServiceMock() *batchServiceMock {
+	return &batchServiceMock{}
+}
+
+func (s *batchServiceMock) CreateBatch(tenantID string, r CreateBatchRequest) (*BatchResponse, error) {
+	args := s.Called(tenantID, r)
+	return args.Get(0).(*BatchResponse), args.Error(1)
+}
+
+func (s *batchServiceMock) GetBatch(tenantID string, id int64) (*BatchResponse, error) {
+	args := s.Called(tenantID, id)
+	return args.Get(0).(*BatchResponse), args.Error(1)
+}
+
+func (s *batchServiceMock) GetBatchResults(tenantID string, id int64) ([]BatchItemResponse, error) {
+	args := s.Called(tenantID, id)
+	return args.Get(0).([]BatchItemResponse), args.Error(1)
+}
+
+func (s *batchServiceMock) ProcessBatches() error {
+	args := s.Called()
+	return args.Error(0)
+}
@@ -0,0 +1,457 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
+)
+
+// batchStaleAfter is how long a claimed batch may go without progress before
+// another worker takes it over.
+const batchStaleAfter = 5 * time.Minute
+
+type batchService struct {
+	batchRepo  repository.BatchRepository
+	walletRepo repository.WalletRepository
+	tenantRepo repository.TenantRepository
+	riskSrv    RiskService
+	feeRepo    repository.FeeRepository
+	aliasRepo  repository.AliasRepository
+}
+
+func NewBatchService(batchRepo repository.BatchRepository, walletRepo repository.WalletRepository, tenantRepo repository.TenantRepository, riskSrv RiskService, feeRepo repository.FeeRepository, aliasRepo repository.AliasRepository) BatchService {
+	return batchService{batchRepo: batchRepo, walletRepo: walletRepo, tenantRepo: tenantRepo, riskSrv: riskSrv, feeRepo: feeRepo, aliasRepo: aliasRepo}
+}
+
+// CreateBatch stores the batch for ProcessBatches. Items are only checked
+// for shape here; wallets, funds and limits are checked as each is applied.
+// Account numbers and aliases are the exception: they are resolved to
+// wallets now, so one that parses but is no wallet's is caught up front.
+func (s batchService) CreateBatch(tenantID string, r CreateBatchRequest) (*BatchResponse, error) {
+	if len(r.Items) == 0 {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "items", Message: "is required"}})
+	}
+
+	toWalletIDs, fields, err := s.destinations(tenantID, r.Items)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	items := make([]repository.NewBatchItem, 0, len(r.Items))
+	for i, item := range r.Items {
+		field := fmt.Sprintf("items[%d].to_account_number", i)
+		if item.ToAlias != "" {
+			field = fmt.Sprintf("items[%d].to_alias", i)
+		}
+		toWalletID := toWalletIDs[i]
+		switch {
+		case (item.ToAccountNumber != "" || item.ToAlias != "") && toWalletID == 0:
+			// Already reported.
+		case item.Operation == repository.BatchTransfer && toWalletID == 0:
+			fields = append(fields, errs.FieldError{Field: field, Message: "is required for Transfer"})
+		case item.Operation == repository.BatchTransfer && toWalletID == item.WalletID:
+			fields = append(fields, errs.FieldError{Field: field, Message: "must differ from wallet_id"})
+		case item.Operation != repository.BatchTransfer && toWalletID != 0:
+			fields = append(fields, errs.FieldError{Field: field, Message: "is only allowed for Transfer"})
+		}
+
+		items = append(items, repository.NewBatchItem{
+			Operation:  item.Operation,
+			WalletID:   item.WalletID,
+			ToWalletID: toWalletID,
+			Amount:     item.Amount,
+			Reference:  item.Reference,
+		})
+	}
+	if len(fields) > 0 {
+		return nil, errs.NewFieldValidationError(fields)
+	}
+
+	batch, err := s.batchRepo.CreateBatch(tenantID, repository.NewBatch{
+		Mode:        r.Mode,
+		RequestedBy: r.RequestedBy,
+		Items:       items,
+	})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return newBatchResponse(batch), nil
+}
+
+// destinations returns each item's destination wallet id, resolved from
+// its account number or alias, and the problems with them. An item names
+// its destination one way only; one named wrongly, or not at all, is left
+// at 0.
+func (s batchService) destinations(tenantID string, items []BatchItemRequest) ([]int64, []errs.FieldError, error) {
+	toWalletIDs := make([]int64, len(items))
+	fields := []errs.FieldError{}
+	var numberPositions, aliasPositions []int
+	numbers := []accountNumberField{}
+	aliases := []aliasField{}
+	for i, item := range items {
+		switch {
+		case item.ToAlias != "" && item.ToAccountNumber != "":
+			fields = append(fields, errs.FieldError{Field: fmt.Sprintf("items[%d].to_alias", i), Message: "must not be given with to_account_number"})
+		case item.ToAccountNumber != "":
+			numberPositions = append(numberPositions, i)
+			numbers = append(numbers, accountNumberField{field: fmt.Sprintf("items[%d].to_account_number", i), number: item.ToAccountNumber})
+		case item.ToAlias != "":
+			aliasPositions = append(aliasPositions, i)
+			aliases = append(aliases, aliasField{field: fmt.Sprintf("items[%d].to_alias", i), alias: item.ToAlias})
+		}
+	}
+
+	if len(numbers) > 0 {
+		ids, numberFields, err := resolveAccountNumbers(s.walletRepo, tenantID, numbers)
+		if err != nil {
+			return nil, nil, err
+		}
+		fields = append(fields, numberFields...)
+		for j, id := range ids {
+			toWalletIDs[numberPositions[j]] = id
+			if id == 0 {
+				fields = append(fields, errs.FieldError{Field: numbers[j].field, Message: "is not the account number of a wallet"})
+			}
+		}
+	}
+	if len(aliases) > 0 {
+		ids, aliasFields, err := resolveAliases(s.aliasRepo, tenantID, aliases)
+		if err != nil {
+			return nil, nil, err
+		}
+		fields = append(fields, aliasFields...)
+		for j, id := range ids {
+			toWalletIDs[aliasPositions[j]] = id
+			if id == 0 {
+				fields = append(fields, errs.FieldError{Field: aliases[j].field, Message: "is not a registered alias"})
+			}
+		}
+	}
+
+	return toWalletIDs, fields, nil
+}
+
+func (s batchService) GetBatch(tenantID string, id int64) (*BatchResponse, error) {
+	batch, err := s.batchRepo.GetBatch(tenantID, id)
+	if err != nil {
+		return nil, batchError(err)
+	}
+
+	return newBatchResponse(batch), nil
+}
+
+func (s batchService) GetBatchResults(tenantID string, id int64) ([]BatchItemResponse, error) {
+	_, err := s.batchRepo.GetBatch(tenantID, id)
+	if err != nil {
+		return nil, batchError(err)
+	}
+
+	items, err := s.batchRepo.GetBatchItems(tenantID, id)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	itemResponses := []BatchItemResponse{}
+	for _, item := range items {
+		itemResponses = append(itemResponses, BatchItemResponse{
+			Position:      item.Position,
+			Operation:     item.Operation,
+			WalletID:      item.WalletID,
+			ToWalletID:    item.ToWalletID,
+			Amount:        item.Amount,
+			Reference:     item.Reference,
+			Status:        item.Status,
+			Fee:           item.Fee,
+			TransactionID: item.TransactionID,
+			ErrorCode:     item.ErrorCode,
+			ErrorMessage:  item.ErrorMessage,
+		})
+	}
+
+	return itemResponses, nil
+}
+
+// ProcessBatches works through the pending batches of every tenant until
+// none is left. An unexpected error stops it with the batch still claimed,
+// so the batch is picked up again once the claim goes stale.
+func (s batchService) ProcessBatches() error {
+	for {
+		batch, err := s.batchRepo.ClaimBatch(batchStaleAfter)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		err = s.process(batch)
+		if err != nil {
+			return fmt.Errorf("batch %d: %w", batch.BatchID, err)
+		}
+	}
+}
+
+// process resumes the batch from its first pending item, so a batch taken
+// over from a failed worker never applies an item twice.
+func (s batchService) process(batch *repository.Batch) error {
+	tenant, err := s.tenantRepo.GetTenant(batch.TenantID)
+	if err != nil {
+		return err
+	}
+
+	items, err := s.batchRepo.GetBatchItems(batch.TenantID, batch.BatchID)
+	if err != nil {
+		return err
+	}
+
+	pending := []repository.BatchItem{}
+	failed := false
+	for _, item := range items {
+		switch item.Status {
+		case repository.BatchItemPending:
+			pending = append(pending, item)
+		case repository.BatchItemFailed:
+			failed = true
+		}
+	}
+
+	if batch.Mode == repository.BatchAllOrNothing {
+		return s.processAllOrNothing(tenant, batch.BatchID, pending, failed)
+	}
+	return s.processBestEffort(tenant, batch.BatchID, pending)
+}
+
+// processBestEffort applies each item in its own database transaction and
+// records why the others failed.
+func (s batchService) processBestEffort(tenant *repository.Tenant, batchID int64, items []repository.BatchItem) error {
+	for _, item := range items {
+		posting, err := s.prepare(tenant, item, map[int64]float64{})
+		if err == nil {
+			err = s.batchRepo.ApplyBatchItems(tenant.TenantID, batchID, []repository.BatchPosting{posting})
+		}
+		if err == nil {
+			continue
+		}
+
+		failure, ok := batchItemFailure(item.ItemID, err)
+		if !ok {
+			return err
+		}
+		err = s.batchRepo.FailBatchItems(tenant.TenantID, batchID, []repository.BatchItemFailure{failure})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := s.batchRepo.FinishBatch(tenant.TenantID, batchID, repository.BatchCompleted)
+	return err
+}
+
+// processAllOrNothing checks every item before applying any, then applies
+// them all in one database transaction. failed reports that an earlier run
+// already failed an item, leaving only the batch to finish.
+func (s batchService) processAllOrNothing(tenant *repository.Tenant, batchID int64, items []repository.BatchItem, failed bool) error {
+	if !failed {
+		failures, err := s.applyAll(tenant, batchID, items)
+		if err != nil {
+			return err
+		}
+		if len(failures) > 0 {
+			err = s.batchRepo.FailBatchItems(tenant.TenantID, batchID, failures)
+			if err != nil {
+				return err
+			}
+			failed = true
+		}
+	}
+
+	status := repository.BatchCompleted
+	if failed {
+		status = repository.BatchFailed
+	}
+	_, err := s.batchRepo.FinishBatch(tenant.TenantID, batchID, status)
+	return err
+}
+
+// applyAll applies the items together, or returns why they could not be.
+func (s batchService) applyAll(tenant *repository.Tenant, batchID int64, items []repository.BatchItem) ([]repository.BatchItemFailure, error) {
+	failures := []repository.BatchItemFailure{}
+	postings := []repository.BatchPosting{}
+	pending := map[int64]float64{}
+	for _, item := range items {
+		posting, err := s.prepare(tenant, item, pending)
+		if err != nil {
+			failure, ok := batchItemFailure(item.ItemID, err)
+			if !ok {
+				return nil, err
+			}
+			failures = append(failures, failure)
+			continue
+		}
+		postings = append(postings, posting)
+	}
+	if len(failures) > 0 || len(postings) == 0 {
+		return failures, nil
+	}
+
+	err := s.batchRepo.ApplyBatchItems(tenant.TenantID, batchID, postings)
+	var itemErr repository.BatchItemError
+	if errors.As(err, &itemErr) {
+		failure, ok := batchItemFailure(itemErr.ItemID, err)
+		if !ok {
+			return nil, err
+		}
+		return append(failures, failure), nil
+	}
+
+	return failures, err
+}
+
+// prepare checks an item as the equivalent single request would be checked
+// and prices it. pending holds the balance changes of earlier items checked
+// but not yet applied, by wallet, and is updated with this item's.
+//
+// Nobody reviews a batch item before it is applied, so an item that would be
+// held for approval fails instead.
+func (s batchService) prepare(tenant *repository.Tenant, item repository.BatchItem, pending map[int64]float64) (repository.BatchPosting, error) {
+	posting := repository.BatchPosting{
+		ItemID:     item.ItemID,
+		Operation:  item.Operation,
+		WalletID:   item.WalletID,
+		ToWalletID: item.ToWalletID,
+		Amount:     item.Amount,
+		Reference:  item.Reference,
+	}
+
+	if tenant.MaxTransactionAmount != nil && item.Amount > *tenant.MaxTransactionAmount {
+		return posting, errs.NewLimitExceededError(fmt.Sprintf("amount must not exceed %.2f", *tenant.MaxTransactionAmount))
+	}
+	if tenant.ApprovalThreshold != nil && item.Amount > *tenant.ApprovalThreshold {
+		return posting, errs.NewApprovalRequiredError(fmt.Sprintf("amounts above %.2f need approval; submit it as a single request", *tenant.ApprovalThreshold))
+	}
+
+	wallet, err := s.walletRepo.GetWallet(tenant.TenantID, item.WalletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return posting, errs.NewWalletNotFoundError()
+		}
+
+		return posting, errs.NewUnexpectedError().WithCause(err)
+	}
+	if wallet.Status == repository.StatusClosed {
+		return posting, errs.NewWalletClosedError()
+	}
+
+	if item.Operation == repository.BatchAdd {
+		if err := checkMaxBalance(tenant, wallet, pending, item.Amount); err != nil {
+			return posting, err
+		}
+		pending[item.WalletID] += item.Amount
+		return posting, nil
+	}
+
+	if item.Operation == repository.BatchTransfer {
+		destination, err := s.walletRepo.GetWallet(tenant.TenantID, item.ToWalletID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return posting, errs.NewInvalidDestinationError("destination wallet not found")
+			}
+
+			return posting, errs.NewUnexpectedError().WithCause(err)
+		}
+		if destination.Status != repository.StatusActive {
+			return posting, errs.NewInvalidDestinationError("destination wallet is not active")
+		}
+		if destination.Currency != wallet.Currency {
+			return posting, errs.NewInvalidDestinationError("destination wallet holds a different currency")
+		}
+		if err := checkMaxBalance(tenant, destination, pending, item.Amount); err != nil {
+			return posting, err
+		}
+	}
+
+	charge, err := feeFor(s.feeRepo, tenant.TenantID, item.Operation, wallet.Currency, item.Amount)
+	if err != nil {
+		return posting, err
+	}
+	if wallet.Balance+pending[item.WalletID] < item.Amount+charge.Fee {
+		return posting, errs.NewInsufficientFundsError()
+	}
+
+	assessment, err := s.riskSrv.AssessDebit(tenant.TenantID, wallet, item.Operation, item.Amount)
+	if err != nil {
+		return posting, err
+	}
+	switch assessment.Decision {
+	case risk.Deny:
+		return posting, errs.NewTransactionDeniedError()
+	case risk.Review:
+		return posting, errs.NewApprovalRequiredError(fmt.Sprintf("held by risk rule %s; submit it as a single request", assessment.Rule))
+	}
+
+	posting.Fee = charge.Fee
+	posting.RevenueWalletID = charge.RevenueWalletID
+	pending[item.WalletID] -= item.Amount + charge.Fee
+	if item.Operation == repository.BatchTransfer {
+		pending[item.ToWalletID] += item.Amount
+	}
+
+	return posting, nil
+}
+
+// checkMaxBalance rejects a credit that would take the wallet, pockets and
+// pending changes included, above the tenant's maximum balance.
+func checkMaxBalance(tenant *repository.Tenant, wallet *repository.Wallet, pending map[int64]float64, amount float64) error {
+	if tenant.MaxBalance != nil && wallet.Balance+wallet.PocketBalance+pending[wallet.WalletID]+amount > *tenant.MaxBalance {
+		return errs.NewLimitExceededError(fmt.Sprintf("balance must not exceed %.2f", *tenant.MaxBalance))
+	}
+
+	return nil
+}
+
+// batchItemFailure describes why an item failed, or reports false when err
+// is not the item's fault, e.g. the database is unavailable, and the batch
+// should be retried instead.
+func batchItemFailure(itemID int64, err error) (repository.BatchItemFailure, bool) {
+	var itemErr repository.BatchItemError
+	if errors.As(err, &itemErr) {
+		err = mutationError(itemErr.Err)
+	}
+
+	var appErr errs.AppError
+	if !errors.As(err, &appErr) || appErr.Code == errs.CodeInternal {
+		return repository.BatchItemFailure{}, false
+	}
+
+	return repository.BatchItemFailure{ItemID: itemID, Code: string(appErr.Code), Message: appErr.Message}, true
+}
+
+func newBatchResponse(batch *repository.Batch) *BatchResponse {
+	return &BatchResponse{
+		BatchID:        batch.BatchID,
+		Mode:           batch.Mode,
+		Status:         batch.Status,
+		TotalItems:     batch.Items,
+		ProcessedItems: batch.Succeeded + batch.Failed + batch.Skipped,
+		SucceededItems: batch.Succeeded,
+		FailedItems:    batch.Failed,
+		SkippedItems:   batch.Skipped,
+		RequestedBy:    batch.RequestedBy,
+		CreatedAt:      batch.CreatedAt,
+		CompletedAt:    batch.CompletedAt,
+	}
+}
+
+func batchError(err error) error {
+	if errors.Is(err, repository.ErrBatchNotFound) {
+		return errs.NewBatchNotFoundError()
+	}
+
+	return errs.NewUnexpectedError().WithCause(err)
+}
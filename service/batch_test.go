@@ -0,0 +1,339 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestCreateBatch(t *testing.T) {
+	t.Run("store items for processing", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{"1234567897": 2}, nil)
+
+		batchRepo := repository.NewBatchRepositoryMock()
+		batchRepo.On("CreateBatch", tenantID, repository.NewBatch{
+			Mode:        "best_effort",
+			RequestedBy: "key_1",
+			Items: []repository.NewBatchItem{
+				{Operation: "Add", WalletID: 1, Amount: 1500, Reference: "payroll"},
+				{Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 250},
+			},
+		}).Return(&repository.Batch{BatchID: 3, Mode: "best_effort", Status: "Pending", RequestedBy: "key_1", Items: 2}, nil)
+
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		batch, err := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode: "best_effort",
+			Items: []service.BatchItemRequest{
+				{Operation: "Add", WalletID: 1, Amount: 1500, Reference: "payroll"},
+				{Operation: "Transfer", WalletID: 1, ToAccountNumber: "123-4-56789-7", Amount: 250},
+			},
+			RequestedBy: "key_1",
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &service.BatchResponse{BatchID: 3, Mode: "best_effort", Status: "Pending", TotalItems: 2, RequestedBy: "key_1"}, batch)
+	})
+
+	t.Run("transfer destination", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897", "1234567897"}).Return(map[string]int64{"1234567897": 1}, nil)
+
+		batchRepo := repository.NewBatchRepositoryMock()
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		_, err := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode: "all_or_nothing",
+			Items: []service.BatchItemRequest{
+				{Operation: "Transfer", WalletID: 1, Amount: 250},
+				{Operation: "Transfer", WalletID: 1, ToAccountNumber: "123-4-56789-7", Amount: 250},
+				{Operation: "Add", WalletID: 1, ToAccountNumber: "123-4-56789-7", Amount: 250},
+			},
+		})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{
+			{Field: "items[0].to_account_number", Message: "is required for Transfer"},
+			{Field: "items[1].to_account_number", Message: "must differ from wallet_id"},
+			{Field: "items[2].to_account_number", Message: "is only allowed for Transfer"},
+		}), err)
+		batchRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("destination by account number", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{"1234567897": 2}, nil)
+
+		batchRepo := repository.NewBatchRepositoryMock()
+		batchRepo.On("CreateBatch", tenantID, repository.NewBatch{
+			Mode:  "best_effort",
+			Items: []repository.NewBatchItem{{Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 250}},
+		}).Return(&repository.Batch{BatchID: 3, Mode: "best_effort", Status: "Pending", Items: 1}, nil)
+
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		_, err := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode:  "best_effort",
+			Items: []service.BatchItemRequest{{Operation: "Transfer", WalletID: 1, ToAccountNumber: "123-4-56789-7", Amount: 250}},
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		batchRepo.AssertExpectations(t)
+	})
+
+	t.Run("account number problems", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"9876543217"}).Return(map[string]int64{}, nil)
+
+		batchRepo := repository.NewBatchRepositoryMock()
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		_, mistyped := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode: "all_or_nothing",
+			Items: []service.BatchItemRequest{
+				{Operation: "Transfer", WalletID: 1, ToAccountNumber: "123-4-56788-7", Amount: 250},
+			},
+		})
+		_, unknown := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode:  "all_or_nothing",
+			Items: []service.BatchItemRequest{{Operation: "Transfer", WalletID: 1, ToAccountNumber: "9876543217", Amount: 250}},
+		})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{
+			{Field: "items[0].to_account_number", Message: "check digit does not match"},
+		}), mistyped)
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{
+			{Field: "items[0].to_account_number", Message: "is not the account number of a wallet"},
+		}), unknown)
+		batchRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+
+	t.Run("destination by alias", func(t *testing.T) {
+		// Arrange
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("FindAliases", tenantID, []string{"+66812345678"}).Return(map[string]int64{"+66812345678": 2}, nil)
+
+		batchRepo := repository.NewBatchRepositoryMock()
+		batchRepo.On("CreateBatch", tenantID, repository.NewBatch{
+			Mode:  "best_effort",
+			Items: []repository.NewBatchItem{{Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 250}},
+		}).Return(&repository.Batch{BatchID: 3, Mode: "best_effort", Status: "Pending", Items: 1}, nil)
+
+		batchService := service.NewBatchService(batchRepo, repository.NewWalletRepositoryMock(), newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), aliasRepo)
+
+		// Act
+		_, err := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode:  "best_effort",
+			Items: []service.BatchItemRequest{{Operation: "Transfer", WalletID: 1, ToAlias: "081-234-5678", Amount: 250}},
+		})
+
+		// Assert
+		assert.NoError(t, err)
+		batchRepo.AssertExpectations(t)
+	})
+
+	t.Run("alias problems", func(t *testing.T) {
+		// Arrange
+		aliasRepo := repository.NewAliasRepositoryMock()
+		aliasRepo.On("FindAliases", tenantID, []string{"somchai@example.com"}).Return(map[string]int64{}, nil)
+
+		batchRepo := repository.NewBatchRepositoryMock()
+		batchService := service.NewBatchService(batchRepo, repository.NewWalletRepositoryMock(), newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), aliasRepo)
+
+		// Act
+		_, malformed := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode: "all_or_nothing",
+			Items: []service.BatchItemRequest{
+				{Operation: "Transfer", WalletID: 1, ToAlias: "@sj", Amount: 250},
+				{Operation: "Transfer", WalletID: 1, ToAccountNumber: "123-4-56789-7", ToAlias: "@somchai", Amount: 250},
+			},
+		})
+		_, unknown := batchService.CreateBatch(tenantID, service.CreateBatchRequest{
+			Mode:  "all_or_nothing",
+			Items: []service.BatchItemRequest{{Operation: "Transfer", WalletID: 1, ToAlias: "Somchai@Example.com", Amount: 250}},
+		})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{
+			{Field: "items[1].to_alias", Message: "must not be given with to_account_number"},
+			{Field: "items[0].to_alias", Message: "must be 3 to 30 letters, digits, dots or underscores, starting with a letter"},
+		}), malformed)
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{
+			{Field: "items[0].to_alias", Message: "is not a registered alias"},
+		}), unknown)
+		batchRepo.AssertNotCalled(t, "CreateBatch", mock.Anything, mock.Anything)
+	})
+}
+
+func TestProcessBatches(t *testing.T) {
+	claim := func(batchRepo *mock.Mock, batch *repository.Batch) {
+		batchRepo.On("ClaimBatch", mock.Anything).Return(batch, nil).Once()
+		batchRepo.On("ClaimBatch", mock.Anything).Return(&repository.Batch{}, sql.ErrNoRows)
+	}
+
+	t.Run("best effort applies what it can", func(t *testing.T) {
+		// Arrange
+		batchRepo := repository.NewBatchRepositoryMock()
+		claim(&batchRepo.Mock, &repository.Batch{BatchID: 3, TenantID: tenantID, Mode: "best_effort"})
+		batchRepo.On("GetBatchItems", tenantID, int64(3)).Return([]repository.BatchItem{
+			{ItemID: 10, Position: 0, Operation: "Add", WalletID: 1, Amount: 1500, Status: "Pending"},
+			{ItemID: 11, Position: 1, Operation: "Deduct", WalletID: 2, Amount: 500, Status: "Pending"},
+		}, nil)
+		batchRepo.On("ApplyBatchItems", tenantID, int64(3), []repository.BatchPosting{{ItemID: 10, Operation: "Add", WalletID: 1, Amount: 1500}}).Return(nil)
+		batchRepo.On("FailBatchItems", tenantID, int64(3), []repository.BatchItemFailure{{ItemID: 11, Code: "INSUFFICIENT_FUNDS", Message: "balance not enough"}}).Return(nil)
+		batchRepo.On("FinishBatch", tenantID, int64(3), "Completed").Return(&repository.Batch{}, nil)
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Currency: "THB", Status: "Active"}, nil)
+		walletRepo.On("GetWallet", tenantID, int64(2)).Return(&repository.Wallet{WalletID: 2, Currency: "THB", Balance: 100, Status: "Active"}, nil)
+
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		err := batchService.ProcessBatches()
+
+		// Assert
+		assert.NoError(t, err)
+		batchRepo.AssertExpectations(t)
+	})
+
+	t.Run("all or nothing counts earlier items", func(t *testing.T) {
+		// Arrange
+		batchRepo := repository.NewBatchRepositoryMock()
+		claim(&batchRepo.Mock, &repository.Batch{BatchID: 3, TenantID: tenantID, Mode: "all_or_nothing"})
+		batchRepo.On("GetBatchItems", tenantID, int64(3)).Return([]repository.BatchItem{
+			{ItemID: 10, Position: 0, Operation: "Deduct", WalletID: 1, Amount: 60, Status: "Pending"},
+			{ItemID: 11, Position: 1, Operation: "Deduct", WalletID: 1, Amount: 60, Status: "Pending"},
+		}, nil)
+		batchRepo.On("FailBatchItems", tenantID, int64(3), []repository.BatchItemFailure{{ItemID: 11, Code: "INSUFFICIENT_FUNDS", Message: "balance not enough"}}).Return(nil)
+		batchRepo.On("FinishBatch", tenantID, int64(3), "Failed").Return(&repository.Batch{}, nil)
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Currency: "THB", Balance: 100, Status: "Active"}, nil)
+
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		err := batchService.ProcessBatches()
+
+		// Assert
+		assert.NoError(t, err)
+		batchRepo.AssertExpectations(t)
+		batchRepo.AssertNotCalled(t, "ApplyBatchItems", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("all or nothing rolls back on a failed item", func(t *testing.T) {
+		// Arrange
+		batchRepo := repository.NewBatchRepositoryMock()
+		claim(&batchRepo.Mock, &repository.Batch{BatchID: 3, TenantID: tenantID, Mode: "all_or_nothing"})
+		batchRepo.On("GetBatchItems", tenantID, int64(3)).Return([]repository.BatchItem{
+			{ItemID: 10, Position: 0, Operation: "Add", WalletID: 1, Amount: 100, Status: "Pending"},
+			{ItemID: 11, Position: 1, Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 50, Status: "Pending"},
+		}, nil)
+		batchRepo.On("ApplyBatchItems", tenantID, int64(3), []repository.BatchPosting{
+			{ItemID: 10, Operation: "Add", WalletID: 1, Amount: 100},
+			{ItemID: 11, Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 50},
+		}).Return(repository.BatchItemError{ItemID: 11, Err: repository.ErrDestinationUnavailable})
+		batchRepo.On("FailBatchItems", tenantID, int64(3), []repository.BatchItemFailure{{ItemID: 11, Code: "INVALID_DESTINATION", Message: "destination wallet cannot receive funds"}}).Return(nil)
+		batchRepo.On("FinishBatch", tenantID, int64(3), "Failed").Return(&repository.Batch{}, nil)
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Currency: "THB", Status: "Active"}, nil)
+		walletRepo.On("GetWallet", tenantID, int64(2)).Return(&repository.Wallet{WalletID: 2, Currency: "THB", Status: "Active"}, nil)
+
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		err := batchService.ProcessBatches()
+
+		// Assert
+		assert.NoError(t, err)
+		batchRepo.AssertExpectations(t)
+	})
+
+	t.Run("transfer pays the fee", func(t *testing.T) {
+		// Arrange
+		batchRepo := repository.NewBatchRepositoryMock()
+		claim(&batchRepo.Mock, &repository.Batch{BatchID: 3, TenantID: tenantID, Mode: "best_effort"})
+		batchRepo.On("GetBatchItems", tenantID, int64(3)).Return([]repository.BatchItem{
+			{ItemID: 10, Position: 0, Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 100, Reference: "rent", Status: "Pending"},
+		}, nil)
+		batchRepo.On("ApplyBatchItems", tenantID, int64(3), []repository.BatchPosting{
+			{ItemID: 10, Operation: "Transfer", WalletID: 1, ToWalletID: 2, Amount: 100, Fee: 5, RevenueWalletID: 99, Reference: "rent"},
+		}).Return(nil)
+		batchRepo.On("FinishBatch", tenantID, int64(3), "Completed").Return(&repository.Batch{}, nil)
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Currency: "THB", Balance: 105, Status: "Active"}, nil)
+		walletRepo.On("GetWallet", tenantID, int64(2)).Return(&repository.Wallet{WalletID: 2, Currency: "THB", Status: "Active"}, nil)
+		feeRepo := repository.NewFeeRepositoryMock()
+		feeRepo.On("GetFeeRule", tenantID, "Transfer", "THB").Return(&repository.FeeRule{Flat: 5, RevenueWalletID: 99}, nil)
+
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), feeRepo, repository.NewAliasRepositoryMock())
+
+		// Act
+		err := batchService.ProcessBatches()
+
+		// Assert
+		assert.NoError(t, err)
+		batchRepo.AssertExpectations(t)
+	})
+
+	t.Run("unexpected error leaves the batch claimed", func(t *testing.T) {
+		// Arrange
+		batchRepo := repository.NewBatchRepositoryMock()
+		claim(&batchRepo.Mock, &repository.Batch{BatchID: 3, TenantID: tenantID, Mode: "best_effort"})
+		batchRepo.On("GetBatchItems", tenantID, int64(3)).Return([]repository.BatchItem{
+			{ItemID: 10, Position: 0, Operation: "Add", WalletID: 1, Amount: 100, Status: "Pending"},
+		}, nil)
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{}, sql.ErrConnDone)
+
+		batchService := service.NewBatchService(batchRepo, walletRepo, newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		err := batchService.ProcessBatches()
+
+		// Assert
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+		batchRepo.AssertNotCalled(t, "FailBatchItems", mock.Anything, mock.Anything, mock.Anything)
+		batchRepo.AssertNotCalled(t, "FinishBatch", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("resume a failed all or nothing batch", func(t *testing.T) {
+		// Arrange
+		batchRepo := repository.NewBatchRepositoryMock()
+		claim(&batchRepo.Mock, &repository.Batch{BatchID: 3, TenantID: tenantID, Mode: "all_or_nothing"})
+		batchRepo.On("GetBatchItems", tenantID, int64(3)).Return([]repository.BatchItem{
+			{ItemID: 10, Position: 0, Operation: "Add", WalletID: 1, Amount: 100, Status: "Pending"},
+			{ItemID: 11, Position: 1, Operation: "Add", WalletID: 404, Amount: 100, Status: "Failed"},
+		}, nil)
+		batchRepo.On("FinishBatch", tenantID, int64(3), "Failed").Return(&repository.Batch{}, nil)
+
+		batchService := service.NewBatchService(batchRepo, repository.NewWalletRepositoryMock(), newTenantRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock(), repository.NewAliasRepositoryMock())
+
+		// Act
+		err := batchService.ProcessBatches()
+
+		// Assert
+		assert.NoError(t, err)
+		batchRepo.AssertExpectations(t)
+		batchRepo.AssertNotCalled(t, "ApplyBatchItems", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
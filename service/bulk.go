@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/topnarapat/go-wallet/errs"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+)
+
+// MaxImportRows is the most wallets a single import may hold.
+const MaxImportRows = 100000
+
+// ImportWalletsRequest carries the wallets to import in Body, either as CSV
+// with a header row naming ImportWalletRow's fields or as NDJSON with one
+// ImportWalletRow object per line.
+type ImportWalletsRequest struct {
+	Format string
+	DryRun bool
+	Body   io.Reader
+}
+
+// ImportWalletRow is one wallet as it stood in the system it comes from.
+// Currency defaults to the tenant's and status to Active; in CSV, metadata
+// and labels are JSON objects.
+type ImportWalletRow struct {
+	Currency  string            `json:"currency"`
+	Balance   float64           `json:"balance" validate:"gte=0,max=10000000,decimals=2"`
+	Status    string            `json:"status" validate:"oneof=Active Deactive"`
+	CreatedAt string            `json:"created_at" validate:"required"`
+	Metadata  json.RawMessage   `json:"metadata"`
+	Labels    map[string]string `json:"labels" validate:"max=20"`
+}
+
+// ImportReport lists every problem found, by row; rows[0] is the first
+// wallet. WalletIDs holds the new wallets' ids in row order.
+type ImportReport struct {
+	DryRun       bool              `json:"dry_run"`
+	Rows         int               `json:"rows"`
+	Imported     int               `json:"imported"`
+	TotalBalance float64           `json:"total_balance"`
+	Errors       []errs.FieldError `json:"errors"`
+	WalletIDs    []int64           `json:"wallet_ids,omitempty"`
+}
+
+// WalletExport is a wallet's full current state, closed wallets included.
+type WalletExport struct {
+	WalletID      int64             `json:"wallet_id"`
+	ID            string            `json:"id"`
+	AccountNumber string            `json:"account_number"`
+	Currency      string            `json:"currency"`
+	Balance       float64           `json:"balance"`
+	PocketBalance float64           `json:"pocket_balance"`
+	Status        string            `json:"status"`
+	Version       int64             `json:"version"`
+	CreatedAt     time.Time         `json:"created_at"`
+	ClosedAt      *time.Time        `json:"closed_at,omitempty"`
+	ClosureReason string            `json:"closure_reason,omitempty"`
+	Metadata      json.RawMessage   `json:"metadata"`
+	Labels        map[string]string `json:"labels"`
+}
+
+// BulkWalletService moves wallets in and out of a tenant in bulk, for
+// migrations from other systems.
+type BulkWalletService interface {
+	ImportWallets(string, ImportWalletsRequest) (*ImportReport, error)
+	ExportWallets(string, string, io.Writer) error
+}
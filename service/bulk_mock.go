@@ -0,0 +1,28 @@
+package service
+
+import (
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type bulkWalletServiceMock struct {
+	mock.Mock
+}
+
+func NewBulkWalletServiceMock() *bulkWalletServiceMock {
+	return &bulkWalletServiceMock{}
+}
+
+func (s *bulkWalletServiceMock) ImportWallets(tenantID string, r ImportWalletsRequest) (*ImportReport, error) {
+	args := s.Called(tenantID, r)
+	return args.Get(0).(*ImportReport), args.Error(1)
+}
+
+func (s *bulkWalletServiceMock) ExportWallets(tenantID string, format string, w io.Writer) error {
+	args := s.Called(tenantID, format, w)
+	if body, ok := args.Get(0).(string); ok {
+		io.WriteString(w, body)
+	}
+	return args.Error(1)
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/topnarapat/go-wallet/account"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/validate"
+)
+
+// importColumns are the CSV columns an import may have; balance and
+// created_at are required.
+var importColumns = []string{"currency", "balance", "status", "created_at", "metadata", "labels"}
+
+var exportColumns = []string{"wallet_id", "id", "account_number", "currency", "balance", "pocket_balance", "status", "version", "created_at", "closed_at", "closure_reason", "metadata", "labels"}
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type bulkWalletService struct {
+	walletRepo repository.WalletRepository
+	tenantRepo repository.TenantRepository
+	now        func() time.Time
+}
+
+func NewBulkWalletService(walletRepo repository.WalletRepository, tenantRepo repository.TenantRepository) BulkWalletService {
+	return bulkWalletService{walletRepo: walletRepo, tenantRepo: tenantRepo, now: time.Now}
+}
+
+// ImportWallets checks every row and, unless it is a dry run, imports them
+// all at once. A real import with any problem imports nothing and fails
+// with the report's errors.
+func (s bulkWalletService) ImportWallets(tenantID string, r ImportWalletsRequest) (*ImportReport, error) {
+	tenant, err := s.tenantRepo.GetTenant(tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewTenantNotFoundError()
+		}
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	var rows []ImportWalletRow
+	var fields []errs.FieldError
+	switch r.Format {
+	case FormatCSV:
+		rows, fields, err = readImportCSV(r.Body)
+	case FormatNDJSON:
+		rows, fields, err = readImportNDJSON(r.Body)
+	default:
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "format", Message: "must be one of csv, ndjson"}})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ImportReport{DryRun: r.DryRun, Rows: len(rows), Errors: append([]errs.FieldError{}, fields...)}
+	switch {
+	case rows == nil:
+		// The header was unusable; its problems are already reported.
+	case len(rows) == 0:
+		report.Errors = append(report.Errors, errs.FieldError{Field: "rows", Message: "is required"})
+	case len(rows) > MaxImportRows:
+		report.Rows = MaxImportRows
+		report.Errors = append(report.Errors, errs.FieldError{Field: "rows", Message: fmt.Sprintf("must be at most %d", MaxImportRows)})
+	}
+
+	wallets := make([]repository.ImportedWallet, 0, len(rows))
+	for i, row := range rows[:report.Rows] {
+		wallet, rowFields := s.importedWallet(tenant, row)
+		for _, field := range rowFields {
+			field.Field = fmt.Sprintf("rows[%d].%s", i, field.Field)
+			if !hasFieldError(report.Errors, field.Field) {
+				report.Errors = append(report.Errors, field)
+			}
+		}
+		wallets = append(wallets, wallet)
+		report.TotalBalance += row.Balance
+	}
+	report.TotalBalance = roundCents(report.TotalBalance)
+
+	if r.DryRun {
+		return report, nil
+	}
+	if len(report.Errors) > 0 {
+		return nil, errs.NewFieldValidationError(report.Errors)
+	}
+
+	report.WalletIDs, err = s.walletRepo.ImportWallets(tenantID, wallets)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+	report.Imported = len(report.WalletIDs)
+
+	return report, nil
+}
+
+// ExportWallets writes every wallet of the tenant to w as CSV with a header
+// row or as NDJSON, one WalletExport per line.
+func (s bulkWalletService) ExportWallets(tenantID string, format string, w io.Writer) error {
+	var write func(WalletExport) error
+	var flush func() error
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(exportColumns); err != nil {
+			return err
+		}
+		write = func(e WalletExport) error {
+			return writer.Write(exportRecord(e))
+		}
+		flush = func() error {
+			writer.Flush()
+			return writer.Error()
+		}
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+		write = func(e WalletExport) error {
+			return encoder.Encode(e)
+		}
+		flush = func() error { return nil }
+	default:
+		return errs.NewFieldValidationError([]errs.FieldError{{Field: "format", Message: "must be one of csv, ndjson"}})
+	}
+
+	err := s.walletRepo.ExportWallets(tenantID, func(wallet repository.Wallet) error {
+		return write(newWalletExport(&wallet))
+	})
+	if err != nil {
+		return errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return flush()
+}
+
+// importedWallet checks a row against the same rules wallets created through
+// the API follow, and fills in the defaults.
+func (s bulkWalletService) importedWallet(tenant *repository.Tenant, row ImportWalletRow) (repository.ImportedWallet, []errs.FieldError) {
+	fields := []errs.FieldError{}
+	var appErr errs.AppError
+	if err := validate.Struct(row); errors.As(err, &appErr) {
+		fields = append(fields, appErr.Fields...)
+	}
+
+	wallet := repository.ImportedWallet{
+		Currency: row.Currency,
+		Balance:  row.Balance,
+		Status:   row.Status,
+		Metadata: row.Metadata,
+		Labels:   row.Labels,
+	}
+	if wallet.Currency == "" {
+		wallet.Currency = tenant.DefaultCurrency
+	} else if !currencyPattern.MatchString(wallet.Currency) {
+		fields = append(fields, errs.FieldError{Field: "currency", Message: "must be a 3-letter ISO 4217 code"})
+	}
+	if wallet.Status == "" {
+		wallet.Status = repository.StatusActive
+	}
+	if tenant.MaxBalance != nil && row.Balance > *tenant.MaxBalance {
+		fields = append(fields, errs.FieldError{Field: "balance", Message: fmt.Sprintf("must not exceed %.2f", *tenant.MaxBalance)})
+	}
+	if row.CreatedAt != "" {
+		createdAt, err := time.Parse(time.RFC3339, row.CreatedAt)
+		switch {
+		case err != nil:
+			fields = append(fields, errs.FieldError{Field: "created_at", Message: "must be an RFC 3339 timestamp"})
+		case createdAt.After(s.now()):
+			fields = append(fields, errs.FieldError{Field: "created_at", Message: "must not be in the future"})
+		}
+		wallet.CreatedAt = createdAt
+	}
+
+	fields = append(fields, validateMetadata(row.Metadata)...)
+	for _, key := range sortedKeys(row.Labels) {
+		value := row.Labels[key]
+		fields = append(fields, validateLabel(key, &value)...)
+	}
+
+	return wallet, fields
+}
+
+// readImportCSV reads rows from CSV whose header row names the columns, in
+// any order. It returns nil rows when the header itself has problems, and
+// stops one row past the most an import may hold.
+func readImportCSV(r io.Reader) ([]ImportWalletRow, []errs.FieldError, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []ImportWalletRow{}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, errs.NewInvalidRequestBodyError().WithCause(err)
+	}
+
+	fields := []errs.FieldError{}
+	columns := map[string]int{}
+	for i, name := range header {
+		// Spreadsheets often start the file with a byte order mark.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !containsString(importColumns, name) {
+			fields = append(fields, errs.FieldError{Field: name, Message: "is not allowed"})
+			continue
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"balance", "created_at"} {
+		if _, ok := columns[name]; !ok {
+			fields = append(fields, errs.FieldError{Field: name, Message: "column is required"})
+		}
+	}
+	if len(fields) > 0 {
+		return nil, fields, nil
+	}
+
+	rows := []ImportWalletRow{}
+	for len(rows) <= MaxImportRows {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errs.NewInvalidRequestBodyError().WithCause(err)
+		}
+
+		position := len(rows)
+		value := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		invalid := func(name, message string) {
+			fields = append(fields, errs.FieldError{Field: fmt.Sprintf("rows[%d].%s", position, name), Message: message})
+		}
+
+		row := ImportWalletRow{
+			Currency:  value("currency"),
+			Status:    value("status"),
+			CreatedAt: value("created_at"),
+		}
+		if s := value("balance"); s != "" {
+			row.Balance, err = strconv.ParseFloat(s, 64)
+			if err != nil {
+				invalid("balance", "must be a number")
+			}
+		}
+		if s := value("metadata"); s != "" {
+			if json.Valid([]byte(s)) {
+				row.Metadata = json.RawMessage(s)
+			} else {
+				invalid("metadata", "must be a JSON object")
+			}
+		}
+		if s := value("labels"); s != "" {
+			if json.Unmarshal([]byte(s), &row.Labels) != nil {
+				invalid("labels", "must be a JSON object of strings")
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, fields, nil
+}
+
+// readImportNDJSON reads one row per non-blank line. Fields a row does not
+// know and values of the wrong type are reported against the row.
+func readImportNDJSON(r io.Reader) ([]ImportWalletRow, []errs.FieldError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	fields := []errs.FieldError{}
+	rows := []ImportWalletRow{}
+	for len(rows) <= MaxImportRows && scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		position := len(rows)
+		invalid := func(name, message string) {
+			field := fmt.Sprintf("rows[%d]", position)
+			if name != "" {
+				field += "." + name
+			}
+			fields = append(fields, errs.FieldError{Field: field, Message: message})
+		}
+
+		raw := map[string]json.RawMessage{}
+		if json.Unmarshal(line, &raw) != nil {
+			invalid("", "must be a JSON object")
+			rows = append(rows, ImportWalletRow{})
+			continue
+		}
+		for _, key := range sortedKeys(raw) {
+			if !containsString(importColumns, key) {
+				invalid(key, "is not allowed")
+			}
+		}
+
+		row := ImportWalletRow{}
+		var typeErr *json.UnmarshalTypeError
+		if err := json.Unmarshal(line, &row); errors.As(err, &typeErr) {
+			invalid(typeErr.Field, "has the wrong type")
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, errs.NewInvalidRequestBodyError().WithCause(err)
+	}
+
+	return rows, fields, nil
+}
+
+func newWalletExport(wallet *repository.Wallet) WalletExport {
+	return WalletExport{
+		WalletID:      wallet.WalletID,
+		ID:            wallet.PublicID,
+		AccountNumber: account.Format(wallet.AccountNumber),
+		Currency:      wallet.Currency,
+		Balance:       wallet.Balance,
+		PocketBalance: wallet.PocketBalance,
+		Status:        wallet.Status,
+		Version:       wallet.Version,
+		CreatedAt:     wallet.CreatedAt,
+		ClosedAt:      wallet.ClosedAt,
+		ClosureReason: wallet.ClosureReason,
+		Metadata:      wallet.Metadata,
+		Labels:        wallet.Labels,
+	}
+}
+
+func exportRecord(e WalletExport) []string {
+	closedAt := ""
+	if e.ClosedAt != nil {
+		closedAt = e.ClosedAt.Format(time.RFC3339)
+	}
+	labels, _ := json.Marshal(e.Labels)
+
+	return []string{
+		strconv.FormatInt(e.WalletID, 10),
+		e.ID,
+		e.AccountNumber,
+		e.Currency,
+		strconv.FormatFloat(e.Balance, 'f', 2, 64),
+		strconv.FormatFloat(e.PocketBalance, 'f', 2, 64),
+		e.Status,
+		strconv.FormatInt(e.Version, 10),
+		e.CreatedAt.Format(time.RFC3339),
+		closedAt,
+		e.ClosureReason,
+		string(e.Metadata),
+		string(labels),
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFieldError(fields []errs.FieldError, name string) bool {
+	for _, f := range fields {
+		if f.Field == name {
+			return true
+		}
+	}
+	return false
+}
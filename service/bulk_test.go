@@ -0,0 +1,188 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestImportWallets(t *testing.T) {
+	imported := []repository.ImportedWallet{
+		{Currency: "THB", Balance: 1500.25, Status: "Active", CreatedAt: time.Date(2019, time.May, 1, 9, 30, 0, 0, time.UTC), Metadata: json.RawMessage(`{"legacy_id":"A-17"}`), Labels: map[string]string{"tier": "gold"}},
+		{Currency: "USD", Balance: 0, Status: "Deactive", CreatedAt: time.Date(2020, time.January, 2, 0, 0, 0, 0, time.FixedZone("", 7*60*60))},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("ImportWallets", tenantID, imported).Return([]int64{41, 42}, nil)
+
+		bulkService := service.NewBulkWalletService(walletRepo, newTenantRepositoryMock())
+
+		body := "\ufeffBalance,created_at,currency,status,metadata,labels\n" +
+			`1500.25,2019-05-01T09:30:00Z,,,"{""legacy_id"":""A-17""}","{""tier"":""gold""}"` + "\n" +
+			"0,2020-01-02T00:00:00+07:00,USD,Deactive,,\n"
+
+		// Act
+		report, err := bulkService.ImportWallets(tenantID, service.ImportWalletsRequest{Format: "csv", Body: strings.NewReader(body)})
+		expected := &service.ImportReport{Rows: 2, Imported: 2, TotalBalance: 1500.25, Errors: []errs.FieldError{}, WalletIDs: []int64{41, 42}}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, report)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("ImportWallets", tenantID, imported).Return([]int64{41, 42}, nil)
+
+		bulkService := service.NewBulkWalletService(walletRepo, newTenantRepositoryMock())
+
+		body := `{"balance":1500.25,"created_at":"2019-05-01T09:30:00Z","metadata":{"legacy_id":"A-17"},"labels":{"tier":"gold"}}` + "\n\n" +
+			`{"currency":"USD","balance":0,"status":"Deactive","created_at":"2020-01-02T00:00:00+07:00"}` + "\n"
+
+		// Act
+		report, err := bulkService.ImportWallets(tenantID, service.ImportWalletsRequest{Format: "ndjson", Body: strings.NewReader(body)})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{41, 42}, report.WalletIDs)
+	})
+
+	t.Run("dry run reports every problem", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		bulkService := service.NewBulkWalletService(walletRepo, newTenantRepositoryMock())
+
+		body := `{"balance":-1,"created_at":"2019-05-01","currency":"thb"}` + "\n" +
+			`{"balance":"10","created_at":"2999-01-01T00:00:00Z","owner":"x"}` + "\n" +
+			"not json\n"
+
+		// Act
+		report, err := bulkService.ImportWallets(tenantID, service.ImportWalletsRequest{Format: "ndjson", DryRun: true, Body: strings.NewReader(body)})
+		expected := &service.ImportReport{DryRun: true, Rows: 3, TotalBalance: -1, Errors: []errs.FieldError{
+			{Field: "rows[1].owner", Message: "is not allowed"},
+			{Field: "rows[1].balance", Message: "has the wrong type"},
+			{Field: "rows[2]", Message: "must be a JSON object"},
+			{Field: "rows[0].balance", Message: "must be at least 0"},
+			{Field: "rows[0].currency", Message: "must be a 3-letter ISO 4217 code"},
+			{Field: "rows[0].created_at", Message: "must be an RFC 3339 timestamp"},
+			{Field: "rows[1].created_at", Message: "must not be in the future"},
+			{Field: "rows[2].created_at", Message: "is required"},
+		}}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, report)
+		walletRepo.AssertNotCalled(t, "ImportWallets", mock.Anything, mock.Anything)
+	})
+
+	t.Run("problems import nothing", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		bulkService := service.NewBulkWalletService(walletRepo, newTenantRepositoryMock())
+
+		// Act
+		_, err := bulkService.ImportWallets(tenantID, service.ImportWalletsRequest{Format: "csv", Body: strings.NewReader("balance,created_at\nten,2019-05-01T09:30:00Z\n")})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "rows[0].balance", Message: "must be a number"}}), err)
+		walletRepo.AssertNotCalled(t, "ImportWallets", mock.Anything, mock.Anything)
+	})
+
+	t.Run("csv columns", func(t *testing.T) {
+		// Arrange
+		bulkService := service.NewBulkWalletService(repository.NewWalletRepositoryMock(), newTenantRepositoryMock())
+
+		// Act
+		report, err := bulkService.ImportWallets(tenantID, service.ImportWalletsRequest{Format: "csv", DryRun: true, Body: strings.NewReader("wallet_id,balance\n1,10\n")})
+		expected := []errs.FieldError{
+			{Field: "wallet_id", Message: "is not allowed"},
+			{Field: "created_at", Message: "column is required"},
+		}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, report.Errors)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		// Arrange
+		bulkService := service.NewBulkWalletService(repository.NewWalletRepositoryMock(), newTenantRepositoryMock())
+
+		// Act
+		_, err := bulkService.ImportWallets(tenantID, service.ImportWalletsRequest{Format: "ndjson", Body: strings.NewReader("")})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "rows", Message: "is required"}}), err)
+	})
+}
+
+func TestExportWallets(t *testing.T) {
+	closedAt := time.Date(2023, time.February, 1, 8, 0, 0, 0, time.UTC)
+	wallets := []repository.Wallet{
+		{WalletID: 1, PublicID: "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3", AccountNumber: "1234567897", Currency: "THB", Balance: 1000, PocketBalance: 250.5, Status: "Active", Version: 3, CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC), Metadata: json.RawMessage(`{"legacy_id":"A-17"}`), Labels: map[string]string{"tier": "gold"}},
+		{WalletID: 2, PublicID: "wal_0d8e5b3a7f21c6e49b0a3d7c5e1f8a26", AccountNumber: "9876543217", Currency: "THB", Balance: 0, Status: "Closed", Version: 5, CreatedAt: time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC), ClosedAt: &closedAt, ClosureReason: "customer request", Metadata: json.RawMessage(`{}`), Labels: map[string]string{}},
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("ExportWallets", tenantID).Return(wallets, nil)
+
+		bulkService := service.NewBulkWalletService(walletRepo, newTenantRepositoryMock())
+
+		// Act
+		out := &bytes.Buffer{}
+		err := bulkService.ExportWallets(tenantID, "csv", out)
+		expected := "wallet_id,id,account_number,currency,balance,pocket_balance,status,version,created_at,closed_at,closure_reason,metadata,labels\n" +
+			`1,wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3,123-4-56789-7,THB,1000.00,250.50,Active,3,2023-01-27T12:30:00Z,,,"{""legacy_id"":""A-17""}","{""tier"":""gold""}"` + "\n" +
+			"2,wal_0d8e5b3a7f21c6e49b0a3d7c5e1f8a26,987-6-54321-7,THB,0.00,0.00,Closed,5,2023-01-27T12:30:00Z,2023-02-01T08:00:00Z,customer request,{},{}\n"
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("ExportWallets", tenantID).Return(wallets[1:], nil)
+
+		bulkService := service.NewBulkWalletService(walletRepo, newTenantRepositoryMock())
+
+		// Act
+		out := &bytes.Buffer{}
+		err := bulkService.ExportWallets(tenantID, "ndjson", out)
+		expected := `{"wallet_id":2,"id":"wal_0d8e5b3a7f21c6e49b0a3d7c5e1f8a26","account_number":"987-6-54321-7","currency":"THB","balance":0,"pocket_balance":0,"status":"Closed","version":5,"created_at":"2023-01-27T12:30:00Z","closed_at":"2023-02-01T08:00:00Z","closure_reason":"customer request","metadata":{},"labels":{}}` + "\n"
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out.String())
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		bulkService := service.NewBulkWalletService(walletRepo, newTenantRepositoryMock())
+
+		// Act
+		err := bulkService.ExportWallets(tenantID, "xlsx", &bytes.Buffer{})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "format", Message: "must be one of csv, ndjson"}}), err)
+		walletRepo.AssertNotCalled(t, "ExportWallets", mock.Anything)
+	})
+}
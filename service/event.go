@@ -0,0 +1,29 @@
+package service
+
+import (
+	"time"
+
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+type EventResponse struct {
+	EventID   int64     `json:"event_id"`
+	WalletID  int64     `json:"wallet_id"`
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Balance   float64   `json:"balance"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscriber delivers a tenant's wallet events as they commit; walletID 0
+// means every wallet of the tenant. The returned function cancels the
+// subscription.
+type Subscriber interface {
+	Subscribe(tenantID string, walletID int64) (<-chan repository.WalletEvent, func())
+}
+
+type EventService interface {
+	EventsAfter(tenantID string, walletID int64, afterID int64) ([]EventResponse, error)
+	Subscribe(tenantID string, walletID int64) (<-chan EventResponse, func(), error)
+}
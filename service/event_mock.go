@@ -0,0 +1,21 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type eventServiceMock struct {
+	mock.Mock
+}
+
+func NewEventServiceMock() *eventServiceMock {
+	return &eventServiceMock{}
+}
+
+func (s *eventServiceMock) EventsAfter(tenantID string, walletID int64, afterID int64) ([]EventResponse, error) {
+	args := s.Called(tenantID, walletID, afterID)
+	return args.Get(0).([]EventResponse), args.Error(1)
+}
+
+func (s *eventServiceMock) Subscribe(tenantID string, walletID int64) (<-chan EventResponse, func(), error) {
+	args := s.Called(tenantID, walletID)
+	return args.Get(0).(<-chan EventResponse), args.Get(1).(func()), args.Error(2)
+}
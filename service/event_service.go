@@ -0,0 +1,90 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+const eventPageSize = 500
+
+type eventService struct {
+	eventRepo  repository.EventRepository
+	walletRepo repository.WalletRepository
+	subscriber Subscriber
+}
+
+func NewEventService(eventRepo repository.EventRepository, walletRepo repository.WalletRepository, subscriber Subscriber) EventService {
+	return eventService{eventRepo: eventRepo, walletRepo: walletRepo, subscriber: subscriber}
+}
+
+// EventsAfter returns the full history after afterID, paging through the
+// event store so a long disconnect does not leave a gap.
+func (s eventService) EventsAfter(tenantID string, walletID int64, afterID int64) ([]EventResponse, error) {
+	responses := []EventResponse{}
+	for {
+		events, err := s.eventRepo.GetEventsAfter(tenantID, walletID, afterID, eventPageSize)
+		if err != nil {
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+
+		for _, event := range events {
+			responses = append(responses, newEventResponse(event))
+			afterID = event.EventID
+		}
+
+		if len(events) < eventPageSize {
+			return responses, nil
+		}
+	}
+}
+
+func (s eventService) Subscribe(tenantID string, walletID int64) (<-chan EventResponse, func(), error) {
+	if walletID != 0 {
+		_, err := s.walletRepo.GetWallet(tenantID, walletID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, errs.NewWalletNotFoundError()
+			}
+
+			return nil, nil, errs.NewUnexpectedError().WithCause(err)
+		}
+	}
+
+	events, unsubscribe := s.subscriber.Subscribe(tenantID, walletID)
+	responses := make(chan EventResponse)
+	done := make(chan struct{})
+	go func() {
+		defer close(responses)
+		for event := range events {
+			select {
+			case responses <- newEventResponse(event):
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return responses, func() {
+		once.Do(func() {
+			close(done)
+			unsubscribe()
+		})
+	}, nil
+}
+
+func newEventResponse(event repository.WalletEvent) EventResponse {
+	return EventResponse{
+		EventID:   event.EventID,
+		WalletID:  event.WalletID,
+		Type:      event.Type,
+		Amount:    event.Amount,
+		Balance:   event.Balance,
+		Status:    event.Status,
+		CreatedAt: event.CreatedAt,
+	}
+}
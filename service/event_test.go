@@ -0,0 +1,77 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/events"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestEventsAfter(t *testing.T) {
+	t.Run("replay history after event id", func(t *testing.T) {
+		// Arrange
+		createdAt := time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC)
+		eventRepo := repository.NewEventRepositoryMock()
+		eventRepo.On("GetEventsAfter", tenantID, int64(1), int64(10), 500).Return([]repository.WalletEvent{
+			{EventID: 11, WalletID: 1, Type: "credited", Amount: 100, Balance: 1100, Status: "Active", CreatedAt: createdAt},
+			{EventID: 12, WalletID: 1, Type: "debited", Amount: 50, Balance: 1050, Status: "Active", CreatedAt: createdAt},
+		}, nil)
+
+		eventService := service.NewEventService(eventRepo, repository.NewWalletRepositoryMock(), events.NewBroker())
+
+		// Act
+		history, err := eventService.EventsAfter(tenantID, 1, 10)
+		expected := []service.EventResponse{
+			{EventID: 11, WalletID: 1, Type: "credited", Amount: 100, Balance: 1100, Status: "Active", CreatedAt: createdAt},
+			{EventID: 12, WalletID: 1, Type: "debited", Amount: 50, Balance: 1050, Status: "Active", CreatedAt: createdAt},
+		}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, history)
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	t.Run("receive published events", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id}, nil)
+		broker := events.NewBroker()
+
+		eventService := service.NewEventService(repository.NewEventRepositoryMock(), walletRepo, broker)
+
+		// Act
+		ch, unsubscribe, err := eventService.Subscribe(tenantID, id)
+		defer unsubscribe()
+		broker.Publish(repository.WalletEvent{EventID: 7, TenantID: tenantID, WalletID: id, Type: "credited"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, service.EventResponse{EventID: 7, WalletID: id, Type: "credited"}, <-ch)
+	})
+
+	t.Run("wallet not found", func(t *testing.T) {
+		// Arrange
+		var id int64 = 99
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{}, sql.ErrNoRows)
+
+		eventService := service.NewEventService(repository.NewEventRepositoryMock(), walletRepo, events.NewBroker())
+
+		// Act
+		_, _, err := eventService.Subscribe(tenantID, id)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletNotFoundError())
+	})
+}
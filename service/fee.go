@@ -0,0 +1,28 @@
+package service
+
+const (
+	FeeOperationDeduct   = "Deduct"
+	FeeOperationTransfer = "Transfer"
+)
+
+// FeeQuoteRequest prices an operation without executing it. The currency is
+// taken from WalletID when given, then from Currency, and otherwise is the
+// tenant's default currency.
+type FeeQuoteRequest struct {
+	Operation string  `query:"operation"`
+	Amount    float64 `query:"amount"`
+	WalletID  int64   `query:"wallet_id"`
+	Currency  string  `query:"currency"`
+}
+
+type FeeQuoteResponse struct {
+	Operation string  `json:"operation"`
+	Currency  string  `json:"currency"`
+	Amount    float64 `json:"amount"`
+	Fee       float64 `json:"fee"`
+	Total     float64 `json:"total"`
+}
+
+type FeeService interface {
+	Quote(string, FeeQuoteRequest) (*FeeQuoteResponse, error)
+}
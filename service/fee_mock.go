@@ -0,0 +1,16 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type feeServiceMock struct {
+	mock.Mock
+}
+
+func NewFeeServiceMock() *feeServiceMock {
+	return &feeServiceMock{}
+}
+
+func (s *feeServiceMock) Quote(tenantID string, q FeeQuoteRequest) (*FeeQuoteResponse, error) {
+	args := s.Called(tenantID, q)
+	return args.Get(0).(*FeeQuoteResponse), args.Error(1)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+type feeService struct {
+	feeRepo    repository.FeeRepository
+	walletRepo repository.WalletRepository
+	tenantRepo repository.TenantRepository
+}
+
+func NewFeeService(feeRepo repository.FeeRepository, walletRepo repository.WalletRepository, tenantRepo repository.TenantRepository) FeeService {
+	return feeService{feeRepo: feeRepo, walletRepo: walletRepo, tenantRepo: tenantRepo}
+}
+
+func (s feeService) Quote(tenantID string, q FeeQuoteRequest) (*FeeQuoteResponse, error) {
+	if q.Operation != FeeOperationDeduct && q.Operation != FeeOperationTransfer {
+		return nil, errs.NewInvalidOperationError("operation must be Deduct or Transfer")
+	}
+	if !(q.Amount > 0) || math.IsInf(q.Amount, 0) {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "amount", Message: "must be greater than 0"}})
+	}
+
+	currency := q.Currency
+	if q.WalletID != 0 {
+		wallet, err := s.walletRepo.GetWallet(tenantID, q.WalletID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errs.NewWalletNotFoundError()
+			}
+
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+		currency = wallet.Currency
+	}
+	if currency == "" {
+		tenant, err := s.tenantRepo.GetTenant(tenantID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errs.NewTenantNotFoundError()
+			}
+
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+		currency = tenant.DefaultCurrency
+	}
+
+	charge, err := feeFor(s.feeRepo, tenantID, q.Operation, currency, q.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FeeQuoteResponse{
+		Operation: q.Operation,
+		Currency:  currency,
+		Amount:    q.Amount,
+		Fee:       charge.Fee,
+		Total:     roundCents(q.Amount + charge.Fee),
+	}, nil
+}
+
+// feeCharge is the fee for one operation and the wallet it is paid into.
+// The zero value means no fee.
+type feeCharge struct {
+	Fee             float64
+	RevenueWalletID int64
+}
+
+// feeFor prices the operation under the tenant's fee schedule, so quotes and
+// executed operations always agree.
+func feeFor(feeRepo repository.FeeRepository, tenantID string, operation string, currency string, amount float64) (feeCharge, error) {
+	rule, err := feeRepo.GetFeeRule(tenantID, operation, currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return feeCharge{}, nil
+		}
+
+		return feeCharge{}, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return feeCharge{Fee: calculateFee(rule, amount), RevenueWalletID: rule.RevenueWalletID}, nil
+}
+
+// calculateFee applies the rule to the amount. With tiers, the first tier
+// covering the amount sets the flat and percentage parts, and amounts above
+// every tier use the last one. The result is rounded to cents.
+func calculateFee(rule *repository.FeeRule, amount float64) float64 {
+	flat, percent := rule.Flat, rule.Percent
+	for i, tier := range rule.Tiers {
+		if tier.UpTo == nil || amount <= *tier.UpTo || i == len(rule.Tiers)-1 {
+			flat, percent = tier.Flat, tier.Percent
+			break
+		}
+	}
+
+	fee := flat + amount*percent/100
+	if rule.MinFee != nil && fee < *rule.MinFee {
+		fee = *rule.MinFee
+	}
+	if rule.MaxFee != nil && fee > *rule.MaxFee {
+		fee = *rule.MaxFee
+	}
+
+	return roundCents(fee)
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
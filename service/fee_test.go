@@ -0,0 +1,154 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestQuoteFee(t *testing.T) {
+	float := func(f float64) *float64 { return &f }
+
+	cases := []struct {
+		name   string
+		rule   repository.FeeRule
+		amount float64
+		fee    float64
+	}{
+		{"flat", repository.FeeRule{Flat: 10}, 500, 10},
+		{"percentage", repository.FeeRule{Percent: 1.5}, 333.33, 5},
+		{"flat plus percentage", repository.FeeRule{Flat: 2, Percent: 1}, 500, 7},
+		{"minimum", repository.FeeRule{Percent: 1, MinFee: float(5)}, 100, 5},
+		{"maximum", repository.FeeRule{Percent: 1, MaxFee: float(50)}, 100000, 50},
+		{"lower tier", repository.FeeRule{Tiers: []repository.FeeTier{{UpTo: float(1000), Flat: 5}, {Percent: 1}}}, 1000, 5},
+		{"upper tier", repository.FeeRule{Tiers: []repository.FeeTier{{UpTo: float(1000), Flat: 5}, {Percent: 1}}}, 2000, 20},
+		{"above every tier", repository.FeeRule{Tiers: []repository.FeeTier{{UpTo: float(1000), Flat: 5}, {UpTo: float(5000), Flat: 15}}}, 9000, 15},
+		{"tier with cap", repository.FeeRule{Tiers: []repository.FeeTier{{Percent: 2}}, MaxFee: float(30)}, 5000, 30},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			feeRepo := repository.NewFeeRepositoryMock()
+			feeRepo.On("GetFeeRule", tenantID, "Deduct", "THB").Return(&c.rule, nil)
+
+			feeService := service.NewFeeService(feeRepo, repository.NewWalletRepositoryMock(), repository.NewTenantRepositoryMock())
+
+			// Act
+			quote, err := feeService.Quote(tenantID, service.FeeQuoteRequest{Operation: "Deduct", Amount: c.amount, Currency: "THB"})
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, c.fee, quote.Fee)
+			assert.Equal(t, c.amount+c.fee, quote.Total)
+		})
+	}
+
+	t.Run("price in wallet currency", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Currency: "USD"}, nil)
+		feeRepo := repository.NewFeeRepositoryMock()
+		feeRepo.On("GetFeeRule", tenantID, "Transfer", "USD").Return(&repository.FeeRule{Flat: 1}, nil)
+
+		feeService := service.NewFeeService(feeRepo, walletRepo, repository.NewTenantRepositoryMock())
+
+		// Act
+		quote, err := feeService.Quote(tenantID, service.FeeQuoteRequest{Operation: "Transfer", Amount: 100, WalletID: 1, Currency: "THB"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &service.FeeQuoteResponse{Operation: "Transfer", Currency: "USD", Amount: 100, Fee: 1, Total: 101}, quote)
+	})
+
+	t.Run("no fee without a rule", func(t *testing.T) {
+		// Arrange
+		feeService := service.NewFeeService(newFeeRepositoryMock(), repository.NewWalletRepositoryMock(), newTenantRepositoryMock())
+
+		// Act
+		quote, err := feeService.Quote(tenantID, service.FeeQuoteRequest{Operation: "Deduct", Amount: 100})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &service.FeeQuoteResponse{Operation: "Deduct", Currency: "THB", Amount: 100, Fee: 0, Total: 100}, quote)
+	})
+
+	t.Run("invalid operation", func(t *testing.T) {
+		// Arrange
+		feeService := service.NewFeeService(repository.NewFeeRepositoryMock(), repository.NewWalletRepositoryMock(), repository.NewTenantRepositoryMock())
+
+		// Act
+		_, err := feeService.Quote(tenantID, service.FeeQuoteRequest{Operation: "Add", Amount: 100})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewInvalidOperationError(""))
+	})
+}
+
+func TestDeductWithFee(t *testing.T) {
+	feeRule := func() repository.FeeRepository {
+		feeRepo := repository.NewFeeRepositoryMock()
+		feeRepo.On("GetFeeRule", tenantID, "Deduct", "THB").Return(&repository.FeeRule{Flat: 10, RevenueWalletID: 99}, nil)
+		return feeRepo
+	}
+
+	t.Run("charge fee to revenue wallet", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Currency: "THB", Balance: 1000, Status: "Active"}, nil)
+		walletRepo.On("DeductWithFee", tenantID, repository.FeeDeduction{WalletID: id, Amount: 500, Fee: 10, RevenueWalletID: 99}).Return(&repository.Wallet{WalletID: id, Currency: "THB", Balance: 490, Status: "Active", TransactionID: 7}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), feeRule())
+
+		// Act
+		wallet, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 500, Operation: "Deduct"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 490.0, wallet.Balance)
+		assert.Equal(t, 10.0, wallet.Fee)
+		assert.Equal(t, int64(7), wallet.TransactionID)
+		walletRepo.AssertNotCalled(t, "SetBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("balance must cover the fee", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Currency: "THB", Balance: 505, Status: "Active"}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), feeRule())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 500, Operation: "Deduct"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewInsufficientFundsError())
+		walletRepo.AssertNotCalled(t, "DeductWithFee", mock.Anything, mock.Anything)
+	})
+
+	t.Run("fee lookup failure", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Currency: "THB", Balance: 1000, Status: "Active"}, nil)
+		feeRepo := repository.NewFeeRepositoryMock()
+		feeRepo.On("GetFeeRule", tenantID, "Deduct", "THB").Return(&repository.FeeRule{}, sql.ErrConnDone)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), feeRepo)
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 500, Operation: "Deduct"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewUnexpectedError())
+	})
+}
@@ -0,0 +1,42 @@
+package service
+
+import "time"
+
+const (
+	DayCountActual365    = "ACT/365"
+	DayCountActual360    = "ACT/360"
+	DayCountActualActual = "ACT/ACT"
+)
+
+type AccrueInterestRequest struct {
+	Date string `json:"date" validate:"required"`
+}
+
+type PostInterestRequest struct {
+	Period string `json:"period" validate:"required"`
+}
+
+type InterestAccrualResponse struct {
+	Date     string  `json:"date"`
+	Rate     float64 `json:"rate"`
+	DayCount string  `json:"day_count"`
+	Wallets  int64   `json:"wallets"`
+}
+
+type InterestPostingResponse struct {
+	WalletID      int64   `json:"wallet_id"`
+	Period        string  `json:"period"`
+	Accrued       float64 `json:"accrued"`
+	CarriedIn     float64 `json:"carried_in"`
+	Amount        float64 `json:"amount"`
+	CarryOut      float64 `json:"carry_out"`
+	TransactionID *int64  `json:"transaction_id,omitempty"`
+}
+
+// InterestService accrues interest daily and posts it monthly. Both steps
+// may be re-run for the same date or month without paying twice.
+type InterestService interface {
+	AccrueInterest(string, AccrueInterestRequest) (*InterestAccrualResponse, error)
+	PostInterest(string, PostInterestRequest) ([]InterestPostingResponse, error)
+	RunDaily(time.Time) error
+}
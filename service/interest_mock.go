@@ -0,0 +1,30 @@
+package service
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type interestServiceMock struct {
+	mock.Mock
+}
+
+func NewInterestServiceMock() *interestServiceMock {
+	return &interestServiceMock{}
+}
+
+func (s *interestServiceMock) AccrueInterest(tenantID string, r AccrueInterestRequest) (*InterestAccrualResponse, error) {
+	args := s.Called(tenantID, r)
+	return args.Get(0).(*InterestAccrualResponse), args.Error(1)
+}
+
+func (s *interestServiceMock) PostInterest(tenantID string, r PostInterestRequest) ([]InterestPostingResponse, error) {
+	args := s.Called(tenantID, r)
+	return args.Get(0).([]InterestPostingResponse), args.Error(1)
+}
+
+func (s *interestServiceMock) RunDaily(now time.Time) error {
+	args := s.Called(now)
+	return args.Error(0)
+}
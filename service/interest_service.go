@@ -0,0 +1,227 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+const (
+	dateLayout   = "2006-01-02"
+	periodLayout = "2006-01"
+)
+
+type interestService struct {
+	interestRepo repository.InterestRepository
+	tenantRepo   repository.TenantRepository
+	location     *time.Location
+	now          func() time.Time
+}
+
+// NewInterestService accrues each day on the balance at its end in
+// location.
+func NewInterestService(interestRepo repository.InterestRepository, tenantRepo repository.TenantRepository, location *time.Location) InterestService {
+	return interestService{interestRepo: interestRepo, tenantRepo: tenantRepo, location: location, now: time.Now}
+}
+
+func (s interestService) AccrueInterest(tenantID string, r AccrueInterestRequest) (*InterestAccrualResponse, error) {
+	date, err := time.Parse(dateLayout, r.Date)
+	if err != nil {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "date", Message: "must be a date in YYYY-MM-DD format"}})
+	}
+	if !date.Before(s.today()) {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "date", Message: "must be a day that has ended"}})
+	}
+
+	tenant, err := s.interestTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.accrue(tenant, date)
+}
+
+func (s interestService) PostInterest(tenantID string, r PostInterestRequest) ([]InterestPostingResponse, error) {
+	period, err := time.Parse(periodLayout, r.Period)
+	if err != nil {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "period", Message: "must be a month in YYYY-MM format"}})
+	}
+	if period.AddDate(0, 1, 0).After(s.today()) {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "period", Message: "month must have ended"}})
+	}
+
+	if _, err = s.interestTenant(tenantID); err != nil {
+		return nil, err
+	}
+
+	return s.post(tenantID, period)
+}
+
+// RunDaily accrues, for every tenant paying interest, each day from the one
+// after its last accrual up to yesterday, so days missed while the server
+// was down are caught up, and posts every month that has ended with
+// accruals not yet posted. A tenant that never accrued starts at yesterday.
+// It keeps going past a failing tenant and returns the first error.
+func (s interestService) RunDaily(now time.Time) error {
+	now = now.In(s.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	tenants, err := s.tenantRepo.GetTenants()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, tenant := range tenants {
+		if tenant.InterestRate == nil {
+			continue
+		}
+		tenant := tenant
+
+		err := s.catchUp(&tenant, today)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("interest for tenant %s: %w", tenant.TenantID, err)
+		}
+	}
+
+	return firstErr
+}
+
+// catchUp accrues the tenant's days before today not yet accrued, skipping
+// months already posted, then posts the months ended before today.
+func (s interestService) catchUp(tenant *repository.Tenant, today time.Time) error {
+	progress, err := s.interestRepo.GetInterestProgress(tenant.TenantID)
+	if err != nil {
+		return err
+	}
+
+	day := today.AddDate(0, 0, -1)
+	if progress.LastAccrued != nil {
+		day = progress.LastAccrued.AddDate(0, 0, 1)
+	}
+	if progress.LastPosted != nil && day.Before(progress.LastPosted.AddDate(0, 1, 0)) {
+		day = progress.LastPosted.AddDate(0, 1, 0)
+	}
+
+	periods := progress.Unposted
+	for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+		if _, err = s.accrue(tenant, day); err != nil {
+			return err
+		}
+		period := day.AddDate(0, 0, 1-day.Day())
+		if len(periods) == 0 || periods[len(periods)-1].Before(period) {
+			periods = append(periods, period)
+		}
+	}
+
+	for _, period := range periods {
+		if period.AddDate(0, 1, 0).After(today) {
+			break
+		}
+		if _, err = s.post(tenant.TenantID, period); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s interestService) accrue(tenant *repository.Tenant, date time.Time) (*InterestAccrualResponse, error) {
+	days, err := daysInYear(tenant.InterestDayCount, date.Year())
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	n, err := s.interestRepo.AccrueInterest(tenant.TenantID, repository.InterestAccrual{
+		Date:       date,
+		Until:      time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, s.location),
+		Rate:       *tenant.InterestRate,
+		DayCount:   tenant.InterestDayCount,
+		DaysInYear: days,
+	})
+	if errors.Is(err, repository.ErrBeforeLedger) {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "date", Message: "must not be before the ledger started"}})
+	}
+	if errors.Is(err, repository.ErrPeriodPosted) {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "date", Message: "must not be in a month already posted"}})
+	}
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return &InterestAccrualResponse{
+		Date:     date.Format(dateLayout),
+		Rate:     *tenant.InterestRate,
+		DayCount: tenant.InterestDayCount,
+		Wallets:  n,
+	}, nil
+}
+
+func (s interestService) post(tenantID string, period time.Time) ([]InterestPostingResponse, error) {
+	postings, err := s.interestRepo.PostInterest(tenantID, period)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	postingResponses := []InterestPostingResponse{}
+	for _, p := range postings {
+		postingResponses = append(postingResponses, InterestPostingResponse{
+			WalletID:      p.WalletID,
+			Period:        p.Period.Format(periodLayout),
+			Accrued:       p.Accrued,
+			CarriedIn:     p.CarriedIn,
+			Amount:        p.Amount,
+			CarryOut:      p.CarryOut,
+			TransactionID: p.TransactionID,
+		})
+	}
+
+	return postingResponses, nil
+}
+
+// interestTenant returns the tenant if it pays interest.
+func (s interestService) interestTenant(tenantID string) (*repository.Tenant, error) {
+	tenant, err := s.tenantRepo.GetTenant(tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewTenantNotFoundError()
+		}
+
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+	if tenant.InterestRate == nil {
+		return nil, errs.NewInterestNotConfiguredError()
+	}
+
+	return tenant, nil
+}
+
+// today is the current day in the business location, as a date at UTC
+// midnight like the dates the requests give.
+func (s interestService) today() time.Time {
+	now := s.now().In(s.location)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysInYear is the denominator that turns an annual rate into a daily one
+// under the day-count convention. ACT/ACT uses the actual length of the
+// accrual date's year.
+func daysInYear(convention string, year int) (float64, error) {
+	switch convention {
+	case DayCountActual365:
+		return 365, nil
+	case DayCountActual360:
+		return 360, nil
+	case DayCountActualActual:
+		if year%4 == 0 && (year%100 != 0 || year%400 == 0) {
+			return 366, nil
+		}
+		return 365, nil
+	}
+
+	return 0, fmt.Errorf("unknown day-count convention %q", convention)
+}
@@ -0,0 +1,277 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func interestTenant(dayCount string) *repository.Tenant {
+	rate := 1.5
+	return &repository.Tenant{TenantID: tenantID, DefaultCurrency: "THB", InterestRate: &rate, InterestDayCount: dayCount}
+}
+
+func TestAccrueInterest(t *testing.T) {
+	cases := []struct {
+		name       string
+		dayCount   string
+		date       string
+		daysInYear float64
+	}{
+		{"ACT/365", service.DayCountActual365, "2024-02-29", 365},
+		{"ACT/360", service.DayCountActual360, "2024-02-29", 360},
+		{"ACT/ACT leap year", service.DayCountActualActual, "2024-02-29", 366},
+		{"ACT/ACT common year", service.DayCountActualActual, "2023-02-28", 365},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			date, _ := time.Parse("2006-01-02", c.date)
+			tenantRepo := repository.NewTenantRepositoryMock()
+			tenantRepo.On("GetTenant", tenantID).Return(interestTenant(c.dayCount), nil)
+			interestRepo := repository.NewInterestRepositoryMock()
+			interestRepo.On("AccrueInterest", tenantID, repository.InterestAccrual{Date: date, Until: date.AddDate(0, 0, 1), Rate: 1.5, DayCount: c.dayCount, DaysInYear: c.daysInYear}).Return(int64(3), nil)
+
+			interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+			// Act
+			accrual, err := interestService.AccrueInterest(tenantID, service.AccrueInterestRequest{Date: c.date})
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, &service.InterestAccrualResponse{Date: c.date, Rate: 1.5, DayCount: c.dayCount, Wallets: 3}, accrual)
+			interestRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("not configured", func(t *testing.T) {
+		// Arrange
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestService := service.NewInterestService(interestRepo, newTenantRepositoryMock(), time.UTC)
+
+		// Act
+		_, err := interestService.AccrueInterest(tenantID, service.AccrueInterestRequest{Date: "2023-03-31"})
+
+		// Assert
+		assert.Equal(t, errs.NewInterestNotConfiguredError(), err)
+		interestRepo.AssertNotCalled(t, "AccrueInterest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("date before the ledger started", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenant", tenantID).Return(interestTenant(service.DayCountActual365), nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("AccrueInterest", tenantID, mock.Anything).Return(int64(0), repository.ErrBeforeLedger)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+		// Act
+		_, err := interestService.AccrueInterest(tenantID, service.AccrueInterestRequest{Date: "2023-01-31"})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "date", Message: "must not be before the ledger started"}}), err)
+	})
+
+	t.Run("date in a month already posted", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenant", tenantID).Return(interestTenant(service.DayCountActual365), nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("AccrueInterest", tenantID, mock.Anything).Return(int64(0), repository.ErrPeriodPosted)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+		// Act
+		_, err := interestService.AccrueInterest(tenantID, service.AccrueInterestRequest{Date: "2023-03-15"})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "date", Message: "must not be in a month already posted"}}), err)
+	})
+
+	notEnded := []struct {
+		name string
+		date string
+	}{
+		{"future date", time.Now().AddDate(0, 0, 2).Format("2006-01-02")},
+		{"today in the business timezone", time.Now().In(bangkok).Format("2006-01-02")},
+	}
+	for _, c := range notEnded {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			interestRepo := repository.NewInterestRepositoryMock()
+			interestService := service.NewInterestService(interestRepo, repository.NewTenantRepositoryMock(), bangkok)
+
+			// Act
+			_, err := interestService.AccrueInterest(tenantID, service.AccrueInterestRequest{Date: c.date})
+
+			// Assert
+			assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "date", Message: "must be a day that has ended"}}), err)
+			interestRepo.AssertNotCalled(t, "AccrueInterest", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPostInterest(t *testing.T) {
+	t.Run("posted", func(t *testing.T) {
+		// Arrange
+		period := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+		transactionID := int64(9)
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenant", tenantID).Return(interestTenant(service.DayCountActual365), nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("PostInterest", tenantID, period).Return([]repository.InterestPosting{
+			{WalletID: 1, Period: period, Accrued: 1.2739, CarriedIn: 0.0041, Amount: 1.27, CarryOut: 0.008, TransactionID: &transactionID},
+		}, nil)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+		// Act
+		postings, err := interestService.PostInterest(tenantID, service.PostInterestRequest{Period: "2023-03"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, []service.InterestPostingResponse{
+			{WalletID: 1, Period: "2023-03", Accrued: 1.2739, CarriedIn: 0.0041, Amount: 1.27, CarryOut: 0.008, TransactionID: &transactionID},
+		}, postings)
+	})
+
+	t.Run("month not ended", func(t *testing.T) {
+		// Arrange
+		interestService := service.NewInterestService(repository.NewInterestRepositoryMock(), repository.NewTenantRepositoryMock(), time.UTC)
+
+		// Act
+		_, err := interestService.PostInterest(tenantID, service.PostInterestRequest{Period: time.Now().UTC().Format("2006-01")})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "period", Message: "month must have ended"}}), err)
+	})
+}
+
+func TestRunDailyInterest(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2023, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	accruedOn := func(day time.Time) interface{} {
+		return mock.MatchedBy(func(a repository.InterestAccrual) bool { return a.Date.Equal(day) })
+	}
+
+	t.Run("accrues yesterday", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenants").Return([]repository.Tenant{*interestTenant(service.DayCountActual365), {TenantID: "globex"}}, nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("GetInterestProgress", tenantID).Return(&repository.InterestProgress{Unposted: []time.Time{}}, nil)
+		interestRepo.On("AccrueInterest", tenantID, mock.Anything).Return(int64(1), nil)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+		// Act
+		err := interestService.RunDaily(time.Date(2023, 3, 15, 2, 0, 0, 0, time.UTC))
+
+		// Assert
+		assert.NoError(t, err)
+		interestRepo.AssertNumberOfCalls(t, "AccrueInterest", 1)
+		interestRepo.AssertCalled(t, "AccrueInterest", tenantID, accruedOn(date(3, 14)))
+		interestRepo.AssertNotCalled(t, "AccrueInterest", "globex", mock.Anything)
+		interestRepo.AssertNotCalled(t, "PostInterest", mock.Anything, mock.Anything)
+	})
+
+	t.Run("yesterday in the business timezone", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenants").Return([]repository.Tenant{*interestTenant(service.DayCountActual365)}, nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("GetInterestProgress", tenantID).Return(&repository.InterestProgress{LastAccrued: ptrTime(date(3, 13)), Unposted: []time.Time{date(3, 1)}}, nil)
+		interestRepo.On("AccrueInterest", tenantID, mock.Anything).Return(int64(1), nil)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, bangkok)
+
+		// Act
+		err := interestService.RunDaily(time.Date(2023, 3, 14, 18, 0, 0, 0, time.UTC))
+
+		// Assert
+		assert.NoError(t, err)
+		interestRepo.AssertNumberOfCalls(t, "AccrueInterest", 1)
+		interestRepo.AssertCalled(t, "AccrueInterest", tenantID, accruedOn(date(3, 14)))
+	})
+
+	t.Run("posts after month end", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenants").Return([]repository.Tenant{*interestTenant(service.DayCountActual365)}, nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("GetInterestProgress", tenantID).Return(&repository.InterestProgress{LastAccrued: ptrTime(date(3, 30)), Unposted: []time.Time{date(3, 1)}}, nil)
+		interestRepo.On("AccrueInterest", tenantID, accruedOn(date(3, 31))).Return(int64(1), nil)
+		interestRepo.On("PostInterest", tenantID, date(3, 1)).Return([]repository.InterestPosting{}, nil)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+		// Act
+		err := interestService.RunDaily(time.Date(2023, 4, 1, 2, 0, 0, 0, time.UTC))
+
+		// Assert
+		assert.NoError(t, err)
+		interestRepo.AssertExpectations(t)
+	})
+
+	t.Run("catches up missed days and months", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenants").Return([]repository.Tenant{*interestTenant(service.DayCountActual365)}, nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("GetInterestProgress", tenantID).Return(&repository.InterestProgress{
+			LastAccrued: ptrTime(date(2, 27)),
+			LastPosted:  ptrTime(date(1, 1)),
+			Unposted:    []time.Time{date(2, 1)},
+		}, nil)
+		interestRepo.On("AccrueInterest", tenantID, mock.Anything).Return(int64(1), nil)
+		interestRepo.On("PostInterest", tenantID, date(2, 1)).Return([]repository.InterestPosting{}, nil)
+		interestRepo.On("PostInterest", tenantID, date(3, 1)).Return([]repository.InterestPosting{}, nil)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+		// Act
+		err := interestService.RunDaily(time.Date(2023, 4, 2, 2, 0, 0, 0, time.UTC))
+
+		// Assert
+		assert.NoError(t, err)
+		interestRepo.AssertNumberOfCalls(t, "AccrueInterest", 33)
+		interestRepo.AssertCalled(t, "AccrueInterest", tenantID, accruedOn(date(2, 28)))
+		interestRepo.AssertCalled(t, "AccrueInterest", tenantID, accruedOn(date(4, 1)))
+		interestRepo.AssertNumberOfCalls(t, "PostInterest", 2)
+		interestRepo.AssertExpectations(t)
+	})
+
+	t.Run("skips months already posted", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenants").Return([]repository.Tenant{*interestTenant(service.DayCountActual365)}, nil)
+		interestRepo := repository.NewInterestRepositoryMock()
+		interestRepo.On("GetInterestProgress", tenantID).Return(&repository.InterestProgress{LastAccrued: ptrTime(date(3, 20)), LastPosted: ptrTime(date(3, 1)), Unposted: []time.Time{}}, nil)
+		interestRepo.On("AccrueInterest", tenantID, mock.Anything).Return(int64(1), nil)
+
+		interestService := service.NewInterestService(interestRepo, tenantRepo, time.UTC)
+
+		// Act
+		err := interestService.RunDaily(time.Date(2023, 4, 3, 2, 0, 0, 0, time.UTC))
+
+		// Assert
+		assert.NoError(t, err)
+		interestRepo.AssertNumberOfCalls(t, "AccrueInterest", 2)
+		interestRepo.AssertCalled(t, "AccrueInterest", tenantID, accruedOn(date(4, 1)))
+		interestRepo.AssertNotCalled(t, "PostInterest", mock.Anything, mock.Anything)
+	})
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/topnarapat/go-wallet/errs"
+)
+
+const (
+	maxMetadataBytes = 4096
+	maxLabelValueLen = 255
+)
+
+var labelKeyPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9._/-]{0,62})$`)
+
+func validateMetadata(metadata json.RawMessage) []errs.FieldError {
+	if len(metadata) == 0 {
+		return nil
+	}
+	if len(metadata) > maxMetadataBytes {
+		return []errs.FieldError{{Field: "metadata", Message: "must be at most 4096 bytes"}}
+	}
+	if !bytes.HasPrefix(bytes.TrimSpace(metadata), []byte("{")) {
+		return []errs.FieldError{{Field: "metadata", Message: "must be a JSON object"}}
+	}
+	return nil
+}
+
+func validateLabel(key string, value *string) []errs.FieldError {
+	fields := []errs.FieldError{}
+	if !labelKeyPattern.MatchString(key) {
+		fields = append(fields, errs.FieldError{Field: "labels." + key, Message: "key must be lowercase letters, digits, '.', '_', '/' or '-' and at most 63 characters"})
+	}
+	if value != nil && len(*value) > maxLabelValueLen {
+		fields = append(fields, errs.FieldError{Field: "labels." + key, Message: "value must be at most 255 characters"})
+	}
+	return fields
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseLabelFilters turns `?label=team:payments` query values into a map of
+// label key to the value it must equal.
+func parseLabelFilters(filters []string) (map[string]string, error) {
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	labels := map[string]string{}
+	for _, filter := range filters {
+		key, value, found := strings.Cut(filter, ":")
+		if !found || key == "" {
+			return nil, errs.NewBadRequest("label filter must be key:value")
+		}
+		labels[key] = value
+	}
+	return labels, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"io"
+	"time"
+)
+
+// LedgerAccountResponse is an account of the chart of accounts with the
+// totals posted to it and its balance on its normal side: debits less
+// credits for assets and expenses, credits less debits otherwise.
+type LedgerAccountResponse struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Currency string  `json:"currency"`
+	WalletID *int64  `json:"wallet_id,omitempty"`
+	Debits   float64 `json:"debits"`
+	Credits  float64 `json:"credits"`
+	Balance  float64 `json:"balance"`
+}
+
+// TrialBalance lists every account with postings up to the end of the
+// period, From and To being its first and last days, and the totals per
+// currency that show the ledger balances.
+type TrialBalance struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Accounts []TrialBalanceLine  `json:"accounts"`
+	Totals   []TrialBalanceTotal `json:"totals"`
+}
+
+// TrialBalanceLine is an account's balance on its normal side before the
+// period and at its end, and what was posted to it within it.
+type TrialBalanceLine struct {
+	Code           string  `json:"code"`
+	Name           string  `json:"name"`
+	Type           string  `json:"type"`
+	Currency       string  `json:"currency"`
+	WalletID       *int64  `json:"wallet_id,omitempty"`
+	OpeningBalance float64 `json:"opening_balance"`
+	Debits         float64 `json:"debits"`
+	Credits        float64 `json:"credits"`
+	ClosingBalance float64 `json:"closing_balance"`
+}
+
+// TrialBalanceTotal is one currency's debits and credits posted in the
+// period, and its closing balances split into those left on the debit side
+// and those on the credit side. Each pair is equal when the ledger
+// balances.
+type TrialBalanceTotal struct {
+	Currency       string  `json:"currency"`
+	Debits         float64 `json:"debits"`
+	Credits        float64 `json:"credits"`
+	DebitBalances  float64 `json:"debit_balances"`
+	CreditBalances float64 `json:"credit_balances"`
+}
+
+// AccountActivityRequest asks for the lines posted to the account with
+// Code in a period. Currency may be left out unless the account is held in
+// more than one.
+type AccountActivityRequest struct {
+	Code     string
+	Currency string `query:"currency"`
+	From     string `query:"from"`
+	To       string `query:"to"`
+}
+
+// AccountActivity is an account's general ledger for a period: its opening
+// balance, every line posted to it with the balance it left, and its
+// closing balance, all on the account's normal side.
+type AccountActivity struct {
+	Code           string                `json:"code"`
+	Name           string                `json:"name"`
+	Type           string                `json:"type"`
+	Currency       string                `json:"currency"`
+	WalletID       *int64                `json:"wallet_id,omitempty"`
+	From           string                `json:"from"`
+	To             string                `json:"to"`
+	OpeningBalance float64               `json:"opening_balance"`
+	Debits         float64               `json:"debits"`
+	Credits        float64               `json:"credits"`
+	ClosingBalance float64               `json:"closing_balance"`
+	Lines          []AccountActivityLine `json:"lines"`
+}
+
+type AccountActivityLine struct {
+	EntryID       int64     `json:"entry_id"`
+	PostedAt      time.Time `json:"posted_at"`
+	Description   string    `json:"description"`
+	TransactionID *int64    `json:"transaction_id,omitempty"`
+	Debit         float64   `json:"debit"`
+	Credit        float64   `json:"credit"`
+	Balance       float64   `json:"balance"`
+}
+
+// BalanceSheet is what the tenant held and owed at the end of the day
+// AsOf, per currency.
+type BalanceSheet struct {
+	AsOf       string                 `json:"as_of"`
+	Currencies []BalanceSheetCurrency `json:"currencies"`
+}
+
+// BalanceSheetCurrency lists one currency's assets, liabilities, with the
+// wallets as one line, and equity, which includes the revenue less the
+// expenses to date as retained earnings. Total assets equal total
+// liabilities and equity.
+type BalanceSheetCurrency struct {
+	Currency         string             `json:"currency"`
+	Assets           []BalanceSheetLine `json:"assets"`
+	Liabilities      []BalanceSheetLine `json:"liabilities"`
+	Equity           []BalanceSheetLine `json:"equity"`
+	TotalAssets      float64            `json:"total_assets"`
+	TotalLiabilities float64            `json:"total_liabilities"`
+	TotalEquity      float64            `json:"total_equity"`
+}
+
+type BalanceSheetLine struct {
+	Code    string  `json:"code,omitempty"`
+	Name    string  `json:"name"`
+	Balance float64 `json:"balance"`
+}
+
+// LedgerService reads the double-entry ledger every movement of money is
+// posted to. Report periods are whole days in the business timezone, given
+// as YYYY-MM-DD: a period defaults to the month to date and a balance sheet
+// to the end of today.
+type LedgerService interface {
+	GetChartOfAccounts(string) ([]LedgerAccountResponse, error)
+	GetTrialBalance(string, string, string) (*TrialBalance, error)
+	ExportTrialBalance(string, string, string, io.Writer) error
+	GetAccountActivity(string, AccountActivityRequest) (*AccountActivity, error)
+	ExportAccountActivity(string, AccountActivityRequest, io.Writer) error
+	GetBalanceSheet(string, string) (*BalanceSheet, error)
+	ExportBalanceSheet(string, string, io.Writer) error
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"io"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ledgerServiceMock struct {
+	mock.Mock
+}
+
+func NewLedgerServiceMock() *ledgerServiceMock {
+	return &ledgerServiceMock{}
+}
+
+func (s *ledgerServiceMock) GetChartOfAccounts(tenantID string) ([]LedgerAccountResponse, error) {
+	args := s.Called(tenantID)
+	return args.Get(0).([]LedgerAccountResponse), args.Error(1)
+}
+
+func (s *ledgerServiceMock) GetTrialBalance(tenantID string, from string, to string) (*TrialBalance, error) {
+	args := s.Called(tenantID, from, to)
+	return args.Get(0).(*TrialBalance), args.Error(1)
+}
+
+// ExportTrialBalance writes the string the mock returns to w.
+func (s *ledgerServiceMock) ExportTrialBalance(tenantID string, from string, to string, w io.Writer) error {
+	args := s.Called(tenantID, from, to, w)
+	io.WriteString(w, args.Get(0).(string))
+	return args.Error(1)
+}
+
+func (s *ledgerServiceMock) GetAccountActivity(tenantID string, r AccountActivityRequest) (*AccountActivity, error) {
+	args := s.Called(tenantID, r)
+	return args.Get(0).(*AccountActivity), args.Error(1)
+}
+
+// ExportAccountActivity writes the string the mock returns to w.
+func (s *ledgerServiceMock) ExportAccountActivity(tenantID string, r AccountActivityRequest, w io.Writer) error {
+	args := s.Called(tenantID, r, w)
+	io.WriteString(w, args.Get(0).(string))
+	return args.Error(1)
+}
+
+func (s *ledgerServiceMock) GetBalanceSheet(tenantID string, asOf string) (*BalanceSheet, error) {
+	args := s.Called(tenantID, asOf)
+	return args.Get(0).(*BalanceSheet), args.Error(1)
+}
+
+// ExportBalanceSheet writes the string the mock returns to w.
+func (s *ledgerServiceMock) ExportBalanceSheet(tenantID string, asOf string, w io.Writer) error {
+	args := s.Called(tenantID, asOf, w)
+	io.WriteString(w, args.Get(0).(string))
+	return args.Error(1)
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/ledger"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+var (
+	trialBalanceColumns    = []string{"currency", "code", "name", "type", "wallet_id", "opening_balance", "debits", "credits", "closing_balance"}
+	accountActivityColumns = []string{"entry_id", "posted_at", "description", "transaction_id", "debit", "credit", "balance"}
+	balanceSheetColumns    = []string{"currency", "section", "code", "name", "balance"}
+)
+
+type ledgerService struct {
+	ledgerRepo repository.LedgerRepository
+	location   *time.Location
+	now        func() time.Time
+}
+
+// NewLedgerService reads report days in location.
+func NewLedgerService(ledgerRepo repository.LedgerRepository, location *time.Location) LedgerService {
+	return ledgerService{ledgerRepo: ledgerRepo, location: location, now: time.Now}
+}
+
+func (s ledgerService) GetChartOfAccounts(tenantID string) ([]LedgerAccountResponse, error) {
+	accounts, err := s.ledgerRepo.GetAccounts(tenantID)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	responses := []LedgerAccountResponse{}
+	for _, a := range accounts {
+		responses = append(responses, LedgerAccountResponse{
+			Code:     a.Code,
+			Name:     a.Name,
+			Type:     a.Type,
+			Currency: a.Currency,
+			WalletID: a.WalletID,
+			Debits:   a.Debits,
+			Credits:  a.Credits,
+			Balance:  normalBalance(a.Type, a.Debits, a.Credits),
+		})
+	}
+
+	return responses, nil
+}
+
+func (s ledgerService) GetTrialBalance(tenantID string, from string, to string) (*TrialBalance, error) {
+	start, end, err := s.period(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.ledgerRepo.GetAccountTotals(tenantID, repository.LedgerFilter{From: start, To: end})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	report := &TrialBalance{From: start.Format(dateLayout), To: lastDay(end), Accounts: []TrialBalanceLine{}, Totals: []TrialBalanceTotal{}}
+	for _, a := range accounts {
+		if a.OpeningDebits == 0 && a.OpeningCredits == 0 && a.Debits == 0 && a.Credits == 0 {
+			continue
+		}
+		report.Accounts = append(report.Accounts, TrialBalanceLine{
+			Code:           a.Code,
+			Name:           a.Name,
+			Type:           a.Type,
+			Currency:       a.Currency,
+			WalletID:       a.WalletID,
+			OpeningBalance: normalBalance(a.Type, a.OpeningDebits, a.OpeningCredits),
+			Debits:         a.Debits,
+			Credits:        a.Credits,
+			ClosingBalance: normalBalance(a.Type, a.OpeningDebits+a.Debits, a.OpeningCredits+a.Credits),
+		})
+
+		// Accounts come ordered by currency.
+		if len(report.Totals) == 0 || report.Totals[len(report.Totals)-1].Currency != a.Currency {
+			report.Totals = append(report.Totals, TrialBalanceTotal{Currency: a.Currency})
+		}
+		total := &report.Totals[len(report.Totals)-1]
+		total.Debits = roundCents(total.Debits + a.Debits)
+		total.Credits = roundCents(total.Credits + a.Credits)
+		net := roundCents(a.OpeningDebits + a.Debits - a.OpeningCredits - a.Credits)
+		if net > 0 {
+			total.DebitBalances = roundCents(total.DebitBalances + net)
+		} else {
+			total.CreditBalances = roundCents(total.CreditBalances - net)
+		}
+	}
+
+	return report, nil
+}
+
+// ExportTrialBalance writes the trial balance's accounts as CSV.
+func (s ledgerService) ExportTrialBalance(tenantID string, from string, to string, w io.Writer) error {
+	report, err := s.GetTrialBalance(tenantID, from, to)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err = writer.Write(trialBalanceColumns); err != nil {
+		return err
+	}
+	for _, a := range report.Accounts {
+		err = writer.Write([]string{a.Currency, a.Code, a.Name, a.Type, formatWalletID(a.WalletID), formatAmount(a.OpeningBalance), formatAmount(a.Debits), formatAmount(a.Credits), formatAmount(a.ClosingBalance)})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (s ledgerService) GetAccountActivity(tenantID string, r AccountActivityRequest) (*AccountActivity, error) {
+	activity := &AccountActivity{}
+	err := s.accountActivity(tenantID, r, func(a *AccountActivity) error {
+		activity = a
+		activity.Lines = []AccountActivityLine{}
+		return nil
+	}, func(line AccountActivityLine) error {
+		activity.Lines = append(activity.Lines, line)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return activity, nil
+}
+
+// ExportAccountActivity writes the lines posted to the account as CSV,
+// without building them in memory.
+func (s ledgerService) ExportAccountActivity(tenantID string, r AccountActivityRequest, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	err := s.accountActivity(tenantID, r, func(*AccountActivity) error {
+		return writer.Write(accountActivityColumns)
+	}, func(line AccountActivityLine) error {
+		transactionID := ""
+		if line.TransactionID != nil {
+			transactionID = strconv.FormatInt(*line.TransactionID, 10)
+		}
+		return writer.Write([]string{
+			strconv.FormatInt(line.EntryID, 10),
+			line.PostedAt.Format(time.RFC3339),
+			line.Description,
+			transactionID,
+			formatAmount(line.Debit),
+			formatAmount(line.Credit),
+			formatAmount(line.Balance),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// accountActivity finds the account and calls account with it before
+// calling each for every line posted to it in the period, with the balance
+// that line left. account's activity has its closing totals filled in.
+func (s ledgerService) accountActivity(tenantID string, r AccountActivityRequest, account func(*AccountActivity) error, each func(AccountActivityLine) error) error {
+	start, end, err := s.period(r.From, r.To)
+	if err != nil {
+		return err
+	}
+
+	accounts, err := s.ledgerRepo.GetAccountTotals(tenantID, repository.LedgerFilter{Code: r.Code, Currency: r.Currency, From: start, To: end})
+	if err != nil {
+		return errs.NewUnexpectedError().WithCause(err)
+	}
+	if r.Code == "" || len(accounts) == 0 {
+		return errs.NewLedgerAccountNotFoundError()
+	}
+	if len(accounts) > 1 {
+		return errs.NewFieldValidationError([]errs.FieldError{{Field: "currency", Message: "is required for an account held in more than one currency"}})
+	}
+
+	a := accounts[0]
+	activity := &AccountActivity{
+		Code:           a.Code,
+		Name:           a.Name,
+		Type:           a.Type,
+		Currency:       a.Currency,
+		WalletID:       a.WalletID,
+		From:           start.Format(dateLayout),
+		To:             lastDay(end),
+		OpeningBalance: normalBalance(a.Type, a.OpeningDebits, a.OpeningCredits),
+		Debits:         a.Debits,
+		Credits:        a.Credits,
+		ClosingBalance: normalBalance(a.Type, a.OpeningDebits+a.Debits, a.OpeningCredits+a.Credits),
+	}
+	if err = account(activity); err != nil {
+		return err
+	}
+
+	balance := activity.OpeningBalance
+	err = s.ledgerRepo.GetJournalLines(tenantID, a.AccountID, start, end, func(l repository.JournalLine) error {
+		balance = roundCents(balance + normalBalance(a.Type, l.Debit, l.Credit))
+		return each(AccountActivityLine{
+			EntryID:       l.EntryID,
+			PostedAt:      l.PostedAt.In(s.location),
+			Description:   l.Description,
+			TransactionID: l.TransactionID,
+			Debit:         l.Debit,
+			Credit:        l.Credit,
+			Balance:       balance,
+		})
+	})
+	if err != nil {
+		return errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return nil
+}
+
+func (s ledgerService) GetBalanceSheet(tenantID string, asOf string) (*BalanceSheet, error) {
+	end, err := s.endOf("as_of", asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.ledgerRepo.GetAccountTotals(tenantID, repository.LedgerFilter{From: end, To: end})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	report := &BalanceSheet{AsOf: lastDay(end), Currencies: []BalanceSheetCurrency{}}
+	var sheet *BalanceSheetCurrency
+	var wallets, earnings float64
+	// Accounts come ordered by currency, each currency's wallets last.
+	finish := func() {
+		if sheet == nil {
+			return
+		}
+		sheet.Liabilities = append(sheet.Liabilities, BalanceSheetLine{Code: ledger.Wallets, Name: "Wallets", Balance: wallets})
+		sheet.TotalLiabilities = roundCents(sheet.TotalLiabilities + wallets)
+		sheet.Equity = append(sheet.Equity, BalanceSheetLine{Name: "Retained earnings", Balance: earnings})
+		sheet.TotalEquity = roundCents(sheet.TotalEquity + earnings)
+		report.Currencies = append(report.Currencies, *sheet)
+	}
+	for _, a := range accounts {
+		if sheet == nil || sheet.Currency != a.Currency {
+			finish()
+			sheet = &BalanceSheetCurrency{Currency: a.Currency, Assets: []BalanceSheetLine{}, Liabilities: []BalanceSheetLine{}, Equity: []BalanceSheetLine{}}
+			wallets, earnings = 0, 0
+		}
+
+		balance := normalBalance(a.Type, a.OpeningDebits, a.OpeningCredits)
+		line := BalanceSheetLine{Code: a.Code, Name: a.Name, Balance: balance}
+		switch {
+		case a.WalletID != nil:
+			wallets = roundCents(wallets + balance)
+		case a.Type == ledger.Asset:
+			sheet.Assets = append(sheet.Assets, line)
+			sheet.TotalAssets = roundCents(sheet.TotalAssets + balance)
+		case a.Type == ledger.Liability:
+			sheet.Liabilities = append(sheet.Liabilities, line)
+			sheet.TotalLiabilities = roundCents(sheet.TotalLiabilities + balance)
+		case a.Type == ledger.Equity:
+			sheet.Equity = append(sheet.Equity, line)
+			sheet.TotalEquity = roundCents(sheet.TotalEquity + balance)
+		case a.Type == ledger.Revenue:
+			earnings = roundCents(earnings + balance)
+		case a.Type == ledger.Expense:
+			earnings = roundCents(earnings - balance)
+		}
+	}
+	finish()
+
+	return report, nil
+}
+
+// ExportBalanceSheet writes the balance sheet as CSV, one row per line.
+func (s ledgerService) ExportBalanceSheet(tenantID string, asOf string, w io.Writer) error {
+	report, err := s.GetBalanceSheet(tenantID, asOf)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(w)
+	if err = writer.Write(balanceSheetColumns); err != nil {
+		return err
+	}
+	for _, sheet := range report.Currencies {
+		sections := []struct {
+			name  string
+			lines []BalanceSheetLine
+		}{{"assets", sheet.Assets}, {"liabilities", sheet.Liabilities}, {"equity", sheet.Equity}}
+		for _, section := range sections {
+			for _, line := range section.lines {
+				if err = writer.Write([]string{sheet.Currency, section.name, line.Code, line.Name, formatAmount(line.Balance)}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// period reads a report's first and last days and returns the instants it
+// starts and ends at. The last day defaults to today and the first to the
+// first of its month.
+func (s ledgerService) period(from string, to string) (time.Time, time.Time, error) {
+	end, err := s.endOf("to", to)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	last := end.AddDate(0, 0, -1)
+	start := time.Date(last.Year(), last.Month(), 1, 0, 0, 0, 0, s.location)
+	if from != "" {
+		start, err = time.ParseInLocation(dateLayout, from, s.location)
+		if err != nil {
+			return time.Time{}, time.Time{}, errs.NewFieldValidationError([]errs.FieldError{{Field: "from", Message: "must be a YYYY-MM-DD date"}})
+		}
+		if !start.Before(end) {
+			return time.Time{}, time.Time{}, errs.NewFieldValidationError([]errs.FieldError{{Field: "from", Message: "must not be after to"}})
+		}
+	}
+
+	return start, end, nil
+}
+
+// endOf returns the instant the day value ends at, the start of the next
+// day, or the end of today when value is empty.
+func (s ledgerService) endOf(field string, value string) (time.Time, error) {
+	now := s.now().In(s.location)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.location)
+	if value != "" {
+		var err error
+		day, err = time.ParseInLocation(dateLayout, value, s.location)
+		if err != nil {
+			return time.Time{}, errs.NewFieldValidationError([]errs.FieldError{{Field: field, Message: "must be a YYYY-MM-DD date"}})
+		}
+	}
+
+	return day.AddDate(0, 0, 1), nil
+}
+
+// lastDay is the date of the day that ends at end.
+func lastDay(end time.Time) string {
+	return end.AddDate(0, 0, -1).Format(dateLayout)
+}
+
+func normalBalance(accountType string, debits float64, credits float64) float64 {
+	if ledger.DebitNormal(accountType) {
+		return roundCents(debits - credits)
+	}
+	return roundCents(credits - debits)
+}
+
+func formatWalletID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.FormatInt(*id, 10)
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,295 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/ledger"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestGetChartOfAccounts(t *testing.T) {
+	t.Run("balances on normal side", func(t *testing.T) {
+		// Arrange
+		walletID := int64(42)
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccounts", tenantID).Return([]repository.LedgerAccount{
+			{AccountID: 1, Code: ledger.Cash, Name: "Cash", Type: ledger.Asset, Currency: "THB", Debits: 1500.5, Credits: 250},
+			{AccountID: 2, Code: ledger.InterestExpense, Name: "Interest expense", Type: ledger.Expense, Currency: "THB", Debits: 0.1 + 0.2},
+			{AccountID: 3, Code: "2000-42", Name: "Wallet 1234567897", Type: ledger.Liability, Currency: "THB", WalletID: &walletID, Debits: 250, Credits: 1500.8},
+		}, nil)
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+		// Act
+		accounts, err := ledgerService.GetChartOfAccounts(tenantID)
+
+		// Assert
+		if assert.NoError(t, err) && assert.Len(t, accounts, 3) {
+			assert.Equal(t, 1250.5, accounts[0].Balance)
+			assert.Equal(t, 0.3, accounts[1].Balance)
+			assert.Equal(t, 1250.8, accounts[2].Balance)
+			assert.Equal(t, &walletID, accounts[2].WalletID)
+		}
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		// Arrange
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccounts", tenantID).Return([]repository.LedgerAccount{}, errors.New("connection refused"))
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+		// Act
+		_, err := ledgerService.GetChartOfAccounts(tenantID)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewUnexpectedError())
+	})
+}
+
+var march = repository.LedgerFilter{
+	From: time.Date(2023, time.March, 1, 0, 0, 0, 0, bangkok),
+	To:   time.Date(2023, time.April, 1, 0, 0, 0, 0, bangkok),
+}
+
+func marchAccounts() []repository.AccountTotals {
+	walletID := int64(42)
+	return []repository.AccountTotals{
+		{LedgerAccount: repository.LedgerAccount{AccountID: 1, Code: ledger.Cash, Name: "Cash", Type: ledger.Asset, Currency: "THB", Debits: 500.5}, OpeningDebits: 1000},
+		{LedgerAccount: repository.LedgerAccount{AccountID: 2, Code: ledger.Suspense, Name: "Suspense", Type: ledger.Asset, Currency: "THB"}},
+		{LedgerAccount: repository.LedgerAccount{AccountID: 3, Code: ledger.InterestExpense, Name: "Interest expense", Type: ledger.Expense, Currency: "THB", Debits: 20}},
+		{LedgerAccount: repository.LedgerAccount{AccountID: 4, Code: "2000-42", Name: "Wallet 1234567897", Type: ledger.Liability, Currency: "THB", WalletID: &walletID, Credits: 520.5}, OpeningCredits: 1000},
+		{LedgerAccount: repository.LedgerAccount{AccountID: 5, Code: ledger.Cash, Name: "Cash", Type: ledger.Asset, Currency: "USD", Credits: 10}, OpeningDebits: 10},
+		{LedgerAccount: repository.LedgerAccount{AccountID: 6, Code: "2000-43", Name: "Wallet 9876543217", Type: ledger.Liability, Currency: "USD", Debits: 10}, OpeningCredits: 10},
+	}
+}
+
+func TestGetTrialBalance(t *testing.T) {
+	t.Run("period in business timezone", func(t *testing.T) {
+		// Arrange
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccountTotals", tenantID, march).Return(marchAccounts(), nil)
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+		// Act
+		report, err := ledgerService.GetTrialBalance(tenantID, "2023-03-01", "2023-03-31")
+
+		// Assert
+		if assert.NoError(t, err) {
+			assert.Equal(t, "2023-03-01", report.From)
+			assert.Equal(t, "2023-03-31", report.To)
+			if assert.Len(t, report.Accounts, 5, "suspense has no postings") {
+				assert.Equal(t, service.TrialBalanceLine{Code: "1000", Name: "Cash", Type: "Asset", Currency: "THB", OpeningBalance: 1000, Debits: 500.5, ClosingBalance: 1500.5}, report.Accounts[0])
+				assert.Equal(t, 1520.5, report.Accounts[2].ClosingBalance)
+				assert.Equal(t, 0.0, report.Accounts[3].ClosingBalance)
+			}
+			assert.Equal(t, []service.TrialBalanceTotal{
+				{Currency: "THB", Debits: 520.5, Credits: 520.5, DebitBalances: 1520.5, CreditBalances: 1520.5},
+				{Currency: "USD", Debits: 10, Credits: 10},
+			}, report.Totals)
+		}
+	})
+
+	t.Run("month to date by default", func(t *testing.T) {
+		// Arrange
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccountTotals", tenantID, march).Return([]repository.AccountTotals{}, nil)
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+		// Act
+		report, err := ledgerService.GetTrialBalance(tenantID, "", "2023-03-31")
+
+		// Assert
+		if assert.NoError(t, err) {
+			assert.Equal(t, "2023-03-01", report.From)
+			assert.Empty(t, report.Accounts)
+		}
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		cases := []struct {
+			name  string
+			from  string
+			to    string
+			field string
+		}{
+			{"from after to", "2023-04-01", "2023-03-31", "from"},
+			{"not a date", "2023-03-01", "31/03/2023", "to"},
+			{"timestamp", "2023-03-01T00:00:00Z", "2023-03-31", "from"},
+		}
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				// Arrange
+				ledgerRepo := repository.NewLedgerRepositoryMock()
+				ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+				// Act
+				_, err := ledgerService.GetTrialBalance(tenantID, c.from, c.to)
+
+				// Assert
+				var appErr errs.AppError
+				if assert.ErrorAs(t, err, &appErr) && assert.Len(t, appErr.Fields, 1) {
+					assert.Equal(t, c.field, appErr.Fields[0].Field)
+				}
+				ledgerRepo.AssertNotCalled(t, "GetAccountTotals")
+			})
+		}
+	})
+}
+
+func TestExportTrialBalance(t *testing.T) {
+	// Arrange
+	ledgerRepo := repository.NewLedgerRepositoryMock()
+	ledgerRepo.On("GetAccountTotals", tenantID, march).Return(marchAccounts()[:4], nil)
+
+	ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+	buf := &bytes.Buffer{}
+
+	// Act
+	err := ledgerService.ExportTrialBalance(tenantID, "2023-03-01", "2023-03-31", buf)
+
+	// Assert
+	expected := "currency,code,name,type,wallet_id,opening_balance,debits,credits,closing_balance\n" +
+		"THB,1000,Cash,Asset,,1000.00,500.50,0.00,1500.50\n" +
+		"THB,5000,Interest expense,Expense,,0.00,20.00,0.00,20.00\n" +
+		"THB,2000-42,Wallet 1234567897,Liability,42,1000.00,0.00,520.50,1520.50\n"
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, buf.String())
+	}
+}
+
+func TestGetAccountActivity(t *testing.T) {
+	t.Run("running balance", func(t *testing.T) {
+		// Arrange
+		filter := march
+		filter.Code = "2000-42"
+		transactionID := int64(5120)
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccountTotals", tenantID, filter).Return(marchAccounts()[3:4], nil)
+		ledgerRepo.On("GetJournalLines", tenantID, int64(4), march.From, march.To).Return([]repository.JournalLine{
+			{EntryID: 981, PostedAt: time.Date(2023, time.March, 14, 3, 21, 7, 0, time.UTC), Description: "deposit", TransactionID: &transactionID, Credit: 500.5},
+			{EntryID: 990, PostedAt: time.Date(2023, time.March, 31, 17, 0, 0, 0, time.UTC), Description: "interest 2023-03", Credit: 20},
+		}, nil)
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+		// Act
+		activity, err := ledgerService.GetAccountActivity(tenantID, service.AccountActivityRequest{Code: "2000-42", From: "2023-03-01", To: "2023-03-31"})
+
+		// Assert
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1000.0, activity.OpeningBalance)
+			assert.Equal(t, 1520.5, activity.ClosingBalance)
+			if assert.Len(t, activity.Lines, 2) {
+				assert.Equal(t, 1500.5, activity.Lines[0].Balance)
+				assert.Equal(t, "2023-03-14T10:21:07+07:00", activity.Lines[0].PostedAt.Format(time.RFC3339))
+				assert.Equal(t, 1520.5, activity.Lines[1].Balance)
+			}
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		// Arrange
+		filter := march
+		filter.Code = ledger.Cash
+		filter.Currency = "THB"
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccountTotals", tenantID, filter).Return(marchAccounts()[:1], nil)
+		ledgerRepo.On("GetJournalLines", tenantID, int64(1), march.From, march.To).Return([]repository.JournalLine{
+			{EntryID: 981, PostedAt: time.Date(2023, time.March, 14, 3, 21, 7, 0, time.UTC), Description: "deposit", Debit: 500.5},
+		}, nil)
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+		buf := &bytes.Buffer{}
+
+		// Act
+		err := ledgerService.ExportAccountActivity(tenantID, service.AccountActivityRequest{Code: ledger.Cash, Currency: "THB", From: "2023-03-01", To: "2023-03-31"}, buf)
+
+		// Assert
+		expected := "entry_id,posted_at,description,transaction_id,debit,credit,balance\n" +
+			"981,2023-03-14T10:21:07+07:00,deposit,,500.50,0.00,1500.50\n"
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, buf.String())
+		}
+	})
+
+	t.Run("account in several currencies", func(t *testing.T) {
+		// Arrange
+		filter := march
+		filter.Code = ledger.Cash
+		accounts := marchAccounts()
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccountTotals", tenantID, filter).Return([]repository.AccountTotals{accounts[0], accounts[4]}, nil)
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+		// Act
+		_, err := ledgerService.GetAccountActivity(tenantID, service.AccountActivityRequest{Code: ledger.Cash, From: "2023-03-01", To: "2023-03-31"})
+
+		// Assert
+		var appErr errs.AppError
+		if assert.ErrorAs(t, err, &appErr) && assert.Len(t, appErr.Fields, 1) {
+			assert.Equal(t, "currency", appErr.Fields[0].Field)
+		}
+	})
+
+	t.Run("account not found", func(t *testing.T) {
+		// Arrange
+		filter := march
+		filter.Code = "2000-99"
+		ledgerRepo := repository.NewLedgerRepositoryMock()
+		ledgerRepo.On("GetAccountTotals", tenantID, filter).Return([]repository.AccountTotals{}, nil)
+
+		ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+		// Act
+		_, err := ledgerService.GetAccountActivity(tenantID, service.AccountActivityRequest{Code: "2000-99", From: "2023-03-01", To: "2023-03-31"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewLedgerAccountNotFoundError())
+	})
+}
+
+func TestGetBalanceSheet(t *testing.T) {
+	// Arrange
+	end := time.Date(2023, time.April, 1, 0, 0, 0, 0, bangkok)
+	walletID := int64(42)
+	ledgerRepo := repository.NewLedgerRepositoryMock()
+	ledgerRepo.On("GetAccountTotals", tenantID, repository.LedgerFilter{From: end, To: end}).Return([]repository.AccountTotals{
+		{LedgerAccount: repository.LedgerAccount{Code: ledger.Cash, Name: "Cash", Type: ledger.Asset, Currency: "THB"}, OpeningDebits: 1500.5},
+		{LedgerAccount: repository.LedgerAccount{Code: ledger.Suspense, Name: "Suspense", Type: ledger.Asset, Currency: "THB"}, OpeningDebits: 200},
+		{LedgerAccount: repository.LedgerAccount{Code: ledger.InterestExpense, Name: "Interest expense", Type: ledger.Expense, Currency: "THB"}, OpeningDebits: 20},
+		{LedgerAccount: repository.LedgerAccount{Code: "2000-42", Name: "Wallet 1234567897", Type: ledger.Liability, Currency: "THB", WalletID: &walletID}, OpeningCredits: 1520.5},
+		{LedgerAccount: repository.LedgerAccount{Code: "2000-43", Name: "Wallet 9876543217", Type: ledger.Liability, Currency: "THB", WalletID: &walletID}, OpeningCredits: 200},
+	}, nil)
+
+	ledgerService := service.NewLedgerService(ledgerRepo, bangkok)
+
+	// Act
+	report, err := ledgerService.GetBalanceSheet(tenantID, "2023-03-31")
+
+	// Assert
+	expected := &service.BalanceSheet{AsOf: "2023-03-31", Currencies: []service.BalanceSheetCurrency{{
+		Currency:         "THB",
+		Assets:           []service.BalanceSheetLine{{Code: "1000", Name: "Cash", Balance: 1500.5}, {Code: "1900", Name: "Suspense", Balance: 200}},
+		Liabilities:      []service.BalanceSheetLine{{Code: "2000", Name: "Wallets", Balance: 1720.5}},
+		Equity:           []service.BalanceSheetLine{{Name: "Retained earnings", Balance: -20}},
+		TotalAssets:      1700.5,
+		TotalLiabilities: 1720.5,
+		TotalEquity:      -20,
+	}}}
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, report)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import "time"
+
+const (
+	DirectionToPocket = "ToPocket"
+	DirectionToWallet = "ToWallet"
+)
+
+type PocketRequest struct {
+	Name string `json:"name" validate:"required,max=64"`
+}
+
+type PocketMoveRequest struct {
+	Amount    float64 `json:"amount" validate:"required,gt=0,max=10000000,decimals=2"`
+	Direction string  `json:"direction" validate:"required,oneof=ToPocket ToWallet"`
+}
+
+type PocketResponse struct {
+	PocketID  int64      `json:"pocket_id"`
+	WalletID  int64      `json:"wallet_id"`
+	Name      string     `json:"name"`
+	Balance   float64    `json:"balance"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	ClosedAt  *time.Time `json:"closed_at,omitempty"`
+}
+
+type PocketService interface {
+	ListPockets(string, int64) ([]PocketResponse, error)
+	CreatePocket(string, int64, PocketRequest) (*PocketResponse, error)
+	MovePocketFunds(string, int64, int64, PocketMoveRequest) (*PocketResponse, error)
+	ClosePocket(string, int64, int64) (*PocketResponse, error)
+}
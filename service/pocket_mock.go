@@ -0,0 +1,31 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type pocketServiceMock struct {
+	mock.Mock
+}
+
+func NewPocketServiceMock() *pocketServiceMock {
+	return &pocketServiceMock{}
+}
+
+func (s *pocketServiceMock) ListPockets(tenantID string, walletID int64) ([]PocketResponse, error) {
+	args := s.Called(tenantID, walletID)
+	return args.Get(0).([]PocketResponse), args.Error(1)
+}
+
+func (s *pocketServiceMock) CreatePocket(tenantID string, walletID int64, r PocketRequest) (*PocketResponse, error) {
+	args := s.Called(tenantID, walletID, r)
+	return args.Get(0).(*PocketResponse), args.Error(1)
+}
+
+func (s *pocketServiceMock) MovePocketFunds(tenantID string, walletID int64, pocketID int64, r PocketMoveRequest) (*PocketResponse, error) {
+	args := s.Called(tenantID, walletID, pocketID, r)
+	return args.Get(0).(*PocketResponse), args.Error(1)
+}
+
+func (s *pocketServiceMock) ClosePocket(tenantID string, walletID int64, pocketID int64) (*PocketResponse, error) {
+	args := s.Called(tenantID, walletID, pocketID)
+	return args.Get(0).(*PocketResponse), args.Error(1)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+type pocketService struct {
+	pocketRepo repository.PocketRepository
+	walletRepo repository.WalletRepository
+}
+
+func NewPocketService(pocketRepo repository.PocketRepository, walletRepo repository.WalletRepository) PocketService {
+	return pocketService{pocketRepo: pocketRepo, walletRepo: walletRepo}
+}
+
+func (s pocketService) ListPockets(tenantID string, walletID int64) ([]PocketResponse, error) {
+	_, err := s.walletRepo.GetWallet(tenantID, walletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	pockets, err := s.pocketRepo.GetPockets(tenantID, walletID)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	pocketResponses := []PocketResponse{}
+	for _, pocket := range pockets {
+		pocketResponses = append(pocketResponses, *newPocketResponse(&pocket))
+	}
+
+	return pocketResponses, nil
+}
+
+func (s pocketService) CreatePocket(tenantID string, walletID int64, p PocketRequest) (*PocketResponse, error) {
+	name := strings.TrimSpace(p.Name)
+	if name == "" {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "name", Message: "is required"}})
+	}
+
+	pocket, err := s.pocketRepo.CreatePocket(tenantID, repository.NewPocket{WalletID: walletID, Name: name})
+	if err != nil {
+		return nil, pocketError(err)
+	}
+
+	return newPocketResponse(pocket), nil
+}
+
+func (s pocketService) MovePocketFunds(tenantID string, walletID int64, pocketID int64, m PocketMoveRequest) (*PocketResponse, error) {
+	amount := m.Amount
+	switch m.Direction {
+	case DirectionToPocket:
+	case DirectionToWallet:
+		amount = -amount
+	default:
+		return nil, errs.NewInvalidOperationError("direction must be ToPocket or ToWallet")
+	}
+
+	pocket, err := s.pocketRepo.MovePocketFunds(tenantID, repository.PocketMove{
+		WalletID: walletID,
+		PocketID: pocketID,
+		Amount:   amount,
+	})
+	if err != nil {
+		return nil, pocketError(err)
+	}
+
+	return newPocketResponse(pocket), nil
+}
+
+func (s pocketService) ClosePocket(tenantID string, walletID int64, pocketID int64) (*PocketResponse, error) {
+	pocket, err := s.pocketRepo.ClosePocket(tenantID, walletID, pocketID)
+	if err != nil {
+		return nil, pocketError(err)
+	}
+
+	return newPocketResponse(pocket), nil
+}
+
+func newPocketResponse(pocket *repository.Pocket) *PocketResponse {
+	return &PocketResponse{
+		PocketID:  pocket.PocketID,
+		WalletID:  pocket.WalletID,
+		Name:      pocket.Name,
+		Balance:   pocket.Balance,
+		Status:    pocket.Status,
+		CreatedAt: pocket.CreatedAt,
+		ClosedAt:  pocket.ClosedAt,
+	}
+}
+
+func pocketError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.NewWalletNotFoundError()
+	case errors.Is(err, repository.ErrPocketNotFound):
+		return errs.NewPocketNotFoundError()
+	case errors.Is(err, repository.ErrPocketNameTaken):
+		return errs.NewPocketNameTakenError()
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		return errs.NewInsufficientFundsError()
+	case errors.Is(err, repository.ErrInactive):
+		return errs.NewWalletInactiveError()
+	case errors.Is(err, repository.ErrWalletClosed):
+		return errs.NewWalletClosedError()
+	}
+
+	return errs.NewUnexpectedError().WithCause(err)
+}
@@ -0,0 +1,183 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestListPockets(t *testing.T) {
+	t.Run("list pockets of wallet", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id}, nil)
+		pocketRepo := repository.NewPocketRepositoryMock()
+		pocketRepo.On("GetPockets", tenantID, id).Return([]repository.Pocket{
+			{PocketID: 1, WalletID: id, Name: "rent", Balance: 500, Status: "Active"},
+			{PocketID: 2, WalletID: id, Name: "savings", Balance: 0, Status: "Active"},
+		}, nil)
+
+		pocketService := service.NewPocketService(pocketRepo, walletRepo)
+
+		// Act
+		pockets, err := pocketService.ListPockets(tenantID, id)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, pockets, 2)
+		assert.Equal(t, "rent", pockets[0].Name)
+	})
+
+	t.Run("wallet not found", func(t *testing.T) {
+		// Arrange
+		var id int64 = 9
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{}, sql.ErrNoRows)
+		pocketRepo := repository.NewPocketRepositoryMock()
+
+		pocketService := service.NewPocketService(pocketRepo, walletRepo)
+
+		// Act
+		_, err := pocketService.ListPockets(tenantID, id)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletNotFoundError())
+		pocketRepo.AssertNotCalled(t, "GetPockets", mock.Anything, mock.Anything)
+	})
+}
+
+func TestCreatePocket(t *testing.T) {
+	t.Run("trim pocket name", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		pocketRepo := repository.NewPocketRepositoryMock()
+		pocketRepo.On("CreatePocket", tenantID, repository.NewPocket{WalletID: id, Name: "rent"}).Return(&repository.Pocket{
+			PocketID: 1, WalletID: id, Name: "rent", Status: "Active",
+		}, nil)
+
+		pocketService := service.NewPocketService(pocketRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		pocket, err := pocketService.CreatePocket(tenantID, id, service.PocketRequest{Name: "  rent "})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "rent", pocket.Name)
+	})
+
+	t.Run("duplicate pocket name", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		pocketRepo := repository.NewPocketRepositoryMock()
+		pocketRepo.On("CreatePocket", tenantID, repository.NewPocket{WalletID: id, Name: "rent"}).Return(&repository.Pocket{}, repository.ErrPocketNameTaken)
+
+		pocketService := service.NewPocketService(pocketRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := pocketService.CreatePocket(tenantID, id, service.PocketRequest{Name: "rent"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewPocketNameTakenError())
+	})
+
+	t.Run("closed wallet", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		pocketRepo := repository.NewPocketRepositoryMock()
+		pocketRepo.On("CreatePocket", tenantID, repository.NewPocket{WalletID: id, Name: "rent"}).Return(&repository.Pocket{}, repository.ErrWalletClosed)
+
+		pocketService := service.NewPocketService(pocketRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := pocketService.CreatePocket(tenantID, id, service.PocketRequest{Name: "rent"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletClosedError())
+	})
+}
+
+func TestMovePocketFunds(t *testing.T) {
+	cases := []struct {
+		name      string
+		direction string
+		amount    float64
+	}{
+		{name: "move to pocket", direction: service.DirectionToPocket, amount: 250},
+		{name: "move to wallet", direction: service.DirectionToWallet, amount: -250},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			pocketRepo := repository.NewPocketRepositoryMock()
+			pocketRepo.On("MovePocketFunds", tenantID, repository.PocketMove{WalletID: 1, PocketID: 2, Amount: c.amount}).Return(&repository.Pocket{
+				PocketID: 2, WalletID: 1, Balance: 250,
+			}, nil)
+
+			pocketService := service.NewPocketService(pocketRepo, repository.NewWalletRepositoryMock())
+
+			// Act
+			pocket, err := pocketService.MovePocketFunds(tenantID, 1, 2, service.PocketMoveRequest{Amount: 250, Direction: c.direction})
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, 250.0, pocket.Balance)
+		})
+	}
+
+	t.Run("insufficient funds", func(t *testing.T) {
+		// Arrange
+		pocketRepo := repository.NewPocketRepositoryMock()
+		pocketRepo.On("MovePocketFunds", tenantID, repository.PocketMove{WalletID: 1, PocketID: 2, Amount: 250}).Return(&repository.Pocket{}, repository.ErrInsufficientFunds)
+
+		pocketService := service.NewPocketService(pocketRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := pocketService.MovePocketFunds(tenantID, 1, 2, service.PocketMoveRequest{Amount: 250, Direction: service.DirectionToPocket})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewInsufficientFundsError())
+	})
+
+	t.Run("pocket of another wallet", func(t *testing.T) {
+		// Arrange
+		pocketRepo := repository.NewPocketRepositoryMock()
+		pocketRepo.On("MovePocketFunds", tenantID, repository.PocketMove{WalletID: 1, PocketID: 7, Amount: 250}).Return(&repository.Pocket{}, repository.ErrPocketNotFound)
+
+		pocketService := service.NewPocketService(pocketRepo, repository.NewWalletRepositoryMock())
+
+		// Act
+		_, err := pocketService.MovePocketFunds(tenantID, 1, 7, service.PocketMoveRequest{Amount: 250, Direction: service.DirectionToPocket})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewPocketNotFoundError())
+	})
+}
+
+func TestWalletResponsePockets(t *testing.T) {
+	t.Run("consolidated balance for wallet with pockets", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 700, Pockets: 2, PocketBalance: 300}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.GetWalletDetail(tenantID, id)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 700.0, wallet.Balance)
+		assert.Equal(t, 300.0, wallet.PocketBalance)
+		assert.Equal(t, 1000.0, wallet.TotalBalance)
+	})
+}
@@ -0,0 +1,51 @@
+package service
+
+const (
+	PromptPayTopUp          = "topup"
+	PromptPayPaymentRequest = "payment_request"
+)
+
+// FormatPNG asks for a PromptPay payload drawn as a QR code.
+const FormatPNG = "png"
+
+// PromptPayRequest asks for a PromptPay QR code paying into a wallet: a
+// top-up by its owner, the default, or a payment request to someone else,
+// which may carry a reference. Without an amount the payer enters one.
+type PromptPayRequest struct {
+	Purpose   string  `query:"purpose" json:"purpose" validate:"oneof=topup payment_request"`
+	Amount    float64 `query:"amount" json:"amount" validate:"gte=0,max=10000000,decimals=2"`
+	Reference string  `query:"reference" json:"reference" validate:"max=20"`
+}
+
+type PromptPayQR struct {
+	WalletID  int64   `json:"wallet_id"`
+	Purpose   string  `json:"purpose"`
+	Amount    float64 `json:"amount,omitempty"`
+	Reference string  `json:"reference,omitempty"`
+	Payload   string  `json:"payload"`
+}
+
+type ParsePromptPayRequest struct {
+	Payload string `json:"payload" validate:"required,max=512"`
+}
+
+// PromptPayFields are the fields of a PromptPay payload. A payment to a
+// biller names it by proxy_type biller and carries its references.
+type PromptPayFields struct {
+	PointOfInitiation string  `json:"point_of_initiation"`
+	ProxyType         string  `json:"proxy_type"`
+	ProxyID           string  `json:"proxy_id"`
+	Ref1              string  `json:"ref1,omitempty"`
+	Ref2              string  `json:"ref2,omitempty"`
+	Amount            float64 `json:"amount,omitempty"`
+	Currency          string  `json:"currency"`
+	Country           string  `json:"country"`
+	MerchantName      string  `json:"merchant_name,omitempty"`
+	MerchantCity      string  `json:"merchant_city,omitempty"`
+	Purpose           string  `json:"purpose,omitempty"`
+}
+
+type PromptPayService interface {
+	CreateQR(string, int64, PromptPayRequest) (*PromptPayQR, error)
+	ParsePayload(ParsePromptPayRequest) (*PromptPayFields, error)
+}
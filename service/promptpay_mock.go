@@ -0,0 +1,21 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type promptPayServiceMock struct {
+	mock.Mock
+}
+
+func NewPromptPayServiceMock() *promptPayServiceMock {
+	return &promptPayServiceMock{}
+}
+
+func (s *promptPayServiceMock) CreateQR(tenantID string, walletID int64, r PromptPayRequest) (*PromptPayQR, error) {
+	args := s.Called(tenantID, walletID, r)
+	return args.Get(0).(*PromptPayQR), args.Error(1)
+}
+
+func (s *promptPayServiceMock) ParsePayload(r ParsePromptPayRequest) (*PromptPayFields, error) {
+	args := s.Called(r)
+	return args.Get(0).(*PromptPayFields), args.Error(1)
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/promptpay"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+// promptPayPurposes are what each purpose is written as in the payload's
+// additional data.
+var promptPayPurposes = map[string]string{
+	PromptPayTopUp:          "TOPUP",
+	PromptPayPaymentRequest: "PAYMENT REQUEST",
+}
+
+type promptPayService struct {
+	walletRepo repository.WalletRepository
+	tenantRepo repository.TenantRepository
+}
+
+func NewPromptPayService(walletRepo repository.WalletRepository, tenantRepo repository.TenantRepository) PromptPayService {
+	return promptPayService{walletRepo: walletRepo, tenantRepo: tenantRepo}
+}
+
+// CreateQR builds a PromptPay bill payment to the tenant's biller id with
+// the wallet's account number as reference 1, so a payment made with it
+// can be matched to the wallet, and a payment request's reference as
+// reference 2. Bank apps show references in upper case, so they are kept
+// to upper case letters and digits.
+func (s promptPayService) CreateQR(tenantID string, walletID int64, r PromptPayRequest) (*PromptPayQR, error) {
+	if r.Purpose == "" {
+		r.Purpose = PromptPayTopUp
+	}
+	r.Reference = strings.ToUpper(r.Reference)
+	switch {
+	case r.Reference != "" && r.Purpose != PromptPayPaymentRequest:
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "reference", Message: "is only allowed for payment_request"}})
+	case strings.Trim(r.Reference, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789") != "":
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "reference", Message: "must be letters and digits"}})
+	}
+
+	tenant, err := s.tenantRepo.GetTenant(tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewTenantNotFoundError()
+		}
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+	if tenant.PromptPayBillerID == "" {
+		return nil, errs.NewPromptPayNotConfiguredError()
+	}
+
+	wallet, err := s.walletRepo.GetWallet(tenantID, walletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+	if wallet.Status == repository.StatusClosed {
+		return nil, errs.NewWalletClosedError()
+	}
+	if wallet.Currency != "THB" {
+		return nil, errs.NewInvalidOperationError("PromptPay only pays into THB wallets")
+	}
+
+	payload, err := promptpay.Encode(promptpay.Payload{
+		ProxyType:    promptpay.ProxyBiller,
+		ProxyID:      tenant.PromptPayBillerID,
+		Ref1:         wallet.AccountNumber,
+		Ref2:         r.Reference,
+		Amount:       r.Amount,
+		MerchantName: merchantName(tenant.Name),
+		Purpose:      promptPayPurposes[r.Purpose],
+	})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return &PromptPayQR{
+		WalletID:  walletID,
+		Purpose:   r.Purpose,
+		Amount:    r.Amount,
+		Reference: r.Reference,
+		Payload:   payload,
+	}, nil
+}
+
+// ParsePayload reads a PromptPay payload, such as one scanned from a QR
+// code, so it can be checked before paying.
+func (s promptPayService) ParsePayload(r ParsePromptPayRequest) (*PromptPayFields, error) {
+	p, err := promptpay.Parse(r.Payload)
+	if err != nil {
+		return nil, errs.NewFieldValidationError([]errs.FieldError{{Field: "payload", Message: err.Error()}})
+	}
+
+	fields := &PromptPayFields{
+		PointOfInitiation: "static",
+		ProxyType:         p.ProxyType,
+		ProxyID:           p.ProxyID,
+		Ref1:              p.Ref1,
+		Ref2:              p.Ref2,
+		Amount:            p.Amount,
+		Currency:          p.Currency,
+		Country:           p.Country,
+		MerchantName:      p.MerchantName,
+		MerchantCity:      p.MerchantCity,
+		Purpose:           p.Purpose,
+	}
+	if p.Dynamic {
+		fields.PointOfInitiation = "dynamic"
+	}
+	if p.Currency == promptpay.CurrencyTHB {
+		fields.Currency = "THB"
+	}
+	return fields, nil
+}
+
+// merchantName is the tenant's name as a payload's merchant name, which
+// bank apps show to the payer, or empty when it is not plain ASCII, which
+// the field is limited to.
+func merchantName(name string) string {
+	for _, r := range name {
+		if r < ' ' || r > '~' {
+			return ""
+		}
+	}
+	return name
+}
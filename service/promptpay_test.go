@@ -0,0 +1,137 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/promptpay"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func newPromptPayTenantRepositoryMock() repository.TenantRepository {
+	tenantRepo := repository.NewTenantRepositoryMock()
+	tenantRepo.On("GetTenant", tenantID).Return(&repository.Tenant{TenantID: tenantID, Name: "ACME", DefaultCurrency: "THB", PromptPayBillerID: "010555012345601"}, nil)
+	return tenantRepo
+}
+
+func TestCreatePromptPayQR(t *testing.T) {
+	t.Run("payment request with amount and reference", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, AccountNumber: "1234567897", Currency: "THB", Status: "Active"}, nil)
+
+		promptPayService := service.NewPromptPayService(walletRepo, newPromptPayTenantRepositoryMock())
+
+		// Act
+		created, err := promptPayService.CreateQR(tenantID, 1, service.PromptPayRequest{Purpose: "payment_request", Amount: 150, Reference: "inv001"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "INV001", created.Reference)
+		parsed, err := promptpay.Parse(created.Payload)
+		if assert.NoError(t, err) {
+			assert.True(t, parsed.Dynamic)
+			assert.Equal(t, promptpay.ProxyBiller, parsed.ProxyType)
+			assert.Equal(t, "010555012345601", parsed.ProxyID)
+			assert.Equal(t, "1234567897", parsed.Ref1)
+			assert.Equal(t, "INV001", parsed.Ref2)
+			assert.Equal(t, 150.0, parsed.Amount)
+			assert.Equal(t, "ACME", parsed.MerchantName)
+		}
+	})
+
+	t.Run("top-up without amount", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, AccountNumber: "1234567897", Currency: "THB", Status: "Active"}, nil)
+
+		promptPayService := service.NewPromptPayService(walletRepo, newPromptPayTenantRepositoryMock())
+
+		// Act
+		created, err := promptPayService.CreateQR(tenantID, 1, service.PromptPayRequest{})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "topup", created.Purpose)
+		parsed, _ := promptpay.Parse(created.Payload)
+		assert.False(t, parsed.Dynamic)
+		assert.Equal(t, "TOPUP", parsed.Purpose)
+	})
+
+	t.Run("tenant without biller id", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+
+		promptPayService := service.NewPromptPayService(walletRepo, newTenantRepositoryMock())
+
+		// Act
+		_, err := promptPayService.CreateQR(tenantID, 1, service.PromptPayRequest{})
+
+		// Assert
+		assert.Equal(t, errs.NewPromptPayNotConfiguredError(), err)
+		walletRepo.AssertNotCalled(t, "GetWallet", mock.Anything, mock.Anything)
+	})
+
+	t.Run("wallet not in THB", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Currency: "USD", Status: "Active"}, nil)
+
+		promptPayService := service.NewPromptPayService(walletRepo, newPromptPayTenantRepositoryMock())
+
+		// Act
+		_, err := promptPayService.CreateQR(tenantID, 1, service.PromptPayRequest{})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewInvalidOperationError(""))
+	})
+
+	t.Run("reference on a top-up", func(t *testing.T) {
+		// Arrange
+		promptPayService := service.NewPromptPayService(repository.NewWalletRepositoryMock(), newPromptPayTenantRepositoryMock())
+
+		// Act
+		_, err := promptPayService.CreateQR(tenantID, 1, service.PromptPayRequest{Reference: "INV001"})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "reference", Message: "is only allowed for payment_request"}}), err)
+	})
+}
+
+func TestParsePromptPay(t *testing.T) {
+	t.Run("mobile number", func(t *testing.T) {
+		// Arrange
+		promptPayService := service.NewPromptPayService(repository.NewWalletRepositoryMock(), repository.NewTenantRepositoryMock())
+
+		// Act
+		fields, err := promptPayService.ParsePayload(service.ParsePromptPayRequest{Payload: "00020101021129370016A0000006770101110113006681234567853037645802TH6304823E"})
+		expected := &service.PromptPayFields{
+			PointOfInitiation: "static",
+			ProxyType:         "mobile",
+			ProxyID:           "+66812345678",
+			Currency:          "THB",
+			Country:           "TH",
+		}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, fields)
+	})
+
+	t.Run("bad checksum", func(t *testing.T) {
+		// Arrange
+		promptPayService := service.NewPromptPayService(repository.NewWalletRepositoryMock(), repository.NewTenantRepositoryMock())
+
+		// Act
+		_, err := promptPayService.ParsePayload(service.ParsePromptPayRequest{Payload: "00020101021129370016A0000006770101110113006681234567853037645802TH63048230"})
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "payload", Message: "checksum does not match"}}), err)
+	})
+}
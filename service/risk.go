@@ -0,0 +1,16 @@
+package service
+
+import "github.com/topnarapat/go-wallet/repository"
+
+// RiskAssessment is the risk engine's decision for a debit. Rule names the
+// rule that decided, empty when the debit matched none.
+type RiskAssessment struct {
+	Decision string
+	Rule     string
+}
+
+// RiskService screens debits before they are applied. Every debit and
+// transfer out of a wallet goes through AssessDebit.
+type RiskService interface {
+	AssessDebit(string, *repository.Wallet, string, float64) (*RiskAssessment, error)
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"github.com/stretchr/testify/mock"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+type riskServiceMock struct {
+	mock.Mock
+}
+
+func NewRiskServiceMock() *riskServiceMock {
+	return &riskServiceMock{}
+}
+
+func (s *riskServiceMock) AssessDebit(tenantID string, wallet *repository.Wallet, operation string, amount float64) (*RiskAssessment, error) {
+	args := s.Called(tenantID, wallet, operation, amount)
+	return args.Get(0).(*RiskAssessment), args.Error(1)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
+)
+
+type riskService struct {
+	riskRepo repository.RiskRepository
+	engine   *risk.Engine
+}
+
+func NewRiskService(riskRepo repository.RiskRepository, engine *risk.Engine) RiskService {
+	return riskService{riskRepo: riskRepo, engine: engine}
+}
+
+// AssessDebit evaluates the rules against the debit and the wallet's recent
+// activity and records every evaluation before returning the decision. A
+// debit is not allowed through unrecorded.
+func (s riskService) AssessDebit(tenantID string, wallet *repository.Wallet, operation string, amount float64) (*RiskAssessment, error) {
+	activity, err := s.riskRepo.GetWalletActivity(tenantID, wallet.WalletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	facts := risk.Facts{
+		"operation":        operation,
+		"amount":           amount,
+		"balance":          wallet.Balance,
+		"wallet_age_hours": activity.AgeHours,
+		"debit_count_1h":   float64(activity.DebitCount1h),
+		"debit_amount_1h":  activity.DebitAmount1h,
+		"debit_count_24h":  float64(activity.DebitCount24h),
+		"debit_amount_24h": activity.DebitAmount24h,
+		"debit_count_30d":  float64(activity.DebitCount30d),
+		"avg_debit_30d":    activity.AvgDebit30d,
+		"max_debit_30d":    activity.MaxDebit30d,
+	}
+	result := s.engine.Evaluate(facts)
+
+	if len(result.Evaluations) > 0 {
+		factsJSON, err := json.Marshal(facts)
+		if err != nil {
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+
+		evaluations := make([]repository.RiskEvaluation, 0, len(result.Evaluations))
+		for _, e := range result.Evaluations {
+			evaluations = append(evaluations, repository.RiskEvaluation{
+				WalletID:   wallet.WalletID,
+				Operation:  operation,
+				Amount:     amount,
+				Rule:       e.Rule,
+				Expression: e.Expression,
+				Outcome:    e.Outcome,
+				Matched:    e.Matched,
+				Error:      e.Err,
+				Decision:   result.Decision,
+				Facts:      factsJSON,
+			})
+		}
+		if err := s.riskRepo.RecordEvaluations(tenantID, evaluations); err != nil {
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+	}
+
+	return &RiskAssessment{Decision: result.Decision, Rule: result.Rule}, nil
+}
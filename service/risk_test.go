@@ -0,0 +1,135 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestAssessDebit(t *testing.T) {
+	engine, err := risk.NewEngine([]risk.Rule{
+		{Name: "velocity", Expression: "debit_count_1h >= 20", Outcome: risk.Deny},
+		{Name: "new_wallet", Expression: "wallet_age_hours < 24 && amount > 5000", Outcome: risk.Review},
+	})
+	require.NoError(t, err)
+
+	t.Run("decide from wallet activity and record every rule", func(t *testing.T) {
+		// Arrange
+		wallet := &repository.Wallet{WalletID: 1, Balance: 8000}
+		riskRepo := repository.NewRiskRepositoryMock()
+		riskRepo.On("GetWalletActivity", tenantID, int64(1)).Return(&repository.WalletActivity{AgeHours: 2}, nil)
+		riskRepo.On("RecordEvaluations", tenantID, mock.MatchedBy(func(evaluations []repository.RiskEvaluation) bool {
+			return len(evaluations) == 2 &&
+				evaluations[0].Rule == "velocity" && !evaluations[0].Matched &&
+				evaluations[1].Rule == "new_wallet" && evaluations[1].Matched &&
+				evaluations[1].Decision == risk.Review && evaluations[1].Amount == 6000
+		})).Return(nil)
+
+		riskService := service.NewRiskService(riskRepo, engine)
+
+		// Act
+		assessment, err := riskService.AssessDebit(tenantID, wallet, "Deduct", 6000)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &service.RiskAssessment{Decision: risk.Review, Rule: "new_wallet"}, assessment)
+		riskRepo.AssertExpectations(t)
+	})
+
+	t.Run("fail when evaluations cannot be recorded", func(t *testing.T) {
+		// Arrange
+		riskRepo := repository.NewRiskRepositoryMock()
+		riskRepo.On("GetWalletActivity", tenantID, int64(1)).Return(&repository.WalletActivity{AgeHours: 200}, nil)
+		riskRepo.On("RecordEvaluations", tenantID, mock.Anything).Return(assert.AnError)
+
+		riskService := service.NewRiskService(riskRepo, engine)
+
+		// Act
+		_, err := riskService.AssessDebit(tenantID, &repository.Wallet{WalletID: 1, Balance: 8000}, "Deduct", 100)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewUnexpectedError())
+	})
+}
+
+func TestScreenDebit(t *testing.T) {
+	riskDecision := func(decision string, rule string) service.RiskService {
+		riskSrv := service.NewRiskServiceMock()
+		riskSrv.On("AssessDebit", tenantID, mock.Anything, "Deduct", 600.0).Return(&service.RiskAssessment{Decision: decision, Rule: rule}, nil)
+		return riskSrv
+	}
+
+	t.Run("deny debit", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1000, Status: "Active"}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), riskDecision(risk.Deny, "velocity"), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 600, Operation: "Deduct", RequestedBy: "maker"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewTransactionDeniedError())
+		walletRepo.AssertNotCalled(t, "SetBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("hold debit for review", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1000, Status: "Active"}, nil)
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("CreateApproval", tenantID, repository.NewApproval{
+			WalletID:    id,
+			Action:      repository.ActionBalance,
+			Operation:   "Deduct",
+			Amount:      600,
+			RequestedBy: "maker",
+			Reason:      "risk rule new_wallet",
+			TTL:         service.ApprovalTTL,
+		}).Return(&repository.Approval{ApprovalID: 3, WalletID: id, Action: repository.ActionBalance, Status: repository.ApprovalPending, Reason: "risk rule new_wallet"}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), approvalRepo, riskDecision(risk.Review, "new_wallet"), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 600, Operation: "Deduct", RequestedBy: "maker"})
+
+		// Assert
+		var held service.ApprovalRequiredError
+		if assert.ErrorAs(t, err, &held) {
+			assert.Equal(t, "risk rule new_wallet", held.Approval.Reason)
+		}
+		walletRepo.AssertNotCalled(t, "SetBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("skip screening of approved debit", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1000, Status: "Active"}, nil)
+		approvalRepo := repository.NewApprovalRepositoryMock()
+		approvalRepo.On("ApplyApproval", tenantID, repository.ApprovedChange{ApprovalID: 7, DecidedBy: "checker"}).Return(&repository.Wallet{WalletID: id, Balance: 400, Status: "Active"}, nil)
+		riskSrv := service.NewRiskServiceMock()
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), approvalRepo, riskSrv, newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.SetWalletBalance(tenantID, id, service.AddWalletRequest{Balance: 600, Operation: "Deduct", RequestedBy: "maker", ApprovedBy: "checker", ApprovalID: 7})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, 400.0, wallet.Balance)
+		riskSrv.AssertNotCalled(t, "AssessDebit", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WalletHistoryEvent is one event of a wallet's stream with the balance and
+// status the wallet had once it was applied.
+type WalletHistoryEvent struct {
+	Version    int64           `json:"version"`
+	Type       string          `json:"type"`
+	RecordedAt time.Time       `json:"recorded_at"`
+	Data       json.RawMessage `json:"data"`
+	Balance    float64         `json:"balance"`
+	Status     string          `json:"status"`
+}
+
+type ProjectionReport struct {
+	Wallets int     `json:"wallets"`
+	Changed []int64 `json:"changed"`
+}
+
+// StreamService reads wallet streams and rebuilds the wallets read model
+// from them.
+type StreamService interface {
+	GetWalletHistory(string, int64) ([]WalletHistoryEvent, error)
+	RebuildProjections(string, int64) (*ProjectionReport, error)
+}
@@ -0,0 +1,21 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type streamServiceMock struct {
+	mock.Mock
+}
+
+func NewStreamServiceMock() *streamServiceMock {
+	return &streamServiceMock{}
+}
+
+func (s *streamServiceMock) GetWalletHistory(tenantID string, walletID int64) ([]WalletHistoryEvent, error) {
+	args := s.Called(tenantID, walletID)
+	return args.Get(0).([]WalletHistoryEvent), args.Error(1)
+}
+
+func (s *streamServiceMock) RebuildProjections(tenantID string, walletID int64) (*ProjectionReport, error) {
+	args := s.Called(tenantID, walletID)
+	return args.Get(0).(*ProjectionReport), args.Error(1)
+}
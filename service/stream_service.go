@@ -0,0 +1,96 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/topnarapat/go-wallet/aggregate"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+)
+
+type streamService struct {
+	streamRepo repository.StreamRepository
+}
+
+func NewStreamService(streamRepo repository.StreamRepository) StreamService {
+	return streamService{streamRepo: streamRepo}
+}
+
+// GetWalletHistory replays the wallet's stream, oldest event first, showing
+// how each event moved its balance.
+func (s streamService) GetWalletHistory(tenantID string, walletID int64) ([]WalletHistoryEvent, error) {
+	events, err := s.streamRepo.GetStream(tenantID, walletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	history := []WalletHistoryEvent{}
+	wallet := &aggregate.Wallet{}
+	for _, e := range events {
+		if err = wallet.Apply(e); err != nil {
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+
+		data, err := json.Marshal(e)
+		if err != nil {
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+		history = append(history, WalletHistoryEvent{
+			Version:    e.Version,
+			Type:       e.Type,
+			RecordedAt: e.RecordedAt,
+			Data:       data,
+			Balance:    wallet.Balance,
+			Status:     wallet.Status,
+		})
+	}
+
+	return history, nil
+}
+
+// RebuildProjections replays the streams of the tenant's wallets, or of
+// every tenant's when tenantID is empty, and rewrites the rows that differ.
+// A non-zero walletID rebuilds that wallet only. It stops at the first
+// wallet that fails.
+func (s streamService) RebuildProjections(tenantID string, walletID int64) (*ProjectionReport, error) {
+	ids := []int64{walletID}
+	if walletID == 0 {
+		var err error
+		ids, err = s.streamRepo.GetStreamIDs(tenantID)
+		if err != nil {
+			return nil, err
+		}
+	} else if tenantID != "" {
+		if _, err := s.streamRepo.GetStream(tenantID, walletID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errs.NewWalletNotFoundError()
+			}
+			return nil, err
+		}
+	}
+
+	report := &ProjectionReport{Changed: []int64{}}
+	for _, id := range ids {
+		changed, err := s.streamRepo.RebuildWallet(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("wallet %d: %w", id, err)
+		}
+
+		report.Wallets++
+		if changed {
+			report.Changed = append(report.Changed, id)
+		}
+	}
+
+	return report, nil
+}
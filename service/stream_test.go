@@ -0,0 +1,110 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/aggregate"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestGetWalletHistory(t *testing.T) {
+	t.Run("balance after each event", func(t *testing.T) {
+		// Arrange
+		recordedAt := time.Date(2023, time.January, 27, 12, 30, 0, 0, time.UTC)
+		streamRepo := repository.NewStreamRepositoryMock()
+		streamRepo.On("GetStream", tenantID, int64(1)).Return([]aggregate.Event{
+			{WalletID: 1, TenantID: tenantID, Version: 1, Type: aggregate.WalletCreated, RecordedAt: recordedAt, Currency: "THB", Status: aggregate.StatusActive, Amount: 1000},
+			{WalletID: 1, TenantID: tenantID, Version: 2, Type: aggregate.FundsDebited, RecordedAt: recordedAt, Amount: 250, Reason: "fee"},
+			{WalletID: 1, TenantID: tenantID, Version: 3, Type: aggregate.StatusChanged, RecordedAt: recordedAt, Status: aggregate.StatusDeactive},
+		}, nil)
+
+		streamService := service.NewStreamService(streamRepo)
+
+		// Act
+		history, err := streamService.GetWalletHistory(tenantID, 1)
+
+		// Assert
+		assert.NoError(t, err)
+		if assert.Len(t, history, 3) {
+			assert.Equal(t, 1000.0, history[0].Balance)
+			assert.Equal(t, 750.0, history[1].Balance)
+			assert.JSONEq(t, `{"amount":250,"reason":"fee"}`, string(history[1].Data))
+			assert.Equal(t, aggregate.StatusDeactive, history[2].Status)
+			assert.Equal(t, int64(3), history[2].Version)
+		}
+	})
+
+	t.Run("wallet not found", func(t *testing.T) {
+		// Arrange
+		streamRepo := repository.NewStreamRepositoryMock()
+		streamRepo.On("GetStream", tenantID, int64(9)).Return([]aggregate.Event(nil), sql.ErrNoRows)
+
+		streamService := service.NewStreamService(streamRepo)
+
+		// Act
+		_, err := streamService.GetWalletHistory(tenantID, 9)
+
+		// Assert
+		assert.Equal(t, errs.NewWalletNotFoundError(), err)
+	})
+}
+
+func TestRebuildProjections(t *testing.T) {
+	t.Run("every wallet of the tenant", func(t *testing.T) {
+		// Arrange
+		streamRepo := repository.NewStreamRepositoryMock()
+		streamRepo.On("GetStreamIDs", tenantID).Return([]int64{1, 2, 3}, nil)
+		streamRepo.On("RebuildWallet", int64(1)).Return(false, nil)
+		streamRepo.On("RebuildWallet", int64(2)).Return(true, nil)
+		streamRepo.On("RebuildWallet", int64(3)).Return(false, nil)
+
+		streamService := service.NewStreamService(streamRepo)
+
+		// Act
+		report, err := streamService.RebuildProjections(tenantID, 0)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &service.ProjectionReport{Wallets: 3, Changed: []int64{2}}, report)
+	})
+
+	t.Run("wallet of another tenant", func(t *testing.T) {
+		// Arrange
+		streamRepo := repository.NewStreamRepositoryMock()
+		streamRepo.On("GetStream", tenantID, int64(9)).Return([]aggregate.Event(nil), sql.ErrNoRows)
+
+		streamService := service.NewStreamService(streamRepo)
+
+		// Act
+		_, err := streamService.RebuildProjections(tenantID, 9)
+
+		// Assert
+		assert.Equal(t, errs.NewWalletNotFoundError(), err)
+		streamRepo.AssertNotCalled(t, "RebuildWallet", int64(9))
+	})
+
+	t.Run("stop at broken stream", func(t *testing.T) {
+		// Arrange
+		streamRepo := repository.NewStreamRepositoryMock()
+		streamRepo.On("GetStreamIDs", "").Return([]int64{1, 2}, nil)
+		streamRepo.On("RebuildWallet", int64(1)).Return(false, errors.New("stream must start with WalletCreated"))
+
+		streamService := service.NewStreamService(streamRepo)
+
+		// Act
+		_, err := streamService.RebuildProjections("", 0)
+
+		// Assert
+		assert.EqualError(t, err, "wallet 1: stream must start with WalletCreated")
+		streamRepo.AssertNotCalled(t, "RebuildWallet", int64(2))
+	})
+}
@@ -0,0 +1,29 @@
+package service
+
+import "time"
+
+type ReverseTransactionRequest struct {
+	Amount float64 `json:"amount" validate:"gte=0,max=10000000,decimals=2"`
+	Reason string  `json:"reason" validate:"max=255"`
+}
+
+type TransactionResponse struct {
+	TransactionID int64   `json:"transaction_id"`
+	WalletID      int64   `json:"wallet_id"`
+	Operation     string  `json:"operation"`
+	Amount        float64 `json:"amount"`
+	Balance       float64 `json:"balance"`
+	ReversalOf    *int64  `json:"reversal_of,omitempty"`
+	FeeOf         *int64  `json:"fee_of,omitempty"`
+	// CounterpartyWalletID is the other wallet of a transfer.
+	CounterpartyWalletID *int64    `json:"counterparty_wallet_id,omitempty"`
+	ReversedAmount       float64   `json:"reversed_amount,omitempty"`
+	Reason               string    `json:"reason,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+type TransactionService interface {
+	ListTransactions(string, int64) ([]TransactionResponse, error)
+	GetTransaction(string, int64) (*TransactionResponse, error)
+	ReverseTransaction(string, int64, ReverseTransactionRequest) (*TransactionResponse, error)
+}
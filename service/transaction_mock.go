@@ -0,0 +1,26 @@
+package service
+
+import "github.com/stretchr/testify/mock"
+
+type transactionServiceMock struct {
+	mock.Mock
+}
+
+func NewTransactionServiceMock() *transactionServiceMock {
+	return &transactionServiceMock{}
+}
+
+func (s *transactionServiceMock) ListTransactions(tenantID string, walletID int64) ([]TransactionResponse, error) {
+	args := s.Called(tenantID, walletID)
+	return args.Get(0).([]TransactionResponse), args.Error(1)
+}
+
+func (s *transactionServiceMock) GetTransaction(tenantID string, id int64) (*TransactionResponse, error) {
+	args := s.Called(tenantID, id)
+	return args.Get(0).(*TransactionResponse), args.Error(1)
+}
+
+func (s *transactionServiceMock) ReverseTransaction(tenantID string, id int64, r ReverseTransactionRequest) (*TransactionResponse, error) {
+	args := s.Called(tenantID, id, r)
+	return args.Get(0).(*TransactionResponse), args.Error(1)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
+)
+
+type transactionService struct {
+	transactionRepo repository.TransactionRepository
+	walletRepo      repository.WalletRepository
+	riskSrv         RiskService
+}
+
+func NewTransactionService(transactionRepo repository.TransactionRepository, walletRepo repository.WalletRepository, riskSrv RiskService) TransactionService {
+	return transactionService{transactionRepo: transactionRepo, walletRepo: walletRepo, riskSrv: riskSrv}
+}
+
+func (s transactionService) ListTransactions(tenantID string, walletID int64) ([]TransactionResponse, error) {
+	_, err := s.walletRepo.GetWallet(tenantID, walletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	transactions, err := s.transactionRepo.GetTransactions(tenantID, walletID)
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	transactionResponses := []TransactionResponse{}
+	for _, transaction := range transactions {
+		transactionResponses = append(transactionResponses, *newTransactionResponse(&transaction))
+	}
+
+	return transactionResponses, nil
+}
+
+func (s transactionService) GetTransaction(tenantID string, id int64) (*TransactionResponse, error) {
+	transaction, err := s.transactionRepo.GetTransaction(tenantID, id)
+	if err != nil {
+		return nil, transactionError(err)
+	}
+
+	return newTransactionResponse(transaction), nil
+}
+
+// ReverseTransaction refunds a debit or takes back a credit, in full when
+// the request has no amount. Reversals return money along the path it came
+// and are not held to tenant limits, but taking back a credit debits the
+// wallet and is screened by the risk rules like any other debit.
+func (s transactionService) ReverseTransaction(tenantID string, id int64, r ReverseTransactionRequest) (*TransactionResponse, error) {
+	err := s.assessReversal(tenantID, id, r.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	reversal, err := s.transactionRepo.ReverseTransaction(tenantID, repository.TransactionReversal{
+		TransactionID: id,
+		Amount:        r.Amount,
+		Reason:        r.Reason,
+	})
+	if err != nil {
+		return nil, transactionError(err)
+	}
+
+	return newTransactionResponse(reversal), nil
+}
+
+// assessReversal screens the reversal of a credit as a Reverse debit. There
+// is no approval for reversals, so one held for review must be deducted, and
+// approved, instead. Reversals the repository will refuse are left for it to
+// report.
+func (s transactionService) assessReversal(tenantID string, id int64, amount float64) error {
+	original, err := s.transactionRepo.GetTransaction(tenantID, id)
+	if err != nil {
+		return transactionError(err)
+	}
+	if original.Operation != repository.TransactionAdd || original.ReversalOf != nil || original.FeeOf != nil || original.CounterpartyWalletID != nil {
+		return nil
+	}
+	if amount == 0 {
+		amount = original.Amount - original.ReversedAmount
+	}
+	if amount <= 0 {
+		return nil
+	}
+
+	wallet, err := s.walletRepo.GetWallet(tenantID, original.WalletID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.NewWalletNotFoundError()
+		}
+
+		return errs.NewUnexpectedError().WithCause(err)
+	}
+
+	assessment, err := s.riskSrv.AssessDebit(tenantID, wallet, "Reverse", amount)
+	if err != nil {
+		return err
+	}
+
+	switch assessment.Decision {
+	case risk.Deny:
+		return errs.NewTransactionDeniedError()
+	case risk.Review:
+		return errs.NewApprovalRequiredError(fmt.Sprintf("held by risk rule %s; deduct the amount with PUT /wallet/:id instead", assessment.Rule))
+	}
+
+	return nil
+}
+
+func newTransactionResponse(transaction *repository.Transaction) *TransactionResponse {
+	return &TransactionResponse{
+		TransactionID:        transaction.TransactionID,
+		WalletID:             transaction.WalletID,
+		Operation:            transaction.Operation,
+		Amount:               transaction.Amount,
+		Balance:              transaction.Balance,
+		ReversalOf:           transaction.ReversalOf,
+		FeeOf:                transaction.FeeOf,
+		CounterpartyWalletID: transaction.CounterpartyWalletID,
+		ReversedAmount:       transaction.ReversedAmount,
+		Reason:               transaction.Reason,
+		CreatedAt:            transaction.CreatedAt,
+	}
+}
+
+func transactionError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrTransactionNotFound):
+		return errs.NewTransactionNotFoundError()
+	case errors.Is(err, repository.ErrTransactionReversed):
+		return errs.NewTransactionReversedError()
+	case errors.Is(err, repository.ErrNotReversible):
+		return errs.NewTransactionNotReversibleError()
+	case errors.Is(err, repository.ErrReversalExceedsOriginal):
+		return errs.NewReversalExceedsOriginalError()
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		return errs.NewInsufficientFundsError()
+	case errors.Is(err, repository.ErrWalletClosed):
+		return errs.NewWalletClosedError()
+	}
+
+	return errs.NewUnexpectedError().WithCause(err)
+}
@@ -0,0 +1,141 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
+	"github.com/topnarapat/go-wallet/service"
+)
+
+func TestListTransactions(t *testing.T) {
+	t.Run("wallet not found", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(9)).Return(&repository.Wallet{}, sql.ErrNoRows)
+
+		transactionService := service.NewTransactionService(repository.NewTransactionRepositoryMock(), walletRepo, newRiskServiceMock())
+
+		// Act
+		_, err := transactionService.ListTransactions(tenantID, 9)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletNotFoundError())
+	})
+}
+
+func TestReverseTransaction(t *testing.T) {
+	t.Run("link reversal to original", func(t *testing.T) {
+		// Arrange
+		var original int64 = 12
+		transactionRepo := repository.NewTransactionRepositoryMock()
+		transactionRepo.On("GetTransaction", tenantID, original).Return(&repository.Transaction{TransactionID: original, WalletID: 1, Operation: repository.TransactionDeduct, Amount: 200}, nil)
+		transactionRepo.On("ReverseTransaction", tenantID, repository.TransactionReversal{TransactionID: original, Amount: 150, Reason: "refund"}).Return(&repository.Transaction{
+			TransactionID: 13,
+			WalletID:      1,
+			Operation:     repository.TransactionAdd,
+			Amount:        150,
+			Balance:       650,
+			ReversalOf:    &original,
+			Reason:        "refund",
+		}, nil)
+
+		riskSrv := service.NewRiskServiceMock()
+
+		transactionService := service.NewTransactionService(transactionRepo, repository.NewWalletRepositoryMock(), riskSrv)
+
+		// Act
+		reversal, err := transactionService.ReverseTransaction(tenantID, original, service.ReverseTransactionRequest{Amount: 150, Reason: "refund"})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, &original, reversal.ReversalOf)
+		assert.Equal(t, 150.0, reversal.Amount)
+		riskSrv.AssertNotCalled(t, "AssessDebit")
+	})
+
+	t.Run("screen taking back what is left of a credit", func(t *testing.T) {
+		// Arrange
+		wallet := &repository.Wallet{WalletID: 1, Balance: 500}
+		transactionRepo := repository.NewTransactionRepositoryMock()
+		transactionRepo.On("GetTransaction", tenantID, int64(12)).Return(&repository.Transaction{TransactionID: 12, WalletID: 1, Operation: repository.TransactionAdd, Amount: 300, ReversedAmount: 100}, nil)
+		transactionRepo.On("ReverseTransaction", tenantID, repository.TransactionReversal{TransactionID: 12}).Return(&repository.Transaction{TransactionID: 13, WalletID: 1, Operation: repository.TransactionDeduct, Amount: 200, Balance: 300}, nil)
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(wallet, nil)
+		riskSrv := service.NewRiskServiceMock()
+		riskSrv.On("AssessDebit", tenantID, wallet, "Reverse", 200.0).Return(&service.RiskAssessment{Decision: risk.Allow}, nil)
+
+		transactionService := service.NewTransactionService(transactionRepo, walletRepo, riskSrv)
+
+		// Act
+		_, err := transactionService.ReverseTransaction(tenantID, 12, service.ReverseTransactionRequest{})
+
+		// Assert
+		assert.NoError(t, err)
+		riskSrv.AssertExpectations(t)
+	})
+
+	screened := []struct {
+		name       string
+		assessment *service.RiskAssessment
+		want       errs.AppError
+	}{
+		{"credit reversal denied by risk rule", &service.RiskAssessment{Decision: risk.Deny, Rule: "velocity"}, errs.NewTransactionDeniedError()},
+		{"credit reversal held by risk rule", &service.RiskAssessment{Decision: risk.Review, Rule: "velocity"}, errs.NewApprovalRequiredError("")},
+	}
+	for _, c := range screened {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			transactionRepo := repository.NewTransactionRepositoryMock()
+			transactionRepo.On("GetTransaction", tenantID, int64(12)).Return(&repository.Transaction{TransactionID: 12, WalletID: 1, Operation: repository.TransactionAdd, Amount: 300}, nil)
+			walletRepo := repository.NewWalletRepositoryMock()
+			walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Balance: 500}, nil)
+			riskSrv := service.NewRiskServiceMock()
+			riskSrv.On("AssessDebit", tenantID, &repository.Wallet{WalletID: 1, Balance: 500}, "Reverse", 150.0).Return(c.assessment, nil)
+
+			transactionService := service.NewTransactionService(transactionRepo, walletRepo, riskSrv)
+
+			// Act
+			_, err := transactionService.ReverseTransaction(tenantID, 12, service.ReverseTransactionRequest{Amount: 150})
+
+			// Assert
+			assert.ErrorIs(t, err, c.want)
+			transactionRepo.AssertNotCalled(t, "ReverseTransaction")
+		})
+	}
+
+	cases := []struct {
+		name string
+		err  error
+		want errs.AppError
+	}{
+		{"transaction not found", repository.ErrTransactionNotFound, errs.NewTransactionNotFoundError()},
+		{"already fully reversed", repository.ErrTransactionReversed, errs.NewTransactionReversedError()},
+		{"reversal of a reversal", repository.ErrNotReversible, errs.NewTransactionNotReversibleError()},
+		{"refunds above original amount", repository.ErrReversalExceedsOriginal, errs.NewReversalExceedsOriginalError()},
+		{"credit already spent", repository.ErrInsufficientFunds, errs.NewInsufficientFundsError()},
+		{"closed wallet", repository.ErrWalletClosed, errs.NewWalletClosedError()},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			transactionRepo := repository.NewTransactionRepositoryMock()
+			transactionRepo.On("GetTransaction", tenantID, int64(12)).Return(&repository.Transaction{TransactionID: 12, WalletID: 1, Operation: repository.TransactionDeduct, Amount: 200}, nil)
+			transactionRepo.On("ReverseTransaction", tenantID, repository.TransactionReversal{TransactionID: 12}).Return(&repository.Transaction{}, c.err)
+
+			transactionService := service.NewTransactionService(transactionRepo, repository.NewWalletRepositoryMock(), newRiskServiceMock())
+
+			// Act
+			_, err := transactionService.ReverseTransaction(tenantID, 12, service.ReverseTransactionRequest{})
+
+			// Assert
+			assert.ErrorIs(t, err, c.want)
+		})
+	}
+}
@@ -1,31 +1,103 @@
 package service
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type WalletRequest struct {
-	Balance float64 `json:"balance"`
+	Balance  float64           `json:"balance" validate:"gte=0,max=10000000,decimals=2"`
+	Metadata json.RawMessage   `json:"metadata"`
+	Labels   map[string]string `json:"labels" validate:"max=20"`
 }
 
 type AddWalletRequest struct {
-	Balance   float64 `json:"balance"`
-	Operation string  `json:"operation"`
+	Balance   float64 `json:"balance" validate:"required,gt=0,max=10000000,decimals=2"`
+	Operation string  `json:"operation" validate:"required,oneof=Add Deduct"`
+
+	ExpectedVersion int64  `json:"-"`
+	RequestedBy     string `json:"-"`
+	ApprovedBy      string `json:"-"`
+	// ApprovalID, set with ApprovedBy, applies the change as that
+	// approval's decision.
+	ApprovalID int64 `json:"-"`
 }
 
 type StatusWalletRequest struct {
-	Status string `json:"status"`
+	Status string `json:"status" validate:"required,oneof=Active Deactive"`
+
+	ExpectedVersion int64  `json:"-"`
+	RequestedBy     string `json:"-"`
+	ApprovedBy      string `json:"-"`
+	// ApprovalID, set with ApprovedBy, applies the change as that
+	// approval's decision.
+	ApprovalID int64 `json:"-"`
+}
+
+// CloseWalletRequest names the destination, if any, by account number, so a
+// mistyped one fails its check digit rather than sweeping into another
+// wallet.
+type CloseWalletRequest struct {
+	Reason                   string `json:"reason" validate:"required,max=255"`
+	DestinationAccountNumber string `json:"destination_account_number" validate:"max=20"`
+
+	ExpectedVersion int64 `json:"-"`
+}
+
+// PatchWalletRequest follows JSON merge patch semantics: metadata keys and
+// labels set to null are removed, others are added or replaced.
+type PatchWalletRequest struct {
+	Metadata json.RawMessage    `json:"metadata"`
+	Labels   map[string]*string `json:"labels" validate:"max=20"`
+
+	ExpectedVersion int64 `json:"-"`
+}
+
+type ListWalletsRequest struct {
+	IncludeClosed bool     `query:"include_closed"`
+	Labels        []string `query:"label"`
 }
 
+// WalletResponse identifies the wallet by ID, its public id; WalletID is
+// kept while clients move off numeric ids.
 type WalletResponse struct {
-	WalletID  int64     `json:"wallet_id"`
-	Balance   float64   `json:"balance"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	ID            string            `json:"id,omitempty"`
+	WalletID      int64             `json:"wallet_id"`
+	AccountNumber string            `json:"account_number,omitempty"`
+	Balance       float64           `json:"balance"`
+	Currency      string            `json:"currency"`
+	PocketBalance float64           `json:"pocket_balance,omitempty"`
+	TotalBalance  float64           `json:"total_balance,omitempty"`
+	Status        string            `json:"status"`
+	CreatedAt     time.Time         `json:"created_at"`
+	ClosedAt      *time.Time        `json:"closed_at,omitempty"`
+	ClosureReason string            `json:"closure_reason,omitempty"`
+	Metadata      json.RawMessage   `json:"metadata,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	TransactionID int64             `json:"transaction_id,omitempty"`
+	Fee           float64           `json:"fee,omitempty"`
+	Version       int64             `json:"-"`
+}
+
+// AccountSummary is what a sender is shown to confirm an account number
+// before paying into it: enough to recognise the wallet and no more.
+type AccountSummary struct {
+	AccountNumber string `json:"account_number"`
+	DisplayName   string `json:"display_name,omitempty"`
+	Currency      string `json:"currency"`
+	Status        string `json:"status"`
 }
 
+// WalletService methods take the tenant the caller acts for as their first
+// argument.
 type WalletService interface {
-	ListAllWallets() ([]WalletResponse, error)
-	GetWalletDetail(int64) (*WalletResponse, error)
-	CreateWallet(WalletRequest) (*WalletResponse, error)
-	SetWalletBalance(int64, AddWalletRequest) (*WalletResponse, error)
-	SetStatusWallet(int64, StatusWalletRequest) (*WalletResponse, error)
+	ListAllWallets(string, ListWalletsRequest) ([]WalletResponse, error)
+	GetWalletDetail(string, int64) (*WalletResponse, error)
+	FindWalletID(string, string) (int64, error)
+	LookupAccount(string, string) (*AccountSummary, error)
+	CreateWallet(string, WalletRequest) (*WalletResponse, error)
+	SetWalletBalance(string, int64, AddWalletRequest) (*WalletResponse, error)
+	SetStatusWallet(string, int64, StatusWalletRequest) (*WalletResponse, error)
+	CloseWallet(string, int64, CloseWalletRequest) (*WalletResponse, error)
+	UpdateWallet(string, int64, PatchWalletRequest) (*WalletResponse, error)
 }
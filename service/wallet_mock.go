@@ -10,27 +10,47 @@ func NewWalletServiceMock() *walletServiceMock {
 	return &walletServiceMock{}
 }
 
-func (s *walletServiceMock) ListAllWallets() ([]WalletResponse, error) {
-	args := s.Called()
+func (s *walletServiceMock) ListAllWallets(tenantID string, r ListWalletsRequest) ([]WalletResponse, error) {
+	args := s.Called(tenantID, r)
 	return args.Get(0).([]WalletResponse), args.Error(1)
 }
 
-func (s *walletServiceMock) GetWalletDetail(id int64) (*WalletResponse, error) {
-	args := s.Called(id)
+func (s *walletServiceMock) GetWalletDetail(tenantID string, id int64) (*WalletResponse, error) {
+	args := s.Called(tenantID, id)
 	return args.Get(0).(*WalletResponse), args.Error(1)
 }
 
-func (s *walletServiceMock) CreateWallet(r WalletRequest) (*WalletResponse, error) {
-	args := s.Called(r)
+func (s *walletServiceMock) FindWalletID(tenantID string, publicID string) (int64, error) {
+	args := s.Called(tenantID, publicID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (s *walletServiceMock) LookupAccount(tenantID string, number string) (*AccountSummary, error) {
+	args := s.Called(tenantID, number)
+	return args.Get(0).(*AccountSummary), args.Error(1)
+}
+
+func (s *walletServiceMock) CreateWallet(tenantID string, r WalletRequest) (*WalletResponse, error) {
+	args := s.Called(tenantID, r)
+	return args.Get(0).(*WalletResponse), args.Error(1)
+}
+
+func (s *walletServiceMock) SetWalletBalance(tenantID string, id int64, r AddWalletRequest) (*WalletResponse, error) {
+	args := s.Called(tenantID, id, r)
+	return args.Get(0).(*WalletResponse), args.Error(1)
+}
+
+func (s *walletServiceMock) SetStatusWallet(tenantID string, id int64, r StatusWalletRequest) (*WalletResponse, error) {
+	args := s.Called(tenantID, id, r)
 	return args.Get(0).(*WalletResponse), args.Error(1)
 }
 
-func (s *walletServiceMock) SetWalletBalance(id int64, r AddWalletRequest) (*WalletResponse, error) {
-	args := s.Called(id, r)
+func (s *walletServiceMock) CloseWallet(tenantID string, id int64, r CloseWalletRequest) (*WalletResponse, error) {
+	args := s.Called(tenantID, id, r)
 	return args.Get(0).(*WalletResponse), args.Error(1)
 }
 
-func (s *walletServiceMock) SetStatusWallet(id int64, r StatusWalletRequest) (*WalletResponse, error) {
-	args := s.Called(id, r)
+func (s *walletServiceMock) UpdateWallet(tenantID string, id int64, r PatchWalletRequest) (*WalletResponse, error) {
+	args := s.Called(tenantID, id, r)
 	return args.Get(0).(*WalletResponse), args.Error(1)
 }
@@ -2,143 +2,500 @@ package service
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
+	"github.com/topnarapat/go-wallet/account"
 	"github.com/topnarapat/go-wallet/errs"
-	"github.com/topnarapat/go-wallet/logs"
 	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
 )
 
 type walletService struct {
-	walletRepo repository.WalletRepository
+	walletRepo   repository.WalletRepository
+	tenantRepo   repository.TenantRepository
+	approvalRepo repository.ApprovalRepository
+	riskSrv      RiskService
+	feeRepo      repository.FeeRepository
 }
 
-func NewWalletService(walletRepo repository.WalletRepository) WalletService {
-	return walletService{walletRepo: walletRepo}
+func NewWalletService(walletRepo repository.WalletRepository, tenantRepo repository.TenantRepository, approvalRepo repository.ApprovalRepository, riskSrv RiskService, feeRepo repository.FeeRepository) WalletService {
+	return walletService{walletRepo: walletRepo, tenantRepo: tenantRepo, approvalRepo: approvalRepo, riskSrv: riskSrv, feeRepo: feeRepo}
 }
 
-func (s walletService) ListAllWallets() ([]WalletResponse, error) {
-	wallets, err := s.walletRepo.GetAllWallets()
+func (s walletService) ListAllWallets(tenantID string, r ListWalletsRequest) ([]WalletResponse, error) {
+	labels, err := parseLabelFilters(r.Labels)
 	if err != nil {
-		logs.Error(err)
-		return nil, errs.NewUnexpectedError()
+		return nil, err
+	}
+
+	wallets, err := s.walletRepo.GetAllWallets(tenantID, repository.WalletFilter{IncludeClosed: r.IncludeClosed, Labels: labels})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
 	}
 
 	walletResponses := []WalletResponse{}
 	for _, wallet := range wallets {
-		walletResponse := WalletResponse{
-			WalletID:  wallet.WalletID,
-			Balance:   wallet.Balance,
-			Status:    wallet.Status,
-			CreatedAt: wallet.CreatedAt,
-		}
-		walletResponses = append(walletResponses, walletResponse)
+		walletResponses = append(walletResponses, *newWalletResponse(&wallet))
 	}
 
 	return walletResponses, nil
 }
 
-func (s walletService) GetWalletDetail(id int64) (*WalletResponse, error) {
-	wallet, err := s.walletRepo.GetWallet(id)
+func (s walletService) GetWalletDetail(tenantID string, id int64) (*WalletResponse, error) {
+	wallet, err := s.walletRepo.GetWallet(tenantID, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errs.NewNotFoundError("wallet not found")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
 		}
 
-		logs.Error(err)
-		return nil, errs.NewUnexpectedError()
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return newWalletResponse(wallet), nil
+}
+
+// FindWalletID resolves a public wallet id to the internal one.
+func (s walletService) FindWalletID(tenantID string, publicID string) (int64, error) {
+	if !strings.HasPrefix(publicID, repository.PublicIDPrefix) {
+		return 0, errs.NewInvalidIDError()
+	}
+
+	id, err := s.walletRepo.GetWalletID(tenantID, publicID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errs.NewWalletNotFoundError()
+		}
+
+		return 0, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return id, nil
+}
+
+// LookupAccount finds the tenant's wallet with the account number, typed
+// with or without its dashes, so a sender can confirm it before paying.
+func (s walletService) LookupAccount(tenantID string, number string) (*AccountSummary, error) {
+	ids, fields, err := resolveAccountNumbers(s.walletRepo, tenantID, []accountNumberField{{field: "account_number", number: number}})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+	if len(fields) > 0 {
+		return nil, errs.NewFieldValidationError(fields)
+	}
+	if ids[0] == 0 {
+		return nil, errs.NewWalletNotFoundError()
 	}
 
-	walletResponse := WalletResponse{
-		WalletID:  wallet.WalletID,
-		Balance:   wallet.Balance,
-		Status:    wallet.Status,
-		CreatedAt: wallet.CreatedAt,
+	wallet, err := s.walletRepo.GetWallet(tenantID, ids[0])
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
+		}
+
+		return nil, errs.NewUnexpectedError().WithCause(err)
 	}
 
-	return &walletResponse, nil
+	return newAccountSummary(wallet), nil
 }
 
-func (s walletService) CreateWallet(w WalletRequest) (*WalletResponse, error) {
-	wallet, err := s.walletRepo.CreateNewWallet(w.Balance)
+func (s walletService) CreateWallet(tenantID string, w WalletRequest) (*WalletResponse, error) {
+	tenant, err := s.getTenant(tenantID)
 	if err != nil {
-		logs.Error(err)
-		return nil, errs.NewUnexpectedError()
+		return nil, err
+	}
+	if tenant.MaxBalance != nil && w.Balance > *tenant.MaxBalance {
+		return nil, errs.NewLimitExceededError(fmt.Sprintf("balance must not exceed %.2f", *tenant.MaxBalance))
 	}
 
-	walletResponse := WalletResponse{
-		WalletID:  wallet.WalletID,
-		Balance:   wallet.Balance,
-		Status:    wallet.Status,
-		CreatedAt: wallet.CreatedAt,
+	fields := validateMetadata(w.Metadata)
+	for _, key := range sortedKeys(w.Labels) {
+		value := w.Labels[key]
+		fields = append(fields, validateLabel(key, &value)...)
+	}
+	if len(fields) > 0 {
+		return nil, errs.NewFieldValidationError(fields)
 	}
 
-	return &walletResponse, nil
+	wallet, err := s.walletRepo.CreateNewWallet(tenantID, repository.NewWallet{
+		Balance:  w.Balance,
+		Currency: tenant.DefaultCurrency,
+		Metadata: w.Metadata,
+		Labels:   w.Labels,
+	})
+	if err != nil {
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return newWalletResponse(wallet), nil
 }
 
-func (s walletService) SetWalletBalance(id int64, w AddWalletRequest) (*WalletResponse, error) {
+func (s walletService) SetWalletBalance(tenantID string, id int64, w AddWalletRequest) (*WalletResponse, error) {
 	if w.Operation != "Add" && w.Operation != "Deduct" {
-		return nil, errs.NewBadRequest("operation must be Add or Deduct")
+		return nil, errs.NewInvalidOperationError("operation must be Add or Deduct")
+	}
+
+	tenant, err := s.getTenant(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if tenant.MaxTransactionAmount != nil && w.Balance > *tenant.MaxTransactionAmount {
+		return nil, errs.NewLimitExceededError(fmt.Sprintf("amount must not exceed %.2f", *tenant.MaxTransactionAmount))
 	}
 
-	wallet, err := s.walletRepo.GetWallet(id)
+	wallet, err := s.walletRepo.GetWallet(tenantID, id)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errs.NewNotFoundError("wallet not found")
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewWalletNotFoundError()
 		}
 
-		logs.Error(err)
-		return nil, errs.NewUnexpectedError()
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	if wallet.Status == repository.StatusClosed {
+		return nil, errs.NewWalletClosedError()
 	}
 
-	if w.Operation == "Deduct" && wallet.Balance < w.Balance {
-		return nil, errs.NewBadRequest("balance not enough")
+	if w.ExpectedVersion != 0 && wallet.Version != w.ExpectedVersion {
+		return nil, errs.NewPreconditionFailedError()
 	}
 
-	if w.Operation == "Deduct" && wallet.Balance >= w.Balance {
-		wallet, err = s.walletRepo.SetBalance(id, -w.Balance)
+	charge := feeCharge{}
+	if w.Operation == "Deduct" {
+		charge, err = feeFor(s.feeRepo, tenantID, w.Operation, wallet.Currency, w.Balance)
 		if err != nil {
-			return nil, errs.NewUnexpectedError()
+			return nil, err
 		}
 	}
 
-	if w.Operation == "Add" {
-		wallet, err = s.walletRepo.SetBalance(id, w.Balance)
+	if w.Operation == "Deduct" && wallet.Balance < w.Balance+charge.Fee {
+		return nil, errs.NewInsufficientFundsError()
+	}
+
+	if w.Operation == "Add" && tenant.MaxBalance != nil && wallet.Balance+wallet.PocketBalance+w.Balance > *tenant.MaxBalance {
+		return nil, errs.NewLimitExceededError(fmt.Sprintf("balance must not exceed %.2f", *tenant.MaxBalance))
+	}
+
+	// An approved change has already been reviewed by a person, so it is not
+	// screened again.
+	if w.ApprovedBy == "" && w.Operation == "Deduct" {
+		assessment, err := s.riskSrv.AssessDebit(tenantID, wallet, w.Operation, w.Balance)
 		if err != nil {
-			return nil, errs.NewUnexpectedError()
+			return nil, err
+		}
+
+		switch assessment.Decision {
+		case risk.Deny:
+			return nil, errs.NewTransactionDeniedError()
+		case risk.Review:
+			return nil, s.holdForApproval(tenantID, repository.NewApproval{
+				WalletID:    id,
+				Action:      repository.ActionBalance,
+				Operation:   w.Operation,
+				Amount:      w.Balance,
+				RequestedBy: w.RequestedBy,
+				Reason:      "risk rule " + assessment.Rule,
+			})
 		}
 	}
 
-	walletResponse := WalletResponse{
-		WalletID:  wallet.WalletID,
-		Balance:   wallet.Balance,
-		Status:    wallet.Status,
-		CreatedAt: wallet.CreatedAt,
+	if w.ApprovedBy == "" && tenant.ApprovalThreshold != nil && w.Balance > *tenant.ApprovalThreshold {
+		return nil, s.holdForApproval(tenantID, repository.NewApproval{
+			WalletID:    id,
+			Action:      repository.ActionBalance,
+			Operation:   w.Operation,
+			Amount:      w.Balance,
+			RequestedBy: w.RequestedBy,
+		})
 	}
 
-	return &walletResponse, nil
+	if w.ApprovalID != 0 {
+		wallet, err = s.applyApproval(tenantID, repository.ApprovedChange{
+			ApprovalID:      w.ApprovalID,
+			DecidedBy:       w.ApprovedBy,
+			Fee:             charge.Fee,
+			RevenueWalletID: charge.RevenueWalletID,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if w.Operation == "Deduct" && charge.Fee > 0 {
+		wallet, err = s.walletRepo.DeductWithFee(tenantID, repository.FeeDeduction{
+			WalletID:        id,
+			Amount:          w.Balance,
+			Fee:             charge.Fee,
+			RevenueWalletID: charge.RevenueWalletID,
+			Version:         w.ExpectedVersion,
+		})
+		if err != nil {
+			return nil, mutationError(err)
+		}
+	} else if w.Operation == "Deduct" && wallet.Balance >= w.Balance {
+		wallet, err = s.walletRepo.SetBalance(tenantID, id, -w.Balance, w.ExpectedVersion)
+		if err != nil {
+			return nil, mutationError(err)
+		}
+	} else if w.Operation == "Add" {
+		wallet, err = s.walletRepo.SetBalance(tenantID, id, w.Balance, w.ExpectedVersion)
+		if err != nil {
+			return nil, mutationError(err)
+		}
+	}
+
+	response := newWalletResponse(wallet)
+	response.Fee = charge.Fee
+	return response, nil
 }
 
-func (s walletService) SetStatusWallet(id int64, st StatusWalletRequest) (*WalletResponse, error) {
+func (s walletService) SetStatusWallet(tenantID string, id int64, st StatusWalletRequest) (*WalletResponse, error) {
 	if st.Status != "Active" && st.Status != "Deactive" {
-		return nil, errs.NewBadRequest("operation must be Active or Deactive")
+		return nil, errs.NewInvalidStatusError("status must be Active or Deactive")
 	}
 
-	wallet, err := s.walletRepo.SetStatusWallet(id, st.Status)
+	tenant, err := s.getTenant(tenantID)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errs.NewNotFoundError("wallet not found")
+		return nil, err
+	}
+	if st.ApprovedBy == "" && tenant.ApprovalThreshold != nil {
+		wallet, err := s.walletRepo.GetWallet(tenantID, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errs.NewWalletNotFoundError()
+			}
+
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+		if wallet.Balance+wallet.PocketBalance > *tenant.ApprovalThreshold {
+			return nil, s.holdForApproval(tenantID, repository.NewApproval{
+				WalletID:     id,
+				Action:       repository.ActionStatus,
+				WalletStatus: st.Status,
+				RequestedBy:  st.RequestedBy,
+			})
 		}
+	}
 
-		logs.Error(err)
-		return nil, errs.NewUnexpectedError()
+	if st.ApprovalID != 0 {
+		wallet, err := s.applyApproval(tenantID, repository.ApprovedChange{ApprovalID: st.ApprovalID, DecidedBy: st.ApprovedBy})
+		if err != nil {
+			return nil, err
+		}
+
+		return newWalletResponse(wallet), nil
 	}
 
-	walletResponse := WalletResponse{
-		WalletID:  wallet.WalletID,
-		Balance:   wallet.Balance,
-		Status:    wallet.Status,
-		CreatedAt: wallet.CreatedAt,
+	wallet, err := s.walletRepo.SetStatusWallet(tenantID, id, st.Status, st.ExpectedVersion)
+	if err != nil {
+		return nil, mutationError(err)
+	}
+
+	return newWalletResponse(wallet), nil
+}
+
+func (s walletService) CloseWallet(tenantID string, id int64, c CloseWalletRequest) (*WalletResponse, error) {
+	var destinationID int64
+	if c.DestinationAccountNumber != "" {
+		ids, fields, err := resolveAccountNumbers(s.walletRepo, tenantID, []accountNumberField{{field: "destination_account_number", number: c.DestinationAccountNumber}})
+		if err != nil {
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+		if len(fields) > 0 {
+			return nil, errs.NewFieldValidationError(fields)
+		}
+		if ids[0] == 0 {
+			return nil, errs.NewInvalidDestinationError("destination wallet not found")
+		}
+		destinationID = ids[0]
+	}
+
+	if destinationID == id {
+		return nil, errs.NewInvalidDestinationError("destination wallet must differ from the wallet being closed")
+	}
+
+	if destinationID != 0 {
+		destination, err := s.walletRepo.GetWallet(tenantID, destinationID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errs.NewInvalidDestinationError("destination wallet not found")
+			}
+
+			return nil, errs.NewUnexpectedError().WithCause(err)
+		}
+		if destination.Status != "Active" {
+			return nil, errs.NewInvalidDestinationError("destination wallet is not active")
+		}
+
+		err = s.assessSweep(tenantID, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wallet, err := s.walletRepo.CloseWallet(tenantID, repository.CloseWallet{
+		WalletID:      id,
+		DestinationID: destinationID,
+		Reason:        c.Reason,
+		Version:       c.ExpectedVersion,
+	})
+	if err != nil {
+		return nil, mutationError(err)
+	}
+
+	return newWalletResponse(wallet), nil
+}
+
+// assessSweep screens the closure sweep, which debits the wallet's whole
+// balance, its pockets included, like any other debit. There is no approval
+// for closing, so a sweep held for review must be withdrawn, and approved,
+// as a deduction first.
+func (s walletService) assessSweep(tenantID string, id int64) error {
+	wallet, err := s.walletRepo.GetWallet(tenantID, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.NewWalletNotFoundError()
+		}
+
+		return errs.NewUnexpectedError().WithCause(err)
+	}
+
+	swept := *wallet
+	swept.Balance += swept.PocketBalance
+	if swept.Balance <= 0 {
+		return nil
+	}
+
+	assessment, err := s.riskSrv.AssessDebit(tenantID, &swept, "Close", swept.Balance)
+	if err != nil {
+		return err
+	}
+
+	switch assessment.Decision {
+	case risk.Deny:
+		return errs.NewTransactionDeniedError()
+	case risk.Review:
+		return errs.NewApprovalRequiredError(fmt.Sprintf("held by risk rule %s; deduct the balance with PUT /wallet/:id before closing", assessment.Rule))
+	}
+
+	return nil
+}
+
+func (s walletService) UpdateWallet(tenantID string, id int64, p PatchWalletRequest) (*WalletResponse, error) {
+	patch := repository.WalletPatch{
+		WalletID: id,
+		Metadata: p.Metadata,
+		Version:  p.ExpectedVersion,
+	}
+
+	fields := validateMetadata(p.Metadata)
+	for _, key := range sortedKeys(p.Labels) {
+		value := p.Labels[key]
+		fields = append(fields, validateLabel(key, value)...)
+		if value == nil {
+			patch.RemoveLabels = append(patch.RemoveLabels, key)
+			continue
+		}
+		if patch.SetLabels == nil {
+			patch.SetLabels = map[string]string{}
+		}
+		patch.SetLabels[key] = *value
+	}
+	if len(fields) > 0 {
+		return nil, errs.NewFieldValidationError(fields)
+	}
+
+	wallet, err := s.walletRepo.UpdateWallet(tenantID, patch)
+	if err != nil {
+		return nil, mutationError(err)
+	}
+
+	return newWalletResponse(wallet), nil
+}
+
+func newWalletResponse(wallet *repository.Wallet) *WalletResponse {
+	response := &WalletResponse{
+		ID:            wallet.PublicID,
+		WalletID:      wallet.WalletID,
+		AccountNumber: account.Format(wallet.AccountNumber),
+		Balance:       wallet.Balance,
+		Currency:      wallet.Currency,
+		Status:        wallet.Status,
+		CreatedAt:     wallet.CreatedAt,
+		ClosedAt:      wallet.ClosedAt,
+		ClosureReason: wallet.ClosureReason,
+		Metadata:      wallet.Metadata,
+		Labels:        wallet.Labels,
+		TransactionID: wallet.TransactionID,
+		Version:       wallet.Version,
+	}
+
+	// Wallets without pockets keep their original shape; balance is already
+	// the total.
+	if wallet.Pockets > 0 {
+		response.PocketBalance = wallet.PocketBalance
+		response.TotalBalance = wallet.Balance + wallet.PocketBalance
+	}
+
+	return response
+}
+
+// getTenant loads the tenant configuration. Tenants are resolved from API
+// keys before a request gets here, so a missing row means the tenant named in
+// X-Tenant-ID does not exist.
+func (s walletService) getTenant(tenantID string) (*repository.Tenant, error) {
+	tenant, err := s.tenantRepo.GetTenant(tenantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.NewTenantNotFoundError()
+		}
+
+		return nil, errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return tenant, nil
+}
+
+// holdForApproval stores the change as a pending approval and returns the
+// ApprovalRequiredError describing it.
+func (s walletService) holdForApproval(tenantID string, a repository.NewApproval) error {
+	a.TTL = ApprovalTTL
+	approval, err := s.approvalRepo.CreateApproval(tenantID, a)
+	if err != nil {
+		return errs.NewUnexpectedError().WithCause(err)
+	}
+
+	return ApprovalRequiredError{Approval: *newApprovalResponse(approval)}
+}
+
+// applyApproval approves the approval and applies its held change in one
+// database transaction.
+func (s walletService) applyApproval(tenantID string, c repository.ApprovedChange) (*repository.Wallet, error) {
+	wallet, err := s.approvalRepo.ApplyApproval(tenantID, c)
+	if errors.Is(err, repository.ErrApprovalNotPending) || errors.Is(err, repository.ErrApprovalExpired) || errors.Is(err, repository.ErrSelfApproval) {
+		return nil, approvalError(err)
+	}
+	if err != nil {
+		return nil, mutationError(err)
+	}
+
+	return wallet, nil
+}
+
+func mutationError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.NewWalletNotFoundError()
+	case errors.Is(err, repository.ErrVersionConflict):
+		return errs.NewPreconditionFailedError()
+	case errors.Is(err, repository.ErrWalletClosed):
+		return errs.NewWalletClosedError()
+	case errors.Is(err, repository.ErrBalanceNotZero):
+		return errs.NewBalanceNotZeroError()
+	case errors.Is(err, repository.ErrInsufficientFunds):
+		return errs.NewInsufficientFundsError()
+	case errors.Is(err, repository.ErrDestinationUnavailable):
+		return errs.NewInvalidDestinationError("destination wallet cannot receive funds")
 	}
 
-	return &walletResponse, nil
+	return errs.NewUnexpectedError().WithCause(err)
 }
@@ -5,29 +5,52 @@ package service_test
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/topnarapat/go-wallet/errs"
 	"github.com/topnarapat/go-wallet/repository"
+	"github.com/topnarapat/go-wallet/risk"
 	"github.com/topnarapat/go-wallet/service"
 )
 
+const tenantID = "acme"
+
+func newTenantRepositoryMock() repository.TenantRepository {
+	tenantRepo := repository.NewTenantRepositoryMock()
+	tenantRepo.On("GetTenant", tenantID).Return(&repository.Tenant{TenantID: tenantID, DefaultCurrency: "THB"}, nil)
+	return tenantRepo
+}
+
+func newFeeRepositoryMock() repository.FeeRepository {
+	feeRepo := repository.NewFeeRepositoryMock()
+	feeRepo.On("GetFeeRule", tenantID, mock.Anything, mock.Anything).Return(&repository.FeeRule{}, sql.ErrNoRows)
+	return feeRepo
+}
+
+func newRiskServiceMock() service.RiskService {
+	riskSrv := service.NewRiskServiceMock()
+	riskSrv.On("AssessDebit", tenantID, mock.Anything, mock.Anything, mock.Anything).Return(&service.RiskAssessment{Decision: risk.Allow}, nil)
+	return riskSrv
+}
+
 func TestListAllWallets(t *testing.T) {
 	t.Run("get all wallets", func(t *testing.T) {
 		// Arrange
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetAllWallets").Return([]repository.Wallet{
+		walletRepo.On("GetAllWallets", tenantID, repository.WalletFilter{}).Return([]repository.Wallet{
 			{WalletID: 1, Balance: 500, Status: "Active", CreatedAt: time.Date(2022, time.January, 27, 12, 30, 0, 0, time.UTC)},
 			{WalletID: 2, Balance: 0, Status: "Deactive", CreatedAt: time.Date(2022, time.January, 27, 12, 30, 0, 0, time.UTC)},
 		}, nil)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		wallets, _ := walletService.ListAllWallets()
+		wallets, _ := walletService.ListAllWallets(tenantID, service.ListWalletsRequest{})
 		expected := []service.WalletResponse{
 			{WalletID: 1, Balance: 500, Status: "Active", CreatedAt: time.Date(2022, time.January, 27, 12, 30, 0, 0, time.UTC)},
 			{WalletID: 2, Balance: 0, Status: "Deactive", CreatedAt: time.Date(2022, time.January, 27, 12, 30, 0, 0, time.UTC)},
@@ -40,15 +63,31 @@ func TestListAllWallets(t *testing.T) {
 	t.Run("unexpected error", func(t *testing.T) {
 		// Arrange
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetAllWallets").Return([]repository.Wallet{}, errors.New(""))
+		walletRepo.On("GetAllWallets", tenantID, repository.WalletFilter{}).Return([]repository.Wallet{}, errors.New(""))
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.ListAllWallets(tenantID, service.ListWalletsRequest{})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewUnexpectedError())
+	})
+
+	t.Run("unexpected error keeps cause", func(t *testing.T) {
+		// Arrange
+		cause := errors.New("connection refused")
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetAllWallets", tenantID, repository.WalletFilter{}).Return([]repository.Wallet{}, cause)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.ListAllWallets()
+		_, err := walletService.ListAllWallets(tenantID, service.ListWalletsRequest{})
 
 		// Assert
 		assert.ErrorIs(t, err, errs.NewUnexpectedError())
+		assert.ErrorIs(t, err, cause)
 	})
 }
 
@@ -71,17 +110,17 @@ func TestGetWalletDetail(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			// Arrange
 			walletRepo := repository.NewWalletRepositoryMock()
-			walletRepo.On("GetWallet", c.walletID).Return(&repository.Wallet{
+			walletRepo.On("GetWallet", tenantID, c.walletID).Return(&repository.Wallet{
 				WalletID:  c.walletID,
 				Balance:   c.balance,
 				Status:    c.status,
 				CreatedAt: c.createdAt,
 			}, nil)
 
-			walletService := service.NewWalletService(walletRepo)
+			walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 			// Act
-			wallet, _ := walletService.GetWalletDetail(c.walletID)
+			wallet, _ := walletService.GetWalletDetail(tenantID, c.walletID)
 			expected := &service.WalletResponse{
 				WalletID:  c.walletID,
 				Balance:   c.balance,
@@ -98,33 +137,130 @@ func TestGetWalletDetail(t *testing.T) {
 		// Arrange
 		var id int64 = 99
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{}, sql.ErrNoRows)
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{}, sql.ErrNoRows)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.GetWalletDetail(id)
+		_, err := walletService.GetWalletDetail(tenantID, id)
 
 		// Assert
-		assert.ErrorIs(t, err, errs.NewNotFoundError("wallet not found"))
+		assert.ErrorIs(t, err, errs.NewWalletNotFoundError())
 	})
 
 	t.Run("unexpected error", func(t *testing.T) {
 		// Arrange
 		var id int64 = 99
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{}, errors.New(""))
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{}, errors.New(""))
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.GetWalletDetail(id)
+		_, err := walletService.GetWalletDetail(tenantID, id)
 
 		// Assert
 		assert.ErrorIs(t, err, errs.NewUnexpectedError())
 	})
 }
 
+func TestFindWalletID(t *testing.T) {
+	t.Run("public id", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWalletID", tenantID, "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3").Return(int64(42), nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		id, err := walletService.FindWalletID(tenantID, "wal_6f1c2a9e0b7d4e35a1c8f2d9b0e4a7c3")
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(42), id)
+	})
+
+	t.Run("another tenant's wallet", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWalletID", tenantID, "wal_0d8e5b3a7f21c6e49b0a3d7c5e1f8a26").Return(int64(0), sql.ErrNoRows)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.FindWalletID(tenantID, "wal_0d8e5b3a7f21c6e49b0a3d7c5e1f8a26")
+
+		// Assert
+		assert.Equal(t, errs.NewWalletNotFoundError(), err)
+	})
+
+	t.Run("not a public id", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.FindWalletID(tenantID, "abc")
+
+		// Assert
+		assert.Equal(t, errs.NewInvalidIDError(), err)
+		walletRepo.AssertNotCalled(t, "GetWalletID")
+	})
+}
+
+func TestLookupAccount(t *testing.T) {
+	t.Run("masked summary", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{"1234567897": 42}, nil)
+		walletRepo.On("GetWallet", tenantID, int64(42)).Return(&repository.Wallet{
+			WalletID:      42,
+			AccountNumber: "1234567897",
+			Currency:      "THB",
+			Balance:       1250,
+			Status:        "Active",
+			Metadata:      json.RawMessage(`{"display_name":"Somchai Jaidee","customer_ref":"C-1029"}`),
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		summary, err := walletService.LookupAccount(tenantID, "1234567897")
+		expected := &service.AccountSummary{AccountNumber: "xxx-x-xx789-7", DisplayName: "S****** J*****", Currency: "THB", Status: "Active"}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, summary)
+	})
+
+	t.Run("no such account", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.LookupAccount(tenantID, "123-4-56789-7")
+
+		// Assert
+		assert.Equal(t, errs.NewWalletNotFoundError(), err)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.LookupAccount(tenantID, "12345")
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "account_number", Message: "must be 10 digits"}}), err)
+		walletRepo.AssertNotCalled(t, "FindAccountNumbers", mock.Anything, mock.Anything)
+	})
+}
+
 func TestCreateWallet(t *testing.T) {
 	type testCase struct {
 		name      string
@@ -144,21 +280,21 @@ func TestCreateWallet(t *testing.T) {
 		t.Run(c.name, func(t *testing.T) {
 			// Arrange
 			walletRepo := repository.NewWalletRepositoryMock()
-			walletRepo.On("CreateNewWallet", c.balance).Return(&repository.Wallet{
+			walletRepo.On("CreateNewWallet", tenantID, repository.NewWallet{Currency: "THB", Balance: c.balance}).Return(&repository.Wallet{
 				WalletID:  c.walletID,
 				Balance:   c.balance,
 				Status:    c.status,
 				CreatedAt: c.createdAt,
 			}, nil)
 
-			walletService := service.NewWalletService(walletRepo)
+			walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 			balance := service.WalletRequest{
 				Balance: c.balance,
 			}
 
 			// Act
-			wallet, _ := walletService.CreateWallet(balance)
+			wallet, _ := walletService.CreateWallet(tenantID, balance)
 			expected := &service.WalletResponse{
 				WalletID:  c.walletID,
 				Balance:   c.balance,
@@ -175,16 +311,16 @@ func TestCreateWallet(t *testing.T) {
 		// Arrange
 		var balance float64 = 99
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("CreateNewWallet", balance).Return(&repository.Wallet{}, errors.New(""))
+		walletRepo.On("CreateNewWallet", tenantID, repository.NewWallet{Currency: "THB", Balance: balance}).Return(&repository.Wallet{}, errors.New(""))
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		walletRequest := service.WalletRequest{
 			Balance: balance,
 		}
 
 		// Act
-		_, err := walletService.CreateWallet(walletRequest)
+		_, err := walletService.CreateWallet(tenantID, walletRequest)
 
 		// Assert
 		assert.ErrorIs(t, err, errs.NewUnexpectedError())
@@ -200,23 +336,23 @@ func TestSetWalletBalance(t *testing.T) {
 			Operation: "Add",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   2000,
 			Status:    "Active",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
-		walletRepo.On("SetBalance", id, amount.Balance).Return(&repository.Wallet{
+		walletRepo.On("SetBalance", tenantID, id, amount.Balance, int64(0)).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   3000,
 			Status:    "Active",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		wallet, _ := walletService.SetWalletBalance(id, amount)
+		wallet, _ := walletService.SetWalletBalance(tenantID, id, amount)
 		expected := &service.WalletResponse{
 			WalletID:  id,
 			Balance:   3000,
@@ -236,23 +372,23 @@ func TestSetWalletBalance(t *testing.T) {
 			Operation: "Deduct",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   3000,
 			Status:    "Active",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
-		walletRepo.On("SetBalance", id, -amount.Balance).Return(&repository.Wallet{
+		walletRepo.On("SetBalance", tenantID, id, -amount.Balance, int64(0)).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   2000,
 			Status:    "Active",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		wallet, _ := walletService.SetWalletBalance(id, amount)
+		wallet, _ := walletService.SetWalletBalance(tenantID, id, amount)
 		expected := &service.WalletResponse{
 			WalletID:  id,
 			Balance:   2000,
@@ -272,15 +408,15 @@ func TestSetWalletBalance(t *testing.T) {
 			Operation: "Add",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{}, sql.ErrNoRows)
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{}, sql.ErrNoRows)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.SetWalletBalance(id, amount)
+		_, err := walletService.SetWalletBalance(tenantID, id, amount)
 
 		// Assert
-		assert.ErrorIs(t, err, errs.NewNotFoundError("wallet not found"))
+		assert.ErrorIs(t, err, errs.NewWalletNotFoundError())
 	})
 
 	t.Run("unexpected error", func(t *testing.T) {
@@ -291,12 +427,12 @@ func TestSetWalletBalance(t *testing.T) {
 			Operation: "Add",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{}, errors.New(""))
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{}, errors.New(""))
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.SetWalletBalance(id, amount)
+		_, err := walletService.SetWalletBalance(tenantID, id, amount)
 
 		// Assert
 		assert.ErrorIs(t, err, errs.NewUnexpectedError())
@@ -310,21 +446,21 @@ func TestSetWalletBalance(t *testing.T) {
 			Operation: "Deduct",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   500,
 			Status:    "Active",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
-		walletRepo.On("SetBalance", id, amount.Balance).Return(&repository.Wallet{}, errors.New("balance not enough"))
+		walletRepo.On("SetBalance", tenantID, id, amount.Balance, int64(0)).Return(&repository.Wallet{}, errors.New("balance not enough"))
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.SetWalletBalance(id, amount)
+		_, err := walletService.SetWalletBalance(tenantID, id, amount)
 
 		// Assert
-		assert.ErrorIs(t, err, errs.NewBadRequest("balance not enough"))
+		assert.ErrorIs(t, err, errs.NewInsufficientFundsError())
 	})
 
 	t.Run("deduct unexpected error", func(t *testing.T) {
@@ -335,18 +471,18 @@ func TestSetWalletBalance(t *testing.T) {
 			Operation: "Deduct",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   2000,
 			Status:    "Active",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
-		walletRepo.On("SetBalance", id, -amount.Balance).Return(&repository.Wallet{}, errors.New(""))
+		walletRepo.On("SetBalance", tenantID, id, -amount.Balance, int64(0)).Return(&repository.Wallet{}, errors.New(""))
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.SetWalletBalance(id, amount)
+		_, err := walletService.SetWalletBalance(tenantID, id, amount)
 
 		// Assert
 		assert.ErrorIs(t, err, errs.NewUnexpectedError())
@@ -360,18 +496,18 @@ func TestSetWalletBalance(t *testing.T) {
 			Operation: "Add",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("GetWallet", id).Return(&repository.Wallet{
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   2000,
 			Status:    "Active",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
-		walletRepo.On("SetBalance", id, amount.Balance).Return(&repository.Wallet{}, errors.New(""))
+		walletRepo.On("SetBalance", tenantID, id, amount.Balance, int64(0)).Return(&repository.Wallet{}, errors.New(""))
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.SetWalletBalance(id, amount)
+		_, err := walletService.SetWalletBalance(tenantID, id, amount)
 
 		// Assert
 		assert.ErrorIs(t, err, errs.NewUnexpectedError())
@@ -386,17 +522,17 @@ func TestSetStatusWallet(t *testing.T) {
 			Status: "Deactive",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("SetStatusWallet", id, st.Status).Return(&repository.Wallet{
+		walletRepo.On("SetStatusWallet", tenantID, id, st.Status, int64(0)).Return(&repository.Wallet{
 			WalletID:  id,
 			Balance:   2000,
 			Status:    "Deactive",
 			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
 		}, nil)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		wallet, _ := walletService.SetStatusWallet(id, st)
+		wallet, _ := walletService.SetStatusWallet(tenantID, id, st)
 		expected := &service.WalletResponse{
 			WalletID:  id,
 			Balance:   2000,
@@ -415,15 +551,15 @@ func TestSetStatusWallet(t *testing.T) {
 			Status: "Deactive",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("SetStatusWallet", id, st.Status).Return(&repository.Wallet{}, sql.ErrNoRows)
+		walletRepo.On("SetStatusWallet", tenantID, id, st.Status, int64(0)).Return(&repository.Wallet{}, sql.ErrNoRows)
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.SetStatusWallet(id, st)
+		_, err := walletService.SetStatusWallet(tenantID, id, st)
 
 		// Assert
-		assert.ErrorIs(t, err, errs.NewNotFoundError("wallet not found"))
+		assert.ErrorIs(t, err, errs.NewWalletNotFoundError())
 	})
 
 	t.Run("unexpected error", func(t *testing.T) {
@@ -433,14 +569,540 @@ func TestSetStatusWallet(t *testing.T) {
 			Status: "Deactive",
 		}
 		walletRepo := repository.NewWalletRepositoryMock()
-		walletRepo.On("SetStatusWallet", id, st.Status).Return(&repository.Wallet{}, errors.New(""))
+		walletRepo.On("SetStatusWallet", tenantID, id, st.Status, int64(0)).Return(&repository.Wallet{}, errors.New(""))
 
-		walletService := service.NewWalletService(walletRepo)
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
 
 		// Act
-		_, err := walletService.SetStatusWallet(id, st)
+		_, err := walletService.SetStatusWallet(tenantID, id, st)
 
 		// Assert
 		assert.ErrorIs(t, err, errs.NewUnexpectedError())
 	})
 }
+
+func TestOptimisticConcurrency(t *testing.T) {
+	t.Run("add balance with stale version", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		amount := service.AddWalletRequest{
+			Balance:         1000,
+			Operation:       "Add",
+			ExpectedVersion: 2,
+		}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{
+			WalletID:  id,
+			Balance:   2000,
+			Status:    "Active",
+			Version:   3,
+			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, amount)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewPreconditionFailedError())
+		walletRepo.AssertNotCalled(t, "SetBalance", id, amount.Balance, amount.ExpectedVersion)
+	})
+
+	t.Run("add balance with matching version", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		amount := service.AddWalletRequest{
+			Balance:         1000,
+			Operation:       "Add",
+			ExpectedVersion: 3,
+		}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{
+			WalletID:  id,
+			Balance:   2000,
+			Status:    "Active",
+			Version:   3,
+			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
+		}, nil)
+		walletRepo.On("SetBalance", tenantID, id, amount.Balance, amount.ExpectedVersion).Return(&repository.Wallet{
+			WalletID:  id,
+			Balance:   3000,
+			Status:    "Active",
+			Version:   4,
+			CreatedAt: time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.SetWalletBalance(tenantID, id, amount)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, int64(4), wallet.Version)
+	})
+
+	t.Run("set status after concurrent change", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		st := service.StatusWalletRequest{
+			Status:          "Deactive",
+			ExpectedVersion: 3,
+		}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("SetStatusWallet", tenantID, id, st.Status, st.ExpectedVersion).Return(&repository.Wallet{}, repository.ErrVersionConflict)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetStatusWallet(tenantID, id, st)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewPreconditionFailedError())
+	})
+}
+
+func TestCloseWallet(t *testing.T) {
+	closedAt := time.Date(2023, time.March, 31, 9, 0, 0, 0, time.UTC)
+
+	t.Run("close wallet with zero balance", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.CloseWalletRequest{Reason: "customer request"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("CloseWallet", tenantID, repository.CloseWallet{WalletID: id, Reason: "customer request"}).Return(&repository.Wallet{
+			WalletID:      id,
+			Balance:       0,
+			Status:        "Closed",
+			Version:       5,
+			CreatedAt:     time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
+			ClosedAt:      &closedAt,
+			ClosureReason: "customer request",
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.CloseWallet(tenantID, id, request)
+		expected := &service.WalletResponse{
+			WalletID:      id,
+			Balance:       0,
+			Status:        "Closed",
+			CreatedAt:     time.Date(2022, time.January, 29, 12, 30, 0, 0, time.UTC),
+			ClosedAt:      &closedAt,
+			ClosureReason: "customer request",
+			Version:       5,
+		}
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, expected, wallet)
+	})
+
+	t.Run("close wallet sweeping into destination", func(t *testing.T) {
+		// Arrange
+		var id, destination int64 = 1, 2
+		request := service.CloseWalletRequest{Reason: "migrated", DestinationAccountNumber: "123 4 56789 7"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{"1234567897": destination}, nil)
+		walletRepo.On("GetWallet", tenantID, destination).Return(&repository.Wallet{WalletID: destination, Status: "Active"}, nil)
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1000, PocketBalance: 500, Status: "Active"}, nil)
+		walletRepo.On("CloseWallet", tenantID, repository.CloseWallet{WalletID: id, DestinationID: destination, Reason: "migrated"}).Return(&repository.Wallet{
+			WalletID: id,
+			Status:   "Closed",
+			ClosedAt: &closedAt,
+		}, nil)
+		riskSrv := service.NewRiskServiceMock()
+		riskSrv.On("AssessDebit", tenantID, mock.Anything, "Close", 1500.0).Return(&service.RiskAssessment{Decision: risk.Allow}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), riskSrv, newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.CloseWallet(tenantID, id, request)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "Closed", wallet.Status)
+		walletRepo.AssertExpectations(t)
+		riskSrv.AssertExpectations(t)
+	})
+
+	sweepCases := []struct {
+		name       string
+		assessment service.RiskAssessment
+		expected   error
+	}{
+		{name: "closure sweep denied by risk rule", assessment: service.RiskAssessment{Decision: risk.Deny, Rule: "velocity_burst"}, expected: errs.NewTransactionDeniedError()},
+		{name: "closure sweep held by risk rule", assessment: service.RiskAssessment{Decision: risk.Review, Rule: "new_wallet_drain"}, expected: errs.NewApprovalRequiredError("")},
+	}
+	for _, c := range sweepCases {
+		t.Run(c.name, func(t *testing.T) {
+			// Arrange
+			var id, destination int64 = 1, 2
+			request := service.CloseWalletRequest{Reason: "migrated", DestinationAccountNumber: "1234567897"}
+			walletRepo := repository.NewWalletRepositoryMock()
+			walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{"1234567897": destination}, nil)
+			walletRepo.On("GetWallet", tenantID, destination).Return(&repository.Wallet{WalletID: destination, Status: "Active"}, nil)
+			walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Balance: 1500, Status: "Active"}, nil)
+			riskSrv := service.NewRiskServiceMock()
+			riskSrv.On("AssessDebit", tenantID, mock.Anything, "Close", 1500.0).Return(&c.assessment, nil)
+
+			walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), riskSrv, newFeeRepositoryMock())
+
+			// Act
+			_, err := walletService.CloseWallet(tenantID, id, request)
+
+			// Assert
+			assert.ErrorIs(t, err, c.expected)
+			walletRepo.AssertNotCalled(t, "CloseWallet", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("destination account number mistyped", func(t *testing.T) {
+		// Arrange
+		request := service.CloseWalletRequest{Reason: "migrated", DestinationAccountNumber: "123-4-56798-7"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CloseWallet(tenantID, 1, request)
+
+		// Assert
+		assert.Equal(t, errs.NewFieldValidationError([]errs.FieldError{{Field: "destination_account_number", Message: "check digit does not match"}}), err)
+		walletRepo.AssertNotCalled(t, "CloseWallet", mock.Anything, mock.Anything)
+	})
+
+	t.Run("destination account number of no wallet", func(t *testing.T) {
+		// Arrange
+		request := service.CloseWalletRequest{Reason: "migrated", DestinationAccountNumber: "9876543217"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"9876543217"}).Return(map[string]int64{}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CloseWallet(tenantID, 1, request)
+
+		// Assert
+		assert.Equal(t, errs.NewInvalidDestinationError("destination wallet not found"), err)
+	})
+
+	t.Run("close wallet with balance and no destination", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.CloseWalletRequest{Reason: "customer request"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("CloseWallet", tenantID, repository.CloseWallet{WalletID: id, Reason: "customer request"}).Return(&repository.Wallet{}, repository.ErrBalanceNotZero)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CloseWallet(tenantID, id, request)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewBalanceNotZeroError())
+	})
+
+	t.Run("destination is the same wallet", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.CloseWalletRequest{Reason: "customer request", DestinationAccountNumber: "1234567897"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{"1234567897": id}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CloseWallet(tenantID, id, request)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewInvalidDestinationError(""))
+	})
+
+	t.Run("destination not active", func(t *testing.T) {
+		// Arrange
+		var id, destination int64 = 1, 2
+		request := service.CloseWalletRequest{Reason: "customer request", DestinationAccountNumber: "1234567897"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("FindAccountNumbers", tenantID, []string{"1234567897"}).Return(map[string]int64{"1234567897": destination}, nil)
+		walletRepo.On("GetWallet", tenantID, destination).Return(&repository.Wallet{WalletID: destination, Status: "Deactive"}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CloseWallet(tenantID, id, request)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewInvalidDestinationError(""))
+	})
+
+	t.Run("wallet already closed", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.CloseWalletRequest{Reason: "customer request"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("CloseWallet", tenantID, repository.CloseWallet{WalletID: id, Reason: "customer request"}).Return(&repository.Wallet{}, repository.ErrWalletClosed)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CloseWallet(tenantID, id, request)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletClosedError())
+	})
+
+	t.Run("closed wallet is read only", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		amount := service.AddWalletRequest{Balance: 100, Operation: "Add"}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, id).Return(&repository.Wallet{WalletID: id, Status: "Closed", ClosedAt: &closedAt}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, id, amount)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletClosedError())
+	})
+
+	t.Run("list including closed wallets", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetAllWallets", tenantID, repository.WalletFilter{IncludeClosed: true}).Return([]repository.Wallet{
+			{WalletID: 1, Status: "Closed", ClosedAt: &closedAt, ClosureReason: "fraud"},
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallets, err := walletService.ListAllWallets(tenantID, service.ListWalletsRequest{IncludeClosed: true})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "fraud", wallets[0].ClosureReason)
+	})
+}
+
+func TestWalletMetadataAndLabels(t *testing.T) {
+	t.Run("create wallet with metadata and labels", func(t *testing.T) {
+		// Arrange
+		request := service.WalletRequest{
+			Balance:  100,
+			Metadata: json.RawMessage(`{"customer_ref":"C-1"}`),
+			Labels:   map[string]string{"team": "payments"},
+		}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("CreateNewWallet", tenantID, repository.NewWallet{
+			Balance:  100,
+			Currency: "THB",
+			Metadata: json.RawMessage(`{"customer_ref":"C-1"}`),
+			Labels:   map[string]string{"team": "payments"},
+		}).Return(&repository.Wallet{
+			WalletID: 1,
+			Balance:  100,
+			Status:   "Active",
+			Metadata: json.RawMessage(`{"customer_ref":"C-1"}`),
+			Labels:   map[string]string{"team": "payments"},
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.CreateWallet(tenantID, request)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{"customer_ref":"C-1"}`, string(wallet.Metadata))
+		assert.Equal(t, map[string]string{"team": "payments"}, wallet.Labels)
+	})
+
+	t.Run("reject invalid metadata and label keys", func(t *testing.T) {
+		// Arrange
+		request := service.WalletRequest{
+			Metadata: json.RawMessage(`[1,2]`),
+			Labels:   map[string]string{"Team Name": "payments"},
+		}
+		walletRepo := repository.NewWalletRepositoryMock()
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CreateWallet(tenantID, request)
+
+		// Assert
+		var appErr errs.AppError
+		if assert.ErrorAs(t, err, &appErr) {
+			assert.Equal(t, errs.CodeValidationFailed, appErr.Code)
+			assert.Len(t, appErr.Fields, 2)
+		}
+		walletRepo.AssertNotCalled(t, "CreateNewWallet", mock.Anything)
+	})
+
+	t.Run("patch sets and removes labels", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		owner := "payments"
+		request := service.PatchWalletRequest{
+			Metadata: json.RawMessage(`{"display_name":"Rent"}`),
+			Labels:   map[string]*string{"team": &owner, "legacy": nil},
+		}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("UpdateWallet", tenantID, repository.WalletPatch{
+			WalletID:     id,
+			Metadata:     json.RawMessage(`{"display_name":"Rent"}`),
+			SetLabels:    map[string]string{"team": "payments"},
+			RemoveLabels: []string{"legacy"},
+		}).Return(&repository.Wallet{WalletID: id, Labels: map[string]string{"team": "payments"}, Version: 2}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.UpdateWallet(tenantID, id, request)
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"team": "payments"}, wallet.Labels)
+	})
+
+	t.Run("patch closed wallet", func(t *testing.T) {
+		// Arrange
+		var id int64 = 1
+		request := service.PatchWalletRequest{Metadata: json.RawMessage(`{"a":1}`)}
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("UpdateWallet", tenantID, repository.WalletPatch{WalletID: id, Metadata: json.RawMessage(`{"a":1}`)}).Return(&repository.Wallet{}, repository.ErrWalletClosed)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.UpdateWallet(tenantID, id, request)
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewWalletClosedError())
+	})
+
+	t.Run("list wallets by label", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetAllWallets", tenantID, repository.WalletFilter{Labels: map[string]string{"team": "payments"}}).Return([]repository.Wallet{
+			{WalletID: 3, Labels: map[string]string{"team": "payments"}},
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallets, err := walletService.ListAllWallets(tenantID, service.ListWalletsRequest{Labels: []string{"team:payments"}})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Len(t, wallets, 1)
+	})
+
+	t.Run("malformed label filter", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+
+		walletService := service.NewWalletService(walletRepo, newTenantRepositoryMock(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.ListAllWallets(tenantID, service.ListWalletsRequest{Labels: []string{"payments"}})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewBadRequest(""))
+	})
+}
+
+func TestTenantConfiguration(t *testing.T) {
+	maxBalance := 1000.0
+	maxTransaction := 100.0
+	limitedTenant := func() repository.TenantRepository {
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenant", tenantID).Return(&repository.Tenant{
+			TenantID:             tenantID,
+			DefaultCurrency:      "USD",
+			MaxBalance:           &maxBalance,
+			MaxTransactionAmount: &maxTransaction,
+		}, nil)
+		return tenantRepo
+	}
+
+	t.Run("create wallet in tenant default currency", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("CreateNewWallet", tenantID, repository.NewWallet{Currency: "USD", Balance: 500}).Return(&repository.Wallet{
+			WalletID: 1,
+			Balance:  500,
+			Currency: "USD",
+			Status:   "Active",
+		}, nil)
+
+		walletService := service.NewWalletService(walletRepo, limitedTenant(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		wallet, err := walletService.CreateWallet(tenantID, service.WalletRequest{Balance: 500})
+
+		// Assert
+		assert.NoError(t, err)
+		assert.Equal(t, "USD", wallet.Currency)
+	})
+
+	t.Run("create wallet above tenant max balance", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+
+		walletService := service.NewWalletService(walletRepo, limitedTenant(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CreateWallet(tenantID, service.WalletRequest{Balance: 1500})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewLimitExceededError(""))
+		walletRepo.AssertNotCalled(t, "CreateNewWallet", mock.Anything, mock.Anything)
+	})
+
+	t.Run("amount above tenant max transaction amount", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+
+		walletService := service.NewWalletService(walletRepo, limitedTenant(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, 1, service.AddWalletRequest{Balance: 150, Operation: "Add"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewLimitExceededError(""))
+		walletRepo.AssertNotCalled(t, "GetWallet", mock.Anything, mock.Anything)
+	})
+
+	t.Run("add above tenant max balance", func(t *testing.T) {
+		// Arrange
+		walletRepo := repository.NewWalletRepositoryMock()
+		walletRepo.On("GetWallet", tenantID, int64(1)).Return(&repository.Wallet{WalletID: 1, Balance: 950, Status: "Active"}, nil)
+
+		walletService := service.NewWalletService(walletRepo, limitedTenant(), repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.SetWalletBalance(tenantID, 1, service.AddWalletRequest{Balance: 100, Operation: "Add"})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewLimitExceededError(""))
+		walletRepo.AssertNotCalled(t, "SetBalance", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unknown tenant", func(t *testing.T) {
+		// Arrange
+		tenantRepo := repository.NewTenantRepositoryMock()
+		tenantRepo.On("GetTenant", "unknown").Return(&repository.Tenant{}, sql.ErrNoRows)
+
+		walletService := service.NewWalletService(repository.NewWalletRepositoryMock(), tenantRepo, repository.NewApprovalRepositoryMock(), newRiskServiceMock(), newFeeRepositoryMock())
+
+		// Act
+		_, err := walletService.CreateWallet("unknown", service.WalletRequest{Balance: 100})
+
+		// Assert
+		assert.ErrorIs(t, err, errs.NewTenantNotFoundError())
+	})
+}
@@ -0,0 +1,170 @@
+// Package validate checks request DTOs against rules declared in
+// `validate` struct tags, e.g. `validate:"required,gt=0,decimals=2"`.
+//
+// Supported rules: required, gt, gte, lt, lte, min, max, decimals, oneof.
+// Numeric rules apply to the value of number fields and to the length of
+// string fields. Floats are always checked for NaN and infinity.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/topnarapat/go-wallet/errs"
+)
+
+// Struct validates every tagged field of v and returns an errs.AppError
+// listing all violations, or nil when v is valid.
+func Struct(v interface{}) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := []errs.FieldError{}
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field)
+		value := val.Field(i)
+
+		if value.Kind() == reflect.Float32 || value.Kind() == reflect.Float64 {
+			f := value.Float()
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				fields = append(fields, errs.FieldError{Field: name, Message: "must be a finite number"})
+				continue
+			}
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			if message := check(rule, value); message != "" {
+				fields = append(fields, errs.FieldError{Field: name, Message: message})
+				break
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return errs.NewFieldValidationError(fields)
+	}
+	return nil
+}
+
+func fieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func check(rule string, value reflect.Value) string {
+	name, param, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if value.IsZero() {
+			return "is required"
+		}
+	case "oneof":
+		if value.IsZero() {
+			return ""
+		}
+		options := strings.Fields(param)
+		for _, option := range options {
+			if fmt.Sprint(value.Interface()) == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(options, ", ")
+	case "decimals":
+		places, _ := strconv.Atoi(param)
+		if !hasMaxDecimals(value, places) {
+			return fmt.Sprintf("must have at most %d decimal places", places)
+		}
+	case "gt", "gte", "lt", "lte", "min", "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return ""
+		}
+		n, ok := number(value)
+		if !ok {
+			return ""
+		}
+		return compare(name, n, limit, param, hasLength(value))
+	}
+	return ""
+}
+
+func compare(op string, n, limit float64, param string, length bool) string {
+	subject := "must be"
+	if length {
+		subject = "length must be"
+	}
+	switch op {
+	case "gt":
+		if n <= limit {
+			return subject + " greater than " + param
+		}
+	case "gte", "min":
+		if n < limit {
+			return subject + " at least " + param
+		}
+	case "lt":
+		if n >= limit {
+			return subject + " less than " + param
+		}
+	case "lte", "max":
+		if n > limit {
+			return subject + " at most " + param
+		}
+	}
+	return ""
+}
+
+func number(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(value.Len()), true
+	}
+	return 0, false
+}
+
+func hasLength(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+func hasMaxDecimals(value reflect.Value, places int) bool {
+	if value.Kind() != reflect.Float32 && value.Kind() != reflect.Float64 {
+		return true
+	}
+	f := value.Float()
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	_, fraction, found := strings.Cut(s, ".")
+	return !found || len(fraction) <= places
+}
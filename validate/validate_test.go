@@ -0,0 +1,72 @@
+//go:build unit
+// +build unit
+
+package validate_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/topnarapat/go-wallet/errs"
+	"github.com/topnarapat/go-wallet/validate"
+)
+
+type request struct {
+	Amount    float64 `json:"amount" validate:"required,gt=0,max=100,decimals=2"`
+	Operation string  `json:"operation" validate:"required,oneof=Add Deduct"`
+	Note      string  `json:"note" validate:"max=5"`
+	Start     float64 `json:"start" validate:"gte=0"`
+}
+
+func TestStruct(t *testing.T) {
+	type testCase struct {
+		name     string
+		request  request
+		expected []errs.FieldError
+	}
+
+	cases := []testCase{
+		{name: "valid request", request: request{Amount: 10.25, Operation: "Add"}},
+		{name: "missing required fields", request: request{}, expected: []errs.FieldError{
+			{Field: "amount", Message: "is required"},
+			{Field: "operation", Message: "is required"},
+		}},
+		{name: "negative amount", request: request{Amount: -1, Operation: "Add"}, expected: []errs.FieldError{
+			{Field: "amount", Message: "must be greater than 0"},
+		}},
+		{name: "amount above max", request: request{Amount: 101, Operation: "Add"}, expected: []errs.FieldError{
+			{Field: "amount", Message: "must be at most 100"},
+		}},
+		{name: "too many decimals", request: request{Amount: 1.005, Operation: "Add"}, expected: []errs.FieldError{
+			{Field: "amount", Message: "must have at most 2 decimal places"},
+		}},
+		{name: "unknown option", request: request{Amount: 1, Operation: "Move"}, expected: []errs.FieldError{
+			{Field: "operation", Message: "must be one of Add, Deduct"},
+		}},
+		{name: "string too long", request: request{Amount: 1, Operation: "Add", Note: "abcdef"}, expected: []errs.FieldError{
+			{Field: "note", Message: "length must be at most 5"},
+		}},
+		{name: "not a number", request: request{Amount: 1, Operation: "Add", Start: math.NaN()}, expected: []errs.FieldError{
+			{Field: "start", Message: "must be a finite number"},
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			// Act
+			err := validate.Struct(c.request)
+
+			// Assert
+			if c.expected == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var appErr errs.AppError
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.ErrorIs(t, err, errs.NewValidationError(""))
+				assert.Equal(t, c.expected, appErr.Fields)
+			}
+		})
+	}
+}